@@ -0,0 +1,106 @@
+// Command auditverify walks the audit log from the first event and checks its hash
+// chain. It prints the number of events and the head hash; keep the head hash
+// somewhere else and pass it back with --head next time to also detect removal of
+// the newest events. The chain is keyed with the master key of the server, read from
+// the PASSVAULT_MASTER_KEY environment variable; events chained before a master key
+// rotation verify with the old master keys in PASSVAULT_OLD_MASTER_KEYS, numbered as
+// set by PASSVAULT_MASTER_KEY_VERSION.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"passvault/config"
+	"passvault/internal/lib/auditchain"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/postgres"
+	"passvault/internal/storage/sqlite"
+)
+
+const batchSize = 1000
+
+func main() {
+	var storagePath, postgresDSN, head string
+
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "", "PostgreSQL database to verify instead of storage-path")
+	flag.StringVar(&head, "head", "", "head hash printed by a previous run, which must still be in the chain")
+	flag.Parse()
+
+	if storagePath == "" && postgresDSN == "" {
+		panic("storage-path or postgres-dsn is required")
+	}
+
+	masterKeys, err := config.MasterKeysFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("master keys: %w", err))
+	}
+
+	db, err := open(storagePath, postgresDSN)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	verifier, err := encrypted.New(db, masterKeys).AuditVerifier()
+	if err != nil {
+		panic(err)
+	}
+	headSeen := head == ""
+	var afterID int64
+
+	for {
+		events, err := db.AuditEvents(context.Background(), afterID, batchSize)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, event := range events {
+			if err := verifier.Next(event); err != nil {
+				fail(err)
+			}
+			if event.Hash == head {
+				headSeen = true
+			}
+			afterID = event.ID
+		}
+
+		if len(events) < batchSize {
+			break
+		}
+	}
+
+	if !headSeen {
+		fail(fmt.Errorf("%w: head %s not found, events were removed", auditchain.ErrBrokenChain, head))
+	}
+
+	fmt.Printf("audit log ok: %d events, head %s\n", verifier.Count(), verifier.Head())
+}
+
+func open(storagePath, postgresDSN string) (storage.Backend, error) {
+	if postgresDSN != "" {
+		db, err := postgres.New(postgresDSN, storage.RevisionRetention{})
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	db, err := sqlite.New(storagePath, storage.RevisionRetention{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func fail(err error) {
+	if errors.Is(err, auditchain.ErrBrokenChain) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	panic(err)
+}
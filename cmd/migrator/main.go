@@ -4,37 +4,56 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"passvault/internal/storage/schema"
+	"passvault/migrations"
 )
 
+const usage = `usage: migrator [flags] [command]
+
+commands:
+  up          apply all pending migrations (default)
+  down [N]    revert the last N migrations, 1 by default
+  goto V      migrate up or down to version V, 0 reverts everything
+  force V     record V as the clean schema version without running migrations
+  version     print the current and latest schema version
+
+flags:
+`
+
 func main() {
-	var storagePath, migrationsPath, migrationsTable string
+	var storagePath, postgresDSN, migrationsPath, migrationsTable string
 
 	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
-	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations")
-	flag.StringVar(&migrationsTable, "migrations-table", "migrations", "name of migrations table")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "", "URL or key/value DSN of the PostgreSQL database, migrated instead of storage-path")
+	flag.StringVar(&migrationsPath, "migrations-path", "", "directory of migrations to use instead of the embedded ones")
+	flag.StringVar(&migrationsTable, "migrations-table", schema.DefaultTable, "name of migrations table")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if storagePath == "" {
-		panic("storage-path is required")
-	}
-	if migrationsPath == "" {
-		panic("migrations-path is required")
+	if storagePath == "" && postgresDSN == "" {
+		panic("storage-path or postgres-dsn is required")
 	}
 
-	m, err := migrate.New(
-		"file://"+migrationsPath,
-		fmt.Sprintf("sqlite3://%s?x-migrations-table=%s", storagePath, migrationsTable),
-	)
+	m, err := open(storagePath, postgresDSN, migrationsPath, migrationsTable)
 	if err != nil {
 		panic(err)
 	}
+	defer m.Close()
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
+	command, args := "up", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	if err := run(m, command, args); err != nil {
+		if errors.Is(err, schema.ErrNoChange) {
 			fmt.Println("no migrations to apply")
 
 			return
@@ -43,3 +62,113 @@ func main() {
 		panic(err)
 	}
 }
+
+// open returns a migrator of the selected database using the embedded migrations
+// of its backend, or the ones in migrationsPath when it is set.
+func open(storagePath, postgresDSN, migrationsPath, migrationsTable string) (*schema.Migrator, error) {
+	var source fs.FS
+	if migrationsPath != "" {
+		source = os.DirFS(migrationsPath)
+	}
+
+	if postgresDSN != "" {
+		if source == nil {
+			source = migrations.Postgres
+		}
+		return schema.NewPostgres(postgresDSN, migrationsTable, source)
+	}
+
+	if source == nil {
+		source = migrations.SQLite
+	}
+	return schema.NewSQLite(storagePath, migrationsTable, source)
+}
+
+func run(m *schema.Migrator, command string, args []string) error {
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			return fmt.Errorf("up takes no arguments")
+		}
+		before, _, err := m.Version()
+		if err != nil {
+			return err
+		}
+		version, err := m.Up()
+		if err != nil {
+			return err
+		}
+		if version == before {
+			return schema.ErrNoChange
+		}
+		fmt.Printf("migrated from version %d to %d\n", before, version)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			return fmt.Errorf("down takes at most one argument")
+		}
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+			steps = n
+		}
+		if err := m.Down(steps); err != nil {
+			return err
+		}
+		return printVersion(m)
+	case "goto":
+		version, err := versionArg(command, args)
+		if err != nil {
+			return err
+		}
+		if err := m.Goto(version); err != nil {
+			return err
+		}
+		return printVersion(m)
+	case "force":
+		version, err := versionArg(command, args)
+		if err != nil {
+			return err
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+		return printVersion(m)
+	case "version":
+		if len(args) != 0 {
+			return fmt.Errorf("version takes no arguments")
+		}
+		return printVersion(m)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+func versionArg(command string, args []string) (uint, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s takes a version", command)
+	}
+	version, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", args[0])
+	}
+	return uint(version), nil
+}
+
+func printVersion(m *schema.Migrator) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	state := ""
+	if dirty {
+		state = " (dirty)"
+	}
+	fmt.Printf("version %d%s, latest %d\n", version, state, m.Latest())
+	return nil
+}
@@ -0,0 +1,195 @@
+// Command passvault-backup writes encrypted backups of the SQLite vault and restores them.
+//
+// Backups are consistent online snapshots, so the server can keep running while they
+// are taken. Restoring replaces the database file, so stop the server first:
+//
+//	passvault-backup backup --storage-path=./storage/passvault.db --out=vault.db.gz.age --recipient=age1...
+//	passvault-backup restore --storage-path=./storage/passvault.db --in=vault.db.gz.age --identity=key.txt
+//
+// Instead of age public keys, backups can be encrypted with a passphrase read from
+// --passphrase-file or the PASSVAULT_BACKUP_PASSPHRASE environment variable.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"passvault/internal/services/backup"
+	"passvault/internal/storage"
+	"passvault/internal/storage/sqlite"
+	"strings"
+)
+
+const passphraseEnv = "PASSVAULT_BACKUP_PASSPHRASE"
+
+// stringList collects the values of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "backup":
+		err = runBackup(os.Args[2:])
+	case "restore":
+		err = runRestore(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: passvault-backup backup|restore [flags]")
+	os.Exit(2)
+}
+
+func runBackup(args []string) error {
+	var storagePath, out, passphraseFile string
+	var recipients stringList
+
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	flags.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flags.StringVar(&out, "out", "", "file to write the backup to, - for stdout")
+	flags.Var(&recipients, "recipient", "age public key to encrypt the backup to, repeatable")
+	flags.StringVar(&passphraseFile, "passphrase-file", "", "file holding the passphrase to encrypt the backup with")
+	flags.Parse(args)
+
+	if storagePath == "" || out == "" {
+		return fmt.Errorf("storage-path and out are required")
+	}
+	// Opening a missing database would create an empty one and back that up.
+	if _, err := os.Stat(storagePath); err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+	ageRecipients, err := backup.Recipients(recipients, passphrase)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlite.New(storagePath, storage.RevisionRetention{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var w io.WriteCloser = os.Stdout
+	if out != "-" {
+		// The backup is only readable by the owner even though it is encrypted.
+		w, err = os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = backup.Write(context.Background(), w, db, ageRecipients)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if out != "-" {
+			os.Remove(out)
+		}
+		return err
+	}
+
+	if out != "-" {
+		fmt.Printf("backup written to %s\n", out)
+	}
+	return nil
+}
+
+func runRestore(args []string) error {
+	var storagePath, in, identityFile, passphraseFile string
+
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.StringVar(&storagePath, "storage-path", "", "path of the database to replace")
+	flags.StringVar(&in, "in", "", "backup file to restore, - for stdin")
+	flags.StringVar(&identityFile, "identity", "", "age identity file with the private key of a recipient")
+	flags.StringVar(&passphraseFile, "passphrase-file", "", "file holding the passphrase the backup was encrypted with")
+	flags.Parse(args)
+
+	if storagePath == "" || in == "" {
+		return fmt.Errorf("storage-path and in are required")
+	}
+
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	var identity io.Reader
+	if identityFile != "" {
+		f, err := os.Open(identityFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		identity = f
+	}
+
+	identities, err := backup.Identities(identity, passphrase)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	_, statErr := os.Stat(storagePath)
+	replaced := statErr == nil
+
+	version, err := backup.Restore(r, identities, storagePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("restored %s at schema version %d\n", storagePath, version)
+	if replaced {
+		fmt.Printf("the previous database was kept as %s.pre-restore\n", storagePath)
+	}
+	return nil
+}
+
+// readPassphrase reads the passphrase from file, or from the environment when file is empty.
+func readPassphrase(file string) (string, error) {
+	if file == "" {
+		return os.Getenv(passphraseEnv), nil
+	}
+
+	passphrase, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
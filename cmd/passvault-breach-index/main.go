@@ -0,0 +1,130 @@
+// Command passvault-breach-index builds the breached password index the server reads
+// from breach.index:
+//
+//	passvault-breach-index --in=pwnedpasswords.txt --out=./storage/breach.idx
+//
+// The input is a Pwned Passwords dump with SHA-1 hashes ordered by hash, either one
+// file of "HASH:COUNT" lines or a directory of range files named by the 5 hex digit
+// prefix of their "SUFFIX:COUNT" lines, as the range API serves them. "-" reads a file
+// from stdin. Counts are dropped. See package breach for the layout of the index.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"passvault/internal/lib/breach"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var in, out string
+
+	flag.StringVar(&in, "in", "", "Pwned Passwords dump ordered by hash: a file, a directory of range files or - for stdin")
+	flag.StringVar(&out, "out", "", "file to write the index to")
+	flag.Parse()
+
+	if err := run(in, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string) error {
+	if in == "" || out == "" {
+		return fmt.Errorf("in and out are required")
+	}
+
+	// The index is written next to out and renamed over it once complete, so a
+	// running server never opens half an index.
+	f, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w := breach.NewWriter(f)
+	if err := add(w, in); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), out); err != nil {
+		return err
+	}
+
+	fmt.Printf("indexed %d hashes into %s\n", w.Count(), out)
+	return nil
+}
+
+// add adds the hashes of the dump at in.
+func add(w *breach.Writer, in string) error {
+	if in == "-" {
+		return addLines(w, "", os.Stdin, "stdin")
+	}
+
+	info, err := os.Stat(in)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return addFile(w, "", in)
+	}
+
+	// ReadDir sorts by name, which orders range files by prefix.
+	files, err := os.ReadDir(in)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		prefix := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		if file.IsDir() || len(prefix) != breach.PrefixLength {
+			continue
+		}
+		if err := addFile(w, prefix, filepath.Join(in, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFile(w *breach.Writer, prefix, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return addLines(w, prefix, f, path)
+}
+
+func addLines(w *breach.Writer, prefix string, r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		hash, err := breach.ParseLine(prefix, scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		if err := w.Add(hash); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// clearClipboardCommand is run in the background by copy to clear the clipboard.
+	clearClipboardCommand = "clear-clipboard"
+	// clipboardNonceEnv hands clearClipboardCommand the random key of the digest of the
+	// copied value, which it reads from stdin, so that it leaves the clipboard alone
+	// once something else was copied.
+	clipboardNonceEnv = "PASSVAULT_CLI_CLIPBOARD_NONCE"
+)
+
+// clipboardTool is a command line tool that writes and reads the clipboard.
+type clipboardTool struct {
+	copy  []string
+	paste []string
+	// env is set when the tool needs a display server, like X11 or Wayland.
+	env string
+}
+
+// clipboardTools are tried in order; the first one installed is used.
+var clipboardTools = []clipboardTool{
+	{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+	{copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}, env: "WAYLAND_DISPLAY"},
+	{copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}, env: "DISPLAY"},
+	{copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}, env: "DISPLAY"},
+	{copy: []string{"clip.exe"}, paste: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"}},
+}
+
+func findClipboardTool() (*clipboardTool, error) {
+	for _, tool := range clipboardTools {
+		if tool.env != "" && os.Getenv(tool.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool.copy[0]); err == nil {
+			return &tool, nil
+		}
+	}
+	return nil, errors.New("no clipboard found, install wl-clipboard, xclip or xsel")
+}
+
+func copyToClipboard(value string) error {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool.copy[0], err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// scheduleClear starts a copy of the CLI in the background that clears the clipboard
+// after the given time, unless it no longer holds value by then. Nothing derived from
+// value goes into the environment of the child, which other processes can read from
+// /proc: it gets a random nonce there and the digest of value keyed with it on a pipe.
+func scheduleClear(value string, after time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, sha256.Size)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	// The digest fits in the pipe buffer, so it is written before the child starts and
+	// the child reads it whenever it likes, after this process has exited.
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if _, err := w.Write(clipboardDigest(nonce, []byte(value))); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, clearClipboardCommand, after.String())
+	cmd.Env = append(os.Environ(), clipboardNonceEnv+"="+hex.EncodeToString(nonce))
+	cmd.Stdin = r
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// clipboardDigest keys the digest of a clipboard value with nonce.
+func clipboardDigest(nonce, value []byte) []byte {
+	mac := hmac.New(sha256.New, nonce)
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+func clearClipboard(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: passvault-cli %s DURATION", clearClipboardCommand)
+	}
+	after, err := time.ParseDuration(args[0])
+	if err != nil {
+		return err
+	}
+
+	nonce, _ := hex.DecodeString(os.Getenv(clipboardNonceEnv))
+	want, _ := io.ReadAll(io.LimitReader(os.Stdin, sha256.Size))
+
+	// The terminal copy was run from may be closed or interrupted before the time is up.
+	signal.Ignore(syscall.SIGHUP, os.Interrupt)
+	time.Sleep(after)
+
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+
+	if out, err := exec.Command(tool.paste[0], tool.paste[1:]...).Output(); err == nil && len(nonce) > 0 && len(want) > 0 {
+		if !hmac.Equal(clipboardDigest(nonce, bytes.TrimRight(out, "\r\n")), want) {
+			return nil
+		}
+	}
+
+	return copyToClipboard("")
+}
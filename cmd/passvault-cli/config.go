@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// config is what the CLI remembers between runs. It holds no secrets.
+type config struct {
+	// Server is the server of the last login.
+	Server string `json:"server,omitempty"`
+	// Store is where tokens are kept, storeKeyring or storeFile.
+	Store string `json:"store,omitempty"`
+}
+
+// configDir returns the directory of the CLI below the user configuration directory,
+// such as ~/.config/passvault.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "passvault"), nil
+}
+
+func loadConfig() (*config, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{}
+	raw, err := os.ReadFile(filepath.Join(dir, "cli.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c *config) save() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "cli.json"), append(raw, '\n'))
+}
+
+// writeFile replaces the file at path, creating its directory, readable only by the user.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
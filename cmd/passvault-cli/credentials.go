@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"filippo.io/age"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"passvault/pkg/client"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	storeKeyring = "keyring"
+	storeFile    = "file"
+
+	// keyringService names the tokens of the CLI in the keyring of the OS.
+	keyringService = "passvault-cli"
+	passphraseEnv  = "PASSVAULT_CLI_PASSPHRASE"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run passvault-cli login")
+
+// tokenStore keeps the tokens of accounts, one per server.
+type tokenStore interface {
+	Load(server string) (string, error)
+	Save(server, token string) error
+	Delete(server string) error
+}
+
+// newTokenStore returns the store of kind, or of the keyring when there is one and
+// the file otherwise when kind is empty.
+func newTokenStore(kind string) (tokenStore, error) {
+	switch kind {
+	case "":
+		if k := systemKeyring(); k != nil {
+			return k, nil
+		}
+		return newFileStore()
+	case storeKeyring:
+		if k := systemKeyring(); k != nil {
+			return k, nil
+		}
+		return nil, fmt.Errorf("no keyring found, install secret-tool or use --store=%s", storeFile)
+	case storeFile:
+		return newFileStore()
+	default:
+		return nil, fmt.Errorf("unknown token store %q", kind)
+	}
+}
+
+func storeKind(store tokenStore) string {
+	if _, ok := store.(*keyring); ok {
+		return storeKeyring
+	}
+	return storeFile
+}
+
+// keyring keeps tokens in the keyring of the OS through its command line tool:
+// security on macOS and secret-tool of libsecret elsewhere.
+type keyring struct {
+	tool string
+}
+
+func systemKeyring() *keyring {
+	tool := "secret-tool"
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "windows":
+		return nil
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil
+	}
+	return &keyring{tool: tool}
+}
+
+func (k *keyring) Load(server string) (string, error) {
+	args := []string{"lookup", "service", keyringService, "server", server}
+	if k.tool == "security" {
+		args = []string{"find-generic-password", "-s", keyringService, "-a", server, "-w"}
+	}
+
+	out, err := exec.Command(k.tool, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", errNotLoggedIn
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errNotLoggedIn
+	}
+	return token, nil
+}
+
+func (k *keyring) Save(server, token string) error {
+	// The token is written to stdin, never passed as an argument where ps shows it.
+	cmd := exec.Command(k.tool, "store", "--label", keyringService+" "+server, "service", keyringService, "server", server)
+	cmd.Stdin = strings.NewReader(token)
+	if k.tool == "security" {
+		// A trailing -w without a value makes security prompt for the password and
+		// then for it again to confirm.
+		cmd = exec.Command(k.tool, "add-generic-password", "-U", "-s", keyringService, "-a", server, "-w")
+		cmd.Stdin = strings.NewReader(token + "\n" + token + "\n")
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write keyring: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (k *keyring) Delete(server string) error {
+	args := []string{"clear", "service", keyringService, "server", server}
+	if k.tool == "security" {
+		args = []string{"delete-generic-password", "-s", keyringService, "-a", server}
+	}
+
+	err := exec.Command(k.tool, args...).Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return nil
+}
+
+// fileStore keeps the tokens of all servers in a file encrypted with age and a
+// passphrase, for systems without a keyring.
+type fileStore struct {
+	path       string
+	passphrase string
+}
+
+func newFileStore() (*fileStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return &fileStore{path: filepath.Join(dir, "tokens.age")}, nil
+}
+
+func (f *fileStore) Load(server string) (string, error) {
+	tokens, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	token, ok := tokens[server]
+	if !ok {
+		return "", errNotLoggedIn
+	}
+	return token, nil
+}
+
+func (f *fileStore) Save(server, token string) error {
+	tokens, err := f.read()
+	if err != nil && !errors.Is(err, errNotLoggedIn) {
+		return err
+	}
+	if tokens == nil {
+		tokens = map[string]string{}
+	}
+	tokens[server] = token
+	return f.write(tokens)
+}
+
+func (f *fileStore) Delete(server string) error {
+	tokens, err := f.read()
+	if errors.Is(err, errNotLoggedIn) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(tokens, server)
+	return f.write(tokens)
+}
+
+// read decrypts the tokens; a missing file reports errNotLoggedIn.
+func (f *fileStore) read() (map[string]string, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := f.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(raw), identity)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, fmt.Errorf("failed to decrypt %s: wrong passphrase", f.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", f.path, err)
+	}
+
+	var tokens map[string]string
+	if err := json.NewDecoder(r).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	return tokens, nil
+}
+
+func (f *fileStore) write(tokens map[string]string) error {
+	_, statErr := os.Stat(f.path)
+	passphrase, err := f.getPassphrase(errors.Is(statErr, os.ErrNotExist))
+	if err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return writeFile(f.path, buf.Bytes())
+}
+
+// getPassphrase returns the passphrase of the file, asking for it once per run.
+// A new passphrase is asked twice.
+func (f *fileStore) getPassphrase(create bool) (string, error) {
+	if f.passphrase != "" {
+		return f.passphrase, nil
+	}
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		f.passphrase = passphrase
+		return passphrase, nil
+	}
+
+	if !create {
+		passphrase, err := readSecret("Passphrase of " + f.path + ": ")
+		if err != nil {
+			return "", err
+		}
+		f.passphrase = passphrase
+		return passphrase, nil
+	}
+
+	passphrase, err := readSecret("New passphrase to encrypt tokens with: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	again, err := readSecret("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	f.passphrase = passphrase
+	return passphrase, nil
+}
+
+// stdin is shared by every read so that lines piped in are not lost to buffering.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readSecret reads a line from the terminal without echoing it, or from stdin when it
+// is not a terminal.
+func readSecret(prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, prompt)
+		if stty("-echo") == nil {
+			// Echo is off until the line is read; an interrupt must turn it back on.
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-interrupt:
+					stty("echo")
+					fmt.Fprintln(os.Stderr)
+					os.Exit(130)
+				case <-done:
+				}
+			}()
+			defer func() {
+				signal.Stop(interrupt)
+				close(done)
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read %s: %w", strings.TrimSpace(strings.TrimSuffix(prompt, ": ")), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func login(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("login", "[flags] [server]")
+	store := fs.String("store", "", "where to keep the token: keyring or file, default the keyring if there is one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if fs.NArg() == 1 {
+		a.server = fs.Arg(0)
+	}
+	if a.server == "" {
+		return errors.New("server is required")
+	}
+
+	token, err := readSecret("Token: ")
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("token must not be empty")
+	}
+
+	c, err := client.New(a.server, client.WithToken(token), client.WithUserAgent("passvault-cli"))
+	if err != nil {
+		return err
+	}
+	if _, err := c.ListTags(ctx); err != nil {
+		return fmt.Errorf("failed to check token: %w", err)
+	}
+
+	if *store == "" {
+		*store = a.cfg.Store
+	}
+	tokens, err := newTokenStore(*store)
+	if err != nil {
+		return err
+	}
+	if err := tokens.Save(a.server, token); err != nil {
+		return err
+	}
+
+	a.cfg.Server = a.server
+	a.cfg.Store = storeKind(tokens)
+	if err := a.cfg.save(); err != nil {
+		return err
+	}
+
+	return a.message(map[string]string{"server": a.server, "store": a.cfg.Store},
+		"Logged in to %s, token stored in the %s", a.server, a.cfg.Store)
+}
+
+func logout(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("logout", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.server == "" {
+		return errNotLoggedIn
+	}
+
+	tokens, err := newTokenStore(a.cfg.Store)
+	if err != nil {
+		return err
+	}
+	if err := tokens.Delete(a.server); err != nil {
+		return err
+	}
+
+	return a.message(map[string]string{"server": a.server}, "Logged out of %s", a.server)
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/entry/save"
+	"passvault/internal/http-server/handlers/entry/update"
+	"passvault/internal/http-server/handlers/generator/generate"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/entryschema"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listPageSize is the number of entries ls fetches per request with --all.
+const listPageSize = 100
+
+// stringList is a flag that may be given more than once.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// idList is a flag of IDs that may be given more than once.
+type idList []int64
+
+func (l *idList) String() string {
+	ids := make([]string, len(*l))
+	for i, id := range *l {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (l *idList) Set(value string) error {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, id)
+	return nil
+}
+
+// optionalID is an ID flag that is nil unless it is given.
+type optionalID struct {
+	id *int64
+}
+
+func (o *optionalID) String() string {
+	if o.id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*o.id, 10)
+}
+
+func (o *optionalID) Set(value string) error {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	o.id = &id
+	return nil
+}
+
+// parseEntryID parses the flags of a command taking the ID of an entry as its only argument.
+func parseEntryID(fs *flag.FlagSet, args []string) (int64, error) {
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 0, flag.ErrHelp
+	}
+
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid entry ID %q", fs.Arg(0))
+	}
+	return id, nil
+}
+
+func add(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("add", "[flags]")
+	entryType := fs.String("type", models.EntryTypeLogin, "type of the entry, see GET /api/v1/entry-types")
+	data := fs.String("data", "", `entry data as JSON, or "-" to read it from stdin; replaces the field flags`)
+	title := fs.String("title", "", "title of the entry")
+	username := fs.String("username", "", "username of a login")
+	password := fs.String("password", "", `password of a login, or "-" to type it in`)
+	notes := fs.String("notes", "", "notes of the entry")
+	var uris stringList
+	fs.Var(&uris, "uri", "URI of a login, may be repeated")
+	generatePassword := fs.Bool("generate", false, "generate the password of a login")
+	var folder, collection optionalID
+	fs.Var(&folder, "folder", "folder to add the entry to")
+	fs.Var(&collection, "collection", "organization collection to add the entry to instead of the personal vault")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	entryData := *data
+	switch {
+	case entryData == "-":
+		raw, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		entryData = strings.TrimSpace(string(raw))
+	case entryData == "":
+		fields := map[string]any{"title": *title}
+		if *notes != "" {
+			fields["notes"] = *notes
+		}
+		if *username != "" {
+			fields["username"] = *username
+		}
+		if len(uris) > 0 {
+			fields["uris"] = []string(uris)
+		}
+
+		switch {
+		case *generatePassword:
+			generated, err := c.Generate(ctx, generate.Request{Type: "password"})
+			if err != nil {
+				return err
+			}
+			fields["password"] = generated.Value
+		case *password == "-":
+			secret, err := readSecret("Password: ")
+			if err != nil {
+				return err
+			}
+			fields["password"] = secret
+		case *password != "":
+			fields["password"] = *password
+		}
+
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		entryData = string(raw)
+	}
+
+	created, err := c.CreateEntry(ctx, save.Request{EntryType: *entryType, EntryData: entryData, CollectionID: collection.id})
+	if err != nil {
+		return err
+	}
+	if folder.id != nil {
+		if err := c.MoveEntry(ctx, created.ID, folder.id); err != nil {
+			return fmt.Errorf("entry %d added, but not moved: %w", created.ID, err)
+		}
+	}
+
+	warnBreached(created.Breached)
+	return a.message(created, "Added entry %d", created.ID)
+}
+
+func get(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("get", "[flags] ID")
+	field := fs.String("field", "", "print only this field of the entry data, unmasked")
+	reveal := fs.Bool("reveal", false, "show secret fields instead of masking them")
+	id, err := parseEntryID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	entry, err := c.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *field != "" {
+		value, err := entryField(entry, *field)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(a.stdout, value)
+		return err
+	}
+
+	return a.print(entry, func() error {
+		pairs := [][2]string{
+			{"id", strconv.FormatInt(entry.ID, 10)},
+			{"type", entry.EntryType},
+		}
+		if entry.FolderID != nil {
+			pairs = append(pairs, [2]string{"folder", strconv.FormatInt(*entry.FolderID, 10)})
+		}
+		if entry.CollectionID != nil {
+			pairs = append(pairs, [2]string{"collection", strconv.FormatInt(*entry.CollectionID, 10)})
+		}
+		pairs = append(pairs,
+			[2]string{"created", entry.CreatedAt.Local().Format(time.DateTime)},
+			[2]string{"updated", entry.UpdatedAt.Local().Format(time.DateTime)},
+		)
+		return a.fields(append(pairs, dataFields(entry, *reveal)...)...)
+	})
+}
+
+func list(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("ls", "[flags]")
+	entryType := fs.String("type", "", "list only entries of this type")
+	var folder, collection optionalID
+	fs.Var(&folder, "folder", "list only entries of this folder, 0 for entries outside any folder")
+	fs.Var(&collection, "collection", "list the entries of an organization collection instead")
+	var tags idList
+	fs.Var(&tags, "tag", "list only entries with this tag, may be repeated")
+	sortBy := fs.String("sort", "", "sort by name, created_at or updated_at")
+	desc := fs.Bool("desc", false, "sort in descending order")
+	limit := fs.Int("limit", 0, "entries per page, default the page size of the server")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+	all := fs.Bool("all", false, "list every page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	query := models.EntryQuery{
+		Filter: models.EntryFilter{
+			EntryType:    *entryType,
+			FolderID:     folder.id,
+			CollectionID: collection.id,
+			TagIDs:       tags,
+		},
+		Sort:       *sortBy,
+		Descending: *desc,
+		Limit:      *limit,
+		Cursor:     *cursor,
+	}
+	if *all && query.Limit == 0 {
+		query.Limit = listPageSize
+	}
+
+	page, err := c.ListEntries(ctx, query)
+	if err != nil {
+		return err
+	}
+	for *all && page.NextCursor != "" {
+		query.Cursor = page.NextCursor
+		next, err := c.ListEntries(ctx, query)
+		if err != nil {
+			return err
+		}
+		page.Entries = append(page.Entries, next.Entries...)
+		page.NextCursor = next.NextCursor
+	}
+
+	return a.print(page, func() error {
+		rows := make([][]string, len(page.Entries))
+		for i, entry := range page.Entries {
+			data := entryData(&entry)
+			rows[i] = []string{
+				strconv.FormatInt(entry.ID, 10),
+				entry.EntryType,
+				formatValue(data["title"]),
+				formatValue(data["username"]),
+				entry.UpdatedAt.Local().Format(time.DateTime),
+			}
+		}
+		if err := a.table([]string{"ID", "TYPE", "TITLE", "USERNAME", "UPDATED"}, rows); err != nil {
+			return err
+		}
+		if page.NextCursor != "" {
+			fmt.Fprintf(os.Stderr, "more entries: --cursor=%s, or --all\n", page.NextCursor)
+		}
+		return nil
+	})
+}
+
+func remove(ctx context.Context, a *app, args []string) error {
+	id, err := parseEntryID(newFlagSet("rm", "ID"), args)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteEntry(ctx, id); err != nil {
+		return err
+	}
+	return a.message(resp.OK(), "Deleted entry %d", id)
+}
+
+func edit(ctx context.Context, a *app, args []string) error {
+	id, err := parseEntryID(newFlagSet("edit", "ID"), args)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	entry, err := c.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry.ProtocolVersion != 0 {
+		return errors.New("the entry is encrypted by a zero-knowledge client and cannot be edited here")
+	}
+
+	var before bytes.Buffer
+	if err := json.Indent(&before, []byte(entry.EntryData), "", "  "); err != nil {
+		return fmt.Errorf("failed to read entry data: %w", err)
+	}
+	before.WriteByte('\n')
+
+	after, err := editInEditor(before.Bytes())
+	if err != nil {
+		return err
+	}
+	if !json.Valid(after) {
+		return errors.New("the edited entry data is not valid JSON, nothing was changed")
+	}
+
+	var compactBefore, compactAfter bytes.Buffer
+	json.Compact(&compactBefore, before.Bytes())
+	json.Compact(&compactAfter, after)
+	if bytes.Equal(compactBefore.Bytes(), compactAfter.Bytes()) {
+		return a.message(resp.OK(), "No changes")
+	}
+
+	data := compactAfter.String()
+	updated, err := c.UpdateEntry(ctx, id, update.Request{EntryData: &data})
+	if err != nil {
+		return err
+	}
+
+	warnBreached(updated.Breached)
+	return a.message(updated, "Updated entry %d", id)
+}
+
+// editInEditor opens data in $VISUAL or $EDITOR, vi by default, and returns what was saved.
+// The data is written to a file only the user can read, removed again afterwards.
+func editInEditor(data []byte) ([]byte, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "passvault-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	// EDITOR may carry arguments, such as "code --wait".
+	argv := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %s failed: %w", argv[0], err)
+	}
+
+	return os.ReadFile(f.Name())
+}
+
+func totpCode(ctx context.Context, a *app, args []string) error {
+	id, err := parseEntryID(newFlagSet("totp", "ID"), args)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	code, err := c.EntryTOTP(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.print(code, func() error {
+		return a.fields(
+			[2]string{"code", code.Code},
+			[2]string{"remaining", fmt.Sprintf("%ds", code.Remaining)},
+		)
+	})
+}
+
+func warnBreached(breached bool) {
+	if breached {
+		fmt.Fprintln(os.Stderr, "warning: the password appears in known data breaches")
+	}
+}
+
+// entryData decodes the data of an entry; entries encrypted by a client have none.
+func entryData(entry *models.Entry) map[string]any {
+	var data map[string]any
+	if entry.ProtocolVersion == 0 {
+		json.Unmarshal([]byte(entry.EntryData), &data)
+	}
+	return data
+}
+
+// entryField returns a field of the data of an entry for printing.
+func entryField(entry *models.Entry, name string) (string, error) {
+	if entry.ProtocolVersion != 0 {
+		return "", errors.New("the entry is encrypted by a zero-knowledge client")
+	}
+	value, ok := entryData(entry)[name]
+	if !ok {
+		return "", fmt.Errorf("entry %d has no field %q", entry.ID, name)
+	}
+	return formatValue(value), nil
+}
+
+// dataFields returns the fields of the data of an entry in the order of its schema,
+// with secret fields masked unless reveal is set.
+func dataFields(entry *models.Entry, reveal bool) [][2]string {
+	if entry.ProtocolVersion != 0 {
+		return [][2]string{{"data", "encrypted by a zero-knowledge client"}}
+	}
+
+	data := entryData(entry)
+	schema, _ := entryschema.Lookup(entry.EntryType)
+
+	var pairs [][2]string
+	for _, field := range schema.Fields {
+		value, ok := data[field.Name]
+		if !ok {
+			continue
+		}
+		delete(data, field.Name)
+
+		formatted := formatValue(value)
+		if field.Secret && !reveal && formatted != "" {
+			formatted = "********"
+		}
+		pairs = append(pairs, [2]string{field.Name, formatted})
+	}
+
+	// Fields the schema does not know, sorted for a stable output.
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pairs = append(pairs, [2]string{name, formatValue(data[name])})
+	}
+	return pairs
+}
+
+// formatValue formats a value of entry data for a table cell.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return strings.Join(items, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	}
+}
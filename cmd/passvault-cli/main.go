@@ -0,0 +1,172 @@
+// Command passvault-cli works with a vault from the terminal through the API of a
+// passvault server:
+//
+//	passvault-cli login https://vault.example.com
+//	passvault-cli add --title=mail --username=alice --generate
+//	passvault-cli ls
+//	passvault-cli copy 3
+//
+// login stores the token the SSO service issued to the account, in the keyring of the
+// OS when secret-tool (Linux) or security (macOS) is installed and else in a file
+// encrypted with a passphrase, read from PASSVAULT_CLI_PASSPHRASE or the terminal.
+// PASSVAULT_SERVER and PASSVAULT_TOKEN override the stored server and token, for
+// scripts. Commands print tables, or the API responses as JSON with --output=json.
+// Run a command with -h for its flags; flags go before its arguments.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"passvault/pkg/client"
+	"syscall"
+)
+
+const (
+	serverEnv = "PASSVAULT_SERVER"
+	tokenEnv  = "PASSVAULT_TOKEN"
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+	// hidden commands are run by the CLI itself and left out of the usage.
+	hidden bool
+}
+
+var commands = []command{
+	{name: "login", summary: "store the token of an account", run: login},
+	{name: "logout", summary: "forget the stored token", run: logout},
+	{name: "add", summary: "add an entry", run: add},
+	{name: "get", summary: "show an entry", run: get},
+	{name: "ls", summary: "list entries", run: list},
+	{name: "rm", summary: "delete an entry", run: remove},
+	{name: "edit", summary: "edit the data of an entry in $EDITOR", run: edit},
+	{name: "generate", summary: "generate a password or passphrase", run: generatePassword},
+	{name: "copy", summary: "copy a secret of an entry to the clipboard", run: copySecret},
+	{name: "totp", summary: "show the current TOTP code of an entry", run: totpCode},
+	{name: "import", summary: "import entries from another password manager", run: importEntries},
+	{name: "export", summary: "export the vault", run: exportVault},
+	{name: clearClipboardCommand, run: clearClipboard, hidden: true},
+}
+
+// app holds the global flags and the configuration shared by the commands.
+type app struct {
+	server string
+	output string
+	cfg    *config
+	stdout io.Writer
+}
+
+func main() {
+	a := &app{stdout: os.Stdout}
+
+	flags := flag.NewFlagSet("passvault-cli", flag.ContinueOnError)
+	flags.StringVar(&a.server, "server", "", "URL of the passvault server, default $"+serverEnv+" or the server of the last login")
+	flags.StringVar(&a.output, "output", outputTable, "output format: table or json")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "usage: passvault-cli [flags] command [command flags] [arguments]")
+		fmt.Fprintln(out, "\ncommands:")
+		for _, cmd := range commands {
+			if !cmd.hidden {
+				fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+			}
+		}
+		fmt.Fprintln(out, "\nflags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		exit(err)
+	}
+	if a.output != outputTable && a.output != outputJSON {
+		exit(fmt.Errorf("unknown output format %q", a.output))
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name, args := flags.Arg(0), flags.Args()[1:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			exit(err)
+		}
+		a.cfg = cfg
+		if a.server == "" {
+			a.server = os.Getenv(serverEnv)
+		}
+		if a.server == "" {
+			a.server = cfg.Server
+		}
+
+		if err := cmd.run(ctx, a, args); err != nil {
+			stop()
+			exit(err)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+	flags.Usage()
+	os.Exit(2)
+}
+
+func exit(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	fmt.Fprintln(os.Stderr, "passvault-cli:", err)
+	if errors.Is(err, client.ErrUnauthorized) {
+		fmt.Fprintln(os.Stderr, "passvault-cli: the token was rejected, run passvault-cli login")
+	}
+	os.Exit(1)
+}
+
+// client returns a client of the server authenticated with the token of the account.
+func (a *app) client() (*client.Client, error) {
+	if a.server == "" {
+		return nil, errNotLoggedIn
+	}
+
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		store, err := newTokenStore(a.cfg.Store)
+		if err != nil {
+			return nil, err
+		}
+		token, err = store.Load(a.server)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return client.New(a.server, client.WithToken(token), client.WithUserAgent("passvault-cli"))
+}
+
+// newFlagSet returns the flags of a command; usage shows its arguments.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: passvault-cli %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes v as JSON, or calls table to write it for people.
+func (a *app) print(v any, table func() error) error {
+	if a.output == outputJSON {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	return table()
+}
+
+// message reports the outcome of a command that has no response of its own.
+func (a *app) message(v any, format string, args ...any) error {
+	return a.print(v, func() error {
+		_, err := fmt.Fprintf(a.stdout, format+"\n", args...)
+		return err
+	})
+}
+
+// table writes rows aligned in columns under header.
+func (a *app) table(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// fields writes name and value pairs as a two column table.
+func (a *app) fields(pairs ...[2]string) error {
+	rows := make([][]string, len(pairs))
+	for i, pair := range pairs {
+		rows[i] = []string{pair[0], pair[1]}
+	}
+	return a.table([]string{"FIELD", "VALUE"}, rows)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/generator/generate"
+	"passvault/internal/lib/entryschema"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/importer"
+	"passvault/pkg/client"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const exportPassphraseEnv = "PASSVAULT_EXPORT_PASSPHRASE"
+
+func generatePassword(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("generate", "[flags]")
+	passphrase := fs.Bool("passphrase", false, "generate a passphrase of words instead of a password")
+	length := fs.Int("length", 0, "length of a password, default 20")
+	words := fs.Int("words", 0, "words of a passphrase, default 5")
+	separator := fs.String("separator", "-", "separator of the words of a passphrase")
+	noSymbols := fs.Bool("no-symbols", false, "leave symbols out of a password")
+	excludeAmbiguous := fs.Bool("exclude-ambiguous", false, "leave characters such as l, 1, O and 0 out of a password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	req := generate.Request{Type: "password", Length: *length, ExcludeAmbiguous: *excludeAmbiguous}
+	if *noSymbols {
+		symbols := false
+		req.Symbols = &symbols
+	}
+	if *passphrase {
+		req = generate.Request{Type: "passphrase", Words: *words, Separator: separator}
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	generated, err := c.Generate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return a.print(generated, func() error {
+		return a.fields(
+			[2]string{"value", generated.Value},
+			[2]string{"entropy", fmt.Sprintf("%.0f bits", generated.Entropy)},
+		)
+	})
+}
+
+func copySecret(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("copy", "[flags] ID")
+	field := fs.String("field", "", "field to copy, default the first secret field of the entry type; totp copies the current code")
+	clearAfter := fs.Duration("clear-after", 45*time.Second, "clear the clipboard after this long, 0 to keep it")
+	id, err := parseEntryID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	entry, err := c.GetEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if *field == "" {
+		*field = secretField(entry.EntryType)
+	}
+
+	var value string
+	switch *field {
+	case "":
+		return fmt.Errorf("entries of type %s have no secret field, choose one with --field", entry.EntryType)
+	case "totp":
+		code, err := c.EntryTOTP(ctx, id)
+		if err != nil {
+			return err
+		}
+		value = code.Code
+	default:
+		value, err = entryField(entry, *field)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := copyToClipboard(value); err != nil {
+		return err
+	}
+	if *clearAfter > 0 {
+		if err := scheduleClear(value, *clearAfter); err != nil {
+			return fmt.Errorf("copied, but the clipboard will not be cleared: %w", err)
+		}
+		return a.message(map[string]any{"entry_id": id, "field": *field, "clear_after": clearAfter.String()},
+			"Copied %s of entry %d, clearing the clipboard in %s", *field, id, *clearAfter)
+	}
+	return a.message(map[string]any{"entry_id": id, "field": *field}, "Copied %s of entry %d", *field, id)
+}
+
+// secretField returns the field copy takes from entries of entryType by default.
+func secretField(entryType string) string {
+	if entryType == models.EntryTypeTOTP {
+		return "totp"
+	}
+	schema, ok := entryschema.Lookup(entryType)
+	if !ok {
+		return ""
+	}
+	for _, field := range schema.Fields {
+		if field.Secret {
+			return field.Name
+		}
+	}
+	return ""
+}
+
+func importEntries(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("import", "[flags] FILE")
+	format := fs.String("format", "", "format of the file: "+strings.Join(importer.Formats(), ", "))
+	password := fs.Bool("password", false, "type in the password of a KeePass database")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without saving anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *format == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req := client.ImportRequest{Format: *format, File: f, DryRun: *dryRun}
+	if *password {
+		req.Password, err = readSecret("Database password: ")
+		if err != nil {
+			return err
+		}
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	report, err := c.ImportEntries(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return a.print(report, func() error {
+		var rows [][]string
+		for _, group := range []struct {
+			status  string
+			results []importer.Result
+		}{
+			{"created", report.Created},
+			{"skipped", report.Skipped},
+			{"duplicate", report.Duplicates},
+		} {
+			for _, result := range group.results {
+				id := ""
+				if result.EntryID != 0 {
+					id = strconv.FormatInt(result.EntryID, 10)
+				}
+				rows = append(rows, []string{group.status, id, result.EntryType, result.Title, result.Reason})
+			}
+		}
+		if err := a.table([]string{"STATUS", "ID", "TYPE", "TITLE", "REASON"}, rows); err != nil {
+			return err
+		}
+
+		verb := "Imported"
+		if report.DryRun {
+			verb = "Would import"
+		}
+		_, err := fmt.Fprintf(a.stdout, "\n%s %d entries, skipped %d, found %d duplicates\n",
+			verb, len(report.Created), len(report.Skipped), len(report.Duplicates))
+		return err
+	})
+}
+
+// exportResult describes a finished export for --output=json.
+type exportResult struct {
+	File  string `json:"file"`
+	Bytes int64  `json:"bytes"`
+}
+
+func exportVault(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("export", "[flags]")
+	format := fs.String("format", exporter.FormatEncrypted, "format of the export: "+strings.Join(exporter.Formats(), ", "))
+	out := fs.String("out", "", `file to write the export to, "-" for stdout`)
+	confirmPlain := fs.Bool("confirm-plain", false, "write the secrets in plain text for the bitwarden and csv formats")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *out == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	opts := exporter.Options{Format: *format, ConfirmPlain: *confirmPlain}
+	if *format == exporter.FormatEncrypted {
+		opts.Passphrase = os.Getenv(exportPassphraseEnv)
+		if opts.Passphrase == "" {
+			passphrase, err := readSecret("Passphrase to encrypt the export with: ")
+			if err != nil {
+				return err
+			}
+			opts.Passphrase = passphrase
+		}
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		return c.Export(ctx, opts, os.Stdout)
+	}
+
+	// Written next to its destination and renamed, so a failed export leaves no partial file.
+	f, err := os.CreateTemp(filepath.Dir(*out), ".passvault-export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	counter := &countingWriter{w: f}
+	err = c.Export(ctx, opts, counter)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), *out); err != nil {
+		return err
+	}
+
+	return a.message(exportResult{File: *out, Bytes: counter.n}, "Exported %d bytes to %s", counter.n, *out)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
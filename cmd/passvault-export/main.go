@@ -0,0 +1,152 @@
+// Command passvault-export exports the personal vault of an account, like
+// GET /api/v1/export does:
+//
+//	passvault-export --storage-path=./storage/passvault.db --account-id=1 --out=vault.age
+//
+// The default format is an archive encrypted with the passphrase read from
+// --passphrase-file or the PASSVAULT_EXPORT_PASSPHRASE environment variable, see
+// package exporter for its layout. The plain text formats bitwarden and csv are only
+// written with --confirm-plain. The vault is decrypted with the master keys of the
+// server, read from the PASSVAULT_MASTER_KEY, PASSVAULT_MASTER_KEY_VERSION and
+// PASSVAULT_OLD_MASTER_KEYS environment variables, and every export is written to the
+// audit log of the account.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"passvault/config"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/exporter"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/postgres"
+	"passvault/internal/storage/sqlite"
+	"strings"
+)
+
+const passphraseEnv = "PASSVAULT_EXPORT_PASSPHRASE"
+
+func main() {
+	var storagePath, postgresDSN, format, out, passphraseFile string
+	var accountID int64
+	var confirmPlain bool
+
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "", "PostgreSQL database to export from instead of storage-path")
+	flag.Int64Var(&accountID, "account-id", 0, "account to export the vault of")
+	flag.StringVar(&format, "format", exporter.FormatEncrypted, "format of the export: "+strings.Join(exporter.Formats(), ", "))
+	flag.StringVar(&out, "out", "", "file to write the export to")
+	flag.StringVar(&passphraseFile, "passphrase-file", "", "file holding the passphrase of an encrypted export")
+	flag.BoolVar(&confirmPlain, "confirm-plain", false, "write the secrets in plain text for the bitwarden and csv formats")
+	flag.Parse()
+
+	if err := run(storagePath, postgresDSN, accountID, format, out, passphraseFile, confirmPlain); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(storagePath, postgresDSN string, accountID int64, format, out, passphraseFile string, confirmPlain bool) error {
+	if storagePath == "" && postgresDSN == "" {
+		return fmt.Errorf("storage-path or postgres-dsn is required")
+	}
+	if accountID == 0 || out == "" {
+		return fmt.Errorf("account-id and out are required")
+	}
+
+	masterKeys, err := config.MasterKeysFromEnv()
+	if err != nil {
+		return fmt.Errorf("master keys: %w", err)
+	}
+
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	db, err := open(storagePath, postgresDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	vault := encrypted.New(db, masterKeys)
+	service := exporter.New(log, vault)
+
+	opts := exporter.Options{Format: format, Passphrase: passphrase, ConfirmPlain: confirmPlain}
+
+	// The export is buffered so refused options and failures leave no file behind.
+	var export bytes.Buffer
+	if err := service.Export(context.Background(), accountID, &export, opts); err != nil {
+		if errors.Is(err, exporter.ErrUnknownFormat) || errors.Is(err, exporter.ErrPassphraseRequired) || errors.Is(err, exporter.ErrNotConfirmed) {
+			return err
+		}
+		return errors.Join(err, record(vault, accountID, format, http.StatusInternalServerError))
+	}
+
+	if err := os.WriteFile(out, export.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	return record(vault, accountID, format, http.StatusOK)
+}
+
+// record appends the export to the audit log, like the audit middleware of the API.
+func record(vault *encrypted.Storage, accountID int64, format string, status int) error {
+	action := models.AuditExportEncrypted
+	if exporter.Plain(format) {
+		action = models.AuditExportPlain
+	}
+
+	_, err := vault.AppendAuditEvent(context.Background(), models.AuditEvent{
+		AccountID: accountID,
+		Action:    action,
+		UserAgent: "passvault-export",
+		Status:    status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func open(storagePath, postgresDSN string) (storage.Backend, error) {
+	if postgresDSN != "" {
+		db, err := postgres.New(postgresDSN, storage.RevisionRetention{})
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	// Opening a missing database would create an empty one without a schema.
+	if _, err := os.Stat(storagePath); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.New(storagePath, storage.RevisionRetention{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// readPassphrase reads the passphrase from file, or from the environment when file is empty.
+func readPassphrase(file string) (string, error) {
+	if file == "" {
+		return os.Getenv(passphraseEnv), nil
+	}
+
+	passphrase, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
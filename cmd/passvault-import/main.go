@@ -0,0 +1,152 @@
+// Command passvault-import imports the entries exported from another password manager
+// into the vault of an account, like POST /api/v1/entries/import does:
+//
+//	passvault-import --storage-path=./storage/passvault.db --account-id=1 --format=bitwarden --file=export.json --dry-run
+//
+// Formats are bitwarden, keepass_xml, kdbx, 1pux and csv. The vault is encrypted with
+// the master keys of the server, read from the PASSVAULT_MASTER_KEY,
+// PASSVAULT_MASTER_KEY_VERSION and PASSVAULT_OLD_MASTER_KEYS environment variables.
+// The password of a KDBX database is read from --password-file or the
+// PASSVAULT_IMPORT_PASSWORD environment variable.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"passvault/config"
+	"passvault/internal/services/importer"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/postgres"
+	"passvault/internal/storage/sqlite"
+	"strings"
+)
+
+const passwordEnv = "PASSVAULT_IMPORT_PASSWORD"
+
+func main() {
+	var storagePath, postgresDSN, format, file, passwordFile string
+	var accountID int64
+	var dryRun, asJSON bool
+
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "", "PostgreSQL database to import into instead of storage-path")
+	flag.Int64Var(&accountID, "account-id", 0, "account to import the entries for")
+	flag.StringVar(&format, "format", "", "format of the export: "+strings.Join(importer.Formats(), ", "))
+	flag.StringVar(&file, "file", "", "export to import")
+	flag.StringVar(&passwordFile, "password-file", "", "file holding the password of a KDBX database")
+	flag.BoolVar(&dryRun, "dry-run", false, "only report what the import would do")
+	flag.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	flag.Parse()
+
+	if err := run(storagePath, postgresDSN, accountID, format, file, passwordFile, dryRun, asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(storagePath, postgresDSN string, accountID int64, format, file, passwordFile string, dryRun, asJSON bool) error {
+	if storagePath == "" && postgresDSN == "" {
+		return fmt.Errorf("storage-path or postgres-dsn is required")
+	}
+	if accountID == 0 || format == "" || file == "" {
+		return fmt.Errorf("account-id, format and file are required")
+	}
+
+	masterKeys, err := config.MasterKeysFromEnv()
+	if err != nil {
+		return fmt.Errorf("master keys: %w", err)
+	}
+
+	password, err := readPassword(passwordFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	db, err := open(storagePath, postgresDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := importer.New(log, encrypted.New(db, masterKeys))
+
+	report, err := service.Import(context.Background(), accountID, format, data, password, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printReport(report)
+	return nil
+}
+
+func open(storagePath, postgresDSN string) (storage.Backend, error) {
+	if postgresDSN != "" {
+		db, err := postgres.New(postgresDSN, storage.RevisionRetention{})
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	// Opening a missing database would create an empty one without a schema.
+	if _, err := os.Stat(storagePath); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.New(storagePath, storage.RevisionRetention{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func printReport(report *importer.Report) {
+	created := "created"
+	if report.DryRun {
+		created = "would create"
+	}
+
+	for _, result := range report.Created {
+		if result.EntryID != 0 {
+			fmt.Printf("%s\t%s\t%s (entry %d)\n", created, result.EntryType, result.Title, result.EntryID)
+		} else {
+			fmt.Printf("%s\t%s\t%s\n", created, result.EntryType, result.Title)
+		}
+	}
+	for _, result := range report.Duplicates {
+		fmt.Printf("duplicate\t%s\t%s: %s\n", result.EntryType, result.Title, result.Reason)
+	}
+	for _, result := range report.Skipped {
+		fmt.Printf("skipped\t%s\t%s: %s\n", result.EntryType, result.Title, result.Reason)
+	}
+
+	fmt.Printf("%d %s, %d duplicates, %d skipped\n", len(report.Created), created, len(report.Duplicates), len(report.Skipped))
+}
+
+// readPassword reads the password from file, or from the environment when file is empty.
+func readPassword(file string) (string, error) {
+	if file == "" {
+		return os.Getenv(passwordEnv), nil
+	}
+
+	password, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(password), "\r\n"), nil
+}
@@ -0,0 +1,214 @@
+// Command passvault-rotate-keys rotates the key of an account, like
+// POST /api/v1/admin/accounts/{accountID}/key-rotation does, and re-encrypts the
+// vault under the new key before it exits:
+//
+//	passvault-rotate-keys --storage-path=./storage/passvault.db --account-id=1
+//
+// --organization-id rotates the key shared by the collections of an organization
+// instead, and --master rotates the keys of every account and organization still keyed
+// with an older master key, like POST /api/v1/admin/master-key-rotation. Rotations the
+// master key rotation finds already running are left to the server or to a run for
+// that account or organization; the master key status printed at the end lists them.
+//
+// A rotation that failed or was interrupted is resumed from its saved progress
+// instead of starting another key version. With --status the latest rotation, or the
+// master key status, is printed and nothing is changed. The vault is decrypted with
+// the master keys of the server, read from the PASSVAULT_MASTER_KEY,
+// PASSVAULT_MASTER_KEY_VERSION and PASSVAULT_OLD_MASTER_KEYS environment variables, and
+// every rotation started is written to the audit log, of the account for account
+// rotations and of the server otherwise.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"passvault/config"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/postgres"
+	"passvault/internal/storage/sqlite"
+	"syscall"
+)
+
+func main() {
+	var storagePath, postgresDSN string
+	var accountID, orgID int64
+	var batchSize int
+	var master, status bool
+
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "", "PostgreSQL database to rotate in instead of storage-path")
+	flag.Int64Var(&accountID, "account-id", 0, "account to rotate the key of")
+	flag.Int64Var(&orgID, "organization-id", 0, "organization to rotate the key of")
+	flag.BoolVar(&master, "master", false, "rotate every key still derived with an older master key")
+	flag.IntVar(&batchSize, "batch-size", keyrotation.DefaultBatchSize, "entries re-encrypted between two progress saves")
+	flag.BoolVar(&status, "status", false, "print the latest key rotation, or the master key status, and exit")
+	flag.Parse()
+
+	// An interrupted rotation stays running and is resumed by the next run or the server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, storagePath, postgresDSN, accountID, orgID, master, batchSize, status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, storagePath, postgresDSN string, accountID, orgID int64, master bool, batchSize int, status bool) error {
+	if storagePath == "" && postgresDSN == "" {
+		return fmt.Errorf("storage-path or postgres-dsn is required")
+	}
+	targets := 0
+	for _, set := range []bool{accountID != 0, orgID != 0, master} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("exactly one of account-id, organization-id and master is required")
+	}
+
+	masterKeys, err := config.MasterKeysFromEnv()
+	if err != nil {
+		return fmt.Errorf("master keys: %w", err)
+	}
+
+	db, err := open(storagePath, postgresDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	vault := encrypted.New(db, masterKeys)
+	service := keyrotation.New(log, vault, batchSize)
+
+	if master {
+		return rotateMaster(ctx, service, vault, status)
+	}
+	return rotate(ctx, service, vault, accountID, orgID, status)
+}
+
+// rotate rotates the key of an account, or of an organization when orgID is set.
+func rotate(ctx context.Context, service *keyrotation.Service, vault *encrypted.Storage, accountID, orgID int64, status bool) error {
+	id, start, latest := accountID, service.Start, service.Status
+	if orgID != 0 {
+		id, start, latest = orgID, service.StartOrganization, service.OrganizationStatus
+	}
+
+	if status {
+		rotation, err := latest(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJSON(rotation)
+	}
+
+	rotation, err := start(ctx, id)
+	switch {
+	case errors.Is(err, storage.ErrKeyRotationRunning):
+		// Left running by an interrupted run; the server may be working on it too,
+		// which rewraps nothing twice.
+		rotation, err = latest(ctx, id)
+		if err != nil {
+			return err
+		}
+	case errors.Is(err, storage.ErrEncryptionKeyNotFound), errors.Is(err, storage.ErrOrganizationNotFound):
+		return errors.Join(err, record(vault, accountID, http.StatusNotFound))
+	case err != nil:
+		return errors.Join(err, record(vault, accountID, http.StatusInternalServerError))
+	default:
+		if err := record(vault, accountID, http.StatusAccepted); err != nil {
+			return err
+		}
+		if rotation.SharesRemoved > 0 {
+			fmt.Fprintf(os.Stderr, "%d recovery shares were removed, split the key part again\n", rotation.SharesRemoved)
+		}
+	}
+
+	done, err := service.Rotate(ctx, *rotation)
+	if err != nil {
+		return err
+	}
+	return printJSON(done)
+}
+
+// rotateMaster rotates every key still derived with an older master key and prints what
+// is left under one.
+func rotateMaster(ctx context.Context, service *keyrotation.Service, vault *encrypted.Storage, status bool) error {
+	if !status {
+		rotations, err := service.StartMaster(ctx)
+		if err != nil {
+			return errors.Join(err, record(vault, 0, http.StatusInternalServerError))
+		}
+		if err := record(vault, 0, http.StatusAccepted); err != nil {
+			return err
+		}
+
+		for _, rotation := range rotations {
+			if rotation.SharesRemoved > 0 {
+				fmt.Fprintf(os.Stderr, "account %d: %d recovery shares were removed, split the key part again\n",
+					rotation.AccountID, rotation.SharesRemoved)
+			}
+			if _, err := service.Rotate(ctx, rotation); err != nil {
+				return err
+			}
+		}
+	}
+
+	masterStatus, err := service.MasterStatus(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(masterStatus)
+}
+
+// record appends the rotation to the audit log, like the audit middleware of the API.
+func record(vault *encrypted.Storage, accountID int64, status int) error {
+	_, err := vault.AppendAuditEvent(context.Background(), models.AuditEvent{
+		AccountID: accountID,
+		Action:    models.AuditKeyRotate,
+		UserAgent: "passvault-rotate-keys",
+		Status:    status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func open(storagePath, postgresDSN string) (storage.Backend, error) {
+	if postgresDSN != "" {
+		db, err := postgres.New(postgresDSN, storage.RevisionRetention{})
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	// Opening a missing database would create an empty one without a schema.
+	if _, err := os.Stat(storagePath); err != nil {
+		return nil, err
+	}
+	db, err := sqlite.New(storagePath, storage.RevisionRetention{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -2,21 +2,27 @@ package main
 
 import (
 	"context"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"passvault/config"
 	"passvault/internal/clients/sso/grpc"
-	"passvault/internal/http-server/handlers/client/register"
-	"passvault/internal/http-server/handlers/entry/get"
-	"passvault/internal/http-server/handlers/entry/save"
-	authrest "passvault/internal/http-server/middlewares/auth"
-	mwLogger "passvault/internal/http-server/middlewares/logger"
+	"passvault/internal/http-server/router"
+	breachindex "passvault/internal/lib/breach"
 	"passvault/internal/lib/logger/sl"
-	storage "passvault/internal/storage/sqlite"
+	"passvault/internal/services/backup"
+	"passvault/internal/services/breach"
+	"passvault/internal/services/health"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/memory"
+	"passvault/internal/storage/postgres"
+	"passvault/internal/storage/schema"
+	"passvault/internal/storage/sqlite"
+	"passvault/migrations"
 	"syscall"
 )
 
@@ -30,20 +36,37 @@ const (
 	grpcHost = "localhost"
 )
 
+const (
+	storageSQLite   = "sqlite"
+	storagePostgres = "postgres"
+	storageMemory   = "memory"
+)
+
+// memoryPath selects the memory storage driver like SQLite in-memory databases are opened.
+const memoryPath = ":memory:"
+
 func main() {
 	cfg := config.MustLoad()
 
-	db, err := storage.New(cfg.StoragePath)
+	masterKeys, err := cfg.Encryption.MasterKeys()
 	if err != nil {
 		panic(err)
 	}
 
-	defer db.Close()
-
 	log := setupLogger(cfg.Env)
 
 	log = log.With(slog.String("env", cfg.Env))
 
+	db, err := setupStorage(log, cfg)
+	if err != nil {
+		log.Error("failed to set up storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	defer db.Close()
+
+	vault := encrypted.New(db, masterKeys)
+
 	log.Info("initializing server", slog.String("address", cfg.Address))
 	log.Debug("logger debug mode enabled")
 
@@ -53,26 +76,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	router := chi.NewRouter()
+	breaches, closeBreaches, err := setupBreaches(log, cfg)
+	if err != nil {
+		log.Error("failed to open breach index", sl.Err(err))
+		os.Exit(1)
+	}
+
+	defer closeBreaches()
 
-	authMiddleware := authrest.New(slog.New(
-		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-	), cfg.Secret)
+	rotations := keyrotation.New(log, vault, cfg.KeyRotation.BatchSize)
 
-	router.Use(authMiddleware)
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
-	router.Use(mwLogger.New(log))
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
+	healthReporter := health.New(log, vault, vault, revisionRetention(cfg))
 
-	router.Post("/save", save.New(log, db, cfg.HTTPServer.Timeout))
+	handler := router.New(log, cfg.Secret, cfg.HTTPServer.Timeout, db, vault, breaches, rotations, healthReporter, cfg.Admin.Accounts, grpcClient)
 
-	router.Get("/get/{entry_id}", get.New(log, db, cfg.HTTPServer.Timeout))
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
-	router.Get("/list", get.New(log, db, cfg.HTTPServer.Timeout))
+	if err := startBackups(workerCtx, log, cfg, db); err != nil {
+		log.Error("failed to schedule backups", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router.Get("/register", register.New(log, grpcClient, cfg.HTTPServer.Timeout))
+	// Key rotations left running by a previous process are resumed right away.
+	go rotations.Run(workerCtx)
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
@@ -81,7 +108,7 @@ func main() {
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
-		Handler:      router,
+		Handler:      handler,
 		ReadTimeout:  cfg.HTTPServer.Timeout,
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
@@ -98,6 +125,8 @@ func main() {
 	<-done
 	log.Info("stopping server")
 
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
 	defer cancel()
 
@@ -134,3 +163,139 @@ func setupLogger(env string) *slog.Logger {
 
 	return log
 }
+
+// revisionRetention returns how many entry revisions the storage keeps, and for how long.
+func revisionRetention(cfg *config.Config) storage.RevisionRetention {
+	return storage.RevisionRetention{
+		MaxCount: cfg.Revisions.MaxCount,
+		MaxAge:   cfg.Revisions.MaxAge,
+	}
+}
+
+// setupStorage opens the storage backend selected by the config after bringing
+// its schema up to date.
+func setupStorage(log *slog.Logger, cfg *config.Config) (storage.Backend, error) {
+	retention := revisionRetention(cfg)
+
+	driver := cfg.Storage.Driver
+	if driver == storageSQLite && cfg.StoragePath == memoryPath {
+		driver = storageMemory
+	}
+
+	switch driver {
+	case storageMemory:
+		return memory.New(retention), nil
+	case storageSQLite:
+		if cfg.StoragePath == "" {
+			return nil, fmt.Errorf("storage_path is required by the %s storage driver", storageSQLite)
+		}
+		if err := migrateSchema(log, storageSQLite, func() (*schema.Migrator, error) {
+			return schema.NewSQLite(cfg.StoragePath, schema.DefaultTable, migrations.SQLite)
+		}); err != nil {
+			return nil, err
+		}
+		// A nil *sqlite.Storage returned as a Backend would not compare equal to nil.
+		db, err := sqlite.New(cfg.StoragePath, retention)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case storagePostgres:
+		if cfg.Storage.DSN == "" {
+			return nil, fmt.Errorf("storage.dsn is required by the %s storage driver", storagePostgres)
+		}
+		if err := migrateSchema(log, storagePostgres, func() (*schema.Migrator, error) {
+			return schema.NewPostgres(cfg.Storage.DSN, schema.DefaultTable, migrations.Postgres)
+		}); err != nil {
+			return nil, err
+		}
+		db, err := postgres.New(cfg.Storage.DSN, retention)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// migrateSchema applies the pending migrations of a storage driver. It fails on a dirty
+// schema or one written by a newer server, which cmd/migrator has to sort out.
+func migrateSchema(log *slog.Logger, driver string, open func() (*schema.Migrator, error)) error {
+	m, err := open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	before, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Up()
+	if err != nil {
+		return err
+	}
+
+	log.Info("storage schema ready",
+		slog.String("driver", driver),
+		slog.Uint64("version", uint64(version)),
+		slog.Uint64("migrated_from", uint64(before)),
+	)
+
+	return nil
+}
+
+// setupBreaches opens the breached password index when the config sets one. The
+// returned func closes it.
+func setupBreaches(log *slog.Logger, cfg *config.Config) (*breach.Service, func(), error) {
+	if cfg.Breach.Index == "" {
+		log.Info("breach checks disabled")
+		return breach.New(log, nil), func() {}, nil
+	}
+
+	index, err := breachindex.Open(cfg.Breach.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Info("breach index loaded",
+		slog.String("path", cfg.Breach.Index),
+		slog.Uint64("hashes", index.Count()),
+	)
+
+	return breach.New(log, index), func() { index.Close() }, nil
+}
+
+// startBackups starts writing scheduled backups of the sqlite storage in the background
+// when the config asks for them.
+func startBackups(ctx context.Context, log *slog.Logger, cfg *config.Config, db storage.Backend) error {
+	if cfg.Backup.Interval <= 0 {
+		return nil
+	}
+
+	snapshotter, ok := db.(backup.Snapshotter)
+	if !ok {
+		return fmt.Errorf("scheduled backups are not supported by the %s storage driver", cfg.Storage.Driver)
+	}
+	if cfg.Backup.Dir == "" {
+		return fmt.Errorf("backup.dir is required by scheduled backups")
+	}
+
+	recipients, err := backup.Recipients(cfg.Backup.Recipients, cfg.Backup.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	scheduler := backup.NewScheduler(log, snapshotter, recipients, cfg.Backup.Dir, cfg.Backup.Interval, cfg.Backup.Keep)
+	go scheduler.Run(ctx)
+
+	log.Info("scheduled backups",
+		slog.String("dir", cfg.Backup.Dir),
+		slog.Duration("interval", cfg.Backup.Interval),
+		slog.Int("keep", cfg.Backup.Keep),
+	)
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"os"
+	"passvault/internal/lib/envelope"
 	"time"
 )
 
@@ -16,11 +17,93 @@ type GRPCConfig struct {
 	RetriesCount int           `yaml:"reties_count" env-default:"60s"`
 }
 
+type EncryptionConfig struct {
+	// MasterKey is a base64 encoded 32 byte key. Account data keys are derived
+	// from it, so losing it makes every stored entry unreadable.
+	MasterKey string `yaml:"master_key" env:"PASSVAULT_MASTER_KEY" env-required:"true"`
+	// MasterKeyVersion numbers MasterKey. Rotating the master key means giving the new
+	// key the next version, moving the previous one to OldMasterKeys and starting a
+	// master key rotation from the admin API or passvault-rotate-keys --master.
+	MasterKeyVersion int `yaml:"master_key_version" env:"PASSVAULT_MASTER_KEY_VERSION" env-default:"1"`
+	// OldMasterKeys are the previous master keys by version, as "1:<key>,2:<key>" in
+	// the environment. A version can be removed once the master key rotation reports
+	// nothing left under it, but audit events chained before the rotation only verify
+	// while it is listed.
+	OldMasterKeys map[int]string `yaml:"old_master_keys" env:"PASSVAULT_OLD_MASTER_KEYS"`
+}
+
+// MasterKeys decodes the current and old master keys.
+func (c EncryptionConfig) MasterKeys() (*envelope.MasterKeys, error) {
+	return envelope.ParseMasterKeys(c.MasterKeyVersion, c.MasterKey, c.OldMasterKeys)
+}
+
+// MasterKeysFromEnv reads the master keys from the PASSVAULT_MASTER_KEY* environment
+// variables alone, for the command-line tools that do not load a config file.
+func MasterKeysFromEnv() (*envelope.MasterKeys, error) {
+	var cfg EncryptionConfig
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+	return cfg.MasterKeys()
+}
+
+// RevisionsConfig sets how many previous versions of an entry are kept.
+// Zero disables a limit.
+type RevisionsConfig struct {
+	MaxCount int           `yaml:"max_count" env-default:"50"`
+	MaxAge   time.Duration `yaml:"max_age" env-default:"0s"`
+}
+
+// StorageConfig selects the storage backend. The sqlite driver stores the vault in
+// the file at Config.StoragePath, the postgres driver connects to DSN and the memory
+// driver keeps it in memory until the server stops. A storage_path of ":memory:"
+// selects the memory driver too.
+type StorageConfig struct {
+	Driver string `yaml:"driver" env:"PASSVAULT_STORAGE_DRIVER" env-default:"sqlite"`
+	DSN    string `yaml:"dsn" env:"PASSVAULT_POSTGRES_DSN"`
+}
+
+// BackupConfig schedules encrypted backups of the sqlite storage into Dir. They are
+// encrypted to the age public keys in Recipients, or with Passphrase when there are
+// none. An Interval of zero disables them and Keep of zero keeps every backup.
+type BackupConfig struct {
+	Dir        string        `yaml:"dir" env:"PASSVAULT_BACKUP_DIR"`
+	Interval   time.Duration `yaml:"interval" env-default:"0s"`
+	Keep       int           `yaml:"keep" env-default:"7"`
+	Recipients []string      `yaml:"recipients"`
+	Passphrase string        `yaml:"passphrase" env:"PASSVAULT_BACKUP_PASSPHRASE"`
+}
+
+// BreachConfig points to the breached password index built from a Pwned Passwords
+// dump by cmd/passvault-breach-index. Without an Index breach checks are disabled.
+type BreachConfig struct {
+	Index string `yaml:"index" env:"PASSVAULT_BREACH_INDEX"`
+}
+
+// AdminConfig lists the accounts allowed to use the admin API, such as starting
+// key rotations. Without any the admin API is closed.
+type AdminConfig struct {
+	Accounts []int64 `yaml:"accounts" env:"PASSVAULT_ADMIN_ACCOUNTS" env-separator:","`
+}
+
+// KeyRotationConfig sets how many entries the key rotation worker re-encrypts
+// between two progress saves.
+type KeyRotationConfig struct {
+	BatchSize int `yaml:"batch_size" env-default:"100"`
+}
+
 type Config struct {
-	Env         string     `yaml:"env" env-default:"development"`
-	GRPC        GRPCConfig `yaml:"grpc"`
-	StoragePath string     `yaml:"storage_path" env-required:"true"`
-	Secret      string     `yaml:"secret" env-required:"true"`
+	Env         string            `yaml:"env" env-default:"development"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	StoragePath string            `yaml:"storage_path"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Secret      string            `yaml:"secret" env-required:"true"`
+	Encryption  EncryptionConfig  `yaml:"encryption"`
+	Revisions   RevisionsConfig   `yaml:"revisions"`
+	Backup      BackupConfig      `yaml:"backup"`
+	Breach      BreachConfig      `yaml:"breach"`
+	Admin       AdminConfig       `yaml:"admin"`
+	KeyRotation KeyRotationConfig `yaml:"key_rotation"`
 	HTTPServer  `yaml:"http_server"`
 }
 
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+	AuditEntryCreate     = "entry.create"
+	AuditEntryList       = "entry.list"
+	AuditEntrySearch     = "entry.search"
+	AuditEntryRead       = "entry.read"
+	AuditEntryUpdate     = "entry.update"
+	AuditEntryDelete     = "entry.delete"
+	AuditEntryMove       = "entry.move"
+	AuditEntryTag        = "entry.tag"
+	AuditEntryCollection = "entry.collection"
+	AuditEntryImport     = "entry.import"
+	AuditEntryTOTP       = "entry.totp"
+	AuditRevisionList    = "revision.list"
+	AuditRevisionRead    = "revision.read"
+	AuditRevisionRestore = "revision.restore"
+	AuditShareCreate     = "share.create"
+	AuditShareList       = "share.list"
+	AuditShareRevoke     = "share.revoke"
+	AuditShareReceived   = "share.received"
+	AuditKeySave         = "key.save"
+	AuditKeyRead         = "key.read"
+	AuditKeyDelete       = "key.delete"
+	AuditKeySplit        = "key.split"
+	AuditKeyRecover      = "key.recover"
+	AuditKeyZKEnable     = "key.zero_knowledge"
+	AuditKeyRotate       = "key.rotate"
+	AuditExportEncrypted = "export.encrypted"
+	AuditExportPlain     = "export.plain"
+	AuditReportHealth    = "report.health"
+)
+
+// AuditEvent records one request of an account against the vault. Status is the
+// HTTP status of the response, so denied attempts are recorded too. Hash chains
+// the event to the one before it, see package auditchain.
+type AuditEvent struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	AccountID int64     `json:"account_id"`
+	Action    string    `json:"action"`
+	EntryID   *int64    `json:"entry_id,omitempty"`
+	RequestID string    `json:"request_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Status    int       `json:"status"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditFilter narrows down the audit events of an account. Zero values match everything.
+type AuditFilter struct {
+	EntryID *int64
+	Action  string
+	// Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
+	// BeforeID pages backwards through the log: only events with a lower ID match.
+	BeforeID int64
+	Limit    int
+}
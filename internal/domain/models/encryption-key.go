@@ -7,5 +7,16 @@ type EncryptionKey struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	AccountId int64
-	KeyPart   string
+	// KeyPart is the key part itself, or for Shamir shares (ShareIndex above 0) a
+	// commitment to the share; shares are never stored.
+	KeyPart    string
+	ShareIndex int
+	Threshold  int
+	// Holder is who a share was handed to.
+	Holder string
+	// KeyVersion numbers the key parts of an account, a key rotation adds the next one.
+	KeyVersion int
+	// MasterKeyVersion is the version of the server master key the key part derives
+	// keys with.
+	MasterKeyVersion int
 }
@@ -0,0 +1,18 @@
+package models
+
+// EntryAccess is what an account may do with an entry. Personal entries are fully
+// accessible to their owner and to others through shares; entries in a collection
+// are accessible according to the organization role of the account.
+type EntryAccess struct {
+	EntryID int64
+	// OwnerID is the account_id of the entry. Its key encrypts personal entries.
+	OwnerID int64
+	// CollectionID and OrganizationID are set for entries in a collection,
+	// which are encrypted with the organization key.
+	CollectionID   *int64
+	OrganizationID *int64
+
+	Read   bool
+	Write  bool
+	Delete bool
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+)
+
+// EntryRevision is a previous version of an entry. Action tells what replaced it.
+type EntryRevision struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	EntryID   int64     `json:"entry_id"`
+	AccountId int64     `json:"account_id"`
+	Action    string    `json:"action"`
+	EntryType string    `json:"entry_type"`
+	EntryData string    `json:"entry_data,omitempty"`
+	// CollectionID is the collection the entry was in at the time of the revision.
+	CollectionID *int64 `json:"collection_id,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Write access includes read access.
+const (
+	SharePermissionRead  = "read"
+	SharePermissionWrite = "write"
+)
+
+// EntryShare grants AccountID access to an entry of OwnerID until ExpiresAt, or
+// until it is revoked when ExpiresAt is nil.
+type EntryShare struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EntryID    int64      `json:"entry_id"`
+	OwnerID    int64      `json:"owner_id"`
+	AccountID  int64      `json:"account_id"`
+	Permission string     `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
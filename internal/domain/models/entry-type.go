@@ -0,0 +1,13 @@
+package models
+
+// Entry types accepted by the API. The fields each type carries in entry_data
+// are described by the schemas in internal/lib/entryschema.
+const (
+	EntryTypeLogin      = "login"
+	EntryTypeSecureNote = "secure_note"
+	EntryTypeCreditCard = "credit_card"
+	EntryTypeSSHKey     = "ssh_key"
+	EntryTypeAPIToken   = "api_token"
+	EntryTypeIdentity   = "identity"
+	EntryTypeTOTP       = "totp"
+)
@@ -3,10 +3,57 @@ package models
 import "time"
 
 type Entry struct {
-	ID        int64
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	AccountId int64
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AccountId int64     `json:"account_id"`
+	EntryType string    `json:"entry_type"`
+	EntryData string    `json:"entry_data"`
+	FolderID  *int64    `json:"folder_id,omitempty"`
+	TagIDs    []int64   `json:"tag_ids,omitempty"`
+	// CollectionID is set for entries of an organization collection.
+	CollectionID *int64 `json:"collection_id,omitempty"`
+	// ProtocolVersion is set for entry data encrypted by a zero-knowledge client,
+	// see package vaultcrypto.
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+}
+
+// EntryFilter narrows down ListEntries. Zero values match every personal entry.
+type EntryFilter struct {
+	// CollectionID lists the entries of a collection instead of personal entries.
+	CollectionID *int64
+	// FolderID limits entries to a folder; a pointer to 0 selects entries outside any folder.
+	FolderID *int64
+	// TagIDs limits entries to those carrying all the given tags.
+	TagIDs []int64
+	// EntryType limits entries to a single type.
 	EntryType string
-	EntryData string
+	// CreatedAfter, CreatedBefore, UpdatedAfter and UpdatedBefore bound the
+	// entry timestamps; the lower bounds are inclusive, the upper ones exclusive.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+}
+
+const (
+	EntrySortName      = "name"
+	EntrySortCreatedAt = "created_at"
+	EntrySortUpdatedAt = "updated_at"
+)
+
+// EntryQuery selects one page of entries for ListEntriesPage.
+type EntryQuery struct {
+	Filter EntryFilter
+	// Sort is one of the EntrySort constants, entries with equal keys are ordered by ID.
+	Sort       string
+	Descending bool
+	Limit      int
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+type EntryPage struct {
+	Entries    []Entry `json:"entries"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AccountId int64     `json:"account_id"`
+	ParentID  *int64    `json:"parent_id"`
+	Name      string    `json:"name"`
+}
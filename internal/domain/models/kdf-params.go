@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// KDFParams are the key derivation parameters of an account in zero-knowledge mode,
+// see package vaultcrypto. Clients fetch them to derive the key they encrypt entries
+// with. Salt is base64 encoded and KeyCheck is a ciphertext telling clients whether
+// they derived the right key. Accounts with KDF parameters only accept client-side
+// encrypted entries.
+type KDFParams struct {
+	AccountID       int64     `json:"account_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	Algorithm       string    `json:"algorithm"`
+	Iterations      int       `json:"iterations"`
+	Memory          int       `json:"memory"`
+	Parallelism     int       `json:"parallelism"`
+	Salt            string    `json:"salt"`
+	KeyCheck        string    `json:"key_check"`
+	ProtocolVersion int       `json:"protocol_version"`
+}
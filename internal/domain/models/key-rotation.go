@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	KeyRotationRunning = "running"
+	KeyRotationDone    = "done"
+	KeyRotationFailed  = "failed"
+)
+
+// KeyRotation tracks the re-encryption of the personal entries and revisions of an
+// account under key version ToVersion, or with OrganizationID set, of the entries and
+// revisions in the collections of an organization. EntryCursor and RevisionCursor are
+// the IDs of the last rows rewrapped, so a rotation resumes after them. Entries and
+// Revisions count the rows rewrapped so far. SharesRemoved counts the Shamir shares
+// of the previous account key part dropped when the rotation started: they cannot
+// recover the new key part, so the account has to split it again.
+type KeyRotation struct {
+	ID             int64      `json:"id"`
+	AccountID      int64      `json:"account_id,omitempty"`
+	OrganizationID int64      `json:"organization_id,omitempty"`
+	FromVersion    int        `json:"from_version"`
+	ToVersion      int        `json:"to_version"`
+	Status         string     `json:"status"`
+	EntryCursor    int64      `json:"entry_cursor"`
+	RevisionCursor int64      `json:"revision_cursor"`
+	Entries        int        `json:"entries"`
+	Revisions      int        `json:"revisions"`
+	SharesRemoved  int        `json:"shares_removed"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// MasterKeyStatus lists the accounts and organizations whose current key part is still
+// derived under a master key version older than MasterKeyVersion. Old master keys can
+// be removed from the configuration once both lists are empty.
+type MasterKeyStatus struct {
+	MasterKeyVersion int     `json:"master_key_version"`
+	Accounts         []int64 `json:"accounts"`
+	Organizations    []int64 `json:"organizations"`
+}
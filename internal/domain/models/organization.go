@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+// orgRoleRank orders organization roles from least to most privileged.
+var orgRoleRank = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleAdmin:  3,
+	OrgRoleOwner:  4,
+}
+
+// ValidOrgRole reports whether role is one of the organization roles.
+func ValidOrgRole(role string) bool {
+	return orgRoleRank[role] > 0
+}
+
+// OrgRoleAtLeast reports whether role grants everything min does.
+func OrgRoleAtLeast(role, min string) bool {
+	return ValidOrgRole(role) && orgRoleRank[role] >= orgRoleRank[min]
+}
+
+// Organization is returned with the Role of the account that asked for it.
+type Organization struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+}
+
+type OrganizationMember struct {
+	OrganizationID int64     `json:"organization_id"`
+	AccountID      int64     `json:"account_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Collection is a vault shared by the members of an organization.
+type Collection struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID int64     `json:"organization_id"`
+	Name           string    `json:"name"`
+}
+
+// OrganizationKey is a version of the key part of an organization. Like account key
+// parts they are versioned, a key rotation adds the next one.
+type OrganizationKey struct {
+	ID               int64
+	CreatedAt        time.Time
+	OrganizationID   int64
+	KeyPart          string
+	KeyVersion       int
+	MasterKeyVersion int
+}
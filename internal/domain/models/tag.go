@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AccountId int64     `json:"account_id"`
+	Name      string    `json:"name"`
+}
@@ -0,0 +1,130 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type AuditEventLister interface {
+	ListAuditEvents(ctx context.Context, accountID int64, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+// New lists the audit events of the caller, newest first.
+func New(log *slog.Logger, eventLister AuditEventLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.audit.list.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		filter, err := parseFilter(r)
+		if err != nil {
+			log.Error("invalid query", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		events, err := eventLister.ListAuditEvents(ctx, claims.AccountID, filter)
+		if err != nil {
+			log.Error("failed to retrieve audit events", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve audit events"))
+			return
+		}
+
+		if events == nil {
+			events = []models.AuditEvent{}
+		}
+
+		log.Info("audit events retrieved", slog.Int("count", len(events)))
+		render.JSON(w, r, events)
+	}
+}
+
+// parseFilter reads the query parameters entry_id, action, since and until as
+// RFC 3339 times, before_id, the lowest ID of the previous page, and limit (1 to
+// 500, default 50).
+func parseFilter(r *http.Request) (models.AuditFilter, error) {
+	filter := models.AuditFilter{Limit: defaultLimit}
+	values := r.URL.Query()
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := values.Get("entry_id"); value != "" {
+		entryID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || entryID <= 0 {
+			return filter, errors.New("invalid entry_id")
+		}
+		filter.EntryID = &entryID
+	}
+
+	if value := values.Get("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return filter, errors.New("invalid before_id")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	filter.Action = values.Get("action")
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	}
+	for _, param := range times {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s", param.name)
+		}
+		*param.dst = &t
+	}
+
+	return filter, nil
+}
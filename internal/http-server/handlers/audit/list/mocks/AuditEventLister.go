@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockAuditEventLister struct {
+	mock.Mock
+}
+
+func (m *MockAuditEventLister) ListAuditEvents(ctx context.Context, accountID int64, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	args := m.Called(ctx, accountID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditEvent), args.Error(1)
+}
+
+type mockConstructorTestingTAuditEventLister interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewAuditEventLister(t mockConstructorTestingTAuditEventLister) *MockAuditEventLister {
+	mock := &MockAuditEventLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,101 @@
+package check
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/breach"
+)
+
+type Request struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Response reports whether the password appears in the breach corpus.
+type Response struct {
+	resp.Response
+	Breached bool `json:"breached"`
+}
+
+type BreachChecker interface {
+	Check(password string) (bool, error)
+}
+
+// New checks a password against the local breach corpus. Nothing is stored.
+func New(log *slog.Logger, breachChecker BreachChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.breach.check.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		breached, err := breachChecker.Check(req.Password)
+		if err != nil {
+			if errors.Is(err, breach.ErrNotConfigured) {
+				log.Info("breach corpus is not configured")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				render.JSON(w, r, resp.Error("breach check is not available"))
+				return
+			}
+			log.Error("failed to check password", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to check password"))
+			return
+		}
+
+		// The password is a secret, only the outcome is logged.
+		log.Info("password checked", slog.Bool("breached", breached))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Breached: breached,
+		})
+	}
+}
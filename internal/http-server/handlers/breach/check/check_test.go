@@ -0,0 +1,107 @@
+package check_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/breach/check"
+	mocks "passvault/internal/http-server/handlers/breach/check/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/services/breach"
+	"testing"
+)
+
+func TestCheckHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		breached   bool
+		mockError  error
+		mock       bool
+		respError  string
+		respStatus int
+	}{
+		{
+			name:       "Breached",
+			body:       `{"password": "password"}`,
+			breached:   true,
+			mock:       true,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Not Breached",
+			body:       `{"password": "v3ry-uncommon-s3cret"}`,
+			mock:       true,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Empty Password",
+			body:       `{"password": ""}`,
+			respError:  "field Password is a required field",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Empty Request",
+			body:       "",
+			respError:  "empty request",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not Configured",
+			body:       `{"password": "password"}`,
+			mock:       true,
+			mockError:  fmt.Errorf("services.breach.Check: %w", breach.ErrNotConfigured),
+			respError:  "breach check is not available",
+			respStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Check Error",
+			body:       `{"password": "password"}`,
+			mock:       true,
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to check password",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			breachCheckerMock := mocks.NewBreachChecker(t)
+
+			if tc.mock {
+				var req check.Request
+				require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+				breachCheckerMock.On("Check", req.Password).
+					Return(tc.breached, tc.mockError).
+					Once()
+			}
+
+			handler := check.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), breachCheckerMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/breach-check", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(handler, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp check.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.breached, resp.Breached)
+		})
+	}
+}
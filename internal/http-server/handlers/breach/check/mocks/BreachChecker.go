@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBreachChecker struct {
+	mock.Mock
+}
+
+func (m *MockBreachChecker) Check(password string) (bool, error) {
+	args := m.Called(password)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockConstructorTestingTBreachChecker interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewBreachChecker(t mockConstructorTestingTBreachChecker) *MockBreachChecker {
+	mock := &MockBreachChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,36 +3,68 @@ package register
 import (
 	"context"
 	"errors"
-	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
 	"log/slog"
 	"net/http"
-	"passvault/internal/clients/sso/grpc"
 	resp "passvault/internal/lib/api/response"
 	"passvault/internal/lib/logger/sl"
 	"time"
 )
 
-type EntryRegister interface {
-	RegisterClient(ctx context.Context, appName string, secret string, redirectUrl string) (int, error)
+type Request struct {
+	AppName     string `json:"app_name" validate:"required"`
+	Secret      string `json:"secret" validate:"required"`
+	RedirectUrl string `json:"redirect_url" validate:"required,url"`
 }
 
-func New(log *slog.Logger, grpcClient *grpc.Client, timeout time.Duration) http.HandlerFunc {
+type Response struct {
+	resp.Response
+	AppID int64 `json:"app_id,omitempty"`
+}
+
+type ClientRegisterer interface {
+	RegisterClient(ctx context.Context, appName string, secret string, redirectUrl string) (int64, error)
+}
+
+func New(log *slog.Logger, clientRegisterer ClientRegisterer, timeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.client.register.New"
 
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		appName := chi.URLParam(r, "appName")
-		secret := chi.URLParam(r, "secret")
-		redirectUrl := chi.URLParam(r, "redirectUrl")
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
 
 		select {
 		case <-ctx.Done():
@@ -43,21 +75,25 @@ func New(log *slog.Logger, grpcClient *grpc.Client, timeout time.Duration) http.
 		default:
 		}
 
-		entry, err := grpcClient.RegisterClient(ctx, appName, secret, redirectUrl)
+		appID, err := clientRegisterer.RegisterClient(ctx, req.AppName, req.Secret, req.RedirectUrl)
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				log.Error("request timeout", slog.String("appName", appName))
+				log.Error("request timeout", slog.String("appName", req.AppName))
 				w.WriteHeader(http.StatusGatewayTimeout)
 				render.JSON(w, r, resp.Error("request timed out"))
 				return
 			}
 			log.Error("failed to register client", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("failed to retrieve entry"))
+			render.JSON(w, r, resp.Error("failed to register client"))
 			return
 		}
 
-		log.Info("app registered", slog.String("appName", appName))
-		render.JSON(w, r, entry)
+		log.Info("app registered", slog.String("appName", req.AppName))
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			AppID:    appID,
+		})
 	}
 }
@@ -0,0 +1,115 @@
+package create
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id"`
+}
+
+type CollectionCreator interface {
+	CreateCollection(ctx context.Context, orgID int64, name string) (int64, error)
+}
+
+// New creates a collection in an organization. The route must be limited to admins.
+func New(log *slog.Logger, collectionCreator CollectionCreator, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.collection.create.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		collectionID, err := collectionCreator.CreateCollection(ctx, id, req.Name)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrCollectionExists):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("collection already exists"))
+			default:
+				log.Error("failed to create collection", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to create collection"))
+			}
+			return
+		}
+
+		log.Info("collection created", slog.Int64("orgID", id), slog.Int64("collectionID", collectionID))
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       collectionID,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type CollectionDeleter interface {
+	DeleteCollection(ctx context.Context, orgID int64, collectionID int64) error
+}
+
+// New deletes an empty collection. The route must be limited to admins.
+func New(log *slog.Logger, collectionDeleter CollectionDeleter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.collection.delete.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		collectionParam := chi.URLParam(r, "collectionID")
+		collectionID, err := strconv.ParseInt(collectionParam, 10, 64)
+		if err != nil {
+			log.Error("invalid collectionID parameter", slog.String("collectionID", collectionParam))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid collectionID"))
+			return
+		}
+
+		if err := collectionDeleter.DeleteCollection(ctx, id, collectionID); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrCollectionNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("collection not found"))
+			case errors.Is(err, storage.ErrCollectionNotEmpty):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("collection still has entries"))
+			default:
+				log.Error("failed to delete collection", slog.Int64("collectionID", collectionID), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to delete collection"))
+			}
+			return
+		}
+
+		log.Info("collection deleted", slog.Int64("collectionID", collectionID))
+		render.JSON(w, r, resp.OK())
+	}
+}
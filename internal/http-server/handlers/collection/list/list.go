@@ -0,0 +1,69 @@
+package list
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"strconv"
+	"time"
+)
+
+type CollectionLister interface {
+	ListCollections(ctx context.Context, orgID int64) ([]models.Collection, error)
+}
+
+// New returns the collections of an organization.
+func New(log *slog.Logger, collectionLister CollectionLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.collection.list.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		collections, err := collectionLister.ListCollections(ctx, id)
+		if err != nil {
+			log.Error("failed to retrieve collections", slog.Int64("orgID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve collections"))
+			return
+		}
+
+		if collections == nil {
+			collections = []models.Collection{}
+		}
+
+		log.Info("collections retrieved", slog.Int("count", len(collections)))
+		render.JSON(w, r, collections)
+	}
+}
@@ -0,0 +1,63 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"time"
+)
+
+type KeyPartDeleter interface {
+	DeleteKeyPart(ctx context.Context, accountID int64) error
+}
+
+func New(log *slog.Logger, keyPartDeleter KeyPartDeleter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.delete.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := keyPartDeleter.DeleteKeyPart(ctx, claims.AccountID); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEncryptionKeyNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("encryption key not found"))
+			case errors.Is(err, storage.ErrEncryptionKeyInUse):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("encryption key is still used by entries and has no recovery shares"))
+			default:
+				log.Error("failed to delete key part", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to delete key part"))
+			}
+			return
+		}
+
+		log.Info("key part deleted", slog.Int64("accountID", claims.AccountID))
+		render.JSON(w, r, resp.OK())
+	}
+}
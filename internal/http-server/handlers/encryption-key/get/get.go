@@ -0,0 +1,68 @@
+package get
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+	KeyPart string `json:"key_part,omitempty"`
+}
+
+type KeyPartRetriever interface {
+	RetrieveKeyPart(ctx context.Context, accountID int64) (string, error)
+}
+
+func New(log *slog.Logger, keyPartRetriever KeyPartRetriever, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.get.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		keyPart, err := keyPartRetriever.RetrieveKeyPart(ctx, claims.AccountID)
+		if err != nil {
+			if errors.Is(err, storage.ErrEncryptionKeyNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("encryption key not found"))
+				return
+			}
+			log.Error("failed to retrieve key part", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve key part"))
+			return
+		}
+
+		log.Info("key part retrieved", slog.Int64("accountID", claims.AccountID))
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			KeyPart:  keyPart,
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package prelogin
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"passvault/pkg/vaultcrypto"
+	"time"
+)
+
+// Response holds what a zero-knowledge client needs to derive the key of the
+// account and to check it, see package vaultcrypto.
+type Response struct {
+	resp.Response
+	KDF             vaultcrypto.KDFParams `json:"kdf"`
+	KeyCheck        string                `json:"key_check"`
+	ProtocolVersion int                   `json:"protocol_version"`
+}
+
+type KDFParamsGetter interface {
+	KDFParams(ctx context.Context, accountID int64) (*models.KDFParams, error)
+}
+
+func New(log *slog.Logger, kdfParamsGetter KDFParamsGetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.prelogin.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		params, err := kdfParamsGetter.KDFParams(ctx, claims.AccountID)
+		if err != nil {
+			if errors.Is(err, storage.ErrKDFParamsNotFound) {
+				log.Info("zero-knowledge mode is not enabled")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("zero-knowledge mode is not enabled"))
+				return
+			}
+			log.Error("failed to retrieve KDF parameters", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve KDF parameters"))
+			return
+		}
+
+		salt, err := base64.StdEncoding.DecodeString(params.Salt)
+		if err != nil {
+			log.Error("stored salt is not base64", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve KDF parameters"))
+			return
+		}
+
+		log.Info("KDF parameters retrieved")
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			KDF: vaultcrypto.KDFParams{
+				Algorithm:   params.Algorithm,
+				Iterations:  uint32(params.Iterations),
+				Memory:      uint32(params.Memory),
+				Parallelism: uint8(params.Parallelism),
+				Salt:        salt,
+			},
+			KeyCheck:        params.KeyCheck,
+			ProtocolVersion: params.ProtocolVersion,
+		})
+	}
+}
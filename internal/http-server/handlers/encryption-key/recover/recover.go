@@ -0,0 +1,104 @@
+package recover
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/keyshare"
+	"passvault/internal/storage"
+	"time"
+)
+
+type Request struct {
+	Shares []keyshare.Share `json:"shares" validate:"required,min=2,dive"`
+}
+
+type KeyRecoverer interface {
+	Recover(ctx context.Context, accountID int64, shares []keyshare.Share) error
+}
+
+func New(log *slog.Logger, keyRecoverer KeyRecoverer, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.recover.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// Shares are secrets, only their count is logged.
+		log.Info("request body decoded", slog.Int("shares", len(req.Shares)))
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		if err := keyRecoverer.Recover(ctx, claims.AccountID, req.Shares); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrKeySharesNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("key shares not found"))
+			case errors.Is(err, keyshare.ErrNotEnoughShares):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("not enough shares"))
+			case errors.Is(err, keyshare.ErrInvalidShare), errors.Is(err, keyshare.ErrKeyMismatch):
+				log.Warn("key recovery rejected", sl.Err(err))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("invalid share"))
+			default:
+				log.Error("failed to recover key part", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to recover key"))
+			}
+			return
+		}
+
+		log.Info("key part recovered")
+		render.JSON(w, r, resp.OK())
+	}
+}
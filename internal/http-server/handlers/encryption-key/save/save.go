@@ -1,6 +1,8 @@
 package save
 
 import (
+	"context"
+	"encoding/base64"
 	"errors"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -10,11 +12,14 @@ import (
 	"net/http"
 	authrest "passvault/internal/http-server/middlewares/auth"
 	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/envelope"
 	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"time"
 )
 
 type Request struct {
-	KeyPart string `json:"key_part" validate:"required"`
+	KeyPart string `json:"key_part" validate:"required,base64"`
 }
 
 type Response struct {
@@ -23,21 +28,29 @@ type Response struct {
 }
 
 type KeyPartSaver interface {
-	SaveKeyPart(accountId int64, keyPart string) (int64, error)
+	SaveKeyPart(ctx context.Context, accountId int64, keyPart string) (int64, error)
 }
 
-func New(log *slog.Logger, keyPartSaver KeyPartSaver) http.HandlerFunc {
+func New(log *slog.Logger, keyPartSaver KeyPartSaver, timeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.encryption-key.save.New"
 
-		log = log.With(
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
 		// Retrieve UserClaims from context
-		claims, ok := r.Context().Value(authrest.UserClaimsKey).(*authrest.UserClaims)
-		if !ok || claims == nil {
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
 			log.Error("unauthorized access: user claims not found in context")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -47,9 +60,10 @@ func New(log *slog.Logger, keyPartSaver KeyPartSaver) http.HandlerFunc {
 		log = log.With(slog.Int64("account_id", claims.AccountID))
 
 		var req Request
-		err := render.DecodeJSON(r.Body, &req)
+		err = render.DecodeJSON(r.Body, &req)
 		if errors.Is(err, io.EOF) {
 			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, resp.Response{
 				Status: resp.StatusError,
 				Error:  "empty request",
@@ -58,6 +72,7 @@ func New(log *slog.Logger, keyPartSaver KeyPartSaver) http.HandlerFunc {
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, resp.Response{
 				Status: resp.StatusError,
 				Error:  "failed to decode request",
@@ -65,32 +80,47 @@ func New(log *slog.Logger, keyPartSaver KeyPartSaver) http.HandlerFunc {
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("req", req))
-
 		if err := validator.New().Struct(req); err != nil {
 			var validateErr validator.ValidationErrors
 			if errors.As(err, &validateErr) {
 				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
 				render.JSON(w, r, resp.ValidationError(validateErr))
 				return
 			}
 		}
 
-		// Use AccountID from claims if needed in saving process
-		id, err := keyPartSaver.SaveKeyPart(claims.AccountID, req.KeyPart)
+		// Key parts feed the data key derivation, weak ones are refused.
+		if raw, _ := base64.StdEncoding.DecodeString(req.KeyPart); len(raw) < envelope.KeySize {
+			log.Error("key part too short", slog.Int("bytes", len(raw)))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("key part must be at least 32 bytes"))
+			return
+		}
+
+		id, err := keyPartSaver.SaveKeyPart(ctx, claims.AccountID, req.KeyPart)
 		if err != nil {
-			log.Error("failed to save entry", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to save entry"))
+			if errors.Is(err, storage.ErrEncryptionKeyExists) {
+				log.Info("key part already exists")
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("encryption key already exists"))
+				return
+			}
+			log.Error("failed to save key part", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to save key part"))
 			return
 		}
 
-		log.Info("entry saved", slog.Int64("id", id))
-		responseOK(w, r)
+		log.Info("key part saved", slog.Int64("id", id))
+		responseCreated(w, r, id)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request) {
+func responseCreated(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusCreated)
 	render.JSON(w, r, Response{
 		Response: resp.OK(),
+		ID:       id,
 	})
 }
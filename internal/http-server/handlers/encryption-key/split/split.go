@@ -0,0 +1,115 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/keyshare"
+	"passvault/internal/storage"
+	"time"
+)
+
+type Request struct {
+	// Holders are who the shares are handed to, one share each.
+	Holders   []string `json:"holders" validate:"required,min=2,max=255,unique,dive,required,max=255"`
+	Threshold int      `json:"threshold" validate:"required,min=2"`
+}
+
+type Response struct {
+	resp.Response
+	Threshold int              `json:"threshold,omitempty"`
+	Shares    []keyshare.Share `json:"shares,omitempty"`
+}
+
+type KeySplitter interface {
+	Split(ctx context.Context, accountID int64, holders []string, threshold int) ([]keyshare.Share, error)
+}
+
+func New(log *slog.Logger, keySplitter KeySplitter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.split.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		shares, err := keySplitter.Split(ctx, claims.AccountID, req.Holders, req.Threshold)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEncryptionKeyNotFound):
+				log.Error("no key part to split", sl.Err(err))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("encryption key not found"))
+			case errors.Is(err, storage.ErrKeyRotationRunning):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("key rotation running"))
+			case errors.Is(err, keyshare.ErrInvalidParams):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid split parameters"))
+			default:
+				log.Error("failed to split key part", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to split key"))
+			}
+			return
+		}
+
+		log.Info("key part split", slog.Int("parts", len(req.Holders)), slog.Int("threshold", req.Threshold))
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Threshold: req.Threshold,
+			Shares:    shares,
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package zeroknowledge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"passvault/pkg/vaultcrypto"
+	"strconv"
+	"time"
+)
+
+// Request enables zero-knowledge mode with the KDF parameters the client derived its
+// key with and a key check made with that key, see package vaultcrypto.
+type Request struct {
+	KDF             vaultcrypto.KDFParams `json:"kdf"`
+	KeyCheck        string                `json:"key_check" validate:"required"`
+	ProtocolVersion int                   `json:"protocol_version" validate:"required"`
+}
+
+type KDFParamsSaver interface {
+	SaveKDFParams(ctx context.Context, params models.KDFParams) error
+}
+
+// New enables zero-knowledge mode for the account. It cannot be turned off again. From
+// then on the personal vault only accepts entries encrypted by the client. Accounts
+// whose personal vault already has entries or revisions are refused, as the server
+// has seen their plaintext; they have to start from an empty vault.
+func New(log *slog.Logger, kdfParamsSaver KDFParamsSaver, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.encryption-key.zeroknowledge.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		if msg := validate(req); msg != "" {
+			log.Error("invalid request", slog.String("error", msg))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(msg))
+			return
+		}
+
+		err = kdfParamsSaver.SaveKDFParams(ctx, models.KDFParams{
+			AccountID:       claims.AccountID,
+			Algorithm:       req.KDF.Algorithm,
+			Iterations:      int(req.KDF.Iterations),
+			Memory:          int(req.KDF.Memory),
+			Parallelism:     int(req.KDF.Parallelism),
+			Salt:            base64.StdEncoding.EncodeToString(req.KDF.Salt),
+			KeyCheck:        req.KeyCheck,
+			ProtocolVersion: req.ProtocolVersion,
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrKDFParamsExist) {
+				log.Info("zero-knowledge mode already enabled")
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("zero-knowledge mode is already enabled"))
+				return
+			}
+			if errors.Is(err, storage.ErrVaultNotEmpty) {
+				log.Info("vault not empty, zero-knowledge mode refused")
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("vault already has entries"))
+				return
+			}
+			log.Error("failed to save KDF parameters", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to enable zero-knowledge mode"))
+			return
+		}
+
+		log.Info("zero-knowledge mode enabled", slog.Int("protocol_version", req.ProtocolVersion))
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// validate checks the parameters against the protocol, so that clients never get
+// parameters from the server they would refuse to derive a key with.
+func validate(req Request) string {
+	if req.ProtocolVersion != vaultcrypto.ProtocolVersion {
+		return "unsupported protocol version " + strconv.Itoa(req.ProtocolVersion)
+	}
+	if err := req.KDF.Validate(); err != nil {
+		return err.Error()
+	}
+	if err := vaultcrypto.Check(req.KeyCheck); err != nil {
+		return "field KeyCheck is not valid: " + err.Error()
+	}
+	return ""
+}
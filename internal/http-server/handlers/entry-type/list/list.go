@@ -0,0 +1,35 @@
+package list
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/entryschema"
+)
+
+type Response struct {
+	resp.Response
+	EntryTypes []entryschema.Schema `json:"entry_types"`
+}
+
+// New lists the entry types with the fields clients can put in entry_data.
+func New(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry-type.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		schemas := entryschema.All()
+
+		log.Debug("entry types listed", slog.Int("count", len(schemas)))
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			EntryTypes: schemas,
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package bulkimport
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/importer"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// MaxFileSize limits the size of uploaded exports.
+const MaxFileSize = 32 << 20
+
+// Response reports what the import created, skipped and found duplicated.
+type Response struct {
+	resp.Response
+	Report *importer.Report `json:"report,omitempty"`
+}
+
+type EntryImporter interface {
+	Import(ctx context.Context, accountID int64, format string, data []byte, password string, dryRun bool) (*importer.Report, error)
+}
+
+// New imports the export uploaded as a multipart form: the file in "file", its
+// "format", the "password" of encrypted databases and "dry_run" to only report what
+// the import would do.
+func New(log *slog.Logger, entryImporter EntryImporter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.bulkimport.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		r.Body = http.MaxBytesReader(w, r.Body, MaxFileSize)
+		if err := r.ParseMultipartForm(MaxFileSize); err != nil {
+			log.Error("failed to parse form", sl.Err(err))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, resp.Error("file too large"))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		format := r.FormValue("format")
+		if format == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field format is a required field"))
+			return
+		}
+
+		dryRun := false
+		if value := r.FormValue("dry_run"); value != "" {
+			dryRun, err = strconv.ParseBool(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("field dry_run is not valid"))
+				return
+			}
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field file is a required field"))
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			log.Error("failed to read file", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// The export holds secrets, only its format and size are logged.
+		log.Info("request body decoded", slog.String("format", format), slog.Int("size", len(data)), slog.Bool("dry_run", dryRun))
+
+		report, err := entryImporter.Import(ctx, claims.AccountID, format, data, r.FormValue("password"), dryRun)
+		if err != nil {
+			switch {
+			case errors.Is(err, importer.ErrUnknownFormat):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("unknown import format"))
+			case errors.Is(err, importer.ErrInvalidFile):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid import file"))
+			case errors.Is(err, importer.ErrPasswordRequired):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("password required"))
+			case errors.Is(err, importer.ErrInvalidPassword):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid password"))
+			case errors.Is(err, storage.ErrPlaintextRefused):
+				// Imports are parsed on the server, so they are plaintext by nature.
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("account only accepts client-side encrypted entries"))
+			default:
+				log.Error("failed to import entries", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to import entries"))
+			}
+			return
+		}
+
+		log.Info("entries imported",
+			slog.Int("created", len(report.Created)),
+			slog.Int("skipped", len(report.Skipped)),
+			slog.Int("duplicates", len(report.Duplicates)),
+		)
+
+		status := http.StatusCreated
+		if dryRun {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Report:   report,
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package bulkimport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/entry/bulkimport"
+	mocks "passvault/internal/http-server/handlers/entry/bulkimport/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/services/importer"
+	"testing"
+	"time"
+)
+
+func TestImportHandler(t *testing.T) {
+	report := &importer.Report{
+		Format:     importer.FormatCSV,
+		Created:    []importer.Result{{Title: "example.com", EntryType: "login", EntryID: 7}},
+		Skipped:    []importer.Result{},
+		Duplicates: []importer.Result{},
+	}
+
+	cases := []struct {
+		name       string
+		fields     map[string]string
+		file       string
+		dryRun     bool
+		mockError  error
+		respError  string
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			fields:     map[string]string{"format": "csv"},
+			file:       "name,url,username,password\n",
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:       "Dry Run",
+			fields:     map[string]string{"format": "csv", "dry_run": "true"},
+			file:       "name,url,username,password\n",
+			dryRun:     true,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Missing Format",
+			fields:     map[string]string{},
+			file:       "name,url,username,password\n",
+			respError:  "field format is a required field",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Missing File",
+			fields:     map[string]string{"format": "csv"},
+			respError:  "field file is a required field",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Dry Run",
+			fields:     map[string]string{"format": "csv", "dry_run": "maybe"},
+			file:       "name,url,username,password\n",
+			respError:  "field dry_run is not valid",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown Format",
+			fields:     map[string]string{"format": "csv"},
+			file:       "name,url,username,password\n",
+			mockError:  fmt.Errorf("services.importer.Import: %w", importer.ErrUnknownFormat),
+			respError:  "unknown import format",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Password",
+			fields:     map[string]string{"format": "csv"},
+			file:       "name,url,username,password\n",
+			mockError:  fmt.Errorf("services.importer.Import: %w", importer.ErrInvalidPassword),
+			respError:  "invalid password",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Import Error",
+			fields:     map[string]string{"format": "csv"},
+			file:       "name,url,username,password\n",
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to import entries",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			entryImporterMock := mocks.NewEntryImporter(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				var result *importer.Report
+				if tc.mockError == nil {
+					result = report
+				}
+				entryImporterMock.On("Import", mock.AnythingOfType("*context.timerCtx"), int64(123), tc.fields["format"], []byte(tc.file), "", tc.dryRun).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := bulkimport.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), entryImporterMock, 5*time.Second)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			for name, value := range tc.fields {
+				require.NoError(t, form.WriteField(name, value))
+			}
+			if tc.file != "" {
+				file, err := form.CreateFormFile("file", "passwords.csv")
+				require.NoError(t, err)
+				_, err = file.Write([]byte(tc.file))
+				require.NoError(t, err)
+			}
+			require.NoError(t, form.Close())
+
+			req, err := http.NewRequest(http.MethodPost, "/entries/import", &body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(handler, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp bulkimport.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, report, resp.Report)
+			}
+		})
+	}
+}
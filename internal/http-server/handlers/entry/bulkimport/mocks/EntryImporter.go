@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/services/importer"
+)
+
+type MockEntryImporter struct {
+	mock.Mock
+}
+
+func (m *MockEntryImporter) Import(ctx context.Context, accountID int64, format string, data []byte, password string, dryRun bool) (*importer.Report, error) {
+	args := m.Called(ctx, accountID, format, data, password, dryRun)
+	report, _ := args.Get(0).(*importer.Report)
+	return report, args.Error(1)
+}
+
+type mockConstructorTestingTEntryImporter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntryImporter(t mockConstructorTestingTEntryImporter) *MockEntryImporter {
+	mock := &MockEntryImporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
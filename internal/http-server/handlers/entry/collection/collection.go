@@ -0,0 +1,105 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// Request moves the entry into the personal vault of the account when CollectionID is null or omitted.
+type Request struct {
+	CollectionID *int64 `json:"collection_id"`
+}
+
+type EntryCollectionSetter interface {
+	SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64) error
+}
+
+// New moves an entry between the personal vault and organization collections.
+func New(log *slog.Logger, entryCollectionSetter EntryCollectionSetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.collection.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := entryCollectionSetter.SetEntryCollection(ctx, claims.AccountID, id, req.CollectionID); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEntryNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+			case errors.Is(err, storage.ErrCollectionNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("collection not found"))
+			case errors.Is(err, storage.ErrAccessDenied):
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrPlaintextRefused):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("account only accepts client-side encrypted entries"))
+			case errors.Is(err, storage.ErrCiphertextRefused):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("client-side encrypted entries are not accepted here"))
+			default:
+				log.Error("failed to move entry", slog.Int64("entryID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to move entry"))
+			}
+			return
+		}
+
+		log.Info("entry collection set", slog.Int64("entryID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
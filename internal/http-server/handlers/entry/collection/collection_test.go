@@ -0,0 +1,97 @@
+package collection_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/entry/collection"
+	mocks "passvault/internal/http-server/handlers/entry/collection/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestCollectionHandler(t *testing.T) {
+	collectionID := int64(7)
+
+	cases := []struct {
+		name       string
+		entryID    string
+		body       string
+		setup      func(m *mocks.MockEntryCollectionSetter)
+		respStatus int
+	}{
+		{
+			name:    "Move into collection",
+			entryID: "1",
+			body:    `{"collection_id": 7}`,
+			setup: func(m *mocks.MockEntryCollectionSetter) {
+				m.On("SetEntryCollection", mock.Anything, int64(123), int64(1), &collectionID).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Move into personal vault",
+			entryID: "1",
+			body:    `{"collection_id": null}`,
+			setup: func(m *mocks.MockEntryCollectionSetter) {
+				m.On("SetEntryCollection", mock.Anything, int64(123), int64(1), (*int64)(nil)).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Viewer of the collection",
+			entryID: "1",
+			body:    `{"collection_id": 7}`,
+			setup: func(m *mocks.MockEntryCollectionSetter) {
+				m.On("SetEntryCollection", mock.Anything, int64(123), int64(1), &collectionID).
+					Return(fmt.Errorf("storage.sqlite.SetEntryCollection: %w", storage.ErrAccessDenied)).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Collection of another organization",
+			entryID: "1",
+			body:    `{"collection_id": 7}`,
+			setup: func(m *mocks.MockEntryCollectionSetter) {
+				m.On("SetEntryCollection", mock.Anything, int64(123), int64(1), &collectionID).
+					Return(fmt.Errorf("storage.sqlite.SetEntryCollection: %w", storage.ErrCollectionNotFound)).Once()
+			},
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid Entry ID",
+			entryID:    "invalid",
+			body:       `{}`,
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntryCollectionSetter := mocks.NewEntryCollectionSetter(t)
+			if tc.setup != nil {
+				tc.setup(mockEntryCollectionSetter)
+			}
+
+			router := chi.NewRouter()
+			router.Put("/{entryID}/collection", collection.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockEntryCollectionSetter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/%s/collection", tc.entryID), bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
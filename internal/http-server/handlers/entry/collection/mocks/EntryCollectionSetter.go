@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEntryCollectionSetter struct {
+	mock.Mock
+}
+
+func (m *MockEntryCollectionSetter) SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64) error {
+	args := m.Called(ctx, accountID, entryID, collectionID)
+	return args.Error(0)
+}
+
+type mockConstructorTestingTEntryCollectionSetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntryCollectionSetter(t mockConstructorTestingTEntryCollectionSetter) *MockEntryCollectionSetter {
+	mock := &MockEntryCollectionSetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
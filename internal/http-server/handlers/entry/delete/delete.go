@@ -1,6 +1,8 @@
 package delete
 
 import (
+	"context"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -9,24 +11,34 @@ import (
 	authrest "passvault/internal/http-server/middlewares/auth"
 	resp "passvault/internal/lib/api/response"
 	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
 	"strconv"
+	"time"
 )
 
 type EntryDeleter interface {
-	DeleteEntry(accountId int64, entryID int64) error
+	DeleteEntry(ctx context.Context, accountId int64, entryID int64) error
 }
 
-func New(log *slog.Logger, entryDeleter EntryDeleter) http.HandlerFunc {
+func New(log *slog.Logger, entryDeleter EntryDeleter, timeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.entry.delete.New"
 
-		log = log.With(
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		claims, ok := r.Context().Value(authrest.UserClaimsKey).(*authrest.UserClaims)
-		if !ok || claims == nil {
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
 			log.Error("unauthorized access: user claims not found in context")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -36,6 +48,7 @@ func New(log *slog.Logger, entryDeleter EntryDeleter) http.HandlerFunc {
 		id, err := strconv.ParseInt(entryID, 10, 64)
 		if err != nil {
 			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, resp.Response{
 				Status: resp.StatusError,
 				Error:  "invalid entryID",
@@ -43,8 +56,21 @@ func New(log *slog.Logger, entryDeleter EntryDeleter) http.HandlerFunc {
 			return
 		}
 
-		if err := entryDeleter.DeleteEntry(claims.AccountID, id); err != nil {
+		if err := entryDeleter.DeleteEntry(ctx, claims.AccountID, id); err != nil {
+			if errors.Is(err, storage.ErrEntryNotFound) {
+				log.Info("entry not found", slog.Int64("entryID", id))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrAccessDenied) {
+				log.Info("entry access denied", slog.Int64("entryID", id))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
 			log.Error("failed to delete entry", slog.Int64("entryID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
 			render.JSON(w, r, resp.Error("failed to delete entry"))
 			return
 		}
@@ -0,0 +1,73 @@
+package delete_test
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/entry/delete"
+	mocks "passvault/internal/http-server/handlers/entry/delete/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		entryID    string
+		mockError  error
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			entryID:    "1",
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Entry ID",
+			entryID:    "invalid",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Entry not found",
+			entryID:    "1",
+			mockError:  fmt.Errorf("storage.sqlite.DeleteEntry: %w", storage.ErrEntryNotFound),
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Error while deleting entry",
+			entryID:    "1",
+			mockError:  fmt.Errorf("unexpected error"),
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntryDeleter := mocks.NewEntryDeleter(t)
+
+			if tc.name != "Invalid Entry ID" {
+				mockEntryDeleter.On("DeleteEntry", mock.AnythingOfType("*context.timerCtx"), int64(123), int64(1)).Return(tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			handler := delete.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockEntryDeleter, 5*time.Second)
+			router.Delete("/{entryID}", handler)
+
+			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/%s", tc.entryID), nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
@@ -3,16 +3,15 @@ package mocks
 import (
 	"context"
 	"github.com/stretchr/testify/mock"
-	"passvault/internal/http-server/handlers/entry/get"
 )
 
 type MockEntryDeleter struct {
 	mock.Mock
 }
 
-func (m *MockEntryDeleter) DeleteEntry(ctx context.Context, accountId int64, entryID int64) (*get.Entry, error) {
+func (m *MockEntryDeleter) DeleteEntry(ctx context.Context, accountId int64, entryID int64) error {
 	args := m.Called(ctx, accountId, entryID)
-	return args.Get(0).(*get.Entry), args.Error(1)
+	return args.Error(0)
 }
 
 type mockConstructorTestingTEntryDeleter interface {
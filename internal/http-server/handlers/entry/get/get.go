@@ -8,22 +8,17 @@ import (
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
+	"passvault/internal/domain/models"
 	authrest "passvault/internal/http-server/middlewares/auth"
 	resp "passvault/internal/lib/api/response"
 	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
 	"strconv"
 	"time"
 )
 
-type Entry struct {
-	ID        int64  `json:"id"`
-	AccountId int64  `json:"account_id"`
-	EntryType string `json:"entry_type"`
-	EntryData string `json:"entry_data"`
-}
-
 type EntryGetter interface {
-	GetEntry(ctx context.Context, accountID int64, entryID int64) (*Entry, error)
+	GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error)
 }
 
 func New(log *slog.Logger, entryGetter EntryGetter, timeout time.Duration) http.HandlerFunc {
@@ -78,6 +73,12 @@ func New(log *slog.Logger, entryGetter EntryGetter, timeout time.Duration) http.
 				render.JSON(w, r, resp.Error("request timed out"))
 				return
 			}
+			if errors.Is(err, storage.ErrEntryNotFound) {
+				log.Info("entry not found", slog.Int64("entryID", id))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+				return
+			}
 			log.Error("failed to retrieve entry", slog.Int64("entryID", id), sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
 			render.JSON(w, r, resp.Error("failed to retrieve entry"))
@@ -9,9 +9,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"passvault/internal/domain/models"
 	"passvault/internal/http-server/handlers/entry/get"
 	mocks "passvault/internal/http-server/handlers/entry/get/mocks"
 	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
 	"testing"
 	"time"
 
@@ -23,14 +25,14 @@ func TestGetHandler(t *testing.T) {
 	cases := []struct {
 		name       string
 		entryID    string
-		mockEntry  get.Entry
+		mockEntry  models.Entry
 		mockError  error
 		respStatus int
 	}{
 		{
 			name:    "Success",
 			entryID: "1",
-			mockEntry: get.Entry{
+			mockEntry: models.Entry{
 				ID:        1,
 				EntryType: "password",
 				EntryData: "supersecretpassword",
@@ -41,14 +43,21 @@ func TestGetHandler(t *testing.T) {
 		{
 			name:       "Invalid Entry ID",
 			entryID:    "invalid",
-			mockEntry:  get.Entry{},
+			mockEntry:  models.Entry{},
 			mockError:  nil,
 			respStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "Entry not found",
+			entryID:    "1",
+			mockEntry:  models.Entry{},
+			mockError:  fmt.Errorf("storage.sqlite.GetEntry: %w", storage.ErrEntryNotFound),
+			respStatus: http.StatusNotFound,
+		},
 		{
 			name:       "Error while retrieving entry",
 			entryID:    "1",
-			mockEntry:  get.Entry{},
+			mockEntry:  models.Entry{},
 			mockError:  fmt.Errorf("failed to retrieve entry"),
 			respStatus: http.StatusInternalServerError,
 		},
@@ -79,7 +88,7 @@ func TestGetHandler(t *testing.T) {
 			assert.Equal(t, tc.respStatus, resp.StatusCode)
 
 			if tc.respStatus == http.StatusOK {
-				var responseEntry get.Entry
+				var responseEntry models.Entry
 				err := json.NewDecoder(resp.Body).Decode(&responseEntry)
 				require.NoError(t, err)
 				assert.Equal(t, tc.mockEntry, responseEntry)
@@ -3,16 +3,16 @@ package mocks
 import (
 	"context"
 	"github.com/stretchr/testify/mock"
-	"passvault/internal/http-server/handlers/entry/get"
+	"passvault/internal/domain/models"
 )
 
 type MockEntryGetter struct {
 	mock.Mock
 }
 
-func (m *MockEntryGetter) GetEntry(ctx context.Context, accountId int64, entryID int64) (*get.Entry, error) {
+func (m *MockEntryGetter) GetEntry(ctx context.Context, accountId int64, entryID int64) (*models.Entry, error) {
 	args := m.Called(ctx, accountId, entryID)
-	return args.Get(0).(*get.Entry), args.Error(1)
+	return args.Get(0).(*models.Entry), args.Error(1)
 }
 
 type mockConstructorTestingTEntryGetter interface {
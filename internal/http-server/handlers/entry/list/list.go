@@ -3,19 +3,27 @@ package list
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
-	"passvault/internal/http-server/handlers/entry/get"
+	"passvault/internal/domain/models"
 	authrest "passvault/internal/http-server/middlewares/auth"
 	resp "passvault/internal/lib/api/response"
 	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
 	"time"
 )
 
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
 type EntryLister interface {
-	ListEntries(ctx context.Context, accountId int64) ([]get.Entry, error)
+	ListEntriesPage(ctx context.Context, accountId int64, query models.EntryQuery) (*models.EntryPage, error)
 }
 
 func New(log *slog.Logger, entryLister EntryLister, timeout time.Duration) http.HandlerFunc {
@@ -41,6 +49,14 @@ func New(log *slog.Logger, entryLister EntryLister, timeout time.Duration) http.
 			return
 		}
 
+		query, err := parseQuery(r)
+		if err != nil {
+			log.Error("invalid query", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 			log.Error("request context cancelled", sl.Err(ctx.Err()))
@@ -50,8 +66,13 @@ func New(log *slog.Logger, entryLister EntryLister, timeout time.Duration) http.
 		default:
 		}
 
-		entries, err := entryLister.ListEntries(ctx, claims.AccountID)
+		page, err := entryLister.ListEntriesPage(ctx, claims.AccountID, query)
 		if err != nil {
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid cursor"))
+				return
+			}
 			if errors.Is(err, context.DeadlineExceeded) {
 				log.Error("request timeout", slog.Int64("accountID", claims.AccountID), sl.Err(err))
 				w.WriteHeader(http.StatusGatewayTimeout)
@@ -64,7 +85,102 @@ func New(log *slog.Logger, entryLister EntryLister, timeout time.Duration) http.
 			return
 		}
 
-		log.Info("entries retrieved", slog.Int("count", len(entries)))
-		render.JSON(w, r, entries)
+		if page.Entries == nil {
+			page.Entries = []models.Entry{}
+		}
+
+		log.Info("entries retrieved", slog.Int("count", len(page.Entries)))
+		render.JSON(w, r, page)
 	}
 }
+
+// parseQuery reads the paging, sorting and filter query parameters:
+//
+//   - limit (1 to 500, default 50) and cursor, the next_cursor of the previous page;
+//   - sort, one of name, created_at (default) or updated_at, and order, asc (default) or desc;
+//   - entry_type, and created_after, created_before, updated_after, updated_before as RFC 3339 times;
+//   - folder_id, where 0 selects entries outside any folder, and tag_id, which may be
+//     repeated to require several tags;
+//   - collection_id, which lists the entries of an organization collection instead of
+//     personal entries.
+func parseQuery(r *http.Request) (models.EntryQuery, error) {
+	query := models.EntryQuery{
+		Sort:  models.EntrySortCreatedAt,
+		Limit: defaultLimit,
+	}
+	values := r.URL.Query()
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		query.Limit = limit
+	}
+	query.Cursor = values.Get("cursor")
+
+	switch value := values.Get("sort"); value {
+	case "":
+	case models.EntrySortName, models.EntrySortCreatedAt, models.EntrySortUpdatedAt:
+		query.Sort = value
+	default:
+		return query, errors.New("sort must be one of name, created_at, updated_at")
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	query.Filter.EntryType = values.Get("entry_type")
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &query.Filter.CreatedAfter},
+		{"created_before", &query.Filter.CreatedBefore},
+		{"updated_after", &query.Filter.UpdatedAfter},
+		{"updated_before", &query.Filter.UpdatedBefore},
+	}
+	for _, param := range times {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("invalid %s", param.name)
+		}
+		*param.dst = &t
+	}
+
+	if value := values.Get("folder_id"); value != "" {
+		folderID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || folderID < 0 {
+			return query, errors.New("invalid folder_id")
+		}
+		query.Filter.FolderID = &folderID
+	}
+
+	if value := values.Get("collection_id"); value != "" {
+		collectionID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || collectionID <= 0 {
+			return query, errors.New("invalid collection_id")
+		}
+		query.Filter.CollectionID = &collectionID
+	}
+
+	for _, value := range values["tag_id"] {
+		tagID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || tagID <= 0 {
+			return query, errors.New("invalid tag_id")
+		}
+		query.Filter.TagIDs = append(query.Filter.TagIDs, tagID)
+	}
+
+	return query, nil
+}
@@ -11,41 +11,105 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"passvault/internal/http-server/handlers/entry/get"
+	"passvault/internal/domain/models"
 	"passvault/internal/http-server/handlers/entry/list"
 	mocks "passvault/internal/http-server/handlers/entry/list/mocks"
 	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
 	"testing"
 	"time"
 )
 
 func TestListHandler(t *testing.T) {
+	createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
 	cases := []struct {
-		name        string
-		mockEntries []get.Entry
-		mockError   error
-		respStatus  int
+		name       string
+		query      string
+		entryQuery *models.EntryQuery
+		mockPage   *models.EntryPage
+		mockError  error
+		respStatus int
 	}{
 		{
-			name: "Success",
-			mockEntries: []get.Entry{
-				{ID: 1, EntryType: "password", EntryData: "secret1"},
-				{ID: 2, EntryType: "note", EntryData: "note content"},
+			name:       "Success",
+			entryQuery: &models.EntryQuery{Sort: "created_at", Limit: 50},
+			mockPage: &models.EntryPage{
+				Entries: []models.Entry{
+					{ID: 1, EntryType: "login", EntryData: "secret1"},
+					{ID: 2, EntryType: "secure_note", EntryData: "note content"},
+				},
+				NextCursor: "next",
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Empty List",
+			entryQuery: &models.EntryQuery{Sort: "created_at", Limit: 50},
+			mockPage:   &models.EntryPage{},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:  "Paging, sorting and filters",
+			query: "?limit=10&cursor=abc&sort=name&order=desc&entry_type=login&created_after=2024-01-01T00:00:00Z&folder_id=4&tag_id=1&tag_id=2",
+			entryQuery: &models.EntryQuery{
+				Filter: models.EntryFilter{
+					FolderID:     ptr(int64(4)),
+					TagIDs:       []int64{1, 2},
+					EntryType:    "login",
+					CreatedAfter: &createdAfter,
+				},
+				Sort:       "name",
+				Descending: true,
+				Limit:      10,
+				Cursor:     "abc",
+			},
+			mockPage: &models.EntryPage{
+				Entries: []models.Entry{
+					{ID: 1, EntryType: "login", EntryData: "secret1", FolderID: ptr(int64(4)), TagIDs: []int64{1, 2, 3}},
+				},
 			},
-			mockError:  nil,
 			respStatus: http.StatusOK,
 		},
 		{
-			name:        "Empty List",
-			mockEntries: []get.Entry{},
-			mockError:   nil,
-			respStatus:  http.StatusOK,
+			name:       "Entries outside folders",
+			query:      "?folder_id=0",
+			entryQuery: &models.EntryQuery{Filter: models.EntryFilter{FolderID: ptr(int64(0))}, Sort: "created_at", Limit: 50},
+			mockPage:   &models.EntryPage{},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Stale cursor",
+			query:      "?cursor=garbage",
+			entryQuery: &models.EntryQuery{Sort: "created_at", Limit: 50, Cursor: "garbage"},
+			mockError:  fmt.Errorf("storage.sqlite.ListEntriesPage: %w", storage.ErrInvalidCursor),
+			respStatus: http.StatusBadRequest,
 		},
 		{
-			name:        "Error while retrieving entries",
-			mockEntries: nil,
-			mockError:   fmt.Errorf("failed to retrieve entries"),
-			respStatus:  http.StatusInternalServerError,
+			name:       "Invalid tag",
+			query:      "?tag_id=work",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Limit too large",
+			query:      "?limit=100000",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown sort",
+			query:      "?sort=password",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid time range",
+			query:      "?updated_before=yesterday",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Error while retrieving entries",
+			entryQuery: &models.EntryQuery{Sort: "created_at", Limit: 50},
+			mockError:  fmt.Errorf("failed to retrieve entries"),
+			respStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -53,7 +117,9 @@ func TestListHandler(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockEntryLister := mocks.NewEntryLister(t)
 
-			mockEntryLister.On("ListEntries", mock.AnythingOfType("*context.timerCtx"), int64(123)).Return(tc.mockEntries, tc.mockError)
+			if tc.entryQuery != nil {
+				mockEntryLister.On("ListEntriesPage", mock.AnythingOfType("*context.timerCtx"), int64(123), *tc.entryQuery).Return(tc.mockPage, tc.mockError)
+			}
 
 			router := chi.NewRouter()
 			handler := list.New(slog.New(
@@ -61,7 +127,7 @@ func TestListHandler(t *testing.T) {
 			), mockEntryLister, 5*time.Second)
 			router.Get("/", handler)
 
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.query, nil)
 			rr := httptest.NewRecorder()
 
 			utils.TestMiddleware(router, rr, req)
@@ -72,13 +138,17 @@ func TestListHandler(t *testing.T) {
 			assert.Equal(t, tc.respStatus, resp.StatusCode)
 
 			if tc.respStatus == http.StatusOK {
-				var responseEntries []get.Entry
-				err := json.NewDecoder(resp.Body).Decode(&responseEntries)
+				var page models.EntryPage
+				err := json.NewDecoder(resp.Body).Decode(&page)
 				require.NoError(t, err)
-				assert.Equal(t, tc.mockEntries, responseEntries)
+				assert.Equal(t, tc.mockPage.NextCursor, page.NextCursor)
+				assert.Len(t, page.Entries, len(tc.mockPage.Entries))
+				assert.NotNil(t, page.Entries)
 			}
-
-			mockEntryLister.AssertExpectations(t)
 		})
 	}
 }
+
+func ptr[T any](v T) *T {
+	return &v
+}
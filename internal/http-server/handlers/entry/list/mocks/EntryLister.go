@@ -3,16 +3,17 @@ package mocks
 import (
 	"context"
 	"github.com/stretchr/testify/mock"
-	"passvault/internal/http-server/handlers/entry/get"
+	"passvault/internal/domain/models"
 )
 
 type MockEntryLister struct {
 	mock.Mock
 }
 
-func (m *MockEntryLister) ListEntries(ctx context.Context, accountId int64) ([]get.Entry, error) {
-	args := m.Called(ctx, accountId)
-	return args.Get(0).([]get.Entry), args.Error(1)
+func (m *MockEntryLister) ListEntriesPage(ctx context.Context, accountId int64, query models.EntryQuery) (*models.EntryPage, error) {
+	args := m.Called(ctx, accountId, query)
+	page, _ := args.Get(0).(*models.EntryPage)
+	return page, args.Error(1)
 }
 
 type mockConstructorTestingTEntryLister interface {
@@ -14,6 +14,11 @@ func (m *MockEntrySaver) SaveEntry(ctx context.Context, accountId int64, entryTy
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockEntrySaver) SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error) {
+	args := m.Called(ctx, accountID, collectionID, entryType, entryData)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type mockConstructorTestingTEntrySaver interface {
 	mock.TestingT
 	Cleanup(func())
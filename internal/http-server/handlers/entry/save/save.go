@@ -9,27 +9,40 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"passvault/internal/http-server/middlewares/audit"
 	authrest "passvault/internal/http-server/middlewares/auth"
 	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/entryschema"
 	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
 	"time"
 )
 
+// Request saves a personal entry unless CollectionID is set.
 type Request struct {
-	EntryType string `json:"entry_type" validate:"required"`
-	EntryData string `json:"entry_data" validate:"required"`
+	EntryType    string `json:"entry_type" validate:"required"`
+	EntryData    string `json:"entry_data" validate:"required"`
+	CollectionID *int64 `json:"collection_id,omitempty"`
 }
 
+// Response flags Breached when the password of a login entry appears in the
+// breach corpus. The entry is saved either way.
 type Response struct {
 	resp.Response
-	ID int64 `json:"id"`
+	ID       int64 `json:"id"`
+	Breached bool  `json:"breached,omitempty"`
 }
 
 type EntrySaver interface {
 	SaveEntry(ctx context.Context, accountId int64, entryType, entryData string) (int64, error)
+	SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error)
 }
 
-func New(log *slog.Logger, entrySaver EntrySaver, timeout time.Duration) http.HandlerFunc {
+type BreachChecker interface {
+	CheckEntry(entryType, entryData string) (bool, error)
+}
+
+func New(log *slog.Logger, entrySaver EntrySaver, breachChecker BreachChecker, timeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.entry.save.New"
 
@@ -60,6 +73,7 @@ func New(log *slog.Logger, entrySaver EntrySaver, timeout time.Duration) http.Ha
 		decodeErr = render.DecodeJSON(r.Body, &req)
 		if errors.Is(decodeErr, io.EOF) {
 			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, resp.Response{
 				Status: resp.StatusError,
 				Error:  "empty request",
@@ -68,6 +82,7 @@ func New(log *slog.Logger, entrySaver EntrySaver, timeout time.Duration) http.Ha
 		}
 		if decodeErr != nil {
 			log.Error("failed to decode request body", sl.Err(decodeErr))
+			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, resp.Response{
 				Status: resp.StatusError,
 				Error:  "failed to decode request",
@@ -75,17 +90,26 @@ func New(log *slog.Logger, entrySaver EntrySaver, timeout time.Duration) http.Ha
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("req", req))
+		// Entry data is a secret, only the type is logged.
+		log.Info("request body decoded", slog.String("entry_type", req.EntryType))
 
 		if err := validator.New().Struct(req); err != nil {
 			var validateErr validator.ValidationErrors
 			if errors.As(err, &validateErr) {
 				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
 				render.JSON(w, r, resp.ValidationError(validateErr))
 				return
 			}
 		}
 
+		if err := entryschema.Validate(req.EntryType, req.EntryData); err != nil {
+			log.Error("entry does not match its schema", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 			log.Error("request context cancelled", sl.Err(ctx.Err()))
@@ -95,20 +119,52 @@ func New(log *slog.Logger, entrySaver EntrySaver, timeout time.Duration) http.Ha
 		default:
 		}
 
-		id, err := entrySaver.SaveEntry(ctx, claims.AccountID, req.EntryType, req.EntryData)
+		var id int64
+		if req.CollectionID != nil {
+			id, err = entrySaver.SaveCollectionEntry(ctx, claims.AccountID, *req.CollectionID, req.EntryType, req.EntryData)
+		} else {
+			id, err = entrySaver.SaveEntry(ctx, claims.AccountID, req.EntryType, req.EntryData)
+		}
 		if err != nil {
-			log.Error("failed to save entry", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to save entry"))
+			switch {
+			case errors.Is(err, storage.ErrCollectionNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("collection not found"))
+			case errors.Is(err, storage.ErrAccessDenied):
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrPlaintextRefused):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("account only accepts client-side encrypted entries"))
+			case errors.Is(err, storage.ErrCiphertextRefused):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("client-side encrypted entries are not accepted here"))
+			default:
+				log.Error("failed to save entry", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to save entry"))
+			}
 			return
 		}
 
-		log.Info("entry saved", slog.Int64("id", id))
-		responseOK(w, r)
+		audit.SetEntryID(r.Context(), id)
+
+		// A failed breach check must not fail a save that already happened.
+		breached, err := breachChecker.CheckEntry(req.EntryType, req.EntryData)
+		if err != nil {
+			log.Error("failed to check entry for breaches", sl.Err(err))
+		}
+
+		log.Info("entry saved", slog.Int64("id", id), slog.Bool("breached", breached))
+		responseCreated(w, r, id, breached)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request) {
+func responseCreated(w http.ResponseWriter, r *http.Request, id int64, breached bool) {
+	w.WriteHeader(http.StatusCreated)
 	render.JSON(w, r, Response{
 		Response: resp.OK(),
+		ID:       id,
+		Breached: breached,
 	})
 }
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"log/slog"
@@ -20,29 +19,76 @@ import (
 
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
-		name      string
-		entryType string
-		entryData string
-		respError string
-		mockError error
+		name       string
+		entryType  string
+		entryData  string
+		respError  string
+		mockError  error
+		breached   bool
+		breachErr  error
+		respStatus int
 	}{
 		{
-			name:      "Success",
-			entryType: "password",
-			entryData: "supersecretpassword",
+			name:       "Success",
+			entryType:  "login",
+			entryData:  `{"title": "mail", "password": "supersecretpassword"}`,
+			respStatus: http.StatusCreated,
 		},
 		{
-			name:      "Empty Data",
-			entryType: "password",
-			entryData: "",
-			respError: "field EntryData is a required field",
+			name:       "Empty Data",
+			entryType:  "login",
+			entryData:  "",
+			respError:  "field EntryData is a required field",
+			respStatus: http.StatusBadRequest,
 		},
 		{
-			name:      "SaveEntry Error",
-			entryType: "password",
-			entryData: "supersecretpassword",
-			respError: "failed to save entry",
-			mockError: errors.New("unexpected error"),
+			name:       "Unknown Type",
+			entryType:  "password",
+			entryData:  "supersecretpassword",
+			respError:  `unknown entry type: "password"`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Data Does Not Match Schema",
+			entryType:  "login",
+			entryData:  `{"password": "supersecretpassword"}`,
+			respError:  "invalid entry data: field title is a required field",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "TOTP",
+			entryType:  "totp",
+			entryData:  `{"title": "github", "secret": "otpauth://totp/GitHub:daria?secret=JBSWY3DPEHPK3PXP"}`,
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:       "Invalid TOTP Secret",
+			entryType:  "totp",
+			entryData:  `{"title": "github", "secret": "123456"}`,
+			respError:  "invalid entry data: field secret must be an otpauth://totp/ URI or a base32 secret",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Breached Password",
+			entryType:  "login",
+			entryData:  `{"title": "mail", "password": "password"}`,
+			breached:   true,
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:       "Breach Check Error",
+			entryType:  "login",
+			entryData:  `{"title": "mail", "password": "supersecretpassword"}`,
+			breachErr:  errors.New("unexpected error"),
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:       "SaveEntry Error",
+			entryType:  "login",
+			entryData:  `{"title": "mail", "password": "supersecretpassword"}`,
+			respError:  "failed to save entry",
+			mockError:  errors.New("unexpected error"),
+			respStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -54,19 +100,28 @@ func TestSaveHandler(t *testing.T) {
 
 			entrySaverMock := mocks.NewEntrySaver(t)
 
-			if tc.respError == "" || tc.mockError != nil {
+			if tc.respStatus != http.StatusBadRequest {
 				entrySaverMock.On("SaveEntry", mock.AnythingOfType("*context.timerCtx"), int64(123), tc.entryType, mock.AnythingOfType("string")).
 					Return(int64(1), tc.mockError).
 					Once()
 			}
 
+			breachCheckerMock := mocks.NewBreachChecker(t)
+
+			if tc.respStatus == http.StatusCreated {
+				breachCheckerMock.On("CheckEntry", tc.entryType, tc.entryData).
+					Return(tc.breached, tc.breachErr).
+					Once()
+			}
+
 			handler := save.New(slog.New(
 				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-			), entrySaverMock, 5*time.Second)
+			), entrySaverMock, breachCheckerMock, 5*time.Second)
 
-			input := fmt.Sprintf(`{"entry_type": "%s", "entry_data": "%s"}`, tc.entryType, tc.entryData)
+			input, err := json.Marshal(map[string]string{"entry_type": tc.entryType, "entry_data": tc.entryData})
+			require.NoError(t, err)
 
-			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(input))
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
@@ -74,7 +129,7 @@ func TestSaveHandler(t *testing.T) {
 			//handler.ServeHTTP(rr, req) // Call the handler wrapped in the middleware instead
 			utils.TestMiddleware(handler, rr, req)
 
-			require.Equal(t, rr.Code, http.StatusOK)
+			require.Equal(t, tc.respStatus, rr.Code)
 
 			body := rr.Body.String()
 
@@ -83,6 +138,11 @@ func TestSaveHandler(t *testing.T) {
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 
 			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respStatus == http.StatusCreated {
+				require.Equal(t, int64(1), resp.ID)
+				require.Equal(t, tc.breached, resp.Breached)
+			}
 		})
 	}
 }
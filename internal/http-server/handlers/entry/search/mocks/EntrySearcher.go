@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEntrySearcher struct {
+	mock.Mock
+}
+
+func (m *MockEntrySearcher) SearchEntries(ctx context.Context, accountID int64, query string) ([]int64, error) {
+	args := m.Called(ctx, accountID, query)
+	ids, _ := args.Get(0).([]int64)
+	return ids, args.Error(1)
+}
+
+type mockConstructorTestingTEntrySearcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntrySearcher(t mockConstructorTestingTEntrySearcher) *MockEntrySearcher {
+	mock := &MockEntrySearcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+	"unicode/utf8"
+)
+
+const maxQueryLength = 256
+
+type Response struct {
+	resp.Response
+	IDs []int64 `json:"ids"`
+}
+
+type EntrySearcher interface {
+	SearchEntries(ctx context.Context, accountID int64, query string) ([]int64, error)
+}
+
+// New returns the IDs of the account entries matching the q query parameter.
+func New(log *slog.Logger, entrySearcher EntrySearcher, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.search.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// The query is not logged, it may contain parts of secrets.
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field q is a required field"))
+			return
+		}
+		if utf8.RuneCountInString(query) > maxQueryLength {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field q is too long"))
+			return
+		}
+
+		ids, err := entrySearcher.SearchEntries(ctx, claims.AccountID, query)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Error("request timeout", slog.Int64("accountID", claims.AccountID))
+				w.WriteHeader(http.StatusGatewayTimeout)
+				render.JSON(w, r, resp.Error("request timed out"))
+				return
+			}
+			log.Error("failed to search entries", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to search entries"))
+			return
+		}
+
+		if ids == nil {
+			ids = []int64{}
+		}
+
+		log.Info("entries searched", slog.Int("count", len(ids)))
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			IDs:      ids,
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"passvault/internal/http-server/handlers/entry/search"
+	mocks "passvault/internal/http-server/handlers/entry/search/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		setup      func(m *mocks.MockEntrySearcher)
+		respStatus int
+		respIDs    []int64
+	}{
+		{
+			name:  "Matches",
+			query: "mail example",
+			setup: func(m *mocks.MockEntrySearcher) {
+				m.On("SearchEntries", mock.Anything, int64(123), "mail example").Return([]int64{1, 7}, nil).Once()
+			},
+			respStatus: http.StatusOK,
+			respIDs:    []int64{1, 7},
+		},
+		{
+			name:  "No matches",
+			query: "bank",
+			setup: func(m *mocks.MockEntrySearcher) {
+				m.On("SearchEntries", mock.Anything, int64(123), "bank").Return(nil, nil).Once()
+			},
+			respStatus: http.StatusOK,
+			respIDs:    []int64{},
+		},
+		{
+			name:       "Empty query",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Query too long",
+			query:      strings.Repeat("a", 257),
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Storage error",
+			query: "mail",
+			setup: func(m *mocks.MockEntrySearcher) {
+				m.On("SearchEntries", mock.Anything, int64(123), "mail").Return(nil, errors.New("disk failure")).Once()
+			},
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntrySearcher := mocks.NewEntrySearcher(t)
+			if tc.setup != nil {
+				tc.setup(mockEntrySearcher)
+			}
+
+			router := chi.NewRouter()
+			router.Get("/search", search.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockEntrySearcher, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodGet, "/search?q="+url.QueryEscape(tc.query), nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			if tc.respStatus == http.StatusOK {
+				var resp search.Response
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+				assert.Equal(t, tc.respIDs, resp.IDs)
+			}
+		})
+	}
+}
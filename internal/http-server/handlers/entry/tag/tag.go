@@ -0,0 +1,95 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// Request replaces all tags of the entry; an empty list removes them.
+type Request struct {
+	TagIDs []int64 `json:"tag_ids"`
+}
+
+type EntryTagger interface {
+	SetEntryTags(ctx context.Context, accountID int64, entryID int64, tagIDs []int64) error
+}
+
+func New(log *slog.Logger, entryTagger EntryTagger, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.tag.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := entryTagger.SetEntryTags(ctx, claims.AccountID, id, req.TagIDs); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEntryNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+			case errors.Is(err, storage.ErrTagNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("tag not found"))
+			default:
+				log.Error("failed to tag entry", slog.Int64("entryID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to tag entry"))
+			}
+			return
+		}
+
+		log.Info("entry tagged", slog.Int64("entryID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
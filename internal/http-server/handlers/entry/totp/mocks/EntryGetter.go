@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockEntryGetter struct {
+	mock.Mock
+}
+
+func (m *MockEntryGetter) GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error) {
+	args := m.Called(ctx, accountID, entryID)
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+type mockConstructorTestingTEntryGetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntryGetter(t mockConstructorTestingTEntryGetter) *MockEntryGetter {
+	mock := &MockEntryGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,145 @@
+package totp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"math"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/lib/totp"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+var errNoTOTP = errors.New("entry has no TOTP secret")
+
+type Response struct {
+	resp.Response
+	Code string `json:"code,omitempty"`
+	// Remaining is the number of seconds until the code rotates.
+	Remaining int `json:"remaining,omitempty"`
+	Period    int `json:"period,omitempty"`
+}
+
+type EntryGetter interface {
+	GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error)
+}
+
+// New returns the current code of a totp entry, or of a login with a totp field.
+func New(log *slog.Logger, entryGetter EntryGetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.totp.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		entry, err := entryGetter.GetEntry(ctx, claims.AccountID, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrEntryNotFound) {
+				log.Info("entry not found", slog.Int64("entryID", id))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+				return
+			}
+			log.Error("failed to retrieve entry", slog.Int64("entryID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve entry"))
+			return
+		}
+
+		key, err := keyOf(entry)
+		if err != nil {
+			log.Info("entry has no valid TOTP key", slog.Int64("entryID", id), sl.Err(err))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		now := time.Now()
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Code:      key.Code(now),
+			Remaining: int(math.Ceil(key.Remaining(now).Seconds())),
+			Period:    key.Period,
+		})
+	}
+}
+
+// keyOf reads the TOTP key of an entry. The algorithm, digits and period fields of
+// totp entries override the parameters of their secret.
+func keyOf(entry *models.Entry) (*totp.Key, error) {
+	var data struct {
+		Secret    string `json:"secret"`
+		TOTP      string `json:"totp"`
+		Algorithm string `json:"algorithm"`
+		Digits    int    `json:"digits"`
+		Period    int    `json:"period"`
+	}
+	if err := json.Unmarshal([]byte(entry.EntryData), &data); err != nil {
+		return nil, errNoTOTP
+	}
+
+	secret := data.Secret
+	switch entry.EntryType {
+	case models.EntryTypeTOTP:
+	case models.EntryTypeLogin:
+		secret = data.TOTP
+	default:
+		return nil, errNoTOTP
+	}
+	if secret == "" {
+		return nil, errNoTOTP
+	}
+
+	key, err := totp.Parse(secret)
+	if err != nil {
+		return nil, err
+	}
+	if data.Algorithm != "" {
+		key.Algorithm = data.Algorithm
+	}
+	if data.Digits != 0 {
+		key.Digits = data.Digits
+	}
+	if data.Period != 0 {
+		key.Period = data.Period
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
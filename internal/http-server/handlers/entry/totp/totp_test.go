@@ -0,0 +1,147 @@
+package totp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/entry/totp"
+	mocks "passvault/internal/http-server/handlers/entry/totp/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	libtotp "passvault/internal/lib/totp"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestTOTPHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		entryID    string
+		entry      *models.Entry
+		mockError  error
+		key        string
+		digits     int
+		period     int
+		respError  string
+		respStatus int
+	}{
+		{
+			name:    "TOTP Entry",
+			entryID: "1",
+			entry: &models.Entry{ID: 1, EntryType: models.EntryTypeTOTP,
+				EntryData: `{"title": "github", "secret": "otpauth://totp/GitHub:daria?secret=JBSWY3DPEHPK3PXP"}`},
+			key:        "JBSWY3DPEHPK3PXP",
+			digits:     6,
+			period:     30,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Fields Override URI",
+			entryID: "1",
+			entry: &models.Entry{ID: 1, EntryType: models.EntryTypeTOTP,
+				EntryData: `{"title": "vpn", "secret": "otpauth://totp/vpn?secret=JBSWY3DPEHPK3PXP&digits=6", "digits": 8, "period": 60, "algorithm": "SHA256"}`},
+			key:        "otpauth://totp/vpn?secret=JBSWY3DPEHPK3PXP&digits=8&period=60&algorithm=SHA256",
+			digits:     8,
+			period:     60,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Login With TOTP",
+			entryID: "1",
+			entry: &models.Entry{ID: 1, EntryType: models.EntryTypeLogin,
+				EntryData: `{"title": "mail", "password": "s3cret", "totp": "JBSW Y3DP EHPK 3PXP"}`},
+			key:        "JBSWY3DPEHPK3PXP",
+			digits:     6,
+			period:     30,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Login Without TOTP",
+			entryID: "1",
+			entry: &models.Entry{ID: 1, EntryType: models.EntryTypeLogin,
+				EntryData: `{"title": "mail", "password": "s3cret"}`},
+			respError:  "entry has no TOTP secret",
+			respStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Note",
+			entryID: "1",
+			entry: &models.Entry{ID: 1, EntryType: models.EntryTypeSecureNote,
+				EntryData: `{"title": "wifi", "content": "JBSWY3DPEHPK3PXP"}`},
+			respError:  "entry has no TOTP secret",
+			respStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid Entry ID",
+			entryID:    "invalid",
+			respError:  "invalid entryID",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Entry Not Found",
+			entryID:    "1",
+			mockError:  fmt.Errorf("storage.sqlite.GetEntry: %w", storage.ErrEntryNotFound),
+			respError:  "entry not found",
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "GetEntry Error",
+			entryID:    "1",
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to retrieve entry",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			entryGetterMock := mocks.NewEntryGetter(t)
+
+			if tc.entryID == "1" {
+				entryGetterMock.On("GetEntry", mock.AnythingOfType("*context.timerCtx"), int64(123), int64(1)).
+					Return(tc.entry, tc.mockError).
+					Once()
+			}
+
+			router := chi.NewRouter()
+			router.Get("/{entryID}/totp", totp.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), entryGetterMock, 5*time.Second))
+
+			before := time.Now()
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.entryID+"/totp", nil)
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(router, rr, req)
+			after := time.Now()
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp totp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				key, err := libtotp.Parse(tc.key)
+				require.NoError(t, err)
+				// The code may have rotated while the request was served.
+				require.Contains(t, []string{key.Code(before), key.Code(after)}, resp.Code)
+				require.Len(t, resp.Code, tc.digits)
+				require.Equal(t, tc.period, resp.Period)
+				require.GreaterOrEqual(t, resp.Remaining, 1)
+				require.LessOrEqual(t, resp.Remaining, tc.period)
+			}
+		})
+	}
+}
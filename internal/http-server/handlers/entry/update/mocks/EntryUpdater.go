@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockEntryUpdater struct {
+	mock.Mock
+}
+
+func (m *MockEntryUpdater) GetEntry(ctx context.Context, accountId int64, entryID int64) (*models.Entry, error) {
+	args := m.Called(ctx, accountId, entryID)
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func (m *MockEntryUpdater) UpdateEntry(ctx context.Context, accountId int64, entryID int64, entryType, entryData string) error {
+	args := m.Called(ctx, accountId, entryID, entryType, entryData)
+	return args.Error(0)
+}
+
+type mockConstructorTestingTEntryUpdater interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntryUpdater(t mockConstructorTestingTEntryUpdater) *MockEntryUpdater {
+	mock := &MockEntryUpdater{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,186 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/entryschema"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// Request replaces both fields on PUT. On PATCH omitted fields keep their current value.
+type Request struct {
+	EntryType *string `json:"entry_type,omitempty"`
+	EntryData *string `json:"entry_data,omitempty"`
+}
+
+// Response flags Breached when the password of a login entry appears in the
+// breach corpus. The entry is updated either way.
+type Response struct {
+	resp.Response
+	Breached bool `json:"breached,omitempty"`
+}
+
+type EntryUpdater interface {
+	GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error)
+	UpdateEntry(ctx context.Context, accountID int64, entryID int64, entryType, entryData string) error
+}
+
+type BreachChecker interface {
+	CheckEntry(entryType, entryData string) (bool, error)
+}
+
+func New(log *slog.Logger, entryUpdater EntryUpdater, breachChecker BreachChecker, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.entry.update.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if msg := validate(r.Method, req); msg != "" {
+			log.Error("invalid request", slog.String("error", msg))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(msg))
+			return
+		}
+
+		if req.EntryType == nil || req.EntryData == nil {
+			current, err := entryUpdater.GetEntry(ctx, claims.AccountID, id)
+			if err != nil {
+				responseStorageError(w, r, log, id, err)
+				return
+			}
+			if req.EntryType == nil {
+				req.EntryType = &current.EntryType
+			}
+			if req.EntryData == nil {
+				req.EntryData = &current.EntryData
+			}
+		}
+
+		if err := entryschema.Validate(*req.EntryType, *req.EntryData); err != nil {
+			log.Error("entry does not match its schema", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		if err := entryUpdater.UpdateEntry(ctx, claims.AccountID, id, *req.EntryType, *req.EntryData); err != nil {
+			responseStorageError(w, r, log, id, err)
+			return
+		}
+
+		// A failed breach check must not fail an update that already happened.
+		breached, err := breachChecker.CheckEntry(*req.EntryType, *req.EntryData)
+		if err != nil {
+			log.Error("failed to check entry for breaches", sl.Err(err))
+		}
+
+		log.Info("entry updated", slog.Int64("entryID", id), slog.Bool("breached", breached))
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Breached: breached,
+		})
+	}
+}
+
+func validate(method string, req Request) string {
+	if req.EntryType == nil && req.EntryData == nil {
+		return "nothing to update"
+	}
+	if method == http.MethodPut {
+		if req.EntryType == nil {
+			return "field EntryType is a required field"
+		}
+		if req.EntryData == nil {
+			return "field EntryData is a required field"
+		}
+	}
+	if req.EntryType != nil && *req.EntryType == "" {
+		return "field EntryType is a required field"
+	}
+	if req.EntryData != nil && *req.EntryData == "" {
+		return "field EntryData is a required field"
+	}
+	return ""
+}
+
+func responseStorageError(w http.ResponseWriter, r *http.Request, log *slog.Logger, id int64, err error) {
+	switch {
+	case errors.Is(err, storage.ErrEntryNotFound):
+		log.Info("entry not found", slog.Int64("entryID", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, resp.Error("entry not found"))
+	case errors.Is(err, storage.ErrAccessDenied):
+		log.Info("entry access denied", slog.Int64("entryID", id))
+		w.WriteHeader(http.StatusForbidden)
+		render.JSON(w, r, resp.Error("access denied"))
+	case errors.Is(err, storage.ErrPlaintextRefused):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, resp.Error("account only accepts client-side encrypted entries"))
+	case errors.Is(err, storage.ErrCiphertextRefused):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, resp.Error("client-side encrypted entries are not accepted here"))
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Error("request timeout", slog.Int64("entryID", id))
+		w.WriteHeader(http.StatusGatewayTimeout)
+		render.JSON(w, r, resp.Error("request timed out"))
+	default:
+		log.Error("failed to update entry", slog.Int64("entryID", id), sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, resp.Error("failed to update entry"))
+	}
+}
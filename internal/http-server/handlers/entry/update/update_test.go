@@ -0,0 +1,148 @@
+package update_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/entry/update"
+	mocks "passvault/internal/http-server/handlers/entry/update/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		entryID    string
+		body       string
+		setup      func(m *mocks.MockEntryUpdater)
+		breached   bool
+		respStatus int
+	}{
+		{
+			name:    "Put",
+			method:  http.MethodPut,
+			entryID: "1",
+			body:    `{"entry_type": "login", "entry_data": "{\"title\": \"mail\", \"password\": \"rotated\"}"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("UpdateEntry", mock.Anything, int64(123), int64(1), "login", `{"title": "mail", "password": "rotated"}`).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Put Breached Password",
+			method:  http.MethodPut,
+			entryID: "1",
+			body:    `{"entry_type": "login", "entry_data": "{\"title\": \"mail\", \"password\": \"password\"}"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("UpdateEntry", mock.Anything, int64(123), int64(1), "login", `{"title": "mail", "password": "password"}`).Return(nil).Once()
+			},
+			breached:   true,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Put without data",
+			method:     http.MethodPut,
+			entryID:    "1",
+			body:       `{"entry_type": "login"}`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Patch keeps omitted fields",
+			method:  http.MethodPatch,
+			entryID: "1",
+			body:    `{"entry_data": "{\"title\": \"mail\", \"password\": \"rotated\"}"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("GetEntry", mock.Anything, int64(123), int64(1)).
+					Return(&models.Entry{ID: 1, AccountId: 123, EntryType: "login", EntryData: `{"title": "mail"}`}, nil).Once()
+				m.On("UpdateEntry", mock.Anything, int64(123), int64(1), "login", `{"title": "mail", "password": "rotated"}`).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Patch breaking the schema",
+			method:  http.MethodPatch,
+			entryID: "1",
+			body:    `{"entry_type": "secure_note"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("GetEntry", mock.Anything, int64(123), int64(1)).
+					Return(&models.Entry{ID: 1, AccountId: 123, EntryType: "login", EntryData: `{"title": "mail"}`}, nil).Once()
+			},
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Patch with nothing to update",
+			method:     http.MethodPatch,
+			entryID:    "1",
+			body:       `{}`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Entry ID",
+			method:     http.MethodPut,
+			entryID:    "invalid",
+			body:       `{"entry_type": "login", "entry_data": "{\"title\": \"mail\"}"}`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Entry of another account",
+			method:  http.MethodPut,
+			entryID: "2",
+			body:    `{"entry_type": "login", "entry_data": "{\"title\": \"mail\"}"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("UpdateEntry", mock.Anything, int64(123), int64(2), "login", `{"title": "mail"}`).
+					Return(fmt.Errorf("storage.sqlite.UpdateEntry: %w", storage.ErrEntryNotFound)).Once()
+			},
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:    "Entry shared for reading",
+			method:  http.MethodPut,
+			entryID: "3",
+			body:    `{"entry_type": "login", "entry_data": "{\"title\": \"mail\"}"}`,
+			setup: func(m *mocks.MockEntryUpdater) {
+				m.On("UpdateEntry", mock.Anything, int64(123), int64(3), "login", `{"title": "mail"}`).
+					Return(fmt.Errorf("storage.sqlite.UpdateEntry: %w", storage.ErrAccessDenied)).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntryUpdater := mocks.NewEntryUpdater(t)
+			if tc.setup != nil {
+				tc.setup(mockEntryUpdater)
+			}
+
+			mockBreachChecker := mocks.NewBreachChecker(t)
+			mockBreachChecker.On("CheckEntry", mock.Anything, mock.Anything).Return(tc.breached, nil).Maybe()
+
+			router := chi.NewRouter()
+			handler := update.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockEntryUpdater, mockBreachChecker, 5*time.Second)
+			router.Put("/{entryID}", handler)
+			router.Patch("/{entryID}", handler)
+
+			req := httptest.NewRequest(tc.method, fmt.Sprintf("/%s", tc.entryID), bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+			assert.Equal(t, tc.breached, strings.Contains(rr.Body.String(), `"breached":true`))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type FolderDeleter interface {
+	DeleteFolder(ctx context.Context, accountID int64, folderID int64) error
+}
+
+// New deletes a folder. Its subfolders and entries move up to the parent folder.
+func New(log *slog.Logger, folderDeleter FolderDeleter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.delete.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		folderID := chi.URLParam(r, "folderID")
+		id, err := strconv.ParseInt(folderID, 10, 64)
+		if err != nil {
+			log.Error("invalid folderID parameter", slog.String("folderID", folderID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid folderID"))
+			return
+		}
+
+		if err := folderDeleter.DeleteFolder(ctx, claims.AccountID, id); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrFolderNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("folder not found"))
+			case errors.Is(err, storage.ErrFolderExists):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("parent folder already has a subfolder with the same name"))
+			default:
+				log.Error("failed to delete folder", slog.Int64("folderID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to delete folder"))
+			}
+			return
+		}
+
+		log.Info("folder deleted", slog.Int64("folderID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
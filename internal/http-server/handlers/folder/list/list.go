@@ -0,0 +1,59 @@
+package list
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+)
+
+type FolderLister interface {
+	ListFolders(ctx context.Context, accountID int64) ([]models.Folder, error)
+}
+
+// New returns all folders of the account as a flat list; clients build the tree from parent_id.
+func New(log *slog.Logger, folderLister FolderLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.list.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		folders, err := folderLister.ListFolders(ctx, claims.AccountID)
+		if err != nil {
+			log.Error("failed to retrieve folders", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve folders"))
+			return
+		}
+
+		if folders == nil {
+			folders = []models.Folder{}
+		}
+
+		log.Info("folders retrieved", slog.Int("count", len(folders)))
+		render.JSON(w, r, folders)
+	}
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFolderMover struct {
+	mock.Mock
+}
+
+func (m *MockFolderMover) MoveFolder(ctx context.Context, accountID int64, folderID int64, parentID *int64) error {
+	args := m.Called(ctx, accountID, folderID, parentID)
+	return args.Error(0)
+}
+
+type mockConstructorTestingTFolderMover interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewFolderMover(t mockConstructorTestingTFolderMover) *MockFolderMover {
+	mock := &MockFolderMover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
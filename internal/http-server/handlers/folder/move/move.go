@@ -0,0 +1,98 @@
+package move
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// Request moves the folder to the top level when ParentID is null or omitted.
+type Request struct {
+	ParentID *int64 `json:"parent_id"`
+}
+
+type FolderMover interface {
+	MoveFolder(ctx context.Context, accountID int64, folderID int64, parentID *int64) error
+}
+
+func New(log *slog.Logger, folderMover FolderMover, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.move.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		folderID := chi.URLParam(r, "folderID")
+		id, err := strconv.ParseInt(folderID, 10, 64)
+		if err != nil {
+			log.Error("invalid folderID parameter", slog.String("folderID", folderID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid folderID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := folderMover.MoveFolder(ctx, claims.AccountID, id, req.ParentID); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrFolderNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("folder not found"))
+			case errors.Is(err, storage.ErrFolderCycle):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("folder cannot be moved into itself"))
+			case errors.Is(err, storage.ErrFolderExists):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("folder already exists"))
+			default:
+				log.Error("failed to move folder", slog.Int64("folderID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to move folder"))
+			}
+			return
+		}
+
+		log.Info("folder moved", slog.Int64("folderID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
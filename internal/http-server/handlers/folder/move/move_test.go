@@ -0,0 +1,97 @@
+package move_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/folder/move"
+	mocks "passvault/internal/http-server/handlers/folder/move/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestMoveHandler(t *testing.T) {
+	parentID := int64(2)
+
+	cases := []struct {
+		name       string
+		folderID   string
+		body       string
+		setup      func(m *mocks.MockFolderMover)
+		respStatus int
+	}{
+		{
+			name:     "Move into folder",
+			folderID: "1",
+			body:     `{"parent_id": 2}`,
+			setup: func(m *mocks.MockFolderMover) {
+				m.On("MoveFolder", mock.Anything, int64(123), int64(1), &parentID).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:     "Move to top level",
+			folderID: "1",
+			body:     `{"parent_id": null}`,
+			setup: func(m *mocks.MockFolderMover) {
+				m.On("MoveFolder", mock.Anything, int64(123), int64(1), (*int64)(nil)).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:     "Move into own subfolder",
+			folderID: "1",
+			body:     `{"parent_id": 2}`,
+			setup: func(m *mocks.MockFolderMover) {
+				m.On("MoveFolder", mock.Anything, int64(123), int64(1), &parentID).
+					Return(fmt.Errorf("storage.sqlite.MoveFolder: %w", storage.ErrFolderCycle)).Once()
+			},
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "Folder of another account",
+			folderID: "3",
+			body:     `{}`,
+			setup: func(m *mocks.MockFolderMover) {
+				m.On("MoveFolder", mock.Anything, int64(123), int64(3), (*int64)(nil)).
+					Return(fmt.Errorf("storage.sqlite.MoveFolder: %w", storage.ErrFolderNotFound)).Once()
+			},
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid Folder ID",
+			folderID:   "invalid",
+			body:       `{}`,
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockFolderMover := mocks.NewFolderMover(t)
+			if tc.setup != nil {
+				tc.setup(mockFolderMover)
+			}
+
+			router := chi.NewRouter()
+			router.Post("/{folderID}/move", move.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockFolderMover, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%s/move", tc.folderID), bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
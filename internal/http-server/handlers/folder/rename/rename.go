@@ -0,0 +1,105 @@
+package rename
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type FolderRenamer interface {
+	RenameFolder(ctx context.Context, accountID int64, folderID int64, name string) error
+}
+
+func New(log *slog.Logger, folderRenamer FolderRenamer, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.rename.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		folderID := chi.URLParam(r, "folderID")
+		id, err := strconv.ParseInt(folderID, 10, 64)
+		if err != nil {
+			log.Error("invalid folderID parameter", slog.String("folderID", folderID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid folderID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		if err := folderRenamer.RenameFolder(ctx, claims.AccountID, id, req.Name); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrFolderNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("folder not found"))
+			case errors.Is(err, storage.ErrFolderExists):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("folder already exists"))
+			default:
+				log.Error("failed to rename folder", slog.Int64("folderID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to rename folder"))
+			}
+			return
+		}
+
+		log.Info("folder renamed", slog.Int64("folderID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
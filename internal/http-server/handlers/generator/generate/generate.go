@@ -0,0 +1,167 @@
+package generate
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/generator"
+	"passvault/internal/lib/logger/sl"
+)
+
+const (
+	TypePassword   = "password"
+	TypePassphrase = "passphrase"
+
+	defaultLength    = 20
+	defaultWords     = 5
+	defaultSeparator = "-"
+)
+
+// Request selects a password or a passphrase. The character classes of passwords
+// default to all enabled; unset lengths take their defaults of 20 characters and 5
+// words separated by "-".
+type Request struct {
+	Type string `json:"type" validate:"required,oneof=password passphrase"`
+
+	Length           int   `json:"length,omitempty"`
+	Lowercase        *bool `json:"lowercase,omitempty"`
+	Uppercase        *bool `json:"uppercase,omitempty"`
+	Digits           *bool `json:"digits,omitempty"`
+	Symbols          *bool `json:"symbols,omitempty"`
+	MinLowercase     int   `json:"min_lowercase,omitempty"`
+	MinUppercase     int   `json:"min_uppercase,omitempty"`
+	MinDigits        int   `json:"min_digits,omitempty"`
+	MinSymbols       int   `json:"min_symbols,omitempty"`
+	ExcludeAmbiguous bool  `json:"exclude_ambiguous,omitempty"`
+
+	Words      int     `json:"words,omitempty"`
+	Separator  *string `json:"separator,omitempty"`
+	Capitalize string  `json:"capitalize,omitempty"`
+}
+
+// Response holds the generated value and the estimate of its entropy in bits.
+type Response struct {
+	resp.Response
+	Value   string  `json:"value,omitempty"`
+	Entropy float64 `json:"entropy,omitempty"`
+}
+
+// New generates a password or a passphrase. Nothing is stored.
+func New(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.generator.generate.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		var result generator.Result
+		if req.Type == TypePassphrase {
+			result, err = generator.Passphrase(passphraseOptions(req))
+		} else {
+			result, err = generator.Password(passwordOptions(req))
+		}
+		if err != nil {
+			if errors.Is(err, generator.ErrInvalidOptions) {
+				log.Info("invalid generator options", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(err.Error()))
+				return
+			}
+			log.Error("failed to generate", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to generate"))
+			return
+		}
+
+		// The value is a secret, only its strength is logged.
+		log.Debug("generated", slog.String("type", req.Type), slog.Float64("entropy", result.Entropy))
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Value:    result.Value,
+			Entropy:  math.Round(result.Entropy*100) / 100,
+		})
+	}
+}
+
+func passwordOptions(req Request) generator.PasswordOptions {
+	opts := generator.PasswordOptions{
+		Length:           req.Length,
+		Lowercase:        enabled(req.Lowercase),
+		Uppercase:        enabled(req.Uppercase),
+		Digits:           enabled(req.Digits),
+		Symbols:          enabled(req.Symbols),
+		MinLowercase:     req.MinLowercase,
+		MinUppercase:     req.MinUppercase,
+		MinDigits:        req.MinDigits,
+		MinSymbols:       req.MinSymbols,
+		ExcludeAmbiguous: req.ExcludeAmbiguous,
+	}
+	if opts.Length == 0 {
+		opts.Length = defaultLength
+	}
+	return opts
+}
+
+func passphraseOptions(req Request) generator.PassphraseOptions {
+	opts := generator.PassphraseOptions{
+		Words:      req.Words,
+		Separator:  defaultSeparator,
+		Capitalize: req.Capitalize,
+	}
+	if opts.Words == 0 {
+		opts.Words = defaultWords
+	}
+	if req.Separator != nil {
+		opts.Separator = *req.Separator
+	}
+	return opts
+}
+
+// enabled treats unset classes as enabled.
+func enabled(class *bool) bool {
+	return class == nil || *class
+}
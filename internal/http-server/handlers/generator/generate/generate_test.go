@@ -0,0 +1,116 @@
+package generate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/generator/generate"
+	"passvault/internal/http-server/handlers/utils"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGenerateHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		check      func(t *testing.T, value string)
+		entropy    float64
+		respError  string
+		respStatus int
+	}{
+		{
+			name: "Default Password",
+			body: `{"type": "password"}`,
+			check: func(t *testing.T, value string) {
+				require.Len(t, value, 20)
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name: "Digits Only",
+			body: `{"type": "password", "length": 6, "lowercase": false, "uppercase": false, "symbols": false}`,
+			check: func(t *testing.T, value string) {
+				require.Len(t, value, 6)
+				for _, r := range value {
+					require.True(t, unicode.IsDigit(r))
+				}
+			},
+			entropy:    19.93,
+			respStatus: http.StatusOK,
+		},
+		{
+			name: "Passphrase",
+			body: `{"type": "passphrase", "words": 4, "separator": " ", "capitalize": "all"}`,
+			check: func(t *testing.T, value string) {
+				require.Len(t, strings.Split(value, " "), 4)
+				require.Equal(t, strings.ToUpper(value), value)
+			},
+			entropy:    51.7,
+			respStatus: http.StatusOK,
+		},
+		{
+			name: "Default Passphrase",
+			body: `{"type": "passphrase"}`,
+			check: func(t *testing.T, value string) {
+				// Some words hold a hyphen themselves, like "t-shirt".
+				require.GreaterOrEqual(t, len(strings.Split(value, "-")), 5)
+			},
+			entropy:    64.62,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Empty Body",
+			respError:  "empty request",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown Type",
+			body:       `{"type": "pin"}`,
+			respError:  "field Type is not valid",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Options",
+			body:       `{"type": "password", "length": 4, "min_digits": 5}`,
+			respError:  "invalid generator options: minimums add up to more than the length",
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := generate.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			))
+
+			req, err := http.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(handler, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp generate.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				tc.check(t, resp.Value)
+				if tc.entropy != 0 {
+					require.Equal(t, tc.entropy, resp.Entropy)
+				}
+			}
+		})
+	}
+}
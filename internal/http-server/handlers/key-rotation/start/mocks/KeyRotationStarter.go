@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockKeyRotationStarter struct {
+	mock.Mock
+}
+
+func (m *MockKeyRotationStarter) Start(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(*models.KeyRotation), args.Error(1)
+}
+
+type mockConstructorTestingTKeyRotationStarter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewKeyRotationStarter(t mockConstructorTestingTKeyRotationStarter) *MockKeyRotationStarter {
+	mock := &MockKeyRotationStarter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,101 @@
+package start
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+	Rotation *models.KeyRotation `json:"rotation"`
+	Warning  string              `json:"warning,omitempty"`
+}
+
+type KeyRotationStarter interface {
+	Start(ctx context.Context, accountID int64) (*models.KeyRotation, error)
+}
+
+// New starts rotating the key of the account in the accountID URL parameter, or resumes
+// its failed rotation. The entries are re-encrypted in the background; the route must be
+// limited to admins. Starting a rotation removes the recovery shares of the account, as
+// they cannot restore the new key part: the response counts them in the rotation's
+// shares_removed and warns that the key part must be split again.
+func New(log *slog.Logger, keyRotationStarter KeyRotationStarter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.key-rotation.start.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		accountID := chi.URLParam(r, "accountID")
+		id, err := strconv.ParseInt(accountID, 10, 64)
+		if err != nil {
+			log.Error("invalid accountID parameter", slog.String("accountID", accountID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid accountID"))
+			return
+		}
+
+		rotation, err := keyRotationStarter.Start(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEncryptionKeyNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("encryption key not found"))
+			case errors.Is(err, storage.ErrKeyRotationRunning):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("key rotation already running"))
+			default:
+				log.Error("failed to start key rotation", slog.Int64("accountID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to start key rotation"))
+			}
+			return
+		}
+
+		log.Info("key rotation started",
+			slog.Int64("admin_id", claims.AccountID),
+			slog.Int64("accountID", id),
+			slog.Int64("rotationID", rotation.ID),
+			slog.Int("shares_removed", rotation.SharesRemoved),
+		)
+		response := Response{
+			Response: resp.OK(),
+			Rotation: rotation,
+		}
+		if rotation.SharesRemoved > 0 {
+			response.Warning = fmt.Sprintf("%d recovery shares were removed, split the key part again", rotation.SharesRemoved)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		render.JSON(w, r, response)
+	}
+}
@@ -0,0 +1,110 @@
+package start_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/key-rotation/start"
+	mocks "passvault/internal/http-server/handlers/key-rotation/start/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestStartHandler(t *testing.T) {
+	rotation := &models.KeyRotation{ID: 1, AccountID: 7, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning}
+	withShares := &models.KeyRotation{ID: 1, AccountID: 7, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning, SharesRemoved: 3}
+
+	cases := []struct {
+		name       string
+		accountID  string
+		rotation   *models.KeyRotation
+		mockError  error
+		respError  string
+		warning    string
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			accountID:  "7",
+			rotation:   rotation,
+			respStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Shares Removed",
+			accountID:  "7",
+			rotation:   withShares,
+			warning:    "3 recovery shares were removed, split the key part again",
+			respStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Invalid Account ID",
+			accountID:  "me",
+			respError:  "invalid accountID",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No Encryption Key",
+			accountID:  "7",
+			mockError:  fmt.Errorf("services.keyrotation.Start: %w", storage.ErrEncryptionKeyNotFound),
+			respError:  "encryption key not found",
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Already Running",
+			accountID:  "7",
+			mockError:  fmt.Errorf("services.keyrotation.Start: %w", storage.ErrKeyRotationRunning),
+			respError:  "key rotation already running",
+			respStatus: http.StatusConflict,
+		},
+		{
+			name:       "Error while starting",
+			accountID:  "7",
+			mockError:  fmt.Errorf("unexpected error"),
+			respError:  "failed to start key rotation",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockKeyRotationStarter := mocks.NewKeyRotationStarter(t)
+
+			if tc.respStatus != http.StatusBadRequest {
+				mockKeyRotationStarter.On("Start", mock.AnythingOfType("*context.timerCtx"), int64(7)).
+					Return(tc.rotation, tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/{accountID}/key-rotation", start.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockKeyRotationStarter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, "/"+tc.accountID+"/key-rotation", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var response start.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			if tc.respError != "" {
+				require.Equal(t, tc.respError, response.Error)
+				return
+			}
+			require.Equal(t, resp.StatusOK, response.Status)
+			require.Equal(t, tc.rotation, response.Rotation)
+			require.Equal(t, tc.warning, response.Warning)
+		})
+	}
+}
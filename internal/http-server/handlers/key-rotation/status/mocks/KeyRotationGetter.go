@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockKeyRotationGetter struct {
+	mock.Mock
+}
+
+func (m *MockKeyRotationGetter) Status(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(*models.KeyRotation), args.Error(1)
+}
+
+type mockConstructorTestingTKeyRotationGetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewKeyRotationGetter(t mockConstructorTestingTKeyRotationGetter) *MockKeyRotationGetter {
+	mock := &MockKeyRotationGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,85 @@
+package status_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/key-rotation/status"
+	mocks "passvault/internal/http-server/handlers/key-rotation/status/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestStatusHandler(t *testing.T) {
+	rotation := &models.KeyRotation{ID: 1, AccountID: 7, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning, EntryCursor: 40, Entries: 40}
+
+	cases := []struct {
+		name       string
+		accountID  string
+		rotation   *models.KeyRotation
+		mockError  error
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			accountID:  "7",
+			rotation:   rotation,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Account ID",
+			accountID:  "me",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No Rotation",
+			accountID:  "7",
+			mockError:  fmt.Errorf("services.keyrotation.Status: %w", storage.ErrKeyRotationNotFound),
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Error while retrieving",
+			accountID:  "7",
+			mockError:  fmt.Errorf("unexpected error"),
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockKeyRotationGetter := mocks.NewKeyRotationGetter(t)
+
+			if tc.respStatus != http.StatusBadRequest {
+				mockKeyRotationGetter.On("Status", mock.AnythingOfType("*context.timerCtx"), int64(7)).
+					Return(tc.rotation, tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Get("/{accountID}/key-rotation", status.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockKeyRotationGetter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.accountID+"/key-rotation", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			if tc.respStatus == http.StatusOK {
+				var got models.KeyRotation
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+				require.Equal(t, *tc.rotation, got)
+			}
+		})
+	}
+}
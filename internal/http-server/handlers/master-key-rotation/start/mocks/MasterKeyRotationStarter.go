@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockMasterKeyRotationStarter struct {
+	mock.Mock
+}
+
+func (m *MockMasterKeyRotationStarter) StartMaster(ctx context.Context) ([]models.KeyRotation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.KeyRotation), args.Error(1)
+}
+
+type mockConstructorTestingTMasterKeyRotationStarter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewMasterKeyRotationStarter(t mockConstructorTestingTMasterKeyRotationStarter) *MockMasterKeyRotationStarter {
+	mock := &MockMasterKeyRotationStarter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
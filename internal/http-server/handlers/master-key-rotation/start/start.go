@@ -0,0 +1,70 @@
+package start
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+	Rotations []models.KeyRotation `json:"rotations"`
+}
+
+type MasterKeyRotationStarter interface {
+	StartMaster(ctx context.Context) ([]models.KeyRotation, error)
+}
+
+// New starts rotating the key of every account and organization still keyed with an
+// older master key than the configured one. The data is re-encrypted in the background;
+// the route must be limited to admins. Account rotations remove the recovery shares of
+// the account, each rotation counts them in its shares_removed.
+func New(log *slog.Logger, masterKeyRotationStarter MasterKeyRotationStarter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.master-key-rotation.start.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rotations, err := masterKeyRotationStarter.StartMaster(ctx)
+		if err != nil {
+			log.Error("failed to start master key rotation", slog.Int("started", len(rotations)), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to start master key rotation"))
+			return
+		}
+
+		log.Info("master key rotation started",
+			slog.Int64("admin_id", claims.AccountID),
+			slog.Int("rotations", len(rotations)),
+		)
+		w.WriteHeader(http.StatusAccepted)
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Rotations: rotations,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package start_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/master-key-rotation/start"
+	mocks "passvault/internal/http-server/handlers/master-key-rotation/start/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	resp "passvault/internal/lib/api/response"
+	"testing"
+	"time"
+)
+
+func TestStartHandler(t *testing.T) {
+	rotations := []models.KeyRotation{
+		{ID: 1, AccountID: 7, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning, SharesRemoved: 3},
+		{ID: 2, OrganizationID: 4, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning},
+	}
+
+	cases := []struct {
+		name       string
+		rotations  []models.KeyRotation
+		mockError  error
+		respError  string
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			rotations:  rotations,
+			respStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Nothing To Rotate",
+			rotations:  []models.KeyRotation{},
+			respStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Error while starting",
+			rotations:  rotations[:1],
+			mockError:  fmt.Errorf("unexpected error"),
+			respError:  "failed to start master key rotation",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMasterKeyRotationStarter := mocks.NewMasterKeyRotationStarter(t)
+			mockMasterKeyRotationStarter.On("StartMaster", mock.AnythingOfType("*context.timerCtx")).
+				Return(tc.rotations, tc.mockError).Once()
+
+			router := chi.NewRouter()
+			router.Post("/master-key-rotation", start.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockMasterKeyRotationStarter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, "/master-key-rotation", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var response start.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			if tc.respError != "" {
+				require.Equal(t, tc.respError, response.Error)
+				return
+			}
+			require.Equal(t, resp.StatusOK, response.Status)
+			require.Equal(t, tc.rotations, response.Rotations)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockMasterKeyStatusGetter struct {
+	mock.Mock
+}
+
+func (m *MockMasterKeyStatusGetter) MasterStatus(ctx context.Context) (*models.MasterKeyStatus, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*models.MasterKeyStatus), args.Error(1)
+}
+
+type mockConstructorTestingTMasterKeyStatusGetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewMasterKeyStatusGetter(t mockConstructorTestingTMasterKeyStatusGetter) *MockMasterKeyStatusGetter {
+	mock := &MockMasterKeyStatusGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
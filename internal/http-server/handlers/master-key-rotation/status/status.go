@@ -0,0 +1,60 @@
+package status
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+)
+
+type MasterKeyStatusGetter interface {
+	MasterStatus(ctx context.Context) (*models.MasterKeyStatus, error)
+}
+
+// New returns the configured master key version with the accounts and organizations
+// still keyed with an older one. Once both lists are empty, the older master keys can be
+// removed from the config. The route must be limited to admins.
+func New(log *slog.Logger, masterKeyStatusGetter MasterKeyStatusGetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.master-key-rotation.status.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		status, err := masterKeyStatusGetter.MasterStatus(ctx)
+		if err != nil {
+			log.Error("failed to retrieve master key status", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve master key status"))
+			return
+		}
+
+		log.Info("master key status retrieved",
+			slog.Int("master_key_version", status.MasterKeyVersion),
+			slog.Int("accounts", len(status.Accounts)),
+			slog.Int("organizations", len(status.Organizations)),
+		)
+		render.JSON(w, r, status)
+	}
+}
@@ -0,0 +1,66 @@
+package status_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/master-key-rotation/status"
+	mocks "passvault/internal/http-server/handlers/master-key-rotation/status/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"testing"
+	"time"
+)
+
+func TestStatusHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     *models.MasterKeyStatus
+		mockError  error
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			status:     &models.MasterKeyStatus{MasterKeyVersion: 2, Accounts: []int64{7}, Organizations: []int64{4}},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Error while retrieving",
+			status:     (*models.MasterKeyStatus)(nil),
+			mockError:  fmt.Errorf("unexpected error"),
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMasterKeyStatusGetter := mocks.NewMasterKeyStatusGetter(t)
+			mockMasterKeyStatusGetter.On("MasterStatus", mock.AnythingOfType("*context.timerCtx")).
+				Return(tc.status, tc.mockError).Once()
+
+			router := chi.NewRouter()
+			router.Get("/master-key-rotation", status.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockMasterKeyStatusGetter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodGet, "/master-key-rotation", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			if tc.respStatus == http.StatusOK {
+				var got models.MasterKeyStatus
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+				require.Equal(t, *tc.status, got)
+			}
+		})
+	}
+}
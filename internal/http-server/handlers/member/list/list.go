@@ -0,0 +1,69 @@
+package list
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"strconv"
+	"time"
+)
+
+type MemberLister interface {
+	ListMembers(ctx context.Context, orgID int64) ([]models.OrganizationMember, error)
+}
+
+// New returns the members of an organization with their roles.
+func New(log *slog.Logger, memberLister MemberLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.member.list.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		members, err := memberLister.ListMembers(ctx, id)
+		if err != nil {
+			log.Error("failed to retrieve members", slog.Int64("orgID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve members"))
+			return
+		}
+
+		if members == nil {
+			members = []models.OrganizationMember{}
+		}
+
+		log.Info("members retrieved", slog.Int("count", len(members)))
+		render.JSON(w, r, members)
+	}
+}
@@ -0,0 +1,113 @@
+package remove
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	"passvault/internal/http-server/middlewares/authz"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type MemberRemover interface {
+	MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error)
+	RemoveMember(ctx context.Context, orgID int64, accountID int64) error
+}
+
+// New removes a member from an organization. Members may leave on their own; removing
+// others needs the admin role, and removing an owner the owner role.
+func New(log *slog.Logger, memberRemover MemberRemover, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.member.remove.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		memberID := chi.URLParam(r, "accountID")
+		accountID, err := strconv.ParseInt(memberID, 10, 64)
+		if err != nil {
+			log.Error("invalid accountID parameter", slog.String("accountID", memberID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid accountID"))
+			return
+		}
+
+		if accountID != claims.AccountID {
+			callerRole, _ := authz.RoleFromContext(r.Context())
+			required := models.OrgRoleAdmin
+			current, err := memberRemover.MemberRole(ctx, id, accountID)
+			switch {
+			case errors.Is(err, storage.ErrMemberNotFound):
+				// Let RemoveMember report the missing member.
+			case err != nil:
+				log.Error("failed to get member role", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to remove member"))
+				return
+			case current == models.OrgRoleOwner:
+				required = models.OrgRoleOwner
+			}
+			if !models.OrgRoleAtLeast(callerRole, required) {
+				log.Info("member removal denied", slog.Int64("orgID", id), slog.Int64("memberID", accountID))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+		}
+
+		if err := memberRemover.RemoveMember(ctx, id, accountID); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrMemberNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("member not found"))
+			case errors.Is(err, storage.ErrLastOwner):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("organization must keep an owner"))
+			default:
+				log.Error("failed to remove member", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to remove member"))
+			}
+			return
+		}
+
+		log.Info("member removed", slog.Int64("orgID", id), slog.Int64("memberID", accountID))
+		render.JSON(w, r, resp.OK())
+	}
+}
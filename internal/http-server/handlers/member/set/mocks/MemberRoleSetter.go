@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMemberRoleSetter struct {
+	mock.Mock
+}
+
+func (m *MockMemberRoleSetter) MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error) {
+	args := m.Called(ctx, orgID, accountID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMemberRoleSetter) SetMemberRole(ctx context.Context, orgID int64, accountID int64, role string) error {
+	args := m.Called(ctx, orgID, accountID, role)
+	return args.Error(0)
+}
+
+type mockConstructorTestingTMemberRoleSetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewMemberRoleSetter(t mockConstructorTestingTMemberRoleSetter) *MockMemberRoleSetter {
+	mock := &MockMemberRoleSetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
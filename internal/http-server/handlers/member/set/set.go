@@ -0,0 +1,135 @@
+package set
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	"passvault/internal/http-server/middlewares/authz"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Request struct {
+	Role string `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type MemberRoleSetter interface {
+	MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error)
+	SetMemberRole(ctx context.Context, orgID int64, accountID int64, role string) error
+}
+
+// New adds an account to an organization or changes its role. The route must be limited
+// to admins; only owners may make other owners or change the role of an owner.
+func New(log *slog.Logger, memberRoleSetter MemberRoleSetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.member.set.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		memberID := chi.URLParam(r, "accountID")
+		accountID, err := strconv.ParseInt(memberID, 10, 64)
+		if err != nil {
+			log.Error("invalid accountID parameter", slog.String("accountID", memberID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid accountID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		callerRole, _ := authz.RoleFromContext(r.Context())
+		if callerRole != models.OrgRoleOwner {
+			current, err := memberRoleSetter.MemberRole(ctx, id, accountID)
+			if err != nil && !errors.Is(err, storage.ErrMemberNotFound) {
+				log.Error("failed to get member role", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to set member role"))
+				return
+			}
+			if req.Role == models.OrgRoleOwner || current == models.OrgRoleOwner {
+				log.Info("only owners may manage owners", slog.Int64("orgID", id))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("only owners may manage owners"))
+				return
+			}
+		}
+
+		if err := memberRoleSetter.SetMemberRole(ctx, id, accountID, req.Role); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrLastOwner):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("organization must keep an owner"))
+			default:
+				log.Error("failed to set member role", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to set member role"))
+			}
+			return
+		}
+
+		log.Info("member role set", slog.Int64("orgID", id), slog.Int64("memberID", accountID), slog.String("role", req.Role))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,117 @@
+package set_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/member/set"
+	mocks "passvault/internal/http-server/handlers/member/set/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/http-server/middlewares/authz"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestSetHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		accountID  string
+		body       string
+		setup      func(m *mocks.MockMemberRoleSetter)
+		respStatus int
+	}{
+		{
+			name:      "Admin adds an editor",
+			accountID: "124",
+			body:      `{"role": "editor"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleAdmin, nil).Once()
+				m.On("MemberRole", mock.Anything, int64(1), int64(124)).
+					Return("", fmt.Errorf("storage.sqlite.MemberRole: %w", storage.ErrMemberNotFound)).Once()
+				m.On("SetMemberRole", mock.Anything, int64(1), int64(124), models.OrgRoleEditor).Return(nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:      "Admin makes an owner",
+			accountID: "124",
+			body:      `{"role": "owner"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleAdmin, nil).Once()
+				m.On("MemberRole", mock.Anything, int64(1), int64(124)).Return(models.OrgRoleEditor, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:      "Admin demotes an owner",
+			accountID: "124",
+			body:      `{"role": "viewer"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleAdmin, nil).Once()
+				m.On("MemberRole", mock.Anything, int64(1), int64(124)).Return(models.OrgRoleOwner, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:      "Last owner steps down",
+			accountID: "123",
+			body:      `{"role": "admin"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleOwner, nil).Once()
+				m.On("SetMemberRole", mock.Anything, int64(1), int64(123), models.OrgRoleAdmin).
+					Return(fmt.Errorf("storage.sqlite.SetMemberRole: %w", storage.ErrLastOwner)).Once()
+			},
+			respStatus: http.StatusConflict,
+		},
+		{
+			name:      "Editor cannot manage members",
+			accountID: "124",
+			body:      `{"role": "viewer"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleEditor, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:      "Unknown role",
+			accountID: "124",
+			body:      `{"role": "superuser"}`,
+			setup: func(m *mocks.MockMemberRoleSetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleOwner, nil).Once()
+			},
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMemberRoleSetter := mocks.NewMemberRoleSetter(t)
+			if tc.setup != nil {
+				tc.setup(mockMemberRoleSetter)
+			}
+
+			log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			router := chi.NewRouter()
+			router.Route("/{orgID}", func(r chi.Router) {
+				r.Use(authz.Organization(log, mockMemberRoleSetter, 5*time.Second))
+				r.With(authz.RequireRole(models.OrgRoleAdmin)).Put("/members/{accountID}", set.New(log, mockMemberRoleSetter, 5*time.Second))
+			})
+
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/1/members/%s", tc.accountID), bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
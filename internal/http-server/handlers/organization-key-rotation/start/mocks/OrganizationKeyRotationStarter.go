@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockOrganizationKeyRotationStarter struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationKeyRotationStarter) StartOrganization(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).(*models.KeyRotation), args.Error(1)
+}
+
+type mockConstructorTestingTOrganizationKeyRotationStarter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewOrganizationKeyRotationStarter(t mockConstructorTestingTOrganizationKeyRotationStarter) *MockOrganizationKeyRotationStarter {
+	mock := &MockOrganizationKeyRotationStarter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
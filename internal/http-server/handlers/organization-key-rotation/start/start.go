@@ -0,0 +1,92 @@
+package start
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+	Rotation *models.KeyRotation `json:"rotation"`
+}
+
+type OrganizationKeyRotationStarter interface {
+	StartOrganization(ctx context.Context, orgID int64) (*models.KeyRotation, error)
+}
+
+// New starts rotating the key shared by the collections of the organization in the orgID
+// URL parameter, or resumes its failed rotation. The collection entries are re-encrypted
+// in the background; the route must be limited to admins.
+func New(log *slog.Logger, organizationKeyRotationStarter OrganizationKeyRotationStarter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.organization-key-rotation.start.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		rotation, err := organizationKeyRotationStarter.StartOrganization(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrOrganizationNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("organization not found"))
+			case errors.Is(err, storage.ErrKeyRotationRunning):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("key rotation already running"))
+			default:
+				log.Error("failed to start key rotation", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to start key rotation"))
+			}
+			return
+		}
+
+		log.Info("key rotation started",
+			slog.Int64("admin_id", claims.AccountID),
+			slog.Int64("orgID", id),
+			slog.Int64("rotationID", rotation.ID),
+		)
+		w.WriteHeader(http.StatusAccepted)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Rotation: rotation,
+		})
+	}
+}
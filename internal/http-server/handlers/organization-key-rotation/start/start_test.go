@@ -0,0 +1,100 @@
+package start_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/organization-key-rotation/start"
+	mocks "passvault/internal/http-server/handlers/organization-key-rotation/start/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestStartHandler(t *testing.T) {
+	rotation := &models.KeyRotation{ID: 1, OrganizationID: 7, FromVersion: 1, ToVersion: 2, Status: models.KeyRotationRunning}
+
+	cases := []struct {
+		name       string
+		orgID      string
+		rotation   *models.KeyRotation
+		mockError  error
+		respError  string
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			orgID:      "7",
+			rotation:   rotation,
+			respStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Invalid Organization ID",
+			orgID:      "me",
+			respError:  "invalid orgID",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No Organization",
+			orgID:      "7",
+			mockError:  fmt.Errorf("services.keyrotation.StartOrganization: %w", storage.ErrOrganizationNotFound),
+			respError:  "organization not found",
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Already Running",
+			orgID:      "7",
+			mockError:  fmt.Errorf("services.keyrotation.StartOrganization: %w", storage.ErrKeyRotationRunning),
+			respError:  "key rotation already running",
+			respStatus: http.StatusConflict,
+		},
+		{
+			name:       "Error while starting",
+			orgID:      "7",
+			mockError:  fmt.Errorf("unexpected error"),
+			respError:  "failed to start key rotation",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrganizationKeyRotationStarter := mocks.NewOrganizationKeyRotationStarter(t)
+
+			if tc.respStatus != http.StatusBadRequest {
+				mockOrganizationKeyRotationStarter.On("StartOrganization", mock.AnythingOfType("*context.timerCtx"), int64(7)).
+					Return(tc.rotation, tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/{orgID}/key-rotation", start.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockOrganizationKeyRotationStarter, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, "/"+tc.orgID+"/key-rotation", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var response start.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			if tc.respError != "" {
+				require.Equal(t, tc.respError, response.Error)
+				return
+			}
+			require.Equal(t, resp.StatusOK, response.Status)
+			require.Equal(t, tc.rotation, response.Rotation)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockOrganizationKeyRotationGetter struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationKeyRotationGetter) OrganizationStatus(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).(*models.KeyRotation), args.Error(1)
+}
+
+type mockConstructorTestingTOrganizationKeyRotationGetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewOrganizationKeyRotationGetter(t mockConstructorTestingTOrganizationKeyRotationGetter) *MockOrganizationKeyRotationGetter {
+	mock := &MockOrganizationKeyRotationGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,73 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type OrganizationKeyRotationGetter interface {
+	OrganizationStatus(ctx context.Context, orgID int64) (*models.KeyRotation, error)
+}
+
+// New returns the latest key rotation of the organization in the orgID URL parameter
+// with its progress. The route must be limited to admins.
+func New(log *slog.Logger, organizationKeyRotationGetter OrganizationKeyRotationGetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.organization-key-rotation.status.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		rotation, err := organizationKeyRotationGetter.OrganizationStatus(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrKeyRotationNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("key rotation not found"))
+				return
+			}
+			log.Error("failed to retrieve key rotation", slog.Int64("orgID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve key rotation"))
+			return
+		}
+
+		log.Info("key rotation retrieved", slog.Int64("orgID", id), slog.String("status", rotation.Status))
+		render.JSON(w, r, rotation)
+	}
+}
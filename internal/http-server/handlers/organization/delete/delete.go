@@ -0,0 +1,74 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type OrganizationDeleter interface {
+	DeleteOrganization(ctx context.Context, orgID int64) error
+}
+
+// New deletes an organization without collections. The route must be limited to owners.
+func New(log *slog.Logger, organizationDeleter OrganizationDeleter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.organization.delete.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := authrest.GetUserClaimsFromContext(r.Context()); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgID := chi.URLParam(r, "orgID")
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid orgID"))
+			return
+		}
+
+		if err := organizationDeleter.DeleteOrganization(ctx, id); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrOrganizationNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("organization not found"))
+			case errors.Is(err, storage.ErrOrganizationNotEmpty):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, resp.Error("organization still has collections"))
+			default:
+				log.Error("failed to delete organization", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to delete organization"))
+			}
+			return
+		}
+
+		log.Info("organization deleted", slog.Int64("orgID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,59 @@
+package list
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+)
+
+type OrganizationLister interface {
+	ListOrganizations(ctx context.Context, accountID int64) ([]models.Organization, error)
+}
+
+// New returns the organizations the account is a member of, with its role in each.
+func New(log *slog.Logger, organizationLister OrganizationLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.organization.list.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		orgs, err := organizationLister.ListOrganizations(ctx, claims.AccountID)
+		if err != nil {
+			log.Error("failed to retrieve organizations", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve organizations"))
+			return
+		}
+
+		if orgs == nil {
+			orgs = []models.Organization{}
+		}
+
+		log.Info("organizations retrieved", slog.Int("count", len(orgs)))
+		render.JSON(w, r, orgs)
+	}
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/health"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxAgeDays = 365
+	maxMaxAgeDays     = 3650
+)
+
+type Response struct {
+	resp.Response
+	Report *health.Report `json:"report,omitempty"`
+}
+
+type HealthReporter interface {
+	Report(ctx context.Context, accountID int64, opts health.Options) (*health.Report, error)
+}
+
+// New reports on the health of the logins of the caller. The query parameter
+// max_age_days (1 to 3650, default 365) sets when a password counts as not rotated.
+func New(log *slog.Logger, healthReporter HealthReporter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.report.health.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		maxAgeDays := defaultMaxAgeDays
+		if value := r.URL.Query().Get("max_age_days"); value != "" {
+			maxAgeDays, err = strconv.Atoi(value)
+			if err != nil || maxAgeDays < 1 || maxAgeDays > maxMaxAgeDays {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("max_age_days must be between 1 and "+strconv.Itoa(maxMaxAgeDays)))
+				return
+			}
+		}
+
+		report, err := healthReporter.Report(ctx, claims.AccountID, health.Options{
+			MaxAge: time.Duration(maxAgeDays) * 24 * time.Hour,
+		})
+		if err != nil {
+			log.Error("failed to generate health report", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to generate health report"))
+			return
+		}
+
+		log.Info("health report generated", slog.Int64("accountID", claims.AccountID), slog.Int("score", report.Score))
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Report:   report,
+		})
+	}
+}
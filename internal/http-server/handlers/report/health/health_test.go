@@ -0,0 +1,105 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	healthhandler "passvault/internal/http-server/handlers/report/health"
+	mocks "passvault/internal/http-server/handlers/report/health/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/services/health"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	report := &health.Report{
+		GeneratedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Logins:      2,
+		Score:       50,
+		Weak:        []health.WeakEntry{{EntryID: 7, Score: 0}},
+		Reused:      [][]int64{},
+		Stale:       []health.StaleEntry{},
+		WithoutTOTP: []int64{7},
+		Strength:    map[int64]int{7: 0, 8: 4},
+	}
+
+	cases := []struct {
+		name       string
+		query      string
+		maxAge     time.Duration
+		mockError  error
+		respError  string
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			maxAge:     365 * 24 * time.Hour,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Max Age",
+			query:      "?max_age_days=90",
+			maxAge:     90 * 24 * time.Hour,
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Max Age",
+			query:      "?max_age_days=0",
+			respError:  "max_age_days must be between 1 and 3650",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Report Error",
+			maxAge:     365 * 24 * time.Hour,
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to generate health report",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			healthReporterMock := mocks.NewHealthReporter(t)
+
+			if tc.maxAge != 0 {
+				var result *health.Report
+				if tc.mockError == nil {
+					result = report
+				}
+				healthReporterMock.On("Report", mock.AnythingOfType("*context.timerCtx"), int64(123), health.Options{MaxAge: tc.maxAge}).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := healthhandler.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), healthReporterMock, 5*time.Second)
+
+			req, err := http.NewRequest(http.MethodGet, "/reports/health"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(handler, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp healthhandler.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, report, resp.Report)
+			}
+		})
+	}
+}
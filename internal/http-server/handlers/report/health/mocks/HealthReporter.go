@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/services/health"
+)
+
+type MockHealthReporter struct {
+	mock.Mock
+}
+
+func (m *MockHealthReporter) Report(ctx context.Context, accountID int64, opts health.Options) (*health.Report, error) {
+	args := m.Called(ctx, accountID, opts)
+	report, _ := args.Get(0).(*health.Report)
+	return report, args.Error(1)
+}
+
+type mockConstructorTestingTHealthReporter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewHealthReporter(t mockConstructorTestingTHealthReporter) *MockHealthReporter {
+	mock := &MockHealthReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,75 @@
+package get
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type RevisionGetter interface {
+	GetRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) (*models.EntryRevision, error)
+}
+
+func New(log *slog.Logger, revisionGetter RevisionGetter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.revision.get.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID, errEntry := strconv.ParseInt(chi.URLParam(r, "entryID"), 10, 64)
+		revisionID, errRevision := strconv.ParseInt(chi.URLParam(r, "revisionID"), 10, 64)
+		if errEntry != nil || errRevision != nil {
+			log.Error("invalid path parameters",
+				slog.String("entryID", chi.URLParam(r, "entryID")),
+				slog.String("revisionID", chi.URLParam(r, "revisionID")),
+			)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID or revisionID"))
+			return
+		}
+
+		revision, err := revisionGetter.GetRevision(ctx, claims.AccountID, entryID, revisionID)
+		if err != nil {
+			if errors.Is(err, storage.ErrRevisionNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("revision not found"))
+				return
+			}
+			log.Error("failed to retrieve revision", slog.Int64("revisionID", revisionID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve revision"))
+			return
+		}
+
+		log.Info("revision retrieved", slog.Int64("entryID", entryID), slog.Int64("revisionID", revisionID))
+		render.JSON(w, r, revision)
+	}
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRevisionRestorer struct {
+	mock.Mock
+}
+
+func (m *MockRevisionRestorer) RestoreRevision(ctx context.Context, accountId int64, entryID int64, revisionID int64) error {
+	args := m.Called(ctx, accountId, entryID, revisionID)
+	return args.Error(0)
+}
+
+type mockConstructorTestingTRevisionRestorer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewRevisionRestorer(t mockConstructorTestingTRevisionRestorer) *MockRevisionRestorer {
+	mock := &MockRevisionRestorer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
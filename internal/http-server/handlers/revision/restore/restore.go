@@ -0,0 +1,88 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type RevisionRestorer interface {
+	RestoreRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) error
+}
+
+func New(log *slog.Logger, revisionRestorer RevisionRestorer, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.revision.restore.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID, errEntry := strconv.ParseInt(chi.URLParam(r, "entryID"), 10, 64)
+		revisionID, errRevision := strconv.ParseInt(chi.URLParam(r, "revisionID"), 10, 64)
+		if errEntry != nil || errRevision != nil {
+			log.Error("invalid path parameters",
+				slog.String("entryID", chi.URLParam(r, "entryID")),
+				slog.String("revisionID", chi.URLParam(r, "revisionID")),
+			)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID or revisionID"))
+			return
+		}
+
+		if err := revisionRestorer.RestoreRevision(ctx, claims.AccountID, entryID, revisionID); err != nil {
+			if errors.Is(err, storage.ErrRevisionNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("revision not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrAccessDenied) {
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+			if errors.Is(err, storage.ErrPlaintextRefused) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("account only accepts client-side encrypted entries"))
+				return
+			}
+			if errors.Is(err, storage.ErrCiphertextRefused) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("client-side encrypted entries are not accepted here"))
+				return
+			}
+			log.Error("failed to restore revision", slog.Int64("revisionID", revisionID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to restore revision"))
+			return
+		}
+
+		log.Info("entry restored", slog.Int64("entryID", entryID), slog.Int64("revisionID", revisionID))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,73 @@
+package restore_test
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/revision/restore"
+	mocks "passvault/internal/http-server/handlers/revision/restore/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		mockError  error
+		respStatus int
+	}{
+		{
+			name:       "Success",
+			path:       "/1/revisions/7/restore",
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Revision ID",
+			path:       "/1/revisions/latest/restore",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Revision not found",
+			path:       "/1/revisions/7/restore",
+			mockError:  fmt.Errorf("storage.sqlite.RestoreRevision: %w", storage.ErrRevisionNotFound),
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Error while restoring",
+			path:       "/1/revisions/7/restore",
+			mockError:  fmt.Errorf("unexpected error"),
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRevisionRestorer := mocks.NewRevisionRestorer(t)
+
+			if tc.respStatus != http.StatusBadRequest {
+				mockRevisionRestorer.On("RestoreRevision", mock.AnythingOfType("*context.timerCtx"), int64(123), int64(1), int64(7)).
+					Return(tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/{entryID}/revisions/{revisionID}/restore", restore.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockRevisionRestorer, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package create
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+// Request grants AccountID access to the entry. Without ExpiresAt the share lasts until it is revoked.
+type Request struct {
+	AccountID  int64      `json:"account_id" validate:"required,gt=0"`
+	Permission string     `json:"permission" validate:"required,oneof=read write"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id"`
+}
+
+type EntrySharer interface {
+	ShareEntry(ctx context.Context, ownerID int64, entryID int64, accountID int64, permission string, expiresAt *time.Time) (int64, error)
+}
+
+func New(log *slog.Logger, entrySharer EntrySharer, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.share.create.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		log = log.With(slog.Int64("account_id", claims.AccountID))
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			if errors.As(err, &validateErr) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field ExpiresAt must be in the future"))
+			return
+		}
+
+		shareID, err := entrySharer.ShareEntry(ctx, claims.AccountID, id, req.AccountID, req.Permission, req.ExpiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrEntryNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("entry not found"))
+			case errors.Is(err, storage.ErrInvalidShare):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(storage.ErrInvalidShare.Error()))
+			default:
+				log.Error("failed to share entry", slog.Int64("entryID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to share entry"))
+			}
+			return
+		}
+
+		log.Info("entry shared", slog.Int64("entryID", id), slog.Int64("grantee", req.AccountID), slog.String("permission", req.Permission))
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       shareID,
+		})
+	}
+}
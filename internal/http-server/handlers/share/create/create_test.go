@@ -0,0 +1,105 @@
+package create_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/share/create"
+	mocks "passvault/internal/http-server/handlers/share/create/mocks"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestCreateHandler(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	cases := []struct {
+		name       string
+		entryID    string
+		body       string
+		setup      func(m *mocks.MockEntrySharer)
+		respStatus int
+	}{
+		{
+			name:    "Read share",
+			entryID: "1",
+			body:    `{"account_id": 124, "permission": "read"}`,
+			setup: func(m *mocks.MockEntrySharer) {
+				m.On("ShareEntry", mock.Anything, int64(123), int64(1), int64(124), "read", (*time.Time)(nil)).Return(int64(9), nil).Once()
+			},
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:    "Expiring write share",
+			entryID: "1",
+			body:    fmt.Sprintf(`{"account_id": 124, "permission": "write", "expires_at": %q}`, expiresAt.Format(time.RFC3339)),
+			setup: func(m *mocks.MockEntrySharer) {
+				m.On("ShareEntry", mock.Anything, int64(123), int64(1), int64(124), "write", mock.MatchedBy(func(t *time.Time) bool {
+					return t != nil && t.Equal(expiresAt)
+				})).Return(int64(10), nil).Once()
+			},
+			respStatus: http.StatusCreated,
+		},
+		{
+			name:       "Expiry in the past",
+			entryID:    "1",
+			body:       `{"account_id": 124, "permission": "read", "expires_at": "2020-01-01T00:00:00Z"}`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown permission",
+			entryID:    "1",
+			body:       `{"account_id": 124, "permission": "admin"}`,
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Share with owner",
+			entryID: "1",
+			body:    `{"account_id": 123, "permission": "read"}`,
+			setup: func(m *mocks.MockEntrySharer) {
+				m.On("ShareEntry", mock.Anything, int64(123), int64(1), int64(123), "read", (*time.Time)(nil)).
+					Return(int64(0), fmt.Errorf("storage.sqlite.ShareEntry: %w", storage.ErrInvalidShare)).Once()
+			},
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Entry of another account",
+			entryID: "2",
+			body:    `{"account_id": 124, "permission": "read"}`,
+			setup: func(m *mocks.MockEntrySharer) {
+				m.On("ShareEntry", mock.Anything, int64(123), int64(2), int64(124), "read", (*time.Time)(nil)).
+					Return(int64(0), fmt.Errorf("storage.sqlite.ShareEntry: %w", storage.ErrEntryNotFound)).Once()
+			},
+			respStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntrySharer := mocks.NewEntrySharer(t)
+			if tc.setup != nil {
+				tc.setup(mockEntrySharer)
+			}
+
+			router := chi.NewRouter()
+			router.Post("/{entryID}/shares", create.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), mockEntrySharer, 5*time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%s/shares", tc.entryID), bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
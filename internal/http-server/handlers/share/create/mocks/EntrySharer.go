@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockEntrySharer struct {
+	mock.Mock
+}
+
+func (m *MockEntrySharer) ShareEntry(ctx context.Context, ownerID int64, entryID int64, accountID int64, permission string, expiresAt *time.Time) (int64, error) {
+	args := m.Called(ctx, ownerID, entryID, accountID, permission, expiresAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type mockConstructorTestingTEntrySharer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntrySharer(t mockConstructorTestingTEntrySharer) *MockEntrySharer {
+	mock := &MockEntrySharer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+package received
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"time"
+)
+
+type ReceivedShareLister interface {
+	ListReceivedShares(ctx context.Context, accountID int64) ([]models.EntryShare, error)
+}
+
+// New lists the entries other accounts currently share with the caller.
+func New(log *slog.Logger, shareLister ReceivedShareLister, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.share.received.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		shares, err := shareLister.ListReceivedShares(ctx, claims.AccountID)
+		if err != nil {
+			log.Error("failed to retrieve received shares", slog.Int64("accountID", claims.AccountID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to retrieve shares"))
+			return
+		}
+
+		if shares == nil {
+			shares = []models.EntryShare{}
+		}
+
+		log.Info("received shares retrieved", slog.Int("count", len(shares)))
+		render.JSON(w, r, shares)
+	}
+}
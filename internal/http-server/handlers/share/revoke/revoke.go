@@ -0,0 +1,79 @@
+package revoke
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type ShareRevoker interface {
+	RevokeShare(ctx context.Context, ownerID int64, entryID int64, accountID int64) error
+}
+
+func New(log *slog.Logger, shareRevoker ShareRevoker, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.share.revoke.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entryID := chi.URLParam(r, "entryID")
+		id, err := strconv.ParseInt(entryID, 10, 64)
+		if err != nil {
+			log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid entryID"))
+			return
+		}
+
+		accountID := chi.URLParam(r, "accountID")
+		grantee, err := strconv.ParseInt(accountID, 10, 64)
+		if err != nil {
+			log.Error("invalid accountID parameter", slog.String("accountID", accountID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid accountID"))
+			return
+		}
+
+		if err := shareRevoker.RevokeShare(ctx, claims.AccountID, id, grantee); err != nil {
+			if errors.Is(err, storage.ErrShareNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("share not found"))
+				return
+			}
+			log.Error("failed to revoke share", slog.Int64("entryID", id), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to revoke share"))
+			return
+		}
+
+		log.Info("share revoked", slog.Int64("entryID", id), slog.Int64("grantee", grantee))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,72 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type TagDeleter interface {
+	DeleteTag(ctx context.Context, accountID int64, tagID int64) error
+}
+
+// New deletes a tag and removes it from all entries; the entries themselves are kept.
+func New(log *slog.Logger, tagDeleter TagDeleter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tag.delete.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		tagID := chi.URLParam(r, "tagID")
+		id, err := strconv.ParseInt(tagID, 10, 64)
+		if err != nil {
+			log.Error("invalid tagID parameter", slog.String("tagID", tagID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid tagID"))
+			return
+		}
+
+		if err := tagDeleter.DeleteTag(ctx, claims.AccountID, id); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrTagNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("tag not found"))
+			default:
+				log.Error("failed to delete tag", slog.Int64("tagID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to delete tag"))
+			}
+			return
+		}
+
+		log.Info("tag deleted", slog.Int64("tagID", id))
+		render.JSON(w, r, resp.OK())
+	}
+}
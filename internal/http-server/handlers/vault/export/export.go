@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/middlewares/audit"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/services/exporter"
+	"strconv"
+	"time"
+)
+
+// PassphraseHeader carries the passphrase of encrypted exports, so it stays out of
+// the URLs written to access logs.
+const PassphraseHeader = "X-Export-Passphrase"
+
+type VaultExporter interface {
+	Export(ctx context.Context, accountID int64, w io.Writer, opts exporter.Options) error
+}
+
+// New exports the personal vault of the caller as a file download. The "format" query
+// parameter selects the format, the encrypted archive by default; the plain text
+// formats need "confirm=true". Plain exports are recorded as export.plain in the
+// audit log.
+func New(log *slog.Logger, vaultExporter VaultExporter, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.vault.export.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := authrest.GetAuthErrorFromContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authrest.GetUserClaimsFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		opts := exporter.Options{
+			Format:     r.URL.Query().Get("format"),
+			Passphrase: r.Header.Get(PassphraseHeader),
+		}
+		if opts.Format == "" {
+			opts.Format = exporter.FormatEncrypted
+		}
+		log = log.With(slog.Int64("account_id", claims.AccountID), slog.String("format", opts.Format))
+
+		if exporter.Plain(opts.Format) {
+			audit.SetAction(r.Context(), models.AuditExportPlain)
+		}
+
+		if value := r.URL.Query().Get("confirm"); value != "" {
+			opts.ConfirmPlain, err = strconv.ParseBool(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("field confirm is not valid"))
+				return
+			}
+		}
+
+		// The export is buffered so a failure can still be answered with an error.
+		var body bytes.Buffer
+		if err := vaultExporter.Export(ctx, claims.AccountID, &body, opts); err != nil {
+			switch {
+			case errors.Is(err, exporter.ErrUnknownFormat):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("unknown export format"))
+			case errors.Is(err, exporter.ErrPassphraseRequired):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("header "+PassphraseHeader+" is required"))
+			case errors.Is(err, exporter.ErrNotConfirmed):
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("plain text exports need confirm=true"))
+			case errors.Is(err, exporter.ErrClientEncrypted):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("client-side encrypted entries can only be exported encrypted"))
+			default:
+				log.Error("failed to export vault", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to export vault"))
+			}
+			return
+		}
+
+		contentType, extension := "application/octet-stream", "age"
+		switch opts.Format {
+		case exporter.FormatBitwarden:
+			contentType, extension = "application/json", "json"
+		case exporter.FormatCSV:
+			contentType, extension = "text/csv", "csv"
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf(`attachment; filename="passvault-%s.%s"`, time.Now().UTC().Format("20060102"), extension))
+		w.Header().Set("Cache-Control", "no-store")
+		if _, err := body.WriteTo(w); err != nil {
+			log.Error("failed to write export", sl.Err(err))
+			return
+		}
+
+		log.Info("vault exported")
+	}
+}
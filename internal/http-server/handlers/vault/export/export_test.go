@@ -0,0 +1,134 @@
+package export_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/http-server/handlers/vault/export"
+	mocks "passvault/internal/http-server/handlers/vault/export/mocks"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/services/exporter"
+	"testing"
+	"time"
+)
+
+func TestExportHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		query       string
+		passphrase  string
+		opts        *exporter.Options
+		mockError   error
+		respError   string
+		respStatus  int
+		contentType string
+	}{
+		{
+			name:        "Encrypted",
+			passphrase:  "correct horse",
+			opts:        &exporter.Options{Format: exporter.FormatEncrypted, Passphrase: "correct horse"},
+			respStatus:  http.StatusOK,
+			contentType: "application/octet-stream",
+		},
+		{
+			name:        "Bitwarden",
+			query:       "?format=bitwarden&confirm=true",
+			opts:        &exporter.Options{Format: exporter.FormatBitwarden, ConfirmPlain: true},
+			respStatus:  http.StatusOK,
+			contentType: "application/json",
+		},
+		{
+			name:        "CSV",
+			query:       "?format=csv&confirm=1",
+			opts:        &exporter.Options{Format: exporter.FormatCSV, ConfirmPlain: true},
+			respStatus:  http.StatusOK,
+			contentType: "text/csv",
+		},
+		{
+			name:       "Invalid Confirm",
+			query:      "?format=csv&confirm=maybe",
+			respError:  "field confirm is not valid",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not Confirmed",
+			query:      "?format=csv",
+			opts:       &exporter.Options{Format: exporter.FormatCSV},
+			mockError:  fmt.Errorf("services.exporter.Export: %w", exporter.ErrNotConfirmed),
+			respError:  "plain text exports need confirm=true",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Missing Passphrase",
+			opts:       &exporter.Options{Format: exporter.FormatEncrypted},
+			mockError:  fmt.Errorf("services.exporter.Export: %w", exporter.ErrPassphraseRequired),
+			respError:  "header X-Export-Passphrase is required",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown Format",
+			query:      "?format=xml",
+			opts:       &exporter.Options{Format: "xml"},
+			mockError:  fmt.Errorf("services.exporter.Export: %w", exporter.ErrUnknownFormat),
+			respError:  "unknown export format",
+			respStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Export Error",
+			passphrase: "correct horse",
+			opts:       &exporter.Options{Format: exporter.FormatEncrypted, Passphrase: "correct horse"},
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to export vault",
+			respStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			vaultExporterMock := mocks.NewVaultExporter(t)
+
+			if tc.opts != nil {
+				vaultExporterMock.On("Export", mock.AnythingOfType("*context.timerCtx"), int64(123), mock.Anything, *tc.opts).
+					Return(tc.mockError, "exported").
+					Once()
+			}
+
+			handler := export.New(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), vaultExporterMock, 5*time.Second)
+
+			req, err := http.NewRequest(http.MethodGet, "/export"+tc.query, nil)
+			require.NoError(t, err)
+			if tc.passphrase != "" {
+				req.Header.Set(export.PassphraseHeader, tc.passphrase)
+			}
+
+			rr := httptest.NewRecorder()
+			utils.TestMiddleware(handler, rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			if tc.respError != "" {
+				var response resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				require.Equal(t, tc.respError, response.Error)
+				return
+			}
+
+			require.Equal(t, "exported", rr.Body.String())
+			require.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			require.Contains(t, rr.Header().Get("Content-Disposition"), "attachment; filename=\"passvault-")
+		})
+	}
+}
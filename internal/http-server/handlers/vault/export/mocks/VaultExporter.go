@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"passvault/internal/services/exporter"
+)
+
+type MockVaultExporter struct {
+	mock.Mock
+}
+
+func (m *MockVaultExporter) Export(ctx context.Context, accountID int64, w io.Writer, opts exporter.Options) error {
+	args := m.Called(ctx, accountID, w, opts)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, args.String(1))
+	return err
+}
+
+type mockConstructorTestingTVaultExporter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewVaultExporter(t mockConstructorTestingTVaultExporter) *MockVaultExporter {
+	mock := &MockVaultExporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
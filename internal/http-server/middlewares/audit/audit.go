@@ -0,0 +1,194 @@
+// Package audit records every vault request of an authenticated account in the
+// hash-chained audit log. It runs after the auth middleware.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"strconv"
+	"time"
+)
+
+type EventRecorder interface {
+	AppendAuditEvent(ctx context.Context, event models.AuditEvent) (int64, error)
+}
+
+type targetKey struct{}
+
+type target struct {
+	entryID *int64
+	action  string
+}
+
+// New records action once the handler has answered, with the response status, so
+// denied and failed attempts are logged too. The entry is taken from the entryID URL
+// parameter, or from SetEntryID for handlers that create entries. Handlers can record
+// a more specific action with SetAction. Requests without user claims are not
+// recorded; the handlers refuse them. An event that cannot be written is logged, the
+// response has already been sent by then.
+func New(log *slog.Logger, recorder EventRecorder, action string, timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.audit.New"
+
+			claims, err := authrest.GetUserClaimsFromContext(r.Context())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			t := &target{action: action}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), targetKey{}, t)))
+
+			event := newEvent(r, claims.AccountID, t, ww.Status())
+			if err := appendEvent(r.Context(), recorder, event, timeout); err != nil {
+				log.Error("failed to record audit event",
+					slog.String("op", op),
+					slog.String("request_id", event.RequestID),
+					slog.String("action", event.Action),
+					sl.Err(err),
+				)
+			}
+		})
+	}
+}
+
+// Strict is New for actions that must not happen unrecorded: key, export and share
+// requests. The response is held back until the event is written and replaced with
+// 500 Internal Server Error when it cannot be, so clients never get a response whose
+// request is missing from the audit log.
+func Strict(log *slog.Logger, recorder EventRecorder, action string, timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.audit.Strict"
+
+			claims, err := authrest.GetUserClaimsFromContext(r.Context())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			t := &target{action: action}
+			buf := &bufferedResponse{header: make(http.Header)}
+			next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), targetKey{}, t)))
+
+			event := newEvent(r, claims.AccountID, t, buf.status)
+			if err := appendEvent(r.Context(), recorder, event, timeout); err != nil {
+				log.Error("failed to record audit event, response withheld",
+					slog.String("op", op),
+					slog.String("request_id", event.RequestID),
+					slog.String("action", event.Action),
+					sl.Err(err),
+				)
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+
+			buf.flush(w)
+		})
+	}
+}
+
+// newEvent builds the audit event of a request answered with status.
+func newEvent(r *http.Request, accountID int64, t *target, status int) models.AuditEvent {
+	event := models.AuditEvent{
+		AccountID: accountID,
+		Action:    t.action,
+		EntryID:   t.entryID,
+		RequestID: middleware.GetReqID(r.Context()),
+		IP:        remoteIP(r),
+		UserAgent: r.UserAgent(),
+		Status:    status,
+	}
+	if event.EntryID == nil {
+		if id, err := strconv.ParseInt(chi.URLParam(r, "entryID"), 10, 64); err == nil {
+			event.EntryID = &id
+		}
+	}
+	if event.Status == 0 {
+		event.Status = http.StatusOK
+	}
+	return event
+}
+
+func appendEvent(ctx context.Context, recorder EventRecorder, event models.AuditEvent, timeout time.Duration) error {
+	// The request may already be cancelled, the event must still be written.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	_, err := recorder.AppendAuditEvent(ctx, event)
+	return err
+}
+
+// bufferedResponse holds a response in memory until Strict has recorded its request.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// flush sends the held response to w.
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	status := b.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(b.body.Bytes())
+}
+
+// SetEntryID sets the entry of the audit event of the request, for handlers that
+// only learn the entry ID while handling it.
+func SetEntryID(ctx context.Context, entryID int64) {
+	if t, ok := ctx.Value(targetKey{}).(*target); ok {
+		t.entryID = &entryID
+	}
+}
+
+// SetAction replaces the action of the audit event of the request, for handlers whose
+// requests differ in sensitivity.
+func SetAction(ctx context.Context, action string) {
+	if t, ok := ctx.Value(targetKey{}).(*target); ok {
+		t.action = action
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
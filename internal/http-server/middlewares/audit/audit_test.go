@@ -0,0 +1,158 @@
+package audit_test
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/http-server/middlewares/audit"
+	mocks "passvault/internal/http-server/middlewares/audit/mocks"
+	"testing"
+	"time"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		action    string
+		status    int
+		entryID   int64
+		createdID int64
+		setAction string
+	}{
+		{
+			name:    "Entry read",
+			method:  http.MethodGet,
+			path:    "/entries/1",
+			action:  models.AuditEntryRead,
+			status:  http.StatusOK,
+			entryID: 1,
+		},
+		{
+			name:    "Denied update is recorded",
+			method:  http.MethodPut,
+			path:    "/entries/2",
+			action:  models.AuditEntryUpdate,
+			status:  http.StatusForbidden,
+			entryID: 2,
+		},
+		{
+			name:      "Created entry",
+			method:    http.MethodPost,
+			path:      "/entries",
+			action:    models.AuditEntryCreate,
+			status:    http.StatusCreated,
+			entryID:   3,
+			createdID: 3,
+		},
+		{
+			name:      "Action set by handler",
+			method:    http.MethodGet,
+			path:      "/export",
+			action:    models.AuditExportPlain,
+			status:    http.StatusOK,
+			setAction: models.AuditExportPlain,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := mocks.NewEventRecorder(t)
+			recorder.On("AppendAuditEvent", mock.Anything, mock.MatchedBy(func(event models.AuditEvent) bool {
+				if tc.entryID == 0 && event.EntryID != nil || tc.entryID != 0 && (event.EntryID == nil || *event.EntryID != tc.entryID) {
+					return false
+				}
+				return event.AccountID == 123 && event.Action == tc.action && event.Status == tc.status &&
+					event.IP == "192.0.2.1" && event.UserAgent == "passvault-test"
+			})).Return(int64(1), nil).Once()
+
+			log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if tc.createdID != 0 {
+					audit.SetEntryID(r.Context(), tc.createdID)
+				}
+				if tc.setAction != "" {
+					audit.SetAction(r.Context(), tc.setAction)
+				}
+				w.WriteHeader(tc.status)
+			}
+
+			router := chi.NewRouter()
+			router.With(audit.New(log, recorder, models.AuditEntryCreate, 5*time.Second)).Post("/entries", handler)
+			router.With(audit.New(log, recorder, models.AuditEntryRead, 5*time.Second)).Get("/entries/{entryID}", handler)
+			router.With(audit.New(log, recorder, models.AuditEntryUpdate, 5*time.Second)).Put("/entries/{entryID}", handler)
+			router.With(audit.New(log, recorder, models.AuditExportEncrypted, 5*time.Second)).Get("/export", handler)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("User-Agent", "passvault-test")
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
+
+func TestStrictAuditMiddleware(t *testing.T) {
+	cases := []struct {
+		name      string
+		recordErr error
+		status    int
+		body      string
+	}{
+		{
+			name:   "Response sent once recorded",
+			status: http.StatusCreated,
+			body:   `{"key_part":"secret"}`,
+		},
+		{
+			name:      "Response withheld when recording fails",
+			recordErr: errors.New("disk full"),
+			status:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := mocks.NewEventRecorder(t)
+			recorder.On("AppendAuditEvent", mock.Anything, mock.MatchedBy(func(event models.AuditEvent) bool {
+				// The status the handler answered with is recorded either way.
+				return event.AccountID == 123 && event.Action == models.AuditKeySave && event.Status == http.StatusCreated
+			})).Return(int64(1), tc.recordErr).Once()
+
+			log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "keysave")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"key_part":"secret"}`))
+			}
+
+			router := chi.NewRouter()
+			router.With(audit.Strict(log, recorder, models.AuditKeySave, 5*time.Second)).Post("/keys", handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/keys", nil)
+			req.Header.Set("User-Agent", "passvault-test")
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.status, rr.Code)
+			if tc.recordErr != nil {
+				assert.NotContains(t, rr.Body.String(), "secret")
+				assert.Empty(t, rr.Header().Get("X-Handler"))
+				return
+			}
+			assert.Equal(t, tc.body, rr.Body.String())
+			assert.Equal(t, "keysave", rr.Header().Get("X-Handler"))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockEventRecorder struct {
+	mock.Mock
+}
+
+func (m *MockEventRecorder) AppendAuditEvent(ctx context.Context, event models.AuditEvent) (int64, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type mockConstructorTestingTEventRecorder interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEventRecorder(t mockConstructorTestingTEventRecorder) *MockEventRecorder {
+	mock := &MockEventRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -41,19 +41,16 @@ func New(log *slog.Logger, appSecret string) func(next http.Handler) http.Handle
 			if err != nil {
 				log.Warn("failed to parse token", sl.Err(err))
 
-				// Handle invalid token by setting error in context and halting request
-				ctx := context.WithValue(r.Context(), ErrInvalidToken, err)
+				// End request if token is invalid
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				next.ServeHTTP(w, r.WithContext(ctx)) // End request if token is invalid
 				return
 			}
 
 			if claims.ExpiresAt.Before(time.Now()) {
 				log.Warn("token expired", sl.Err(err))
 
-				ctx := context.WithValue(r.Context(), ErrTokenExpired, ErrTokenExpired)
+				// End request if token is expired
 				http.Error(w, "Token expired", http.StatusUnauthorized)
-				next.ServeHTTP(w, r.WithContext(ctx)) // End request if token is expired
 				return
 			}
 
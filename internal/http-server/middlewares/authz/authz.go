@@ -0,0 +1,209 @@
+// Package authz enforces what an authenticated account may do with the entries
+// and organizations addressed by a route. It runs after the auth middleware.
+package authz
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"passvault/internal/domain/models"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"strconv"
+	"time"
+)
+
+type EntryAccessor interface {
+	EntryAccess(ctx context.Context, accountID int64, entryID int64) (*models.EntryAccess, error)
+}
+
+type MemberRoleGetter interface {
+	MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error)
+}
+
+type entryAccessKey struct{}
+
+type orgRoleKey struct{}
+
+// Entry checks the access of the account to the entry in the entryID URL parameter:
+// GET and HEAD need read access, DELETE delete access and other methods write access.
+// Accounts that can read the entry but not do more get 403.
+//
+// Entries the account cannot read are passed on to the handler, which reports them
+// as not found; revisions of deleted entries stay reachable that way, with the
+// storage checking access to them.
+func Entry(log *slog.Logger, accessor EntryAccessor, timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.authz.Entry"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			claims, err := authrest.GetUserClaimsFromContext(r.Context())
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			entryID := chi.URLParam(r, "entryID")
+			id, err := strconv.ParseInt(entryID, 10, 64)
+			if err != nil {
+				log.Error("invalid entryID parameter", slog.String("entryID", entryID))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid entryID"))
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			access, err := accessor.EntryAccess(ctx, claims.AccountID, id)
+			if errors.Is(err, storage.ErrEntryNotFound) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				log.Error("failed to check entry access", slog.Int64("entryID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to check entry access"))
+				return
+			}
+
+			allowed := access.Write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				allowed = access.Read
+			case http.MethodDelete:
+				allowed = access.Delete
+			}
+			if !allowed {
+				log.Info("entry access denied",
+					slog.Int64("account_id", claims.AccountID),
+					slog.Int64("entryID", id),
+					slog.String("method", r.Method),
+				)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), entryAccessKey{}, access)))
+		})
+	}
+}
+
+// Organization resolves the role of the account in the organization in the orgID URL
+// parameter. Accounts that are not members get 404, so organizations are not revealed.
+func Organization(log *slog.Logger, members MemberRoleGetter, timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.authz.Organization"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			claims, err := authrest.GetUserClaimsFromContext(r.Context())
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			orgID := chi.URLParam(r, "orgID")
+			id, err := strconv.ParseInt(orgID, 10, 64)
+			if err != nil {
+				log.Error("invalid orgID parameter", slog.String("orgID", orgID))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid orgID"))
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			role, err := members.MemberRole(ctx, id, claims.AccountID)
+			if errors.Is(err, storage.ErrMemberNotFound) {
+				log.Info("not a member of the organization", slog.Int64("account_id", claims.AccountID), slog.Int64("orgID", id))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, resp.Error("organization not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get member role", slog.Int64("orgID", id), sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to check organization access"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), orgRoleKey{}, role)))
+		})
+	}
+}
+
+// RequireRole answers 403 unless the Organization middleware found a role of at least min.
+func RequireRole(min string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if role, _ := RoleFromContext(r.Context()); !models.OrgRoleAtLeast(role, min) {
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Admin answers 403 unless the account is one of the configured admin accounts.
+func Admin(log *slog.Logger, admins []int64) func(next http.Handler) http.Handler {
+	allowed := make(map[int64]struct{}, len(admins))
+	for _, id := range admins {
+		allowed[id] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.authz.Admin"
+
+			claims, err := authrest.GetUserClaimsFromContext(r.Context())
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if _, ok := allowed[claims.AccountID]; !ok {
+				log.Info("admin access denied",
+					slog.String("op", op),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.Int64("account_id", claims.AccountID),
+				)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AccessFromContext returns the entry access stored by the Entry middleware.
+func AccessFromContext(ctx context.Context) (*models.EntryAccess, bool) {
+	access, ok := ctx.Value(entryAccessKey{}).(*models.EntryAccess)
+	return access, ok
+}
+
+// RoleFromContext returns the organization role stored by the Organization middleware.
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(orgRoleKey{}).(string)
+	return role, ok
+}
@@ -0,0 +1,217 @@
+package authz_test
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/utils"
+	"passvault/internal/http-server/middlewares/authz"
+	mocks "passvault/internal/http-server/middlewares/authz/mocks"
+	"passvault/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestEntryMiddleware(t *testing.T) {
+	viewer := &models.EntryAccess{EntryID: 1, OwnerID: 124, Read: true}
+	editor := &models.EntryAccess{EntryID: 1, OwnerID: 124, Read: true, Write: true, Delete: true}
+
+	cases := []struct {
+		name       string
+		method     string
+		entryID    string
+		setup      func(m *mocks.MockEntryAccessor)
+		respStatus int
+	}{
+		{
+			name:    "Viewer reads",
+			method:  http.MethodGet,
+			entryID: "1",
+			setup: func(m *mocks.MockEntryAccessor) {
+				m.On("EntryAccess", mock.Anything, int64(123), int64(1)).Return(viewer, nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Viewer writes",
+			method:  http.MethodPut,
+			entryID: "1",
+			setup: func(m *mocks.MockEntryAccessor) {
+				m.On("EntryAccess", mock.Anything, int64(123), int64(1)).Return(viewer, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Viewer deletes",
+			method:  http.MethodDelete,
+			entryID: "1",
+			setup: func(m *mocks.MockEntryAccessor) {
+				m.On("EntryAccess", mock.Anything, int64(123), int64(1)).Return(viewer, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Editor deletes",
+			method:  http.MethodDelete,
+			entryID: "1",
+			setup: func(m *mocks.MockEntryAccessor) {
+				m.On("EntryAccess", mock.Anything, int64(123), int64(1)).Return(editor, nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:    "Unknown entry is left to the handler",
+			method:  http.MethodGet,
+			entryID: "2",
+			setup: func(m *mocks.MockEntryAccessor) {
+				m.On("EntryAccess", mock.Anything, int64(123), int64(2)).
+					Return((*models.EntryAccess)(nil), fmt.Errorf("storage.sqlite.EntryAccess: %w", storage.ErrEntryNotFound)).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Entry ID",
+			method:     http.MethodGet,
+			entryID:    "invalid",
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEntryAccessor := mocks.NewEntryAccessor(t)
+			if tc.setup != nil {
+				tc.setup(mockEntryAccessor)
+			}
+
+			router := chi.NewRouter()
+			router.Route("/{entryID}", func(r chi.Router) {
+				r.Use(authz.Entry(slog.New(
+					slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+				), mockEntryAccessor, 5*time.Second))
+				r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				})
+			})
+
+			req := httptest.NewRequest(tc.method, fmt.Sprintf("/%s", tc.entryID), nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
+
+func TestOrganizationMiddleware(t *testing.T) {
+	cases := []struct {
+		name       string
+		orgID      string
+		setup      func(m *mocks.MockMemberRoleGetter)
+		respStatus int
+	}{
+		{
+			name:  "Admin",
+			orgID: "1",
+			setup: func(m *mocks.MockMemberRoleGetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleAdmin, nil).Once()
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:  "Role below the required one",
+			orgID: "1",
+			setup: func(m *mocks.MockMemberRoleGetter) {
+				m.On("MemberRole", mock.Anything, int64(1), int64(123)).Return(models.OrgRoleEditor, nil).Once()
+			},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:  "Not a member",
+			orgID: "2",
+			setup: func(m *mocks.MockMemberRoleGetter) {
+				m.On("MemberRole", mock.Anything, int64(2), int64(123)).
+					Return("", fmt.Errorf("storage.sqlite.MemberRole: %w", storage.ErrMemberNotFound)).Once()
+			},
+			respStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid Organization ID",
+			orgID:      "invalid",
+			respStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMemberRoleGetter := mocks.NewMemberRoleGetter(t)
+			if tc.setup != nil {
+				tc.setup(mockMemberRoleGetter)
+			}
+
+			router := chi.NewRouter()
+			router.Route("/{orgID}", func(r chi.Router) {
+				r.Use(authz.Organization(slog.New(
+					slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+				), mockMemberRoleGetter, 5*time.Second))
+				r.With(authz.RequireRole(models.OrgRoleAdmin)).Get("/", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", tc.orgID), nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(router, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	cases := []struct {
+		name       string
+		admins     []int64
+		respStatus int
+	}{
+		{
+			name:       "Admin",
+			admins:     []int64{1, 123},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "Not an admin",
+			admins:     []int64{1},
+			respStatus: http.StatusForbidden,
+		},
+		{
+			name:       "No admins configured",
+			respStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := authz.Admin(slog.New(
+				slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			), tc.admins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+
+			utils.TestMiddleware(handler, rr, req)
+
+			assert.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
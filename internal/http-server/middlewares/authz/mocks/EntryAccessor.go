@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"passvault/internal/domain/models"
+)
+
+type MockEntryAccessor struct {
+	mock.Mock
+}
+
+func (m *MockEntryAccessor) EntryAccess(ctx context.Context, accountID int64, entryID int64) (*models.EntryAccess, error) {
+	args := m.Called(ctx, accountID, entryID)
+	return args.Get(0).(*models.EntryAccess), args.Error(1)
+}
+
+type mockConstructorTestingTEntryAccessor interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewEntryAccessor(t mockConstructorTestingTEntryAccessor) *MockEntryAccessor {
+	mock := &MockEntryAccessor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMemberRoleGetter struct {
+	mock.Mock
+}
+
+func (m *MockMemberRoleGetter) MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error) {
+	args := m.Called(ctx, orgID, accountID)
+	return args.String(0), args.Error(1)
+}
+
+type mockConstructorTestingTMemberRoleGetter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+func NewMemberRoleGetter(t mockConstructorTestingTMemberRoleGetter) *MockMemberRoleGetter {
+	mock := &MockMemberRoleGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,223 @@
+// Package router wires the HTTP API: the middlewares and every handler on top of
+// the storage.
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
+	"net/http"
+	"os"
+	"passvault/internal/domain/models"
+	auditlist "passvault/internal/http-server/handlers/audit/list"
+	breachcheck "passvault/internal/http-server/handlers/breach/check"
+	"passvault/internal/http-server/handlers/client/register"
+	collectioncreate "passvault/internal/http-server/handlers/collection/create"
+	collectiondelete "passvault/internal/http-server/handlers/collection/delete"
+	collectionlist "passvault/internal/http-server/handlers/collection/list"
+	keydelete "passvault/internal/http-server/handlers/encryption-key/delete"
+	keyget "passvault/internal/http-server/handlers/encryption-key/get"
+	keyprelogin "passvault/internal/http-server/handlers/encryption-key/prelogin"
+	keyrecover "passvault/internal/http-server/handlers/encryption-key/recover"
+	keysave "passvault/internal/http-server/handlers/encryption-key/save"
+	keysplit "passvault/internal/http-server/handlers/encryption-key/split"
+	keyzeroknowledge "passvault/internal/http-server/handlers/encryption-key/zeroknowledge"
+	entrytypelist "passvault/internal/http-server/handlers/entry-type/list"
+	"passvault/internal/http-server/handlers/entry/bulkimport"
+	entrycollection "passvault/internal/http-server/handlers/entry/collection"
+	entrydelete "passvault/internal/http-server/handlers/entry/delete"
+	"passvault/internal/http-server/handlers/entry/get"
+	"passvault/internal/http-server/handlers/entry/list"
+	entrymove "passvault/internal/http-server/handlers/entry/move"
+	"passvault/internal/http-server/handlers/entry/save"
+	"passvault/internal/http-server/handlers/entry/search"
+	entrytag "passvault/internal/http-server/handlers/entry/tag"
+	entrytotp "passvault/internal/http-server/handlers/entry/totp"
+	"passvault/internal/http-server/handlers/entry/update"
+	foldercreate "passvault/internal/http-server/handlers/folder/create"
+	folderdelete "passvault/internal/http-server/handlers/folder/delete"
+	folderlist "passvault/internal/http-server/handlers/folder/list"
+	foldermove "passvault/internal/http-server/handlers/folder/move"
+	folderrename "passvault/internal/http-server/handlers/folder/rename"
+	"passvault/internal/http-server/handlers/generator/generate"
+	rotationstart "passvault/internal/http-server/handlers/key-rotation/start"
+	rotationstatus "passvault/internal/http-server/handlers/key-rotation/status"
+	masterrotationstart "passvault/internal/http-server/handlers/master-key-rotation/start"
+	masterrotationstatus "passvault/internal/http-server/handlers/master-key-rotation/status"
+	memberlist "passvault/internal/http-server/handlers/member/list"
+	memberremove "passvault/internal/http-server/handlers/member/remove"
+	memberset "passvault/internal/http-server/handlers/member/set"
+	orgrotationstart "passvault/internal/http-server/handlers/organization-key-rotation/start"
+	orgrotationstatus "passvault/internal/http-server/handlers/organization-key-rotation/status"
+	orgcreate "passvault/internal/http-server/handlers/organization/create"
+	orgdelete "passvault/internal/http-server/handlers/organization/delete"
+	orglist "passvault/internal/http-server/handlers/organization/list"
+	reporthealth "passvault/internal/http-server/handlers/report/health"
+	revisionget "passvault/internal/http-server/handlers/revision/get"
+	revisionlist "passvault/internal/http-server/handlers/revision/list"
+	revisionrestore "passvault/internal/http-server/handlers/revision/restore"
+	sharecreate "passvault/internal/http-server/handlers/share/create"
+	sharelist "passvault/internal/http-server/handlers/share/list"
+	sharereceived "passvault/internal/http-server/handlers/share/received"
+	sharerevoke "passvault/internal/http-server/handlers/share/revoke"
+	tagcreate "passvault/internal/http-server/handlers/tag/create"
+	tagdelete "passvault/internal/http-server/handlers/tag/delete"
+	taglist "passvault/internal/http-server/handlers/tag/list"
+	tagrename "passvault/internal/http-server/handlers/tag/rename"
+	"passvault/internal/http-server/handlers/vault/export"
+	"passvault/internal/http-server/middlewares/audit"
+	authrest "passvault/internal/http-server/middlewares/auth"
+	"passvault/internal/http-server/middlewares/authz"
+	mwLogger "passvault/internal/http-server/middlewares/logger"
+	"passvault/internal/services/breach"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/health"
+	"passvault/internal/services/importer"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/services/keyshare"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"time"
+)
+
+// New returns the handler of the API. Key parts are served from db as is and saved with
+// the current master key version through vault, everything else goes through vault, the
+// encrypted view of db. Saved logins and ad-hoc checks are
+// looked up in breaches. Tokens are checked with secret and clients registers OAuth
+// clients with the SSO service. The admin API, open to the admins accounts only, starts
+// key rotations of accounts, organizations and the master key in rotations.
+func New(log *slog.Logger, secret string, timeout time.Duration, db storage.Backend, vault *encrypted.Storage, breaches *breach.Service, rotations *keyrotation.Service, healthReporter *health.Service, admins []int64, clients register.ClientRegisterer) http.Handler {
+	router := chi.NewRouter()
+
+	authMiddleware := authrest.New(slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	), secret)
+
+	router.Use(authMiddleware)
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Logger)
+	router.Use(mwLogger.New(log))
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.URLFormat)
+
+	keyShares := keyshare.New(log, db)
+	entryImporter := importer.New(log, vault)
+	vaultExporter := exporter.New(log, vault)
+
+	// record writes every entry, share and key request to the audit log. Key, export and
+	// share requests go through recordStrict, which withholds the response until the
+	// event is written.
+	record := func(action string) func(http.Handler) http.Handler {
+		return audit.New(log, vault, action, timeout)
+	}
+	recordStrict := func(action string) func(http.Handler) http.Handler {
+		return audit.Strict(log, vault, action, timeout)
+	}
+
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Route("/entries", func(r chi.Router) {
+			r.With(record(models.AuditEntryCreate)).Post("/", save.New(log, vault, breaches, timeout))
+			r.With(record(models.AuditEntryList)).Get("/", list.New(log, vault, timeout))
+			r.With(record(models.AuditEntrySearch)).Get("/search", search.New(log, vault, timeout))
+			r.With(record(models.AuditEntryImport)).Post("/import", bulkimport.New(log, entryImporter, timeout))
+
+			r.Route("/{entryID}", func(r chi.Router) {
+				// The access check runs inside record, so denied requests are audited too.
+				entry := authz.Entry(log, vault, timeout)
+
+				r.With(record(models.AuditEntryRead), entry).Get("/", get.New(log, vault, timeout))
+				r.With(record(models.AuditEntryUpdate), entry).Put("/", update.New(log, vault, breaches, timeout))
+				r.With(record(models.AuditEntryUpdate), entry).Patch("/", update.New(log, vault, breaches, timeout))
+				r.With(record(models.AuditEntryDelete), entry).Delete("/", entrydelete.New(log, vault, timeout))
+				r.With(record(models.AuditEntryMove), entry).Put("/folder", entrymove.New(log, vault, timeout))
+				r.With(record(models.AuditEntryTag), entry).Put("/tags", entrytag.New(log, vault, timeout))
+				r.With(record(models.AuditEntryCollection), entry).Put("/collection", entrycollection.New(log, vault, timeout))
+				r.With(record(models.AuditEntryTOTP), entry).Get("/totp", entrytotp.New(log, vault, timeout))
+
+				r.With(record(models.AuditRevisionList), entry).Get("/revisions", revisionlist.New(log, vault, timeout))
+				r.With(record(models.AuditRevisionRead), entry).Get("/revisions/{revisionID}", revisionget.New(log, vault, timeout))
+				r.With(record(models.AuditRevisionRestore), entry).Post("/revisions/{revisionID}/restore", revisionrestore.New(log, vault, timeout))
+
+				r.With(recordStrict(models.AuditShareCreate), entry).Post("/shares", sharecreate.New(log, vault, timeout))
+				r.With(recordStrict(models.AuditShareList), entry).Get("/shares", sharelist.New(log, vault, timeout))
+				r.With(recordStrict(models.AuditShareRevoke), entry).Delete("/shares/{accountID}", sharerevoke.New(log, vault, timeout))
+			})
+		})
+
+		r.Get("/entry-types", entrytypelist.New(log))
+
+		r.Post("/generate", generate.New(log))
+
+		r.Post("/breach-check", breachcheck.New(log, breaches))
+
+		r.With(record(models.AuditReportHealth)).Get("/reports/health", reporthealth.New(log, healthReporter, timeout))
+
+		r.Get("/audit", auditlist.New(log, vault, timeout))
+
+		// Plain text exports are recorded as export.plain by the handler.
+		r.With(recordStrict(models.AuditExportEncrypted)).Get("/export", export.New(log, vaultExporter, timeout))
+
+		r.Route("/folders", func(r chi.Router) {
+			r.Post("/", foldercreate.New(log, vault, timeout))
+			r.Get("/", folderlist.New(log, vault, timeout))
+			r.Patch("/{folderID}", folderrename.New(log, vault, timeout))
+			r.Post("/{folderID}/move", foldermove.New(log, vault, timeout))
+			r.Delete("/{folderID}", folderdelete.New(log, vault, timeout))
+		})
+
+		r.Route("/organizations", func(r chi.Router) {
+			r.Post("/", orgcreate.New(log, vault, timeout))
+			r.Get("/", orglist.New(log, vault, timeout))
+
+			r.Route("/{orgID}", func(r chi.Router) {
+				r.Use(authz.Organization(log, vault, timeout))
+
+				r.With(authz.RequireRole(models.OrgRoleOwner)).Delete("/", orgdelete.New(log, vault, timeout))
+
+				r.Get("/members", memberlist.New(log, vault, timeout))
+				r.With(authz.RequireRole(models.OrgRoleAdmin)).Put("/members/{accountID}", memberset.New(log, vault, timeout))
+				r.Delete("/members/{accountID}", memberremove.New(log, vault, timeout))
+
+				r.Get("/collections", collectionlist.New(log, vault, timeout))
+				r.With(authz.RequireRole(models.OrgRoleAdmin)).Post("/collections", collectioncreate.New(log, vault, timeout))
+				r.With(authz.RequireRole(models.OrgRoleAdmin)).Delete("/collections/{collectionID}", collectiondelete.New(log, vault, timeout))
+			})
+		})
+
+		r.With(recordStrict(models.AuditShareReceived)).Get("/shares", sharereceived.New(log, vault, timeout))
+
+		r.Route("/tags", func(r chi.Router) {
+			r.Post("/", tagcreate.New(log, vault, timeout))
+			r.Get("/", taglist.New(log, vault, timeout))
+			r.Patch("/{tagID}", tagrename.New(log, vault, timeout))
+			r.Delete("/{tagID}", tagdelete.New(log, vault, timeout))
+		})
+
+		r.Route("/keys", func(r chi.Router) {
+			r.With(recordStrict(models.AuditKeySave)).Post("/", keysave.New(log, vault, timeout))
+			r.With(recordStrict(models.AuditKeyRead)).Get("/", keyget.New(log, db, timeout))
+			r.With(recordStrict(models.AuditKeyDelete)).Delete("/", keydelete.New(log, db, timeout))
+			r.With(recordStrict(models.AuditKeySplit)).Post("/split", keysplit.New(log, keyShares, timeout))
+			r.With(recordStrict(models.AuditKeyRecover)).Post("/recover", keyrecover.New(log, keyShares, timeout))
+			// Prelogin only reads the public KDF parameters, clients call it on every unlock.
+			r.Get("/prelogin", keyprelogin.New(log, db, timeout))
+			r.With(recordStrict(models.AuditKeyZKEnable)).Post("/zero-knowledge", keyzeroknowledge.New(log, db, timeout))
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			// Rotations started by non-admins are refused inside recordStrict and audited.
+			admin := authz.Admin(log, admins)
+
+			r.With(recordStrict(models.AuditKeyRotate), admin).Post("/accounts/{accountID}/key-rotation", rotationstart.New(log, rotations, timeout))
+			r.With(admin).Get("/accounts/{accountID}/key-rotation", rotationstatus.New(log, rotations, timeout))
+			r.With(recordStrict(models.AuditKeyRotate), admin).Post("/organizations/{orgID}/key-rotation", orgrotationstart.New(log, rotations, timeout))
+			r.With(admin).Get("/organizations/{orgID}/key-rotation", orgrotationstatus.New(log, rotations, timeout))
+			r.With(recordStrict(models.AuditKeyRotate), admin).Post("/master-key-rotation", masterrotationstart.New(log, rotations, timeout))
+			r.With(admin).Get("/master-key-rotation", masterrotationstatus.New(log, rotations, timeout))
+		})
+
+		r.Post("/register", register.New(log, clients, timeout))
+	})
+
+	return router
+}
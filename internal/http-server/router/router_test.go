@@ -0,0 +1,763 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/vault/export"
+	"passvault/internal/http-server/router"
+	breachindex "passvault/internal/lib/breach"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/lib/jwt"
+	"passvault/internal/services/breach"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/health"
+	"passvault/internal/services/importer"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/memory"
+	"passvault/pkg/vaultcrypto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "test_secret"
+
+// adminID is the account allowed to use the admin API of test servers.
+const adminID = 1
+
+type fakeRegisterer struct{}
+
+func (fakeRegisterer) RegisterClient(ctx context.Context, appName string, secret string, redirectUrl string) (int64, error) {
+	return 42, nil
+}
+
+// breachedPasswords are the passwords in the breach corpus of test servers.
+var breachedPasswords = []string{"password", "123456"}
+
+// breachIndex writes an index of breachedPasswords.
+func breachIndex(t *testing.T) *breachindex.Index {
+	hashes := make([][20]byte, 0, len(breachedPasswords))
+	for _, password := range breachedPasswords {
+		hashes = append(hashes, breachindex.Hash(password))
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+
+	var buf bytes.Buffer
+	w := breachindex.NewWriter(&buf)
+	for _, hash := range hashes {
+		require.NoError(t, w.Add(hash))
+	}
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "breach.idx")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	index, err := breachindex.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { index.Close() })
+	return index
+}
+
+// client calls the API of a test server as one account.
+type client struct {
+	t         *testing.T
+	url       string
+	accountID int64
+}
+
+// newServer starts the whole API on an empty in-memory vault.
+func newServer(t *testing.T) string {
+	retention := storage.RevisionRetention{MaxCount: 10}
+	db := memory.New(retention)
+	vault := encrypted.New(db, envelope.NewMasterKeys(bytes.Repeat([]byte{7}, 32)))
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	breaches := breach.New(log, breachIndex(t))
+
+	rotations := keyrotation.New(log, vault, 2)
+	ctx, stopRotations := context.WithCancel(context.Background())
+	go rotations.Run(ctx)
+
+	srv := httptest.NewServer(router.New(log, secret, time.Second, db, vault, breaches, rotations, health.New(log, vault, vault, retention), []int64{adminID}, fakeRegisterer{}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(stopRotations)
+	return srv.URL
+}
+
+func as(t *testing.T, url string, accountID int64) *client {
+	return &client{t: t, url: url, accountID: accountID}
+}
+
+// do sends body as JSON and decodes the response into out when it is set.
+// It returns the response status.
+func (c *client) do(method, path string, body any, out any) int {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(c.t, err)
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, c.url+"/api/v1"+path, reader)
+	require.NoError(c.t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if c.accountID != 0 {
+		req.Header.Set("Authorization", "Bearer "+token(c.t, c.accountID))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+
+	if out != nil {
+		require.NoError(c.t, json.NewDecoder(res.Body).Decode(out))
+	}
+	return res.StatusCode
+}
+
+// create posts body and returns the ID of the created resource.
+func (c *client) create(path string, body any) int64 {
+	c.t.Helper()
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	require.Equal(c.t, http.StatusCreated, c.do(http.MethodPost, path, body, &created))
+	require.NotZero(c.t, created.ID)
+	return created.ID
+}
+
+func token(t *testing.T, accountID int64) string {
+	claims := jwt.CustomClaims{
+		AccountID: accountID,
+		Email:     fmt.Sprintf("user%d@example.com", accountID),
+		AppID:     1,
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	signed, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthentication(t *testing.T) {
+	url := newServer(t)
+
+	require.Equal(t, http.StatusUnauthorized, as(t, url, 0).do(http.MethodGet, "/entries", nil, nil))
+
+	req, err := http.NewRequest(http.MethodGet, url+"/api/v1/entries", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	var types struct {
+		EntryTypes []json.RawMessage `json:"entry_types"`
+	}
+	require.Equal(t, http.StatusOK, as(t, url, 0).do(http.MethodGet, "/entry-types", nil, &types))
+	require.NotEmpty(t, types.EntryTypes)
+}
+
+func TestEntryLifecycle(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	id := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "mail"}`})
+
+	var entry models.Entry
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, &entry))
+	require.Equal(t, `{"title": "mail"}`, entry.EntryData)
+
+	update := map[string]any{"entry_data": `{"title": "webmail"}`}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPatch, fmt.Sprintf("/entries/%d", id), update, nil))
+
+	var page models.EntryPage
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries", nil, &page))
+	require.Len(t, page.Entries, 1)
+	require.Equal(t, `{"title": "webmail"}`, page.Entries[0].EntryData)
+
+	var revisions []models.EntryRevision
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d/revisions", id), nil, &revisions))
+	require.Len(t, revisions, 1)
+	require.Equal(t, models.RevisionActionUpdate, revisions[0].Action)
+
+	var revision models.EntryRevision
+	path := fmt.Sprintf("/entries/%d/revisions/%d", id, revisions[0].ID)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, path, nil, &revision))
+	require.Equal(t, `{"title": "mail"}`, revision.EntryData)
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodDelete, fmt.Sprintf("/entries/%d", id), nil, nil))
+	require.Equal(t, http.StatusNotFound, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, nil))
+
+	// Deleted entries come back from their revisions.
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, path+"/restore", nil, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, &entry))
+	require.Equal(t, `{"title": "mail"}`, entry.EntryData)
+
+	// Other accounts cannot tell the entry exists.
+	bob := as(t, alice.url, 2)
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, nil))
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d/revisions", id), nil, nil))
+}
+
+func TestFoldersTagsAndSearch(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	work := alice.create("/folders", map[string]any{"name": "Work"})
+	urgent := alice.create("/tags", map[string]any{"name": "urgent"})
+	github := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "GitHub"}`})
+	alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "Bank"}`})
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/entries/%d/folder", github), map[string]any{"folder_id": work}, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/entries/%d/tags", github), map[string]any{"tag_ids": []int64{urgent}}, nil))
+
+	var page models.EntryPage
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries?folder_id=%d", work), nil, &page))
+	require.Len(t, page.Entries, 1)
+	require.Equal(t, github, page.Entries[0].ID)
+	require.Equal(t, []int64{urgent}, page.Entries[0].TagIDs)
+
+	var found struct {
+		IDs []int64 `json:"ids"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries/search?q=github", nil, &found))
+	require.Equal(t, []int64{github}, found.IDs)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries/search?q=urg", nil, &found))
+	require.Equal(t, []int64{github}, found.IDs)
+
+	require.Equal(t, http.StatusOK, as(t, alice.url, 2).do(http.MethodGet, "/entries/search?q=github", nil, &found))
+	require.Empty(t, found.IDs)
+
+	// Entries of a deleted folder move up to the top level.
+	require.Equal(t, http.StatusOK, alice.do(http.MethodDelete, fmt.Sprintf("/folders/%d", work), nil, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries?folder_id=0", nil, &page))
+	require.Len(t, page.Entries, 2)
+}
+
+func TestSharing(t *testing.T) {
+	url := newServer(t)
+	alice, bob, carol := as(t, url, 1), as(t, url, 2), as(t, url, 3)
+
+	id := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "wifi"}`})
+	alice.create(fmt.Sprintf("/entries/%d/shares", id), map[string]any{"account_id": 2, "permission": models.SharePermissionRead})
+
+	var entry models.Entry
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, &entry))
+	require.Equal(t, `{"title": "wifi"}`, entry.EntryData)
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodPatch, fmt.Sprintf("/entries/%d", id), map[string]any{"entry_data": `{"title": "mine"}`}, nil))
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodDelete, fmt.Sprintf("/entries/%d", id), nil, nil))
+	require.Equal(t, http.StatusNotFound, carol.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, nil))
+
+	var received []models.EntryShare
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/shares", nil, &received))
+	require.Len(t, received, 1)
+	require.Equal(t, id, received[0].EntryID)
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodDelete, fmt.Sprintf("/entries/%d/shares/2", id), nil, nil))
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, nil))
+}
+
+func TestDeniedRequestsAreAudited(t *testing.T) {
+	url := newServer(t)
+	alice, bob := as(t, url, 1), as(t, url, 2)
+
+	id := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "wifi"}`})
+	alice.create(fmt.Sprintf("/entries/%d/shares", id), map[string]any{"account_id": 2, "permission": models.SharePermissionRead})
+
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodPut, fmt.Sprintf("/entries/%d", id),
+		map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "mine"}`}, nil))
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodPost, "/admin/accounts/1/key-rotation", nil, nil))
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/audit?action="+models.AuditEntryUpdate, nil, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, http.StatusForbidden, events[0].Status)
+	require.Equal(t, id, *events[0].EntryID)
+
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/audit?action="+models.AuditKeyRotate, nil, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, http.StatusForbidden, events[0].Status)
+}
+
+func TestTOTP(t *testing.T) {
+	url := newServer(t)
+	alice, bob := as(t, url, 1), as(t, url, 2)
+
+	require.Equal(t, http.StatusBadRequest, alice.do(http.MethodPost, "/entries",
+		map[string]any{"entry_type": models.EntryTypeTOTP, "entry_data": `{"title": "github", "secret": "123456"}`}, nil))
+
+	id := alice.create("/entries", map[string]any{
+		"entry_type": models.EntryTypeTOTP,
+		"entry_data": `{"title": "github", "secret": "otpauth://totp/GitHub:alice?secret=JBSWY3DPEHPK3PXP&digits=8"}`,
+	})
+
+	var code struct {
+		Code      string `json:"code"`
+		Remaining int    `json:"remaining"`
+		Period    int    `json:"period"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d/totp", id), nil, &code))
+	require.Len(t, code.Code, 8)
+	require.Equal(t, 30, code.Period)
+	require.NotZero(t, code.Remaining)
+
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d/totp", id), nil, nil))
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit?action="+models.AuditEntryTOTP, nil, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, id, *events[0].EntryID)
+}
+
+func TestOrganizations(t *testing.T) {
+	url := newServer(t)
+	alice, bob, carol := as(t, url, 1), as(t, url, 2), as(t, url, 3)
+
+	orgID := alice.create("/organizations", map[string]any{"name": "Acme"})
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/organizations/%d/members/2", orgID), map[string]any{"role": models.OrgRoleViewer}, nil))
+	collectionID := alice.create(fmt.Sprintf("/organizations/%d/collections", orgID), map[string]any{"name": "Shared"})
+
+	id := alice.create("/entries", map[string]any{
+		"entry_type":    models.EntryTypeLogin,
+		"entry_data":    `{"title": "router"}`,
+		"collection_id": collectionID,
+	})
+
+	var entry models.Entry
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, &entry))
+	require.Equal(t, `{"title": "router"}`, entry.EntryData)
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodPatch, fmt.Sprintf("/entries/%d", id), map[string]any{"entry_data": `{"title": "mine"}`}, nil))
+	require.Equal(t, http.StatusForbidden, bob.do(http.MethodPost, fmt.Sprintf("/organizations/%d/collections", orgID), map[string]any{"name": "Mine"}, nil))
+
+	var page models.EntryPage
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/entries?collection_id=%d", collectionID), nil, &page))
+	require.Len(t, page.Entries, 1)
+
+	require.Equal(t, http.StatusNotFound, carol.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, nil))
+	require.Equal(t, http.StatusNotFound, carol.do(http.MethodGet, fmt.Sprintf("/organizations/%d/members", orgID), nil, nil))
+
+	var members []models.OrganizationMember
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/organizations/%d/members", orgID), nil, &members))
+	require.Len(t, members, 2)
+
+	require.Equal(t, http.StatusConflict, alice.do(http.MethodDelete, fmt.Sprintf("/organizations/%d/collections/%d", orgID, collectionID), nil, nil))
+}
+
+func TestKeysAndAuditLog(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	require.Equal(t, http.StatusNotFound, alice.do(http.MethodGet, "/keys", nil, nil))
+
+	keyPart := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	alice.create("/keys", map[string]any{"key_part": keyPart})
+	require.Equal(t, http.StatusConflict, alice.do(http.MethodPost, "/keys", map[string]any{"key_part": keyPart}, nil))
+
+	var key struct {
+		KeyPart string `json:"key_part"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/keys", nil, &key))
+	require.Equal(t, keyPart, key.KeyPart)
+
+	id := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "vpn"}`})
+	require.Equal(t, http.StatusConflict, alice.do(http.MethodDelete, "/keys", nil, nil))
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit", nil, &events))
+	require.Len(t, events, 6)
+	require.Equal(t, models.AuditKeyDelete, events[0].Action)
+	require.Equal(t, http.StatusConflict, events[0].Status)
+	require.Equal(t, models.AuditEntryCreate, events[1].Action)
+	require.Equal(t, &id, events[1].EntryID)
+
+	require.Equal(t, http.StatusOK, as(t, alice.url, 2).do(http.MethodGet, "/audit", nil, &events))
+	require.Empty(t, events)
+}
+
+// upload posts fields and file as a multipart form and decodes the response into out.
+func (c *client) upload(path string, fields map[string]string, file string, out any) int {
+	c.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(c.t, form.WriteField(name, value))
+	}
+	part, err := form.CreateFormFile("file", "export")
+	require.NoError(c.t, err)
+	_, err = part.Write([]byte(file))
+	require.NoError(c.t, err)
+	require.NoError(c.t, form.Close())
+
+	req, err := http.NewRequest(http.MethodPost, c.url+"/api/v1"+path, &body)
+	require.NoError(c.t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token(c.t, c.accountID))
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+
+	require.NoError(c.t, json.NewDecoder(res.Body).Decode(out))
+	return res.StatusCode
+}
+
+func TestImport(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	existing := alice.create("/entries", map[string]any{
+		"entry_type": models.EntryTypeLogin,
+		"entry_data": `{"title": "example.com", "uris": ["https://example.com"], "username": "alice", "password": "s3cret"}`,
+	})
+
+	export := "name,url,username,password,note\n" +
+		"example.com,https://example.com,alice,s3cret,\n" +
+		"github,https://github.com,alice,hunter2,work\n" +
+		",,,,\n"
+
+	var imported struct {
+		Report importer.Report `json:"report"`
+	}
+	require.Equal(t, http.StatusOK, alice.upload("/entries/import", map[string]string{"format": "csv", "dry_run": "true"}, export, &imported))
+	require.True(t, imported.Report.DryRun)
+	require.Len(t, imported.Report.Created, 1)
+	require.Zero(t, imported.Report.Created[0].EntryID)
+
+	var found struct {
+		IDs []int64 `json:"ids"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries/search?q=github", nil, &found))
+	require.Empty(t, found.IDs)
+
+	require.Equal(t, http.StatusCreated, alice.upload("/entries/import", map[string]string{"format": "csv"}, export, &imported))
+	require.Len(t, imported.Report.Created, 1)
+	require.Len(t, imported.Report.Skipped, 1)
+	require.Len(t, imported.Report.Duplicates, 1)
+	require.Equal(t, existing, imported.Report.Duplicates[0].EntryID)
+
+	// Imported entries are encrypted and indexed like entries saved one by one.
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries/search?q=github", nil, &found))
+	require.Equal(t, []int64{imported.Report.Created[0].EntryID}, found.IDs)
+
+	var resp struct {
+		Error string `json:"error"`
+	}
+	require.Equal(t, http.StatusBadRequest, alice.upload("/entries/import", map[string]string{"format": "lastpass"}, export, &resp))
+	require.Equal(t, "unknown import format", resp.Error)
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit?action="+models.AuditEntryImport, nil, &events))
+	require.Len(t, events, 3)
+}
+
+// download gets path with the headers and returns the status and the raw body.
+func (c *client) download(path string, headers map[string]string) (int, []byte) {
+	c.t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, c.url+"/api/v1"+path, nil)
+	require.NoError(c.t, err)
+	req.Header.Set("Authorization", "Bearer "+token(c.t, c.accountID))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(c.t, err)
+	return res.StatusCode, body
+}
+
+func TestExport(t *testing.T) {
+	url := newServer(t)
+	alice := as(t, url, 1)
+
+	alice.create("/entries", map[string]any{
+		"entry_type": models.EntryTypeLogin,
+		"entry_data": `{"title": "example.com", "uris": ["https://example.com"], "username": "alice", "password": "s3cret"}`,
+	})
+	as(t, url, 2).create("/entries", map[string]any{
+		"entry_type": models.EntryTypeSecureNote,
+		"entry_data": `{"title": "bob", "content": "not alice's"}`,
+	})
+
+	status, body := alice.download("/export", map[string]string{export.PassphraseHeader: "correct horse"})
+	require.Equal(t, http.StatusOK, status)
+
+	archive, err := exporter.Open(bytes.NewReader(body), "correct horse")
+	require.NoError(t, err)
+	require.Len(t, archive.Entries, 1)
+	require.JSONEq(t,
+		`{"title": "example.com", "uris": ["https://example.com"], "username": "alice", "password": "s3cret"}`,
+		string(archive.Entries[0].Data))
+
+	status, _ = alice.download("/export?format=bitwarden", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, body = alice.download("/export?format=bitwarden&confirm=true", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), `"password": "s3cret"`)
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit?action="+models.AuditExportEncrypted, nil, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit?action="+models.AuditExportPlain, nil, &events))
+	require.Len(t, events, 2)
+	require.Equal(t, http.StatusOK, events[0].Status)
+	require.Equal(t, http.StatusBadRequest, events[1].Status)
+}
+
+func TestGenerate(t *testing.T) {
+	url := newServer(t)
+
+	var generated struct {
+		Value   string  `json:"value"`
+		Entropy float64 `json:"entropy"`
+	}
+	body := map[string]any{"type": "password", "length": 32, "symbols": false}
+	require.Equal(t, http.StatusOK, as(t, url, 1).do(http.MethodPost, "/generate", body, &generated))
+	require.Len(t, generated.Value, 32)
+	require.Greater(t, generated.Entropy, 128.0)
+
+	require.Equal(t, http.StatusUnauthorized, as(t, url, 0).do(http.MethodPost, "/generate", body, nil))
+}
+
+func TestHealthReport(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	first := alice.create("/entries", map[string]any{
+		"entry_type": models.EntryTypeLogin,
+		"entry_data": `{"title": "mail", "password": "password1"}`,
+	})
+	second := alice.create("/entries", map[string]any{
+		"entry_type": models.EntryTypeLogin,
+		"entry_data": `{"title": "shop", "password": "password1"}`,
+	})
+
+	var res struct {
+		Report health.Report `json:"report"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/reports/health?max_age_days=30", nil, &res))
+	require.Equal(t, 2, res.Report.Logins)
+	require.Len(t, res.Report.Weak, 2)
+	require.Equal(t, [][]int64{{first, second}}, res.Report.Reused)
+	require.Equal(t, []int64{first, second}, res.Report.WithoutTOTP)
+	require.Zero(t, res.Report.Score)
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit?action="+models.AuditReportHealth, nil, &events))
+	require.Len(t, events, 1)
+}
+
+func TestRegister(t *testing.T) {
+	client := as(t, newServer(t), 1)
+
+	var registered struct {
+		AppID int64 `json:"app_id"`
+	}
+	body := map[string]any{"app_name": "cli", "secret": "s3cret", "redirect_url": "https://example.com/callback"}
+	require.Equal(t, http.StatusCreated, client.do(http.MethodPost, "/register", body, &registered))
+	require.Equal(t, int64(42), registered.AppID)
+}
+
+func TestBreachCheck(t *testing.T) {
+	alice := as(t, newServer(t), 1)
+
+	var checked struct {
+		Breached bool `json:"breached"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/breach-check", map[string]any{"password": "123456"}, &checked))
+	require.True(t, checked.Breached)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/breach-check", map[string]any{"password": "v3ry-uncommon-s3cret"}, &checked))
+	require.False(t, checked.Breached)
+
+	var saved struct {
+		ID       int64 `json:"id"`
+		Breached bool  `json:"breached"`
+	}
+	body := map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "mail", "password": "password"}`}
+	require.Equal(t, http.StatusCreated, alice.do(http.MethodPost, "/entries", body, &saved))
+	require.True(t, saved.Breached)
+
+	var updated struct {
+		Breached bool `json:"breached"`
+	}
+	body = map[string]any{"entry_data": `{"title": "mail", "password": "v3ry-uncommon-s3cret"}`}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPatch, fmt.Sprintf("/entries/%d", saved.ID), body, &updated))
+	require.False(t, updated.Breached)
+
+	require.Equal(t, http.StatusUnauthorized, as(t, alice.url, 0).do(http.MethodPost, "/breach-check", map[string]any{"password": "123456"}, nil))
+}
+
+func TestZeroKnowledge(t *testing.T) {
+	url := newServer(t)
+	alice, bob := as(t, url, 1), as(t, url, 2)
+
+	require.Equal(t, http.StatusNotFound, alice.do(http.MethodGet, "/keys/prelogin", nil, nil))
+
+	// The client picks the KDF parameters, cheap ones keep the test fast.
+	params, err := vaultcrypto.NewKDFParams()
+	require.NoError(t, err)
+	params.Iterations, params.Memory, params.Parallelism = vaultcrypto.MinIterations, vaultcrypto.MinMemory, 1
+	key, err := vaultcrypto.DeriveKey("correct horse battery staple", params)
+	require.NoError(t, err)
+	check, err := key.KeyCheck()
+	require.NoError(t, err)
+
+	weak := params
+	weak.Iterations = 1
+	enable := map[string]any{"kdf": weak, "key_check": check, "protocol_version": vaultcrypto.ProtocolVersion}
+	require.Equal(t, http.StatusBadRequest, alice.do(http.MethodPost, "/keys/zero-knowledge", enable, nil))
+	enable["kdf"] = params
+
+	// The server has seen the plaintext of entries saved before, even deleted ones
+	// live on in their revisions.
+	legacy := bob.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": `{"title": "old"}`})
+	require.Equal(t, http.StatusConflict, bob.do(http.MethodPost, "/keys/zero-knowledge", enable, nil))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodDelete, fmt.Sprintf("/entries/%d", legacy), nil, nil))
+	require.Equal(t, http.StatusConflict, bob.do(http.MethodPost, "/keys/zero-knowledge", enable, nil))
+
+	require.Equal(t, http.StatusCreated, alice.do(http.MethodPost, "/keys/zero-knowledge", enable, nil))
+	require.Equal(t, http.StatusConflict, alice.do(http.MethodPost, "/keys/zero-knowledge", enable, nil))
+
+	// Another client derives the same key from the prelogin response.
+	var prelogin struct {
+		KDF             vaultcrypto.KDFParams `json:"kdf"`
+		KeyCheck        string                `json:"key_check"`
+		ProtocolVersion int                   `json:"protocol_version"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/keys/prelogin", nil, &prelogin))
+	require.Equal(t, params, prelogin.KDF)
+	require.Equal(t, vaultcrypto.ProtocolVersion, prelogin.ProtocolVersion)
+	derived, err := vaultcrypto.DeriveKey("correct horse battery staple", prelogin.KDF)
+	require.NoError(t, err)
+	require.NoError(t, derived.VerifyKeyCheck(prelogin.KeyCheck))
+	wrong, err := vaultcrypto.DeriveKey("battery staple", prelogin.KDF)
+	require.NoError(t, err)
+	require.ErrorIs(t, wrong.VerifyKeyCheck(prelogin.KeyCheck), vaultcrypto.ErrWrongPassword)
+
+	plaintext := `{"title": "mail", "password": "s3cret"}`
+	require.Equal(t, http.StatusUnprocessableEntity, alice.do(http.MethodPost, "/entries",
+		map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": plaintext}, nil))
+
+	sealed, err := derived.SealEntry(plaintext)
+	require.NoError(t, err)
+	id := alice.create("/entries", map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": sealed})
+
+	var entry models.Entry
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d", id), nil, &entry))
+	require.Equal(t, sealed, entry.EntryData)
+	require.Equal(t, vaultcrypto.ProtocolVersion, entry.ProtocolVersion)
+	opened, err := key.OpenEntry(entry.EntryData)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+
+	require.Equal(t, http.StatusUnprocessableEntity, alice.do(http.MethodPatch, fmt.Sprintf("/entries/%d", id),
+		map[string]any{"entry_data": plaintext}, nil))
+
+	// Unlocking only reads the KDF parameters and leaves no trace in the audit log.
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/audit", nil, &events))
+	require.NotEmpty(t, events)
+	for _, event := range events {
+		if strings.HasPrefix(event.Action, "key.") {
+			require.Equal(t, models.AuditKeyZKEnable, event.Action)
+		}
+	}
+
+	// Accounts without zero-knowledge mode keep their entries readable by the server.
+	require.Equal(t, http.StatusUnprocessableEntity, bob.do(http.MethodPost, "/entries",
+		map[string]any{"entry_type": models.EntryTypeLogin, "entry_data": sealed}, nil))
+}
+
+func TestKeyRotation(t *testing.T) {
+	url := newServer(t)
+	admin, alice := as(t, url, adminID), as(t, url, 2)
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, alice.create("/entries", map[string]any{
+			"entry_type": models.EntryTypeLogin,
+			"entry_data": fmt.Sprintf(`{"title": "mail %d"}`, i),
+		}))
+	}
+
+	require.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, "/admin/accounts/2/key-rotation", nil, nil))
+	require.Equal(t, http.StatusNotFound, admin.do(http.MethodGet, "/admin/accounts/2/key-rotation", nil, nil))
+	require.Equal(t, http.StatusNotFound, admin.do(http.MethodPost, "/admin/accounts/3/key-rotation", nil, nil))
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/keys/split",
+		map[string]any{"holders": []string{"bob", "carol"}, "threshold": 2}, nil))
+
+	// The shares cannot restore the new key part, the response says they are gone.
+	var started struct {
+		Rotation models.KeyRotation `json:"rotation"`
+		Warning  string             `json:"warning"`
+	}
+	require.Equal(t, http.StatusAccepted, admin.do(http.MethodPost, "/admin/accounts/2/key-rotation", nil, &started))
+	require.Equal(t, 2, started.Rotation.ToVersion)
+	require.Equal(t, 2, started.Rotation.SharesRemoved)
+	require.Contains(t, started.Warning, "split the key part again")
+
+	var rotation models.KeyRotation
+	require.Eventually(t, func() bool {
+		admin.do(http.MethodGet, "/admin/accounts/2/key-rotation", nil, &rotation)
+		return rotation.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, started.Rotation.ID, rotation.ID)
+	require.Equal(t, len(ids), rotation.Entries)
+
+	var entry models.Entry
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/entries/%d", ids[0]), nil, &entry))
+	require.Equal(t, `{"title": "mail 0"}`, entry.EntryData)
+
+	var found struct {
+		IDs []int64 `json:"ids"`
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/entries/search?q=mail", nil, &found))
+	require.Equal(t, ids, found.IDs)
+
+	var events []models.AuditEvent
+	require.Equal(t, http.StatusOK, admin.do(http.MethodGet, "/audit?action="+models.AuditKeyRotate, nil, &events))
+	require.Len(t, events, 2)
+	require.Equal(t, http.StatusAccepted, events[0].Status)
+	require.Equal(t, http.StatusNotFound, events[1].Status)
+
+	orgID := alice.create("/organizations", map[string]any{"name": "Acme"})
+	path := fmt.Sprintf("/admin/organizations/%d/key-rotation", orgID)
+	require.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, path, nil, nil))
+	require.Equal(t, http.StatusNotFound, admin.do(http.MethodPost, "/admin/organizations/999/key-rotation", nil, nil))
+	require.Equal(t, http.StatusAccepted, admin.do(http.MethodPost, path, nil, &started))
+	require.Equal(t, orgID, started.Rotation.OrganizationID)
+	require.Eventually(t, func() bool {
+		admin.do(http.MethodGet, path, nil, &rotation)
+		return rotation.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, http.StatusForbidden, alice.do(http.MethodGet, "/admin/master-key-rotation", nil, nil))
+	var status models.MasterKeyStatus
+	require.Equal(t, http.StatusOK, admin.do(http.MethodGet, "/admin/master-key-rotation", nil, &status))
+	require.Equal(t, 1, status.MasterKeyVersion)
+	require.Empty(t, status.Accounts)
+	require.Empty(t, status.Organizations)
+}
@@ -0,0 +1,51 @@
+package argon2d
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"testing"
+)
+
+// Test vectors of golang.org/x/crypto/argon2, generated with the reference implementation.
+var testVectors = []struct {
+	time, memory uint32
+	threads      uint8
+	hash         string
+}{
+	{time: 1, memory: 64, threads: 1, hash: "8727405fd07c32c78d64f547f24150d3f2e703a89f981a19"},
+	{time: 2, memory: 64, threads: 1, hash: "3be9ec79a69b75d3752acb59a1fbb8b295a46529c48fbb75"},
+	{time: 2, memory: 64, threads: 2, hash: "68e2462c98b8bc6bb60ec68db418ae2c9ed24fc6748a40e9"},
+	{time: 3, memory: 256, threads: 2, hash: "f4f0669218eaf3641f39cc97efb915721102f4b128211ef2"},
+	{time: 4, memory: 4096, threads: 4, hash: "935598181aa8dc2b720914aa6435ac8d3e3a4210c5b0fb2d"},
+	{time: 4, memory: 1024, threads: 8, hash: "83604fc2ad0589b9d055578f4d3cc55bc616df3578a896e9"},
+	{time: 2, memory: 64, threads: 3, hash: "22474a423bda2ccd36ec9afd5119e5c8949798cadf659f51"},
+	{time: 3, memory: 1024, threads: 6, hash: "a3351b0319a53229152023d9206902f4ef59661cdca89481"},
+}
+
+func TestVectors(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	for i, v := range testVectors {
+		want, err := hex.DecodeString(v.hash)
+		require.NoError(t, err)
+		require.Equal(t, want, Key(password, salt, v.time, v.memory, v.threads, uint32(len(want))), "test %d", i)
+	}
+}
+
+func TestRFCVector(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+	want, _ := hex.DecodeString("512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb")
+
+	require.Equal(t, want, deriveKey(argon2d, password, salt, secret, data, 3, 32, 4, 32))
+}
+
+// The copy must still agree with the exported variants of the original package.
+func TestMatchesUpstream(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	require.Equal(t, argon2.Key(password, salt, 2, 256, 2, 32), deriveKey(argon2i, password, salt, nil, nil, 2, 256, 2, 32))
+	require.Equal(t, argon2.IDKey(password, salt, 2, 256, 2, 32), deriveKey(argon2id, password, salt, nil, nil, 2, 256, 2, 32))
+}
@@ -0,0 +1,95 @@
+package auditchain_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+	"testing"
+	"time"
+)
+
+var key = []byte("0123456789abcdef0123456789abcdef")
+
+func chain(n int) []models.AuditEvent {
+	events := make([]models.AuditEvent, n)
+	prev := ""
+	for i := range events {
+		entryID := int64(10 + i)
+		events[i] = models.AuditEvent{
+			ID:        int64(i + 1),
+			CreatedAt: time.Date(2024, 11, 6, 12, 0, i, 0, time.UTC),
+			AccountID: 123,
+			Action:    models.AuditEntryRead,
+			EntryID:   &entryID,
+			RequestID: "req",
+			IP:        "127.0.0.1",
+			UserAgent: "test",
+			Status:    200,
+			PrevHash:  prev,
+		}
+		events[i].Hash = auditchain.Hash(key, prev, events[i].ID, events[i])
+		prev = events[i].Hash
+	}
+	return events
+}
+
+func verify(events []models.AuditEvent) error {
+	v := auditchain.NewVerifier(key)
+	for _, event := range events {
+		if err := v.Next(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	events := chain(3)
+
+	v := auditchain.NewVerifier(key)
+	for _, event := range events {
+		require.NoError(t, v.Next(event))
+	}
+	require.Equal(t, 3, v.Count())
+	require.Equal(t, events[2].Hash, v.Head())
+
+	modified := chain(3)
+	modified[1].Action = models.AuditEntryDelete
+	require.ErrorIs(t, verify(modified), auditchain.ErrBrokenChain)
+
+	removed := chain(3)
+	require.ErrorIs(t, verify([]models.AuditEvent{removed[0], removed[2]}), auditchain.ErrBrokenChain)
+
+	// Rewriting a row with a fresh hash still breaks the link of the next row.
+	rehashed := chain(3)
+	rehashed[1].Status = 403
+	rehashed[1].Hash = auditchain.Hash(key, rehashed[1].PrevHash, rehashed[1].ID, rehashed[1])
+	require.ErrorIs(t, verify(rehashed), auditchain.ErrBrokenChain)
+
+	// Without the key the whole chain cannot be rebuilt after a change.
+	forged := chain(3)
+	forged[1].Status = 403
+	other := []byte("fedcba9876543210fedcba9876543210")
+	prev := forged[0].Hash
+	for i := 1; i < len(forged); i++ {
+		forged[i].PrevHash = prev
+		forged[i].Hash = auditchain.Hash(other, prev, forged[i].ID, forged[i])
+		prev = forged[i].Hash
+	}
+	require.ErrorIs(t, verify(forged), auditchain.ErrBrokenChain)
+}
+
+func TestVerifierKeys(t *testing.T) {
+	// The chain goes on under a new key after a master key rotation.
+	events := chain(3)
+	next := []byte("fedcba9876543210fedcba9876543210")
+	events[2].Hash = auditchain.Hash(next, events[2].PrevHash, events[2].ID, events[2])
+
+	require.ErrorIs(t, verify(events), auditchain.ErrBrokenChain)
+
+	v := auditchain.NewVerifier(next, key)
+	for _, event := range events {
+		require.NoError(t, v.Next(event))
+	}
+	require.Equal(t, 3, v.Count())
+}
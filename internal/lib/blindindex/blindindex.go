@@ -0,0 +1,74 @@
+// Package blindindex turns searchable text into keyed HMAC tokens, so entries
+// can be looked up by words of their metadata without storing those words.
+//
+// Text is lower-cased and split into words on anything that is not a letter or
+// a digit. Every prefix of a word, from MinPrefix up to MaxPrefix runes, is
+// indexed, which makes prefix queries ("exa" finds "example.com") possible.
+// The index still leaks how often a token occurs within an account, but not
+// the words themselves to anyone without the account key.
+package blindindex
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	MinPrefix = 2
+	MaxPrefix = 32
+
+	// tokenSize truncates the HMAC output; 128 bits keep collisions negligible.
+	tokenSize = 16
+)
+
+// Words splits text into lower-cased words, dropping words shorter than MinPrefix
+// and cutting words longer than MaxPrefix.
+func Words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		runes := []rune(field)
+		if len(runes) < MinPrefix {
+			continue
+		}
+		if len(runes) > MaxPrefix {
+			runes = runes[:MaxPrefix]
+		}
+		words = append(words, string(runes))
+	}
+	return words
+}
+
+// Token returns the blind token of a single word.
+func Token(key []byte, word string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(word))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:tokenSize])
+}
+
+// IndexTokens returns the sorted, de-duplicated tokens of all word prefixes in values.
+func IndexTokens(key []byte, values ...string) []string {
+	seen := make(map[string]struct{})
+	for _, value := range values {
+		for _, word := range Words(value) {
+			runes := []rune(word)
+			for n := MinPrefix; n <= len(runes); n++ {
+				seen[Token(key, string(runes[:n]))] = struct{}{}
+			}
+		}
+	}
+
+	tokens := make([]string, 0, len(seen))
+	for token := range seen {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
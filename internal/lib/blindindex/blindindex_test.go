@@ -0,0 +1,36 @@
+package blindindex_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"passvault/internal/lib/blindindex"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	require.Equal(t,
+		[]string{"https", "mail", "example", "com", "daria"},
+		blindindex.Words("https://Mail.example.com/?u=Daria"),
+	)
+	require.Empty(t, blindindex.Words("a - b"))
+}
+
+func TestIndexTokens(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	tokens := blindindex.IndexTokens(key, "Mail", "mail.example.com")
+	require.Contains(t, tokens, blindindex.Token(key, "ma"))
+	require.Contains(t, tokens, blindindex.Token(key, "mail"))
+	require.Contains(t, tokens, blindindex.Token(key, "exam"))
+	require.NotContains(t, tokens, blindindex.Token(key, "m"))
+	require.NotContains(t, tokens, blindindex.Token(key, "ail"))
+
+	// "ma", "mai", "mail", "ex" ... "example", "co", "com"
+	require.Len(t, tokens, 3+6+2)
+
+	for _, token := range tokens {
+		require.NotContains(t, token, "mail")
+	}
+
+	other := blindindex.IndexTokens([]byte("another key of thirty-two bytes!"), "mail")
+	require.NotContains(t, tokens, other[0])
+}
@@ -0,0 +1,239 @@
+// Package breach looks SHA-1 password hashes up in a compact index of a breach
+// corpus such as Have I Been Pwned's Pwned Passwords, without network access.
+//
+// An index keeps the first 8 bytes of every hash, which is plenty to tell a few
+// billion hashes apart. Hashes are grouped in 2^20 buckets by their first 20 bits,
+// the 5 hex digit prefix of the k-anonymity range API. The file holds
+//
+//	magic     "PVBREACH" (8 bytes)
+//	keys      bytes 2 to 7 of every hash, sorted, 6 bytes each
+//	fanout    2^20 big endian uint32, the number of keys in the buckets up to each
+//	count     big endian uint64, the number of keys
+//
+// Only the fanout table is loaded; a lookup reads the keys of one bucket.
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	magic      = "PVBREACH"
+	buckets    = 1 << 20
+	keySize    = 6
+	fanoutSize = buckets * 4
+	// PrefixLength is the length of the hex prefixes of range files.
+	PrefixLength = 5
+)
+
+var (
+	ErrInvalidIndex = errors.New("invalid breach index")
+	ErrUnsorted     = errors.New("hashes are not sorted")
+	ErrInvalidHash  = errors.New("invalid SHA-1 hash")
+)
+
+// Hash returns the SHA-1 hash of a password, as the breach corpora store them.
+func Hash(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// ParseLine reads a line of a Pwned Passwords dump: a hex SHA-1 hash optionally
+// followed by ":count". In range files the lines hold the hash without the prefix
+// that names the file; pass that prefix, or "" for lines of whole hashes.
+func ParseLine(prefix, line string) ([sha1.Size]byte, error) {
+	var hash [sha1.Size]byte
+
+	line, _, _ = strings.Cut(strings.TrimSpace(line), ":")
+	raw, err := hex.DecodeString(prefix + line)
+	if err != nil || len(raw) != sha1.Size {
+		return hash, fmt.Errorf("%w: %q", ErrInvalidHash, prefix+line)
+	}
+	copy(hash[:], raw)
+	return hash, nil
+}
+
+func bucket(hash [sha1.Size]byte) int {
+	return int(hash[0])<<12 | int(hash[1])<<4 | int(hash[2])>>4
+}
+
+// Writer writes an index of hashes added in ascending order.
+type Writer struct {
+	w      *bufio.Writer
+	fanout []uint32
+	count  uint64
+	last   []byte
+	err    error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	bw := bufio.NewWriterSize(w, 1<<20)
+	_, err := bw.WriteString(magic)
+	return &Writer{w: bw, fanout: make([]uint32, buckets), err: err}
+}
+
+// Add adds a hash. Hashes must come in ascending order; hashes sharing their key
+// with the previous one are skipped.
+func (w *Writer) Add(hash [sha1.Size]byte) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	key := hash[2 : 2+keySize]
+	b := bucket(hash)
+	if w.last != nil {
+		switch cmp := bytes.Compare(hash[:8], w.last); {
+		case cmp == 0:
+			return nil
+		case cmp < 0:
+			w.err = fmt.Errorf("%w: %x after %x", ErrUnsorted, hash[:8], w.last)
+			return w.err
+		}
+	}
+	if w.count == 1<<32-1 {
+		w.err = fmt.Errorf("%w: too many hashes", ErrInvalidIndex)
+		return w.err
+	}
+
+	if _, err := w.w.Write(key); err != nil {
+		w.err = err
+		return err
+	}
+	w.fanout[b]++
+	w.count++
+	w.last = append(w.last[:0], hash[:8]...)
+	return nil
+}
+
+// Count returns the number of keys added.
+func (w *Writer) Count() uint64 {
+	return w.count
+}
+
+// Close writes the fanout table and flushes the index. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	table := make([]byte, fanoutSize+8)
+	var total uint32
+	for b, n := range w.fanout {
+		total += n
+		binary.BigEndian.PutUint32(table[b*4:], total)
+	}
+	binary.BigEndian.PutUint64(table[fanoutSize:], w.count)
+
+	if _, err := w.w.Write(table); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// Index is an open index file. It is safe for concurrent use.
+type Index struct {
+	file   *os.File
+	fanout []uint32
+	count  uint64
+}
+
+// Open opens the index at path.
+func Open(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := load(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return index, nil
+}
+
+func load(file *os.File) (*Index, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < int64(len(magic)+fanoutSize+8) {
+		return nil, fmt.Errorf("%w: file too short", ErrInvalidIndex)
+	}
+
+	head := make([]byte, len(magic))
+	if _, err := file.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if string(head) != magic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidIndex)
+	}
+
+	table := make([]byte, fanoutSize+8)
+	if _, err := file.ReadAt(table, size-int64(len(table))); err != nil {
+		return nil, err
+	}
+
+	index := &Index{file: file, fanout: make([]uint32, buckets), count: binary.BigEndian.Uint64(table[fanoutSize:])}
+	for b := range index.fanout {
+		index.fanout[b] = binary.BigEndian.Uint32(table[b*4:])
+		if b > 0 && index.fanout[b] < index.fanout[b-1] {
+			return nil, fmt.Errorf("%w: fanout not ascending", ErrInvalidIndex)
+		}
+	}
+	if uint64(index.fanout[buckets-1]) != index.count ||
+		int64(len(magic))+int64(index.count)*keySize+int64(len(table)) != size {
+		return nil, fmt.Errorf("%w: size does not match the count", ErrInvalidIndex)
+	}
+	return index, nil
+}
+
+// Count returns the number of hashes in the index.
+func (i *Index) Count() uint64 {
+	return i.count
+}
+
+// Contains reports whether the index holds hash.
+func (i *Index) Contains(hash [sha1.Size]byte) (bool, error) {
+	b := bucket(hash)
+	var start uint32
+	if b > 0 {
+		start = i.fanout[b-1]
+	}
+	end := i.fanout[b]
+	if start == end {
+		return false, nil
+	}
+
+	keys := make([]byte, int(end-start)*keySize)
+	if _, err := i.file.ReadAt(keys, int64(len(magic))+int64(start)*keySize); err != nil {
+		return false, err
+	}
+
+	key := hash[2 : 2+keySize]
+	n := int(end - start)
+	at := sort.Search(n, func(j int) bool {
+		return bytes.Compare(keys[j*keySize:(j+1)*keySize], key) >= 0
+	})
+	return at < n && bytes.Equal(keys[at*keySize:(at+1)*keySize], key), nil
+}
+
+// ContainsPassword reports whether the index holds the hash of password.
+func (i *Index) ContainsPassword(password string) (bool, error) {
+	return i.Contains(Hash(password))
+}
+
+func (i *Index) Close() error {
+	return i.file.Close()
+}
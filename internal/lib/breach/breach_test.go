@@ -0,0 +1,116 @@
+package breach_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"github.com/stretchr/testify/require"
+	"os"
+	"passvault/internal/lib/breach"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// build writes an index of the passwords and opens it.
+func build(t *testing.T, passwords ...string) *breach.Index {
+	hashes := make([][sha1.Size]byte, 0, len(passwords))
+	for _, password := range passwords {
+		hashes = append(hashes, breach.Hash(password))
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+
+	var buf bytes.Buffer
+	w := breach.NewWriter(&buf)
+	for _, hash := range hashes {
+		require.NoError(t, w.Add(hash))
+	}
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "breach.idx")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	index, err := breach.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { index.Close() })
+	return index
+}
+
+func TestIndex(t *testing.T) {
+	index := build(t, "password", "123456", "hunter2", "correct horse battery staple", "password")
+	require.Equal(t, uint64(4), index.Count())
+
+	for _, password := range []string{"password", "123456", "hunter2", "correct horse battery staple"} {
+		breached, err := index.ContainsPassword(password)
+		require.NoError(t, err)
+		require.True(t, breached, password)
+	}
+	for _, password := range []string{"Password", "hunter3", "", "a long unique passphrase nobody uses"} {
+		breached, err := index.ContainsPassword(password)
+		require.NoError(t, err)
+		require.False(t, breached, password)
+	}
+}
+
+func TestEmptyIndex(t *testing.T) {
+	index := build(t)
+	require.Zero(t, index.Count())
+
+	breached, err := index.ContainsPassword("password")
+	require.NoError(t, err)
+	require.False(t, breached)
+}
+
+func TestWriterRejectsUnsortedHashes(t *testing.T) {
+	w := breach.NewWriter(&bytes.Buffer{})
+	require.NoError(t, w.Add([sha1.Size]byte{2}))
+	require.ErrorIs(t, w.Add([sha1.Size]byte{1}), breach.ErrUnsorted)
+	require.ErrorIs(t, w.Close(), breach.ErrUnsorted)
+}
+
+func TestOpenRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte("PVBREACH"), 0o600))
+	_, err := breach.Open(short)
+	require.ErrorIs(t, err, breach.ErrInvalidIndex)
+
+	var buf bytes.Buffer
+	w := breach.NewWriter(&buf)
+	require.NoError(t, w.Add(breach.Hash("password")))
+	require.NoError(t, w.Close())
+
+	truncated := filepath.Join(dir, "truncated")
+	raw := append(buf.Bytes()[:8:8], buf.Bytes()[14:]...)
+	require.NoError(t, os.WriteFile(truncated, raw, 0o600))
+	_, err = breach.Open(truncated)
+	require.ErrorIs(t, err, breach.ErrInvalidIndex)
+
+	magic := filepath.Join(dir, "magic")
+	raw = append([]byte("NOTBREACH"[:8]), buf.Bytes()[8:]...)
+	require.NoError(t, os.WriteFile(magic, raw, 0o600))
+	_, err = breach.Open(magic)
+	require.ErrorIs(t, err, breach.ErrInvalidIndex)
+}
+
+func TestParseLine(t *testing.T) {
+	// SHA-1 of "password".
+	want := breach.Hash("password")
+
+	hash, err := breach.ParseLine("", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004\r\n")
+	require.NoError(t, err)
+	require.Equal(t, want, hash)
+
+	hash, err = breach.ParseLine("5BAA6", "1E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004")
+	require.NoError(t, err)
+	require.Equal(t, want, hash)
+
+	hash, err = breach.ParseLine("", "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8")
+	require.NoError(t, err)
+	require.Equal(t, want, hash)
+
+	for _, line := range []string{"", "5BAA6:1", "ZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:1"} {
+		_, err := breach.ParseLine("", line)
+		require.ErrorIs(t, err, breach.ErrInvalidHash, line)
+	}
+}
@@ -0,0 +1,282 @@
+// Package entryschema describes the fields every entry type carries in
+// entry_data and validates entry payloads against them.
+//
+// entry_data is a JSON object. Every type has a required "title" and an
+// optional "notes" field in addition to its own fields; unknown fields are
+// refused so typos do not silently drop data.
+package entryschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/totp"
+	"passvault/pkg/vaultcrypto"
+	"sort"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindString     Kind = "string"
+	KindStringList Kind = "string_list"
+	KindInteger    Kind = "integer"
+)
+
+var (
+	ErrUnknownType = errors.New("unknown entry type")
+	ErrInvalidData = errors.New("invalid entry data")
+)
+
+type Field struct {
+	Name     string `json:"name"`
+	Kind     Kind   `json:"kind"`
+	Required bool   `json:"required"`
+	// Secret fields hold credentials; clients should mask them.
+	Secret bool `json:"secret"`
+
+	check func(value any) error
+}
+
+type Schema struct {
+	Type   string  `json:"type"`
+	Fields []Field `json:"fields"`
+}
+
+var commonFields = []Field{
+	{Name: "title", Kind: KindString, Required: true},
+	{Name: "notes", Kind: KindString},
+}
+
+var schemas = map[string]Schema{}
+
+func init() {
+	register(models.EntryTypeLogin,
+		Field{Name: "username", Kind: KindString},
+		Field{Name: "password", Kind: KindString, Secret: true},
+		Field{Name: "uris", Kind: KindStringList},
+		Field{Name: "totp", Kind: KindString, Secret: true, check: checkTOTP},
+	)
+	register(models.EntryTypeSecureNote,
+		Field{Name: "content", Kind: KindString, Required: true, Secret: true},
+	)
+	register(models.EntryTypeCreditCard,
+		Field{Name: "cardholder_name", Kind: KindString},
+		Field{Name: "number", Kind: KindString, Required: true, Secret: true, check: checkCardNumber},
+		Field{Name: "exp_month", Kind: KindInteger, check: checkRange(1, 12)},
+		Field{Name: "exp_year", Kind: KindInteger, check: checkRange(1900, 9999)},
+		Field{Name: "cvv", Kind: KindString, Secret: true},
+		Field{Name: "brand", Kind: KindString},
+	)
+	register(models.EntryTypeSSHKey,
+		Field{Name: "private_key", Kind: KindString, Required: true, Secret: true, check: checkPEM},
+		Field{Name: "public_key", Kind: KindString},
+		Field{Name: "passphrase", Kind: KindString, Secret: true},
+		Field{Name: "fingerprint", Kind: KindString},
+	)
+	register(models.EntryTypeAPIToken,
+		Field{Name: "token", Kind: KindString, Required: true, Secret: true},
+		Field{Name: "service", Kind: KindString},
+		Field{Name: "expires_at", Kind: KindString},
+	)
+	register(models.EntryTypeIdentity,
+		Field{Name: "first_name", Kind: KindString},
+		Field{Name: "last_name", Kind: KindString},
+		Field{Name: "email", Kind: KindString},
+		Field{Name: "phone", Kind: KindString},
+		Field{Name: "address", Kind: KindString},
+		Field{Name: "company", Kind: KindString},
+		Field{Name: "passport_number", Kind: KindString, Secret: true},
+		Field{Name: "license_number", Kind: KindString, Secret: true},
+	)
+	// The secret is an otpauth:// URI or a base32 secret; the other fields override
+	// the parameters of the URI.
+	register(models.EntryTypeTOTP,
+		Field{Name: "secret", Kind: KindString, Required: true, Secret: true, check: checkTOTP},
+		Field{Name: "algorithm", Kind: KindString, check: checkOneOf(totp.AlgorithmSHA1, totp.AlgorithmSHA256, totp.AlgorithmSHA512)},
+		Field{Name: "digits", Kind: KindInteger, check: checkRange(totp.MinDigits, totp.MaxDigits)},
+		Field{Name: "period", Kind: KindInteger, check: checkRange(1, totp.MaxPeriod)},
+		Field{Name: "issuer", Kind: KindString},
+		Field{Name: "account", Kind: KindString},
+	)
+}
+
+func register(entryType string, fields ...Field) {
+	schemas[entryType] = Schema{
+		Type:   entryType,
+		Fields: append(append([]Field{}, commonFields...), fields...),
+	}
+}
+
+// Lookup returns the schema of an entry type.
+func Lookup(entryType string) (Schema, bool) {
+	schema, ok := schemas[entryType]
+	return schema, ok
+}
+
+// All returns the schemas of all entry types ordered by type.
+func All() []Schema {
+	all := make([]Schema, 0, len(schemas))
+	for _, schema := range schemas {
+		all = append(all, schema)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Type < all[j].Type })
+	return all
+}
+
+// Validate checks that entryData is a JSON object matching the schema of entryType.
+// Entry data encrypted by a zero-knowledge client is opaque, only the form of the
+// ciphertext is checked.
+func Validate(entryType, entryData string) error {
+	schema, ok := Lookup(entryType)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, entryType)
+	}
+
+	if vaultcrypto.IsCiphertext(entryData) {
+		if err := vaultcrypto.Check(entryData); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidData, err)
+		}
+		return nil
+	}
+
+	var data map[string]any
+	decoder := json.NewDecoder(strings.NewReader(entryData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil || data == nil {
+		return fmt.Errorf("%w: entry_data must be a JSON object", ErrInvalidData)
+	}
+
+	known := make(map[string]Field, len(schema.Fields))
+	for _, field := range schema.Fields {
+		known[field.Name] = field
+	}
+
+	var problems []string
+	for name := range data {
+		if _, ok := known[name]; !ok {
+			problems = append(problems, fmt.Sprintf("field %s is not allowed for %s entries", name, entryType))
+		}
+	}
+
+	for _, field := range schema.Fields {
+		value, present := data[field.Name]
+		if !present || value == nil || value == "" {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("field %s is a required field", field.Name))
+			}
+			continue
+		}
+
+		if err := checkKind(field.Kind, value); err != nil {
+			problems = append(problems, fmt.Sprintf("field %s %s", field.Name, err))
+			continue
+		}
+		if field.check != nil {
+			if err := field.check(value); err != nil {
+				problems = append(problems, fmt.Sprintf("field %s %s", field.Name, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrInvalidData, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+func checkKind(kind Kind, value any) error {
+	switch kind {
+	case KindString:
+		if _, ok := value.(string); !ok {
+			return errors.New("must be a string")
+		}
+	case KindStringList:
+		list, ok := value.([]any)
+		if !ok {
+			return errors.New("must be a list of strings")
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return errors.New("must be a list of strings")
+			}
+		}
+	case KindInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			return errors.New("must be an integer")
+		}
+		if _, err := number.Int64(); err != nil {
+			return errors.New("must be an integer")
+		}
+	}
+	return nil
+}
+
+func checkRange(min, max int64) func(value any) error {
+	return func(value any) error {
+		n, _ := value.(json.Number).Int64()
+		if n < min || n > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// checkCardNumber accepts 12 to 19 digits, optionally grouped with spaces or
+// dashes, that pass the Luhn checksum.
+func checkCardNumber(value any) error {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value.(string))
+	if len(digits) < 12 || len(digits) > 19 {
+		return errors.New("is not a valid card number")
+	}
+
+	sum := 0
+	for i := range digits {
+		c := digits[len(digits)-1-i]
+		if c < '0' || c > '9' {
+			return errors.New("is not a valid card number")
+		}
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if sum%10 != 0 {
+		return errors.New("is not a valid card number")
+	}
+	return nil
+}
+
+func checkOneOf(values ...string) func(value any) error {
+	return func(value any) error {
+		for _, v := range values {
+			if value.(string) == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+func checkTOTP(value any) error {
+	if _, err := totp.Parse(value.(string)); err != nil {
+		return errors.New("must be an otpauth://totp/ URI or a base32 secret")
+	}
+	return nil
+}
+
+func checkPEM(value any) error {
+	if !strings.Contains(value.(string), "-----BEGIN ") {
+		return errors.New("must be a PEM encoded private key")
+	}
+	return nil
+}
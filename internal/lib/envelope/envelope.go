@@ -0,0 +1,266 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size in bytes of master keys, key parts and derived data keys.
+const KeySize = 32
+
+// sealedPrefix marks values produced by Seal, so they can be told apart from
+// legacy plaintext rows written before encryption was introduced.
+const sealedPrefix = "v1:"
+
+// keyVersionPrefix starts the key version segment of values sealed under a key
+// version above 1, as in "v1:k2:<base64>". Base64 never contains ':'.
+const keyVersionPrefix = "k"
+
+var (
+	ErrInvalidMasterKey = errors.New("invalid master key")
+	ErrUnknownMasterKey = errors.New("unknown master key version")
+	ErrInvalidKeyPart   = errors.New("invalid key part")
+	ErrMalformed        = errors.New("malformed ciphertext")
+	ErrDecrypt          = errors.New("failed to decrypt")
+)
+
+// ParseMasterKey decodes a base64 encoded server master key.
+func ParseMasterKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMasterKey, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidMasterKey, KeySize, len(key))
+	}
+	return key, nil
+}
+
+// MasterKeys are the versions of the server master key. Key parts record the version
+// they are derived under: new ones use the current version, older versions are kept
+// until a master key rotation has moved every key part off them.
+type MasterKeys struct {
+	current int
+	keys    map[int][]byte
+}
+
+// NewMasterKeys returns master keys with key as the only and current version, 1.
+func NewMasterKeys(key []byte) *MasterKeys {
+	return &MasterKeys{current: 1, keys: map[int][]byte{1: key}}
+}
+
+// ParseMasterKeys decodes the base64 encoded current master key of version current
+// and the older versions still in use, keyed by version.
+func ParseMasterKeys(current int, key string, old map[int]string) (*MasterKeys, error) {
+	if current < 1 {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidMasterKey, current)
+	}
+
+	keys := make(map[int][]byte, len(old)+1)
+	for version, s := range old {
+		if version < 1 || version >= current {
+			return nil, fmt.Errorf("%w: old version %d is not below the current version %d", ErrInvalidMasterKey, version, current)
+		}
+		oldKey, err := ParseMasterKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("version %d: %w", version, err)
+		}
+		keys[version] = oldKey
+	}
+
+	currentKey, err := ParseMasterKey(key)
+	if err != nil {
+		return nil, err
+	}
+	keys[current] = currentKey
+
+	return &MasterKeys{current: current, keys: keys}, nil
+}
+
+// Current returns the current version and its key.
+func (m *MasterKeys) Current() (int, []byte) {
+	return m.current, m.keys[m.current]
+}
+
+// Key returns the master key of version.
+func (m *MasterKeys) Key(version int) ([]byte, error) {
+	key, ok := m.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMasterKey, version)
+	}
+	return key, nil
+}
+
+// Versions returns every version, newest first.
+func (m *MasterKeys) Versions() []int {
+	versions := make([]int, 0, len(m.keys))
+	for version := range m.keys {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	return versions
+}
+
+// NewKeyPart generates a random base64 encoded key part for an account.
+func NewKeyPart() (string, error) {
+	buf := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// DeriveDataKey derives the per-account data key from the server master key
+// and the key part stored for the account. Neither value alone is enough to
+// recover the data key.
+func DeriveDataKey(masterKey []byte, keyPart string, accountID int64) ([]byte, error) {
+	return deriveKey(masterKey, keyPart, "passvault entry data key "+strconv.FormatInt(accountID, 10))
+}
+
+// DeriveSearchKey derives the per-account key of the blind search index the
+// same way as DeriveDataKey, but independent of the data key.
+func DeriveSearchKey(masterKey []byte, keyPart string, accountID int64) ([]byte, error) {
+	return deriveKey(masterKey, keyPart, "passvault search index key "+strconv.FormatInt(accountID, 10))
+}
+
+// DeriveOrganizationKey derives the data key shared by the collections of an
+// organization from the master key and the organization key part.
+func DeriveOrganizationKey(masterKey []byte, keyPart string, orgID int64) ([]byte, error) {
+	return deriveKey(masterKey, keyPart, "passvault organization data key "+strconv.FormatInt(orgID, 10))
+}
+
+// DeriveAuditKey derives the key of the audit log hash chain from the master key
+// alone, as the audit log belongs to the server rather than to any account.
+func DeriveAuditKey(masterKey []byte) ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte("passvault audit chain key")), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func deriveKey(masterKey []byte, keyPart string, info string) ([]byte, error) {
+	part, err := base64.StdEncoding.DecodeString(keyPart)
+	if err != nil || len(part) == 0 {
+		return nil, ErrInvalidKeyPart
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, part, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with AES-256-GCM under key. The additional data is
+// authenticated but not stored, so the same value must be passed to Open.
+func Seal(key, plaintext, additionalData []byte) (string, error) {
+	return SealVersion(key, 1, plaintext, additionalData)
+}
+
+// SealVersion is like Seal and records keyVersion in the sealed value, so the
+// key it was sealed under can be looked up with KeyVersion after a key rotation.
+func SealVersion(key []byte, keyVersion int, plaintext, additionalData []byte) (string, error) {
+	if keyVersion < 1 {
+		return "", fmt.Errorf("invalid key version %d", keyVersion)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return VersionPrefix(keyVersion) + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// VersionPrefix returns the prefix of values sealed under keyVersion. Values of key
+// version 1 carry no key version segment, so every sealed value starts with its prefix.
+func VersionPrefix(keyVersion int) string {
+	if keyVersion == 1 {
+		return sealedPrefix
+	}
+	return sealedPrefix + keyVersionPrefix + strconv.Itoa(keyVersion) + ":"
+}
+
+// Open decrypts a value produced by Seal or SealVersion. key must be the key of
+// the version returned by KeyVersion.
+func Open(key []byte, sealed string, additionalData []byte) ([]byte, error) {
+	_, payload, err := split(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether s looks like a value produced by Seal.
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, sealedPrefix)
+}
+
+// KeyVersion returns the version of the key a sealed value was sealed under.
+// Values written by Seal have key version 1.
+func KeyVersion(sealed string) (int, error) {
+	version, _, err := split(sealed)
+	return version, err
+}
+
+// split returns the key version and the base64 payload of a sealed value.
+func split(sealed string) (int, string, error) {
+	if !IsSealed(sealed) {
+		return 0, "", ErrMalformed
+	}
+
+	rest := strings.TrimPrefix(sealed, sealedPrefix)
+	segment, payload, found := strings.Cut(rest, ":")
+	if !found {
+		return 1, rest, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(segment, keyVersionPrefix))
+	if !strings.HasPrefix(segment, keyVersionPrefix) || err != nil || version < 2 {
+		return 0, "", ErrMalformed
+	}
+	return version, payload, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,122 @@
+package envelope_test
+
+import (
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"passvault/internal/lib/envelope"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	keyPart, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+
+	masterKey := make([]byte, envelope.KeySize)
+	key, err := envelope.DeriveDataKey(masterKey, keyPart, 123)
+	require.NoError(t, err)
+
+	sealed, err := envelope.Seal(key, []byte("supersecretpassword"), []byte("123"))
+	require.NoError(t, err)
+	require.True(t, envelope.IsSealed(sealed))
+	require.NotContains(t, sealed, "supersecretpassword")
+
+	plaintext, err := envelope.Open(key, sealed, []byte("123"))
+	require.NoError(t, err)
+	require.Equal(t, "supersecretpassword", string(plaintext))
+
+	_, err = envelope.Open(key, sealed, []byte("124"))
+	require.ErrorIs(t, err, envelope.ErrDecrypt)
+
+	otherKey, err := envelope.DeriveDataKey(masterKey, keyPart, 124)
+	require.NoError(t, err)
+	_, err = envelope.Open(otherKey, sealed, []byte("123"))
+	require.ErrorIs(t, err, envelope.ErrDecrypt)
+
+	_, err = envelope.Open(key, "supersecretpassword", nil)
+	require.ErrorIs(t, err, envelope.ErrMalformed)
+}
+
+func TestSealVersion(t *testing.T) {
+	key := make([]byte, envelope.KeySize)
+
+	sealed, err := envelope.Seal(key, []byte("supersecretpassword"), nil)
+	require.NoError(t, err)
+	version, err := envelope.KeyVersion(sealed)
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	sealed, err = envelope.SealVersion(key, 3, []byte("supersecretpassword"), nil)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sealed, "v1:k3:"))
+	require.True(t, strings.HasPrefix(sealed, envelope.VersionPrefix(3)))
+	require.False(t, strings.HasPrefix(sealed, envelope.VersionPrefix(2)))
+	version, err = envelope.KeyVersion(sealed)
+	require.NoError(t, err)
+	require.Equal(t, 3, version)
+
+	plaintext, err := envelope.Open(key, sealed, nil)
+	require.NoError(t, err)
+	require.Equal(t, "supersecretpassword", string(plaintext))
+
+	_, err = envelope.SealVersion(key, 0, nil, nil)
+	require.Error(t, err)
+
+	for _, malformed := range []string{"supersecretpassword", "v1:k:AAAA", "v1:k1:AAAA", "v1:x2:AAAA"} {
+		_, err := envelope.KeyVersion(malformed)
+		require.ErrorIs(t, err, envelope.ErrMalformed, malformed)
+	}
+}
+
+func TestParseMasterKey(t *testing.T) {
+	_, err := envelope.ParseMasterKey(base64.StdEncoding.EncodeToString(make([]byte, envelope.KeySize)))
+	require.NoError(t, err)
+
+	_, err = envelope.ParseMasterKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.ErrorIs(t, err, envelope.ErrInvalidMasterKey)
+
+	_, err = envelope.ParseMasterKey(strings.Repeat("!", 44))
+	require.ErrorIs(t, err, envelope.ErrInvalidMasterKey)
+}
+
+func TestDeriveAuditKey(t *testing.T) {
+	masterKey := make([]byte, envelope.KeySize)
+	key, err := envelope.DeriveAuditKey(masterKey)
+	require.NoError(t, err)
+	require.Len(t, key, envelope.KeySize)
+
+	again, err := envelope.DeriveAuditKey(masterKey)
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	masterKey[0] = 1
+	other, err := envelope.DeriveAuditKey(masterKey)
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+}
+
+func TestParseMasterKeys(t *testing.T) {
+	first := base64.StdEncoding.EncodeToString(make([]byte, envelope.KeySize))
+	second := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", envelope.KeySize)))
+
+	keys, err := envelope.ParseMasterKeys(2, second, map[int]string{1: first})
+	require.NoError(t, err)
+
+	version, key := keys.Current()
+	require.Equal(t, 2, version)
+	require.Equal(t, []byte(strings.Repeat("k", envelope.KeySize)), key)
+	old, err := keys.Key(1)
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, envelope.KeySize), old)
+	require.Equal(t, []int{2, 1}, keys.Versions())
+
+	_, err = keys.Key(3)
+	require.ErrorIs(t, err, envelope.ErrUnknownMasterKey)
+
+	_, err = envelope.ParseMasterKeys(1, first, map[int]string{2: second})
+	require.ErrorIs(t, err, envelope.ErrInvalidMasterKey)
+	_, err = envelope.ParseMasterKeys(2, second, map[int]string{1: "short"})
+	require.ErrorIs(t, err, envelope.ErrInvalidMasterKey)
+	_, err = envelope.ParseMasterKeys(0, first, nil)
+	require.ErrorIs(t, err, envelope.ErrInvalidMasterKey)
+}
@@ -0,0 +1,308 @@
+// Package generator makes random passwords and passphrases with crypto/rand.
+//
+// Passwords are drawn from the enabled character classes, with a minimum count of
+// characters per class. Passphrases are words of the EFF large wordlist joined by a
+// separator. eff_large_wordlist.txt is the list as published by the Electronic
+// Frontier Foundation under CC BY 3.0 US, 7776 words each preceded by the five dice
+// rolls that pick it:
+//
+// https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt
+//
+// Both come with an estimate of their entropy in bits: the base 2 logarithm of the
+// number of values the options can produce.
+package generator
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinLength = 4
+	MaxLength = 128
+
+	MinWords     = 3
+	MaxWords     = 20
+	MaxSeparator = 8
+)
+
+// Character classes of passwords.
+const (
+	lowercase = "abcdefghijklmnopqrstuvwxyz"
+	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits    = "0123456789"
+	symbols   = "!@#$%^&*()-_=+[]{};:,.<>?/~"
+
+	// ambiguous characters look alike in many fonts.
+	ambiguous = "Il1O0"
+)
+
+// Capitalization of passphrase words.
+const (
+	CapitalizeNone   = "none"
+	CapitalizeFirst  = "first"
+	CapitalizeAll    = "all"
+	CapitalizeRandom = "random"
+)
+
+var ErrInvalidOptions = errors.New("invalid generator options")
+
+//go:embed eff_large_wordlist.txt
+var wordlistFile string
+
+// wordlistSize is the size of the EFF large wordlist, 6^5 words.
+const wordlistSize = 7776
+
+var wordlist = parseWordlist(wordlistFile)
+
+// Result is a generated secret and the estimate of its entropy in bits.
+type Result struct {
+	Value   string
+	Entropy float64
+}
+
+type PasswordOptions struct {
+	Length    int
+	Lowercase bool
+	Uppercase bool
+	Digits    bool
+	Symbols   bool
+	// Minimum counts of the classes; a minimum needs its class enabled.
+	MinLowercase int
+	MinUppercase int
+	MinDigits    int
+	MinSymbols   int
+	// ExcludeAmbiguous leaves out the characters of "Il1O0".
+	ExcludeAmbiguous bool
+}
+
+type PassphraseOptions struct {
+	Words      int
+	Separator  string
+	Capitalize string
+}
+
+type class struct {
+	name  string
+	chars string
+	min   int
+}
+
+// Password generates a password. The required characters of every class are drawn
+// first and the rest from all enabled classes, then the characters are shuffled.
+func Password(opts PasswordOptions) (Result, error) {
+	if opts.Length < MinLength || opts.Length > MaxLength {
+		return Result{}, fmt.Errorf("%w: length must be between %d and %d", ErrInvalidOptions, MinLength, MaxLength)
+	}
+
+	all := []struct {
+		class
+		enabled bool
+	}{
+		{class{"lowercase", lowercase, opts.MinLowercase}, opts.Lowercase},
+		{class{"uppercase", uppercase, opts.MinUppercase}, opts.Uppercase},
+		{class{"digits", digits, opts.MinDigits}, opts.Digits},
+		{class{"symbols", symbols, opts.MinSymbols}, opts.Symbols},
+	}
+
+	var classes []class
+	var pool string
+	required := 0
+	for _, c := range all {
+		if c.min < 0 {
+			return Result{}, fmt.Errorf("%w: minimum of %s must not be negative", ErrInvalidOptions, c.name)
+		}
+		if !c.enabled {
+			if c.min > 0 {
+				return Result{}, fmt.Errorf("%w: minimum of %s needs %s enabled", ErrInvalidOptions, c.name, c.name)
+			}
+			continue
+		}
+		if opts.ExcludeAmbiguous {
+			c.chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguous, r) {
+					return -1
+				}
+				return r
+			}, c.chars)
+		}
+		classes = append(classes, c.class)
+		pool += c.chars
+		required += c.min
+	}
+	if len(classes) == 0 {
+		return Result{}, fmt.Errorf("%w: no character class enabled", ErrInvalidOptions)
+	}
+	if required > opts.Length {
+		return Result{}, fmt.Errorf("%w: minimums add up to more than the length", ErrInvalidOptions)
+	}
+
+	password := make([]byte, 0, opts.Length)
+	for _, c := range classes {
+		for i := 0; i < c.min; i++ {
+			char, err := pick(c.chars)
+			if err != nil {
+				return Result{}, err
+			}
+			password = append(password, char)
+		}
+	}
+	for len(password) < opts.Length {
+		char, err := pick(pool)
+		if err != nil {
+			return Result{}, err
+		}
+		password = append(password, char)
+	}
+	if err := shuffle(password); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Value: string(password), Entropy: passwordEntropy(opts.Length, classes)}, nil
+}
+
+// Passphrase generates a passphrase of words from the wordlist.
+func Passphrase(opts PassphraseOptions) (Result, error) {
+	if opts.Words < MinWords || opts.Words > MaxWords {
+		return Result{}, fmt.Errorf("%w: words must be between %d and %d", ErrInvalidOptions, MinWords, MaxWords)
+	}
+	if utf8.RuneCountInString(opts.Separator) > MaxSeparator {
+		return Result{}, fmt.Errorf("%w: separator must be at most %d characters", ErrInvalidOptions, MaxSeparator)
+	}
+	if opts.Capitalize == "" {
+		opts.Capitalize = CapitalizeNone
+	}
+
+	entropy := float64(opts.Words) * math.Log2(float64(len(wordlist)))
+	switch opts.Capitalize {
+	case CapitalizeNone, CapitalizeFirst, CapitalizeAll:
+	case CapitalizeRandom:
+		// Every word is capitalized or not.
+		entropy += float64(opts.Words)
+	default:
+		return Result{}, fmt.Errorf("%w: capitalize must be one of %s, %s, %s, %s", ErrInvalidOptions,
+			CapitalizeNone, CapitalizeFirst, CapitalizeAll, CapitalizeRandom)
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		n, err := randomInt(len(wordlist))
+		if err != nil {
+			return Result{}, err
+		}
+		word := wordlist[n]
+
+		capitalize := opts.Capitalize == CapitalizeFirst
+		if opts.Capitalize == CapitalizeRandom {
+			coin, err := randomInt(2)
+			if err != nil {
+				return Result{}, err
+			}
+			capitalize = coin == 1
+		}
+		switch {
+		case opts.Capitalize == CapitalizeAll:
+			word = strings.ToUpper(word)
+		case capitalize:
+			first, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(first)) + word[size:]
+		}
+		words[i] = word
+	}
+
+	return Result{Value: strings.Join(words, opts.Separator), Entropy: entropy}, nil
+}
+
+// passwordEntropy counts the passwords of length that hold the minimum of every class:
+// the sum over the counts c_i of the classes, with c_i >= min_i, of the ways to place
+// them, length! / prod(c_i!), times prod(size_i^c_i).
+func passwordEntropy(length int, classes []class) float64 {
+	// ways[n] counts the strings of n characters of the classes seen so far, where
+	// the positions of the characters of each class are chosen among the n.
+	ways := make([]*big.Int, length+1)
+	for n := range ways {
+		ways[n] = new(big.Int)
+	}
+	ways[0].SetInt64(1)
+
+	for _, c := range classes {
+		size := big.NewInt(int64(len(c.chars)))
+		next := make([]*big.Int, length+1)
+		for n := range next {
+			next[n] = new(big.Int)
+			for count := c.min; count <= n; count++ {
+				if ways[n-count].Sign() == 0 {
+					continue
+				}
+				term := new(big.Int).Binomial(int64(n), int64(count))
+				term.Mul(term, new(big.Int).Exp(size, big.NewInt(int64(count)), nil))
+				term.Mul(term, ways[n-count])
+				next[n].Add(next[n], term)
+			}
+		}
+		ways = next
+	}
+
+	return log2(ways[length])
+}
+
+func log2(n *big.Int) float64 {
+	if n.Sign() == 0 {
+		return 0
+	}
+	mantissa := new(big.Float)
+	exponent := new(big.Float).SetInt(n).MantExp(mantissa)
+	m, _ := mantissa.Float64()
+	return math.Log2(m) + float64(exponent)
+}
+
+func parseWordlist(file string) []string {
+	var words []string
+	for _, line := range strings.Split(file, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		words = append(words, fields[len(fields)-1])
+	}
+	// Entropy estimates rely on the list, so a different one must not slip in unnoticed.
+	if len(words) != wordlistSize {
+		panic(fmt.Sprintf("generator: wordlist has %d words, want %d", len(words), wordlistSize))
+	}
+	return words
+}
+
+func pick(chars string) (byte, error) {
+	n, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[n], nil
+}
+
+// shuffle is a Fisher-Yates shuffle.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
@@ -0,0 +1,134 @@
+package generator_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"passvault/internal/lib/generator"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func count(s string, class func(rune) bool) int {
+	n := 0
+	for _, r := range s {
+		if class(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPassword(t *testing.T) {
+	opts := generator.PasswordOptions{
+		Length:       16,
+		Lowercase:    true,
+		Uppercase:    true,
+		Digits:       true,
+		Symbols:      true,
+		MinUppercase: 2,
+		MinDigits:    3,
+		MinSymbols:   1,
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		result, err := generator.Password(opts)
+		require.NoError(t, err)
+		require.Len(t, result.Value, 16)
+		require.GreaterOrEqual(t, count(result.Value, unicode.IsUpper), 2)
+		require.GreaterOrEqual(t, count(result.Value, unicode.IsDigit), 3)
+		require.GreaterOrEqual(t, count(result.Value, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }), 1)
+		seen[result.Value] = true
+	}
+	require.Len(t, seen, 200)
+}
+
+func TestPasswordClasses(t *testing.T) {
+	result, err := generator.Password(generator.PasswordOptions{Length: 64, Digits: true, ExcludeAmbiguous: true})
+	require.NoError(t, err)
+	require.Equal(t, 64, count(result.Value, unicode.IsDigit))
+	require.NotContains(t, result.Value, "0")
+	require.NotContains(t, result.Value, "1")
+	// 64 digits out of 8.
+	require.InDelta(t, 64*math.Log2(8), result.Entropy, 1e-9)
+
+	result, err = generator.Password(generator.PasswordOptions{Length: 20, Lowercase: true, Uppercase: true, ExcludeAmbiguous: true})
+	require.NoError(t, err)
+	require.False(t, strings.ContainsAny(result.Value, "IlO"))
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	// Without minimums every character is one of 26+26+10 = 62.
+	result, err := generator.Password(generator.PasswordOptions{Length: 12, Lowercase: true, Uppercase: true, Digits: true})
+	require.NoError(t, err)
+	require.InDelta(t, 12*math.Log2(62), result.Entropy, 1e-9)
+
+	// A minimum of the whole length leaves no room for the other classes.
+	result, err = generator.Password(generator.PasswordOptions{Length: 4, Lowercase: true, Digits: true, MinLowercase: 4})
+	require.NoError(t, err)
+	require.InDelta(t, 4*math.Log2(26), result.Entropy, 1e-9)
+
+	// At least one digit: all passwords but those of letters only.
+	result, err = generator.Password(generator.PasswordOptions{Length: 4, Lowercase: true, Digits: true, MinDigits: 1})
+	require.NoError(t, err)
+	require.InDelta(t, math.Log2(math.Pow(36, 4)-math.Pow(26, 4)), result.Entropy, 1e-9)
+}
+
+func TestPasswordErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts generator.PasswordOptions
+	}{
+		{"Too short", generator.PasswordOptions{Length: 3, Lowercase: true}},
+		{"Too long", generator.PasswordOptions{Length: 129, Lowercase: true}},
+		{"No class", generator.PasswordOptions{Length: 12}},
+		{"Minimum of disabled class", generator.PasswordOptions{Length: 12, Lowercase: true, MinDigits: 1}},
+		{"Negative minimum", generator.PasswordOptions{Length: 12, Lowercase: true, MinLowercase: -1}},
+		{"Minimums over length", generator.PasswordOptions{Length: 4, Lowercase: true, Digits: true, MinLowercase: 3, MinDigits: 2}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generator.Password(tc.opts)
+			require.ErrorIs(t, err, generator.ErrInvalidOptions)
+		})
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	// Some EFF words hold a hyphen, like "t-shirt".
+	result, err := generator.Passphrase(generator.PassphraseOptions{Words: 6, Separator: "_"})
+	require.NoError(t, err)
+	words := strings.Split(result.Value, "_")
+	require.Len(t, words, 6)
+	for _, word := range words {
+		require.Equal(t, strings.ToLower(word), word)
+	}
+	// Six words out of the 7776 of the EFF large wordlist.
+	require.InDelta(t, 6*math.Log2(7776), result.Entropy, 1e-9)
+
+	result, err = generator.Passphrase(generator.PassphraseOptions{Words: 4, Separator: " ", Capitalize: generator.CapitalizeFirst})
+	require.NoError(t, err)
+	for _, word := range strings.Split(result.Value, " ") {
+		require.True(t, unicode.IsUpper(rune(word[0])))
+	}
+
+	result, err = generator.Passphrase(generator.PassphraseOptions{Words: 4, Capitalize: generator.CapitalizeAll})
+	require.NoError(t, err)
+	require.Equal(t, strings.ToUpper(result.Value), result.Value)
+
+	result, err = generator.Passphrase(generator.PassphraseOptions{Words: 5, Separator: ".", Capitalize: generator.CapitalizeRandom})
+	require.NoError(t, err)
+	require.InDelta(t, 5*math.Log2(7776)+5, result.Entropy, 1e-9)
+
+	for _, opts := range []generator.PassphraseOptions{
+		{Words: 2},
+		{Words: 21},
+		{Words: 5, Separator: "too long sep"},
+		{Words: 5, Capitalize: "camel"},
+	} {
+		_, err := generator.Passphrase(opts)
+		require.ErrorIs(t, err, generator.ErrInvalidOptions)
+	}
+}
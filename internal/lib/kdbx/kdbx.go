@@ -0,0 +1,476 @@
+// Package kdbx decrypts KeePass databases in the KDBX 3.1 and 4 formats into the
+// KeePass XML document they hold.
+//
+// Only password protected databases are supported, key files and hardware keys are
+// not. Databases can use AES-256 or ChaCha20 and the AES-KDF, Argon2d or Argon2id key
+// derivation functions.
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"io"
+	"passvault/internal/lib/argon2d"
+)
+
+var (
+	ErrNotKDBX            = errors.New("not a KDBX database")
+	ErrUnsupported        = errors.New("unsupported KDBX database")
+	ErrInvalidCredentials = errors.New("invalid password or corrupt database")
+	ErrCorrupt            = errors.New("corrupt KDBX database")
+)
+
+// Limits on the cost of key derivation. The parameters come from the header of the
+// database, which is untrusted input when users upload it, so databases asking for
+// more are refused instead of tying up the memory or a CPU of the server. KeePass
+// defaults stay far below them.
+const (
+	maxArgon2Memory      = 1 << 30 // bytes
+	maxArgon2Iterations  = 100
+	maxArgon2Parallelism = 16
+	maxAESRounds         = 10_000_000
+
+	// aesRoundsPerCheck is how many AES-KDF rounds run between two checks of the context.
+	aesRoundsPerCheck = 1 << 16
+)
+
+const (
+	signature1 = 0x9AA2D903
+	signature2 = 0xB54BFB67
+)
+
+// Outer header field IDs.
+const (
+	headerEnd                 = 0
+	headerCipherID            = 2
+	headerCompressionFlags    = 3
+	headerMasterSeed          = 4
+	headerTransformSeed       = 5
+	headerTransformRounds     = 6
+	headerEncryptionIV        = 7
+	headerProtectedStreamKey  = 8
+	headerStreamStartBytes    = 9
+	headerInnerRandomStreamID = 10
+	headerKdfParameters       = 11
+)
+
+// Inner header field IDs of KDBX 4.
+const (
+	innerHeaderEnd       = 0
+	innerHeaderStreamID  = 1
+	innerHeaderStreamKey = 2
+)
+
+var (
+	cipherAES256   = []byte{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	cipherChaCha20 = []byte{0xd6, 0x03, 0x8a, 0x2b, 0x8b, 0x65, 0x4c, 0xb5, 0xa5, 0x24, 0x33, 0x9a, 0x31, 0xdb, 0xb5, 0x9a}
+
+	kdfAES      = []byte{0xc9, 0xd9, 0xf3, 0x9a, 0x62, 0x8a, 0x44, 0x60, 0xbf, 0x74, 0x0d, 0x08, 0xc1, 0x8a, 0x4f, 0xea}
+	kdfArgon2d  = []byte{0xef, 0x63, 0x6d, 0xdf, 0x8c, 0x29, 0x44, 0x4b, 0x91, 0xf7, 0xa9, 0xa4, 0x03, 0xe3, 0x0a, 0x0c}
+	kdfArgon2id = []byte{0x9e, 0x29, 0x8b, 0x19, 0x56, 0xdb, 0x47, 0x73, 0xb2, 0x3d, 0xfc, 0x3e, 0xc6, 0xf0, 0xa1, 0xe6}
+)
+
+// header holds the outer header fields needed to decrypt a database.
+type header struct {
+	major            uint16
+	raw              []byte
+	cipherID         []byte
+	compressed       bool
+	masterSeed       []byte
+	encryptionIV     []byte
+	transformSeed    []byte
+	transformRounds  uint64
+	streamKey        []byte
+	streamStartBytes []byte
+	streamID         uint32
+	kdf              map[string]any
+}
+
+// Decrypt opens a KDBX database with password and returns its XML document, with the
+// values KeePass keeps protected in memory turned into plain text. Databases whose key
+// derivation is more expensive than the limits of this package are ErrUnsupported.
+func Decrypt(ctx context.Context, data []byte, password string) ([]byte, error) {
+	h, body, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	composite := sha256.Sum256([]byte(password))
+	composite = sha256.Sum256(composite[:])
+
+	transformed, err := h.transformKey(ctx, composite[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	if h.major == 4 {
+		payload, err = h.decryptV4(body, transformed)
+	} else {
+		payload, err = h.decryptV3(body, transformed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compressed {
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		payload, err = io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+	}
+
+	streamID, streamKey := h.streamID, h.streamKey
+	if h.major == 4 {
+		streamID, streamKey, payload, err = readInnerHeader(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := newInnerStream(streamID, streamKey)
+	if err != nil {
+		return nil, err
+	}
+	return unprotect(payload, stream)
+}
+
+func readHeader(data []byte) (*header, []byte, error) {
+	if len(data) < 12 ||
+		binary.LittleEndian.Uint32(data[0:4]) != signature1 ||
+		binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return nil, nil, ErrNotKDBX
+	}
+
+	h := &header{major: binary.LittleEndian.Uint16(data[10:12])}
+	if h.major != 3 && h.major != 4 {
+		return nil, nil, fmt.Errorf("%w: version %d", ErrUnsupported, h.major)
+	}
+
+	pos := 12
+	for {
+		sizeLen := 2
+		if h.major == 4 {
+			sizeLen = 4
+		}
+		if len(data) < pos+1+sizeLen {
+			return nil, nil, fmt.Errorf("%w: truncated header", ErrCorrupt)
+		}
+		id := data[pos]
+		var size int
+		if h.major == 4 {
+			size = int(binary.LittleEndian.Uint32(data[pos+1:]))
+		} else {
+			size = int(binary.LittleEndian.Uint16(data[pos+1:]))
+		}
+		pos += 1 + sizeLen
+		if size < 0 || len(data) < pos+size {
+			return nil, nil, fmt.Errorf("%w: truncated header", ErrCorrupt)
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		if err := h.set(id, value); err != nil {
+			return nil, nil, err
+		}
+		if id == headerEnd {
+			break
+		}
+	}
+	h.raw = data[:pos]
+
+	if h.cipherID == nil || h.masterSeed == nil || h.encryptionIV == nil {
+		return nil, nil, fmt.Errorf("%w: missing header fields", ErrCorrupt)
+	}
+	if h.major == 3 && (h.transformSeed == nil || h.streamStartBytes == nil) {
+		return nil, nil, fmt.Errorf("%w: missing header fields", ErrCorrupt)
+	}
+	if h.major == 4 && h.kdf == nil {
+		return nil, nil, fmt.Errorf("%w: missing key derivation parameters", ErrCorrupt)
+	}
+	return h, data[pos:], nil
+}
+
+func (h *header) set(id byte, value []byte) error {
+	switch id {
+	case headerCipherID:
+		h.cipherID = value
+	case headerCompressionFlags:
+		if len(value) != 4 {
+			return fmt.Errorf("%w: invalid compression flags", ErrCorrupt)
+		}
+		h.compressed = binary.LittleEndian.Uint32(value) == 1
+	case headerMasterSeed:
+		if len(value) != 32 {
+			return fmt.Errorf("%w: invalid master seed", ErrCorrupt)
+		}
+		h.masterSeed = value
+	case headerTransformSeed:
+		h.transformSeed = value
+	case headerTransformRounds:
+		if len(value) != 8 {
+			return fmt.Errorf("%w: invalid transform rounds", ErrCorrupt)
+		}
+		h.transformRounds = binary.LittleEndian.Uint64(value)
+	case headerEncryptionIV:
+		h.encryptionIV = value
+	case headerProtectedStreamKey:
+		h.streamKey = value
+	case headerStreamStartBytes:
+		h.streamStartBytes = value
+	case headerInnerRandomStreamID:
+		if len(value) != 4 {
+			return fmt.Errorf("%w: invalid inner stream ID", ErrCorrupt)
+		}
+		h.streamID = binary.LittleEndian.Uint32(value)
+	case headerKdfParameters:
+		kdf, err := readVariantDictionary(value)
+		if err != nil {
+			return err
+		}
+		h.kdf = kdf
+	}
+	return nil
+}
+
+// transformKey derives the transformed key from the composite key with the key
+// derivation function of the database.
+func (h *header) transformKey(ctx context.Context, composite []byte) ([]byte, error) {
+	if h.major == 3 {
+		return aesKDF(ctx, composite, h.transformSeed, h.transformRounds)
+	}
+
+	uuid, _ := h.kdf["$UUID"].([]byte)
+	salt, _ := h.kdf["S"].([]byte)
+	switch {
+	case bytes.Equal(uuid, kdfAES):
+		rounds, _ := h.kdf["R"].(uint64)
+		return aesKDF(ctx, composite, salt, rounds)
+	case bytes.Equal(uuid, kdfArgon2d), bytes.Equal(uuid, kdfArgon2id):
+		iterations, _ := h.kdf["I"].(uint64)
+		memory, _ := h.kdf["M"].(uint64)
+		parallelism, _ := h.kdf["P"].(uint32)
+		version, _ := h.kdf["V"].(uint32)
+		if version != argon2.Version || iterations == 0 || parallelism == 0 || memory/1024 == 0 {
+			return nil, fmt.Errorf("%w: Argon2 parameters", ErrUnsupported)
+		}
+		if memory > maxArgon2Memory || iterations > maxArgon2Iterations || parallelism > maxArgon2Parallelism {
+			return nil, fmt.Errorf("%w: Argon2 parameters above the limits of the server", ErrUnsupported)
+		}
+		if secret, _ := h.kdf["K"].([]byte); len(secret) > 0 {
+			return nil, fmt.Errorf("%w: Argon2 secret key", ErrUnsupported)
+		}
+		if data, _ := h.kdf["A"].([]byte); len(data) > 0 {
+			return nil, fmt.Errorf("%w: Argon2 associated data", ErrUnsupported)
+		}
+
+		derive := argon2d.Key
+		if bytes.Equal(uuid, kdfArgon2id) {
+			derive = argon2.IDKey
+		}
+		return derive(composite, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+	default:
+		return nil, fmt.Errorf("%w: key derivation function", ErrUnsupported)
+	}
+}
+
+// aesKDF encrypts both halves of the key rounds times with AES-256 keyed with seed.
+// It stops early when ctx is done.
+func aesKDF(ctx context.Context, key, seed []byte, rounds uint64) ([]byte, error) {
+	if rounds > maxAESRounds {
+		return nil, fmt.Errorf("%w: AES-KDF rounds above the limits of the server", ErrUnsupported)
+	}
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: AES-KDF seed", ErrCorrupt)
+	}
+
+	transformed := make([]byte, len(key))
+	copy(transformed, key)
+	for i := uint64(0); i < rounds; i++ {
+		if i%aesRoundsPerCheck == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		block.Encrypt(transformed[:16], transformed[:16])
+		block.Encrypt(transformed[16:], transformed[16:])
+	}
+
+	sum := sha256.Sum256(transformed)
+	return sum[:], nil
+}
+
+// masterKey returns the key the payload is encrypted with.
+func (h *header) masterKey(transformed []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, h.masterSeed...), transformed...))
+	return sum[:]
+}
+
+// decryptV3 decrypts the payload of a KDBX 3.1 database and reads its hashed blocks.
+func (h *header) decryptV3(body, transformed []byte) ([]byte, error) {
+	plain, err := h.decrypt(body, h.masterKey(transformed))
+	if err != nil {
+		return nil, err
+	}
+	if len(plain) < len(h.streamStartBytes) || !bytes.Equal(plain[:len(h.streamStartBytes)], h.streamStartBytes) {
+		return nil, ErrInvalidCredentials
+	}
+	plain = plain[len(h.streamStartBytes):]
+
+	var payload []byte
+	for {
+		if len(plain) < 40 {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorrupt)
+		}
+		hash := plain[4:36]
+		size := int(binary.LittleEndian.Uint32(plain[36:40]))
+		plain = plain[40:]
+		if size == 0 {
+			return payload, nil
+		}
+		if size < 0 || len(plain) < size {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorrupt)
+		}
+		if sum := sha256.Sum256(plain[:size]); !bytes.Equal(sum[:], hash) {
+			return nil, fmt.Errorf("%w: block hash mismatch", ErrCorrupt)
+		}
+		payload = append(payload, plain[:size]...)
+		plain = plain[size:]
+	}
+}
+
+// decryptV4 checks the header and block HMACs of a KDBX 4 database and decrypts its payload.
+func (h *header) decryptV4(body, transformed []byte) ([]byte, error) {
+	if len(body) < 64 {
+		return nil, fmt.Errorf("%w: truncated header", ErrCorrupt)
+	}
+	if sum := sha256.Sum256(h.raw); !bytes.Equal(sum[:], body[:32]) {
+		return nil, fmt.Errorf("%w: header hash mismatch", ErrCorrupt)
+	}
+
+	hmacKey := sha512.Sum512(append(append(append([]byte{}, h.masterSeed...), transformed...), 0x01))
+	if !hmac.Equal(blockHMAC(hmacKey[:], ^uint64(0), h.raw), body[32:64]) {
+		return nil, ErrInvalidCredentials
+	}
+	body = body[64:]
+
+	var encrypted []byte
+	for index := uint64(0); ; index++ {
+		if len(body) < 36 {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorrupt)
+		}
+		mac := body[:32]
+		size := int(int32(binary.LittleEndian.Uint32(body[32:36])))
+		if size < 0 || len(body) < 36+size {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorrupt)
+		}
+		// The MAC covers the block index and size as well as the data.
+		if !hmac.Equal(blockHMAC(hmacKey[:], index, body[32:36+size]), mac) {
+			return nil, fmt.Errorf("%w: block HMAC mismatch", ErrCorrupt)
+		}
+		if size == 0 {
+			break
+		}
+		encrypted = append(encrypted, body[36:36+size]...)
+		body = body[36+size:]
+	}
+
+	return h.decrypt(encrypted, h.masterKey(transformed))
+}
+
+// blockHMAC authenticates data of the block at index, see the KDBX 4 format.
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	var le [8]byte
+	binary.LittleEndian.PutUint64(le[:], index)
+	key := sha512.Sum512(append(le[:], hmacKey...))
+
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(le[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// decrypt decrypts the payload with the cipher of the database.
+func (h *header) decrypt(data, key []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(h.cipherID, cipherAES256):
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(h.encryptionIV) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w: invalid AES payload", ErrCorrupt)
+		}
+		plain := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, h.encryptionIV).CryptBlocks(plain, data)
+
+		// A wrong key shows up as broken PKCS#7 padding in KDBX 3.1.
+		padding := int(plain[len(plain)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, ErrInvalidCredentials
+		}
+		for _, b := range plain[len(plain)-padding:] {
+			if int(b) != padding {
+				return nil, ErrInvalidCredentials
+			}
+		}
+		return plain[:len(plain)-padding], nil
+	case bytes.Equal(h.cipherID, cipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.encryptionIV)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid ChaCha20 nonce", ErrCorrupt)
+		}
+		plain := make([]byte, len(data))
+		stream.XORKeyStream(plain, data)
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("%w: cipher", ErrUnsupported)
+	}
+}
+
+// readInnerHeader returns the inner random stream of a KDBX 4 payload and the XML
+// document following the inner header.
+func readInnerHeader(payload []byte) (uint32, []byte, []byte, error) {
+	var streamID uint32
+	var streamKey []byte
+	for {
+		if len(payload) < 5 {
+			return 0, nil, nil, fmt.Errorf("%w: truncated inner header", ErrCorrupt)
+		}
+		id := payload[0]
+		size := int(int32(binary.LittleEndian.Uint32(payload[1:5])))
+		if size < 0 || len(payload) < 5+size {
+			return 0, nil, nil, fmt.Errorf("%w: truncated inner header", ErrCorrupt)
+		}
+		value := payload[5 : 5+size]
+		payload = payload[5+size:]
+
+		switch id {
+		case innerHeaderEnd:
+			return streamID, streamKey, payload, nil
+		case innerHeaderStreamID:
+			if len(value) != 4 {
+				return 0, nil, nil, fmt.Errorf("%w: invalid inner stream ID", ErrCorrupt)
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerHeaderStreamKey:
+			streamKey = value
+		}
+	}
+}
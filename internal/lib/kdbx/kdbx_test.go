@@ -0,0 +1,335 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"passvault/internal/lib/argon2d"
+	"strings"
+	"testing"
+)
+
+// database describes a KDBX file written by encode.
+type database struct {
+	major    uint16
+	cipher   []byte
+	kdf      []byte
+	streamID uint32
+	password string
+	// values are stored as protected values of the document, in order.
+	values []string
+}
+
+const documentTemplate = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile><Root><Group><Name>Root</Name>%s</Group></Root></KeePassFile>`
+
+func (d database) document(stream keyStream) string {
+	var values strings.Builder
+	for _, value := range d.values {
+		protected := []byte(value)
+		stream.XORKeyStream(protected, protected)
+		fmt.Fprintf(&values, `<Entry><String><Key>Password</Key><Value Protected="True">%s</Value></String></Entry>`,
+			base64.StdEncoding.EncodeToString(protected))
+	}
+	return fmt.Sprintf(documentTemplate, values.String())
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func field(buf *bytes.Buffer, major uint16, id byte, value []byte) {
+	buf.WriteByte(id)
+	if major == 4 {
+		binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint16(len(value)))
+	}
+	buf.Write(value)
+}
+
+func le32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+func le64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+func variant(buf *bytes.Buffer, kind byte, key string, value []byte) {
+	buf.WriteByte(kind)
+	buf.Write(le32(uint32(len(key))))
+	buf.WriteString(key)
+	buf.Write(le32(uint32(len(value))))
+	buf.Write(value)
+}
+
+// encode writes d in the KDBX format, mirroring what KeePass does.
+func encode(t *testing.T, d database) []byte {
+	masterSeed, streamKey := random(32), random(32)
+	ivLen := 16
+	if bytes.Equal(d.cipher, cipherChaCha20) {
+		ivLen = 12
+	}
+	iv := random(ivLen)
+
+	composite := sha256.Sum256([]byte(d.password))
+	composite = sha256.Sum256(composite[:])
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(signature1))
+	binary.Write(&header, binary.LittleEndian, uint32(signature2))
+	binary.Write(&header, binary.LittleEndian, uint16(1))
+	binary.Write(&header, binary.LittleEndian, d.major)
+	field(&header, d.major, headerCipherID, d.cipher)
+	field(&header, d.major, headerCompressionFlags, le32(1))
+	field(&header, d.major, headerMasterSeed, masterSeed)
+	field(&header, d.major, headerEncryptionIV, iv)
+
+	var transformed []byte
+	var err error
+	var startBytes []byte
+	if d.major == 3 {
+		seed := random(32)
+		transformed, err = aesKDF(context.Background(), composite[:], seed, 100)
+		require.NoError(t, err)
+		startBytes = random(32)
+		field(&header, 3, headerTransformSeed, seed)
+		field(&header, 3, headerTransformRounds, le64(100))
+		field(&header, 3, headerProtectedStreamKey, streamKey)
+		field(&header, 3, headerStreamStartBytes, startBytes)
+		field(&header, 3, headerInnerRandomStreamID, le32(d.streamID))
+	} else {
+		salt := random(32)
+		var params bytes.Buffer
+		params.Write([]byte{0x00, 0x01})
+		variant(&params, variantByteArray, "$UUID", d.kdf)
+		variant(&params, variantByteArray, "S", salt)
+		switch {
+		case bytes.Equal(d.kdf, kdfAES):
+			variant(&params, variantUint64, "R", le64(100))
+			transformed, err = aesKDF(context.Background(), composite[:], salt, 100)
+			require.NoError(t, err)
+		case bytes.Equal(d.kdf, kdfArgon2d):
+			variant(&params, variantUint32, "P", le32(2))
+			variant(&params, variantUint64, "M", le64(64*1024))
+			variant(&params, variantUint64, "I", le64(2))
+			variant(&params, variantUint32, "V", le32(0x13))
+			transformed = argon2d.Key(composite[:], salt, 2, 64, 2, 32)
+		default:
+			variant(&params, variantUint32, "P", le32(2))
+			variant(&params, variantUint64, "M", le64(64*1024))
+			variant(&params, variantUint64, "I", le64(2))
+			variant(&params, variantUint32, "V", le32(0x13))
+			transformed = argon2.IDKey(composite[:], salt, 2, 64, 2, 32)
+		}
+		params.WriteByte(variantEnd)
+		field(&header, 4, headerKdfParameters, params.Bytes())
+	}
+	field(&header, d.major, headerEnd, []byte("\r\n\r\n"))
+
+	stream, err := newInnerStream(d.streamID, streamKey)
+	require.NoError(t, err)
+
+	// In KDBX 4 the inner header is compressed together with the document.
+	var plain bytes.Buffer
+	if d.major == 4 {
+		field(&plain, 4, innerHeaderStreamID, le32(d.streamID))
+		field(&plain, 4, innerHeaderStreamKey, streamKey)
+		field(&plain, 4, innerHeaderEnd, nil)
+	}
+	plain.WriteString(d.document(stream))
+
+	var payload bytes.Buffer
+	gz := gzip.NewWriter(&payload)
+	gz.Write(plain.Bytes())
+	gz.Close()
+
+	sum := sha256.Sum256(append(append([]byte{}, masterSeed...), transformed...))
+	key := sum[:]
+
+	out := bytes.NewBuffer(header.Bytes())
+	if d.major == 3 {
+		var blocks bytes.Buffer
+		blocks.Write(startBytes)
+		hash := sha256.Sum256(payload.Bytes())
+		blocks.Write(le32(0))
+		blocks.Write(hash[:])
+		blocks.Write(le32(uint32(payload.Len())))
+		blocks.Write(payload.Bytes())
+		blocks.Write(le32(1))
+		blocks.Write(make([]byte, 32))
+		blocks.Write(le32(0))
+		out.Write(encrypt(t, d.cipher, key, iv, blocks.Bytes()))
+		return out.Bytes()
+	}
+
+	hmacKey := hmacBase(masterSeed, transformed)
+	headerHash := sha256.Sum256(header.Bytes())
+	out.Write(headerHash[:])
+	out.Write(blockHMAC(hmacKey, ^uint64(0), header.Bytes()))
+
+	encrypted := encrypt(t, d.cipher, key, iv, payload.Bytes())
+	for index, block := range [][]byte{encrypted, nil} {
+		sized := append(le32(uint32(len(block))), block...)
+		out.Write(blockHMAC(hmacKey, uint64(index), sized))
+		out.Write(sized)
+	}
+	return out.Bytes()
+}
+
+func hmacBase(masterSeed, transformed []byte) []byte {
+	sum := sha512.Sum512(append(append(append([]byte{}, masterSeed...), transformed...), 0x01))
+	return sum[:]
+}
+
+func encrypt(t *testing.T, id, key, iv, plain []byte) []byte {
+	if bytes.Equal(id, cipherChaCha20) {
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		require.NoError(t, err)
+		out := make([]byte, len(plain))
+		stream.XORKeyStream(out, plain)
+		return out
+	}
+
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return out
+}
+
+func TestDecrypt(t *testing.T) {
+	values := []string{"hunter2", "", "p<a&s>s", strings.Repeat("long ", 40)}
+
+	tests := []struct {
+		name string
+		db   database
+	}{
+		{"KDBX 3.1 AES Salsa20", database{major: 3, cipher: cipherAES256, streamID: streamSalsa20}},
+		{"KDBX 3.1 ChaCha20", database{major: 3, cipher: cipherChaCha20, streamID: streamSalsa20}},
+		{"KDBX 4 AES-KDF AES", database{major: 4, cipher: cipherAES256, kdf: kdfAES, streamID: streamChaCha20}},
+		{"KDBX 4 Argon2d ChaCha20", database{major: 4, cipher: cipherChaCha20, kdf: kdfArgon2d, streamID: streamChaCha20}},
+		{"KDBX 4 Argon2id AES Salsa20", database{major: 4, cipher: cipherAES256, kdf: kdfArgon2id, streamID: streamSalsa20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.db.password = "correct horse"
+			tt.db.values = values
+			data := encode(t, tt.db)
+
+			document, err := Decrypt(context.Background(), data, "correct horse")
+			require.NoError(t, err)
+			for _, value := range values {
+				require.Contains(t, string(document), escape(value))
+			}
+			require.NotContains(t, string(document), "Protected")
+
+			_, err = Decrypt(context.Background(), data, "battery staple")
+			require.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestDecryptRejectsTamperedData(t *testing.T) {
+	data := encode(t, database{major: 4, cipher: cipherAES256, kdf: kdfAES, streamID: streamChaCha20, password: "pw", values: []string{"x"}})
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-50] ^= 1
+	_, err := Decrypt(context.Background(), tampered, "pw")
+	require.ErrorIs(t, err, ErrCorrupt)
+
+	_, err = Decrypt(context.Background(), []byte("<KeePassFile/>"), "pw")
+	require.ErrorIs(t, err, ErrNotKDBX)
+
+	_, err = Decrypt(context.Background(), data[:len(data)/2], "pw")
+	require.Error(t, err)
+}
+
+// header writes the outer header of a database with the given key derivation
+// parameters and no payload; the limits are checked before the payload is read.
+func kdfHeader(major uint16, kdf func(params *bytes.Buffer)) []byte {
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(signature1))
+	binary.Write(&header, binary.LittleEndian, uint32(signature2))
+	binary.Write(&header, binary.LittleEndian, uint16(1))
+	binary.Write(&header, binary.LittleEndian, major)
+	field(&header, major, headerCipherID, cipherAES256)
+	field(&header, major, headerMasterSeed, random(32))
+	field(&header, major, headerEncryptionIV, random(16))
+
+	var params bytes.Buffer
+	kdf(&params)
+	if major == 3 {
+		field(&header, 3, headerTransformSeed, random(32))
+		field(&header, 3, headerTransformRounds, params.Bytes())
+		field(&header, 3, headerStreamStartBytes, random(32))
+	} else {
+		field(&header, 4, headerKdfParameters, append(append([]byte{0x00, 0x01}, params.Bytes()...), variantEnd))
+	}
+	field(&header, major, headerEnd, []byte("\r\n\r\n"))
+	return append(header.Bytes(), random(64)...)
+}
+
+func argon2Params(kdf []byte, memory, iterations uint64, parallelism uint32) func(*bytes.Buffer) {
+	return func(params *bytes.Buffer) {
+		variant(params, variantByteArray, "$UUID", kdf)
+		variant(params, variantByteArray, "S", random(32))
+		variant(params, variantUint32, "P", le32(parallelism))
+		variant(params, variantUint64, "M", le64(memory))
+		variant(params, variantUint64, "I", le64(iterations))
+		variant(params, variantUint32, "V", le32(0x13))
+	}
+}
+
+func TestDecryptRejectsExpensiveKeyDerivation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"KDBX 3.1 AES-KDF rounds", kdfHeader(3, func(params *bytes.Buffer) { params.Write(le64(1 << 40)) })},
+		{"KDBX 4 AES-KDF rounds", kdfHeader(4, func(params *bytes.Buffer) {
+			variant(params, variantByteArray, "$UUID", kdfAES)
+			variant(params, variantByteArray, "S", random(32))
+			variant(params, variantUint64, "R", le64(maxAESRounds+1))
+		})},
+		{"Argon2d memory", kdfHeader(4, argon2Params(kdfArgon2d, 1<<42, 2, 2))},
+		{"Argon2id memory", kdfHeader(4, argon2Params(kdfArgon2id, maxArgon2Memory+1024, 2, 2))},
+		{"Argon2 iterations", kdfHeader(4, argon2Params(kdfArgon2d, 64*1024, 1<<31, 2))},
+		{"Argon2 parallelism", kdfHeader(4, argon2Params(kdfArgon2id, 64*1024, 2, 255))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(context.Background(), tt.data, "pw")
+			require.ErrorIs(t, err, ErrUnsupported)
+		})
+	}
+}
+
+func TestDecryptStopsWhenCancelled(t *testing.T) {
+	data := kdfHeader(3, func(params *bytes.Buffer) { params.Write(le64(maxAESRounds)) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Decrypt(ctx, data, "pw")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+	"io"
+	"strings"
+)
+
+// Inner random stream IDs.
+const (
+	streamNone     = 0
+	streamSalsa20  = 2
+	streamChaCha20 = 3
+)
+
+// Variant dictionary value types.
+const (
+	variantEnd       = 0x00
+	variantUint32    = 0x04
+	variantUint64    = 0x05
+	variantBool      = 0x08
+	variantInt32     = 0x0C
+	variantInt64     = 0x0D
+	variantString    = 0x18
+	variantByteArray = 0x42
+)
+
+// salsa20Nonce is the fixed nonce of the Salsa20 inner random stream.
+var salsa20Nonce = []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}
+
+// readVariantDictionary parses the KDBX 4 variant dictionary holding the key
+// derivation parameters.
+func readVariantDictionary(data []byte) (map[string]any, error) {
+	if len(data) < 2 || data[1] != 0x01 {
+		return nil, fmt.Errorf("%w: variant dictionary version", ErrUnsupported)
+	}
+	data = data[2:]
+
+	dict := make(map[string]any)
+	for {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("%w: truncated variant dictionary", ErrCorrupt)
+		}
+		kind := data[0]
+		if kind == variantEnd {
+			return dict, nil
+		}
+
+		if len(data) < 5 {
+			return nil, fmt.Errorf("%w: truncated variant dictionary", ErrCorrupt)
+		}
+		keyLen := int(int32(binary.LittleEndian.Uint32(data[1:5])))
+		data = data[5:]
+		if keyLen < 0 || len(data) < keyLen+4 {
+			return nil, fmt.Errorf("%w: truncated variant dictionary", ErrCorrupt)
+		}
+		key := string(data[:keyLen])
+		valueLen := int(int32(binary.LittleEndian.Uint32(data[keyLen:])))
+		data = data[keyLen+4:]
+		if valueLen < 0 || len(data) < valueLen {
+			return nil, fmt.Errorf("%w: truncated variant dictionary", ErrCorrupt)
+		}
+		value := data[:valueLen]
+		data = data[valueLen:]
+
+		switch kind {
+		case variantUint32, variantInt32:
+			if len(value) != 4 {
+				return nil, fmt.Errorf("%w: variant %q", ErrCorrupt, key)
+			}
+			if kind == variantUint32 {
+				dict[key] = binary.LittleEndian.Uint32(value)
+			} else {
+				dict[key] = int32(binary.LittleEndian.Uint32(value))
+			}
+		case variantUint64, variantInt64:
+			if len(value) != 8 {
+				return nil, fmt.Errorf("%w: variant %q", ErrCorrupt, key)
+			}
+			if kind == variantUint64 {
+				dict[key] = binary.LittleEndian.Uint64(value)
+			} else {
+				dict[key] = int64(binary.LittleEndian.Uint64(value))
+			}
+		case variantBool:
+			if len(value) != 1 {
+				return nil, fmt.Errorf("%w: variant %q", ErrCorrupt, key)
+			}
+			dict[key] = value[0] != 0
+		case variantString:
+			dict[key] = string(value)
+		case variantByteArray:
+			dict[key] = value
+		default:
+			return nil, fmt.Errorf("%w: variant type %#x", ErrUnsupported, kind)
+		}
+	}
+}
+
+// keyStream is the inner random stream protected values are XORed with.
+type keyStream interface {
+	XORKeyStream(dst, src []byte)
+}
+
+func newInnerStream(id uint32, key []byte) (keyStream, error) {
+	switch id {
+	case streamNone:
+		return nil, nil
+	case streamSalsa20:
+		s := &salsa20Stream{key: sha256.Sum256(key), used: 64}
+		copy(s.nonce[:], salsa20Nonce)
+		return s, nil
+	case streamChaCha20:
+		sum := sha512.Sum512(key)
+		stream, err := chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+		if err != nil {
+			return nil, err
+		}
+		return stream, nil
+	default:
+		return nil, fmt.Errorf("%w: inner random stream %d", ErrUnsupported, id)
+	}
+}
+
+// salsa20Stream is a Salsa20 key stream that, unlike salsa.XORKeyStream, keeps its
+// position between calls.
+type salsa20Stream struct {
+	key     [32]byte
+	nonce   [8]byte
+	counter uint64
+	block   [64]byte
+	used    int
+}
+
+func (s *salsa20Stream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == len(s.block) {
+			var counter [16]byte
+			copy(counter[:8], s.nonce[:])
+			binary.LittleEndian.PutUint64(counter[8:], s.counter)
+			s.counter++
+
+			var zero [64]byte
+			salsa.XORKeyStream(s.block[:], zero[:], &counter, &s.key)
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.block[s.used]
+		s.used++
+	}
+}
+
+// unprotect rewrites the XML document with the protected values decrypted. Values
+// are XORed with the stream in document order, so every protected value has to be
+// visited, including those in entry history.
+func unprotect(document []byte, stream keyStream) ([]byte, error) {
+	if stream == nil {
+		return document, nil
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+
+	protected := false
+	var value strings.Builder
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Value" && isProtected(t.Attr) {
+				protected = true
+				value.Reset()
+				t.Attr = nil
+				token = t
+			}
+		case xml.CharData:
+			if protected {
+				value.Write(t)
+				continue
+			}
+		case xml.EndElement:
+			if protected {
+				protected = false
+				plain, err := decryptValue(value.String(), stream)
+				if err != nil {
+					return nil, err
+				}
+				if err := encoder.EncodeToken(xml.CharData(plain)); err != nil {
+					return nil, err
+				}
+			}
+		case xml.ProcInst:
+			// The encoder only accepts the XML declaration as the first token.
+			if t.Target == "xml" {
+				continue
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func isProtected(attrs []xml.Attr) bool {
+	for _, attr := range attrs {
+		if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "true") {
+			return true
+		}
+	}
+	return false
+}
+
+func decryptValue(encoded string, stream keyStream) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: protected value: %v", ErrCorrupt, err)
+	}
+	stream.XORKeyStream(data, data)
+	return data, nil
+}
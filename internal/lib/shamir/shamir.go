@@ -0,0 +1,155 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+//
+// Every share is one byte longer than the secret: the trailing byte holds the
+// x coordinate the share was evaluated at, so shares can be combined in any
+// order.
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	minThreshold = 2
+	maxParts     = 255
+)
+
+var (
+	ErrInvalidParams   = errors.New("invalid split parameters")
+	ErrEmptySecret     = errors.New("secret is empty")
+	ErrNotEnoughShares = errors.New("not enough shares")
+	ErrInvalidShares   = errors.New("invalid shares")
+)
+
+// Split divides secret into parts shares, any threshold of which can
+// reconstruct it. Fewer than threshold shares reveal nothing about the secret.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if threshold < minThreshold || parts < threshold || parts > maxParts {
+		return nil, fmt.Errorf("%w: parts=%d threshold=%d", ErrInvalidParams, parts, threshold)
+	}
+
+	xs, err := randomCoordinates(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = xs[i]
+	}
+
+	coefficients := make([]byte, threshold)
+	for idx, b := range secret {
+		// coefficients[0] is the secret byte, the rest are random.
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		coefficients[0] = b
+
+		for i, x := range xs {
+			shares[i][idx] = evaluate(coefficients, x)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs a secret from at least threshold shares produced by Split.
+// Supplying fewer shares than the threshold yields a wrong secret, which is
+// why callers must track the threshold themselves.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < minThreshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	size := len(shares[0])
+	if size < 2 {
+		return nil, ErrInvalidShares
+	}
+
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]struct{}, len(shares))
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, fmt.Errorf("%w: shares have different lengths", ErrInvalidShares)
+		}
+		x := share[size-1]
+		if x == 0 {
+			return nil, fmt.Errorf("%w: zero x coordinate", ErrInvalidShares)
+		}
+		if _, ok := seen[x]; ok {
+			return nil, fmt.Errorf("%w: duplicate share", ErrInvalidShares)
+		}
+		seen[x] = struct{}{}
+		xs[i] = x
+	}
+
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(shares))
+	for idx := range secret {
+		for i, share := range shares {
+			ys[i] = share[idx]
+		}
+		secret[idx] = interpolateAtZero(xs, ys)
+	}
+
+	return secret, nil
+}
+
+// Equal compares two shares in constant time.
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// randomCoordinates picks n distinct non-zero x coordinates.
+func randomCoordinates(n int) ([]byte, error) {
+	xs := make([]byte, maxParts)
+	for i := range xs {
+		xs[i] = byte(i + 1)
+	}
+
+	// Fisher-Yates shuffle driven by crypto/rand.
+	buf := make([]byte, 2)
+	for i := len(xs) - 1; i > 0; i-- {
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return nil, err
+		}
+		j := int(uint16(buf[0])<<8|uint16(buf[1])) % (i + 1)
+		xs[i], xs[j] = xs[j], xs[i]
+	}
+
+	return xs[:n], nil
+}
+
+// evaluate computes the polynomial with the given coefficients at x using Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var result byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = add(mul(result, x), coefficients[i])
+	}
+	return result
+}
+
+// interpolateAtZero returns f(0) of the Lagrange polynomial through (xs[i], ys[i]).
+func interpolateAtZero(xs, ys []byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(xs[j], add(xs[i], xs[j])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package shamir
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFieldArithmetic(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			require.Equal(t, byte(a), div(mul(byte(a), byte(b)), byte(b)))
+		}
+	}
+	// Known product from FIPS-197, section 4.2.
+	require.Equal(t, byte(0xc1), mul(0x57, 0x83))
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("account master key material")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var picked [][]byte
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+
+		recovered, err := Combine(picked)
+		require.NoError(t, err)
+		require.Equal(t, secret, recovered)
+	}
+
+	recovered, err := Combine(shares[:2])
+	require.NoError(t, err)
+	require.NotEqual(t, secret, recovered)
+}
+
+func TestSplitCombineErrors(t *testing.T) {
+	_, err := Split(nil, 3, 2)
+	require.ErrorIs(t, err, ErrEmptySecret)
+
+	_, err = Split([]byte("secret"), 2, 3)
+	require.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = Split([]byte("secret"), 3, 1)
+	require.ErrorIs(t, err, ErrInvalidParams)
+
+	shares, err := Split([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	_, err = Combine(shares[:1])
+	require.ErrorIs(t, err, ErrNotEnoughShares)
+
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	require.ErrorIs(t, err, ErrInvalidShares)
+
+	_, err = Combine([][]byte{shares[0], shares[1][:3]})
+	require.ErrorIs(t, err, ErrInvalidShares)
+}
@@ -0,0 +1,348 @@
+// Package strength estimates how hard a password is to guess, after zxcvbn.
+//
+// The password is split into the patterns an attacker tries first: common
+// passwords and the user's own inputs, also reversed, capitalized or with l33t
+// substitutions, sequences such as "abc" or "975", repeats, straight runs of
+// keyboard keys and years. Every pattern has a number of guesses; the estimate is
+// the segmentation of the password into patterns and brute forced characters that
+// needs the fewest guesses. The score buckets the guesses like zxcvbn does.
+package strength
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Score buckets, from too guessable to very unguessable.
+const (
+	ScoreTooGuessable = iota
+	ScoreVeryGuessable
+	ScoreSomewhatGuessable
+	ScoreSafelyUnguessable
+	ScoreVeryUnguessable
+)
+
+// bruteforceCardinality is the number of guesses per character not in a pattern.
+const bruteforceCardinality = 10
+
+// minYearSpace is the least number of years guessed around the current one.
+const minYearSpace = 20
+
+// minMatchLength is the length of the shortest pattern.
+const minMatchLength = 3
+
+// maxLength bounds the characters searched for patterns; the rest count as brute
+// forced.
+const maxLength = 128
+
+//go:embed common.txt
+var commonFile string
+
+// commonRanks ranks the most common passwords, 1 being the most common.
+var commonRanks = rank(strings.Fields(commonFile))
+
+var l33t = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'|': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+// Result is the estimate for a password.
+type Result struct {
+	// Score is one of the Score constants.
+	Score int `json:"score"`
+	// GuessesLog10 is the base 10 logarithm of the guesses needed.
+	GuessesLog10 float64 `json:"guesses_log10"`
+}
+
+type match struct {
+	start, end int
+	guesses    float64
+}
+
+// Check estimates the strength of password. userInputs are words the owner of the
+// password is known by, such as the username or the name of the site, which count
+// as the first words an attacker tries.
+func Check(password string, userInputs ...string) Result {
+	chars := []rune(password)
+	inputs := make(map[string]int, len(userInputs))
+	for i, input := range userInputs {
+		input = strings.ToLower(input)
+		if len([]rune(input)) >= minMatchLength {
+			if _, ok := inputs[input]; !ok {
+				inputs[input] = i + 1
+			}
+		}
+	}
+
+	guesses := 1.0
+	if len(chars) > maxLength {
+		guesses = math.Pow(bruteforceCardinality, float64(len(chars)-maxLength))
+		chars = chars[:maxLength]
+	}
+	guesses *= minGuesses(chars, inputs)
+	return Result{Score: score(guesses), GuessesLog10: math.Log10(guesses)}
+}
+
+func score(guesses float64) int {
+	// zxcvbn adds a margin so that guesses just below a threshold still score low.
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return ScoreTooGuessable
+	case guesses < 1e6+delta:
+		return ScoreVeryGuessable
+	case guesses < 1e8+delta:
+		return ScoreSomewhatGuessable
+	case guesses < 1e10+delta:
+		return ScoreSafelyUnguessable
+	}
+	return ScoreVeryUnguessable
+}
+
+// minGuesses returns the guesses of the cheapest segmentation of chars.
+func minGuesses(chars []rune, inputs map[string]int) float64 {
+	if len(chars) == 0 {
+		return 1
+	}
+
+	ending := make([][]match, len(chars)+1)
+	for _, m := range matches(chars, inputs) {
+		ending[m.end] = append(ending[m.end], m)
+	}
+
+	best := make([]float64, len(chars)+1)
+	best[0] = 1
+	for k := 1; k <= len(chars); k++ {
+		best[k] = best[k-1] * bruteforceCardinality
+		for _, m := range ending[k] {
+			best[k] = math.Min(best[k], best[m.start]*m.guesses)
+		}
+	}
+	return best[len(chars)]
+}
+
+func matches(chars []rune, inputs map[string]int) []match {
+	var all []match
+	all = append(all, dictionaryMatches(chars, inputs)...)
+	all = append(all, sequenceMatches(chars)...)
+	all = append(all, repeatMatches(chars, inputs)...)
+	all = append(all, keyboardMatches(chars)...)
+	all = append(all, yearMatches(chars)...)
+	return all
+}
+
+// dictionaryMatches finds common passwords and user inputs, as they are, reversed
+// or with l33t substitutions.
+func dictionaryMatches(chars []rune, inputs map[string]int) []match {
+	lookup := func(word string) (int, bool) {
+		if rank, ok := inputs[word]; ok {
+			return rank, true
+		}
+		rank, ok := commonRanks[word]
+		return rank, ok
+	}
+
+	var found []match
+	for i := 0; i < len(chars); i++ {
+		for j := i + minMatchLength; j <= len(chars); j++ {
+			token := chars[i:j]
+			lower := strings.ToLower(string(token))
+			variations := uppercaseVariations(token)
+
+			guesses := math.Inf(1)
+			if rank, ok := lookup(lower); ok {
+				guesses = float64(rank) * variations
+			}
+			if rank, ok := lookup(reverse(lower)); ok {
+				guesses = math.Min(guesses, float64(rank)*variations*2)
+			}
+			if plain, subs := unl33t(lower); subs > 0 {
+				if rank, ok := lookup(plain); ok {
+					guesses = math.Min(guesses, float64(rank)*variations*math.Pow(2, float64(subs)))
+				}
+			}
+			if !math.IsInf(guesses, 1) {
+				found = append(found, match{start: i, end: j, guesses: guesses})
+			}
+		}
+	}
+	return found
+}
+
+// uppercaseVariations counts the ways the letters of a word could be capitalized,
+// counting the usual first letter, last letter and all capitals as one more each.
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+	for k := 1; k <= upper && k <= lower; k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+// sequenceMatches finds runs of letters or digits going up or down by one.
+func sequenceMatches(chars []rune) []match {
+	var found []match
+	for i := 0; i < len(chars)-1; {
+		delta := chars[i+1] - chars[i]
+		j := i + 1
+		if (delta == 1 || delta == -1) && sameClass(chars[i], chars[j]) {
+			for j+1 < len(chars) && chars[j+1]-chars[j] == delta && sameClass(chars[j], chars[j+1]) {
+				j++
+			}
+		}
+		if length := j - i + 1; length >= minMatchLength {
+			base := 26.0
+			switch {
+			case strings.ContainsRune("aAzZ019", chars[i]):
+				base = 4
+			case unicode.IsDigit(chars[i]):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			found = append(found, match{start: i, end: j + 1, guesses: base * float64(length)})
+			i = j + 1
+			continue
+		}
+		i++
+	}
+	return found
+}
+
+// repeatMatches finds a unit repeated two or more times, such as "aaa" or "abcabc".
+func repeatMatches(chars []rune, inputs map[string]int) []match {
+	var found []match
+	for i := 0; i < len(chars); i++ {
+		for unit := 1; i+2*unit <= len(chars); unit++ {
+			count := 1
+			for i+(count+1)*unit <= len(chars) && string(chars[i+count*unit:i+(count+1)*unit]) == string(chars[i:i+unit]) {
+				count++
+			}
+			if count < 2 || count*unit < minMatchLength {
+				continue
+			}
+			guesses := minGuesses(chars[i:i+unit], inputs) * float64(count)
+			found = append(found, match{start: i, end: i + count*unit, guesses: guesses})
+		}
+	}
+	return found
+}
+
+// keyboardMatches finds straight runs of at least four keys of a row of a QWERTY
+// keyboard, in either direction.
+func keyboardMatches(chars []rune) []match {
+	lower := []rune(strings.ToLower(string(chars)))
+
+	var keys float64
+	for _, row := range keyboardRows {
+		keys += float64(len(row))
+	}
+
+	var found []match
+	for _, row := range keyboardRows {
+		for _, line := range []string{row, reverse(row)} {
+			for i := 0; i < len(lower); i++ {
+				at := strings.IndexRune(line, lower[i])
+				if at < 0 {
+					continue
+				}
+				j := i + 1
+				for j < len(lower) && at+j-i < len(line) && rune(line[at+j-i]) == lower[j] {
+					j++
+				}
+				if length := j - i; length >= 4 {
+					found = append(found, match{start: i, end: j, guesses: keys * 2 * float64(length)})
+				}
+			}
+		}
+	}
+	return found
+}
+
+// yearMatches finds years from 1900 to 2099.
+func yearMatches(chars []rune) []match {
+	now := time.Now().Year()
+
+	var found []match
+	for i := 0; i+4 <= len(chars); i++ {
+		year := 0
+		for _, r := range chars[i : i+4] {
+			if !unicode.IsDigit(r) || r > '9' {
+				year = -1
+				break
+			}
+			year = year*10 + int(r-'0')
+		}
+		if year >= 1900 && year <= 2099 {
+			space := math.Max(math.Abs(float64(year-now)), minYearSpace)
+			found = append(found, match{start: i, end: i + 4, guesses: space})
+		}
+	}
+	return found
+}
+
+func sameClass(a, b rune) bool {
+	return unicode.IsDigit(a) && unicode.IsDigit(b) ||
+		unicode.IsLower(a) && unicode.IsLower(b) ||
+		unicode.IsUpper(a) && unicode.IsUpper(b)
+}
+
+func unl33t(word string) (string, int) {
+	subs := 0
+	plain := strings.Map(func(r rune) rune {
+		if p, ok := l33t[r]; ok {
+			subs++
+			return p
+		}
+		return r
+	}, word)
+	return plain, subs
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+func rank(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, word := range words {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}
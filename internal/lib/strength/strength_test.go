@@ -0,0 +1,51 @@
+package strength_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"passvault/internal/lib/strength"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		password   string
+		userInputs []string
+		score      int
+	}{
+		{password: "", score: strength.ScoreTooGuessable},
+		{password: "password", score: strength.ScoreTooGuessable},
+		{password: "Password", score: strength.ScoreTooGuessable},
+		{password: "P@ssw0rd", score: strength.ScoreTooGuessable},
+		{password: "drowssap", score: strength.ScoreTooGuessable},
+		{password: "qwerty123", score: strength.ScoreTooGuessable},
+		{password: "abcdefghijkl", score: strength.ScoreTooGuessable},
+		{password: "98765432", score: strength.ScoreTooGuessable},
+		{password: "aaaaaaaaaaaaaaaa", score: strength.ScoreTooGuessable},
+		{password: "1987", score: strength.ScoreTooGuessable},
+		{password: "dragon1987", score: strength.ScoreTooGuessable},
+		{password: "alice2024", userInputs: []string{"alice@example.com", "alice"}, score: strength.ScoreTooGuessable},
+		{password: "asdfghjkl;'", score: strength.ScoreVeryGuessable},
+		{password: "x7Kq9", score: strength.ScoreVeryGuessable},
+		{password: "alice2024", score: strength.ScoreSomewhatGuessable},
+		{password: "x7Kq9#mP", score: strength.ScoreSomewhatGuessable},
+		{password: "x7Kq9#mP2v", score: strength.ScoreSafelyUnguessable},
+		{password: "x7Kq9#mP2vL!", score: strength.ScoreVeryUnguessable},
+		{password: "correcthorsebatterystaple", score: strength.ScoreVeryUnguessable},
+		{password: strings.Repeat("x7Kq9#mP2vL!", 20), score: strength.ScoreVeryUnguessable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.password, func(t *testing.T) {
+			result := strength.Check(tc.password, tc.userInputs...)
+			require.Equal(t, tc.score, result.Score, "guesses 10^%.1f", result.GuessesLog10)
+		})
+	}
+}
+
+func TestCheckPatternsLowerGuesses(t *testing.T) {
+	random := strength.Check("kq9x7mpv")
+	for _, password := range []string{"password", "abcdefgh", "qwertyui", "kqkqkqkq", "kq9x1999"} {
+		require.Less(t, strength.Check(password).GuessesLog10, random.GuessesLog10, password)
+	}
+}
@@ -0,0 +1,182 @@
+// Package totp generates time-based one-time passwords as described in RFC 6238,
+// on top of the HMAC-based one-time passwords of RFC 4226.
+//
+// Keys are read from otpauth:// URIs, the format of the QR codes authenticator apps
+// scan, or from bare base32 secrets with the defaults of those apps: SHA1, 6 digits
+// and a 30 second period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmSHA1   = "SHA1"
+	AlgorithmSHA256 = "SHA256"
+	AlgorithmSHA512 = "SHA512"
+)
+
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30
+
+	MinDigits = 6
+	MaxDigits = 8
+	MaxPeriod = 3600
+)
+
+var ErrInvalidKey = errors.New("invalid TOTP key")
+
+// Key holds the shared secret and the parameters of a TOTP generator.
+type Key struct {
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	// Period is the number of seconds a code is valid for.
+	Period  int
+	Issuer  string
+	Account string
+}
+
+// Parse reads an otpauth://totp/ URI or a base32 secret. Parameters missing from
+// the URI take their default values.
+func Parse(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth:") {
+		secret, err := DecodeSecret(s)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return nil, fmt.Errorf("%w: only otpauth://totp/ URIs are supported", ErrInvalidKey)
+	}
+
+	query := u.Query()
+	secret, err := DecodeSecret(query.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}
+
+	// The label is "issuer:account" or just the account.
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return nil, fmt.Errorf("%w: invalid digits %q", ErrInvalidKey, digits)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil {
+			return nil, fmt.Errorf("%w: invalid period %q", ErrInvalidKey, period)
+		}
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DecodeSecret decodes a base32 secret, ignoring case, spaces and padding as
+// authenticator apps do.
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(s))
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty secret", ErrInvalidKey)
+	}
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret is not base32", ErrInvalidKey)
+	}
+	return secret, nil
+}
+
+// Validate checks the parameters of the key.
+func (k *Key) Validate() error {
+	if len(k.Secret) == 0 {
+		return fmt.Errorf("%w: empty secret", ErrInvalidKey)
+	}
+	if newHash(k.Algorithm) == nil {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidKey, k.Algorithm)
+	}
+	if k.Digits < MinDigits || k.Digits > MaxDigits {
+		return fmt.Errorf("%w: digits must be between %d and %d", ErrInvalidKey, MinDigits, MaxDigits)
+	}
+	if k.Period < 1 || k.Period > MaxPeriod {
+		return fmt.Errorf("%w: period must be between 1 and %d seconds", ErrInvalidKey, MaxPeriod)
+	}
+	return nil
+}
+
+// Code returns the code of the key at t.
+func (k *Key) Code(t time.Time) string {
+	return HOTP(k.Secret, uint64(t.Unix())/uint64(k.Period), k.Algorithm, k.Digits)
+}
+
+// Remaining returns how long the code at t stays valid.
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period)
+	return time.Duration(period-t.Unix()%period)*time.Second - time.Duration(t.Nanosecond())
+}
+
+// HOTP returns the RFC 4226 code of counter. The algorithm must be one of the
+// Algorithm constants.
+func HOTP(secret []byte, counter uint64, algorithm string, digits int) string {
+	mac := hmac.New(newHash(algorithm), secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+func newHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case AlgorithmSHA1:
+		return sha1.New
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	}
+	return nil
+}
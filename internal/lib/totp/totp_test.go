@@ -0,0 +1,101 @@
+package totp_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"passvault/internal/lib/totp"
+	"testing"
+	"time"
+)
+
+// TestRFC6238 checks the test vectors of RFC 6238, appendix B.
+func TestRFC6238(t *testing.T) {
+	secrets := map[string][]byte{
+		totp.AlgorithmSHA1:   []byte("12345678901234567890"),
+		totp.AlgorithmSHA256: []byte("12345678901234567890123456789012"),
+		totp.AlgorithmSHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+
+	vectors := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, totp.AlgorithmSHA1, "94287082"},
+		{59, totp.AlgorithmSHA256, "46119246"},
+		{59, totp.AlgorithmSHA512, "90693936"},
+		{1111111109, totp.AlgorithmSHA1, "07081804"},
+		{1111111109, totp.AlgorithmSHA256, "68084774"},
+		{1111111109, totp.AlgorithmSHA512, "25091201"},
+		{1111111111, totp.AlgorithmSHA1, "14050471"},
+		{1111111111, totp.AlgorithmSHA256, "67062674"},
+		{1111111111, totp.AlgorithmSHA512, "99943326"},
+		{1234567890, totp.AlgorithmSHA1, "89005924"},
+		{1234567890, totp.AlgorithmSHA256, "91819424"},
+		{1234567890, totp.AlgorithmSHA512, "93441116"},
+		{2000000000, totp.AlgorithmSHA1, "69279037"},
+		{2000000000, totp.AlgorithmSHA256, "90698825"},
+		{2000000000, totp.AlgorithmSHA512, "38618901"},
+		{20000000000, totp.AlgorithmSHA1, "65353130"},
+		{20000000000, totp.AlgorithmSHA256, "77737706"},
+		{20000000000, totp.AlgorithmSHA512, "47863826"},
+	}
+
+	for _, v := range vectors {
+		key := &totp.Key{Secret: secrets[v.algorithm], Algorithm: v.algorithm, Digits: 8, Period: 30}
+		require.NoError(t, key.Validate())
+		require.Equal(t, v.code, key.Code(time.Unix(v.unix, 0)), "%s at %d", v.algorithm, v.unix)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    *totp.Key
+		wantErr bool
+	}{
+		{
+			name:  "Base32 secret",
+			input: "gezd gnbv gy3t qojq",
+			want:  &totp.Key{Secret: []byte("1234567890"), Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "URI with defaults",
+			input: "otpauth://totp/Example:alice@example.com?secret=GEZDGNBVGY3TQOJQ&issuer=Example",
+			want: &totp.Key{Secret: []byte("1234567890"), Algorithm: "SHA1", Digits: 6, Period: 30,
+				Issuer: "Example", Account: "alice@example.com"},
+		},
+		{
+			name:  "URI with parameters",
+			input: "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&algorithm=sha256&digits=8&period=60",
+			want:  &totp.Key{Secret: []byte("1234567890"), Algorithm: "SHA256", Digits: 8, Period: 60, Account: "alice"},
+		},
+		{name: "Not base32", input: "not a secret!", wantErr: true},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "HOTP URI", input: "otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQ&counter=1", wantErr: true},
+		{name: "URI without secret", input: "otpauth://totp/alice", wantErr: true},
+		{name: "Unsupported algorithm", input: "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&algorithm=MD5", wantErr: true},
+		{name: "Too many digits", input: "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&digits=10", wantErr: true},
+		{name: "Zero period", input: "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&period=0", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := totp.Parse(tc.input)
+			if tc.wantErr {
+				require.ErrorIs(t, err, totp.ErrInvalidKey)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, key)
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key := &totp.Key{Secret: []byte("12345678901234567890"), Algorithm: totp.AlgorithmSHA1, Digits: 6, Period: 30}
+
+	require.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+	require.Equal(t, time.Second, key.Remaining(time.Unix(89, 0)))
+	require.Equal(t, 500*time.Millisecond, key.Remaining(time.Unix(89, 500_000_000)))
+}
@@ -0,0 +1,223 @@
+// Package backup writes encrypted backups of the SQLite vault and restores them.
+//
+// A backup is an online snapshot of the database, gzip compressed and encrypted with
+// age (https://age-encryption.org) to one or more public keys or a passphrase.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"filippo.io/age"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3"
+	"io"
+	"os"
+	"passvault/internal/storage/schema"
+	"passvault/migrations"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNoRecipients = errors.New("backups need a recipient or a passphrase")
+	ErrCorrupt      = errors.New("backup database is corrupt")
+	ErrNotVault     = errors.New("backup does not contain a passvault database")
+)
+
+// Snapshotter copies a consistent image of the database to a new file.
+type Snapshotter interface {
+	Snapshot(ctx context.Context, path string) error
+}
+
+// Recipients parses age public keys ("age1...") to encrypt backups to. Without keys the
+// backup is encrypted with passphrase, as age does not mix passphrases with public keys.
+func Recipients(keys []string, passphrase string) ([]age.Recipient, error) {
+	const op = "services.backup.Recipients"
+
+	if len(keys) == 0 {
+		if passphrase == "" {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoRecipients)
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	if passphrase != "" {
+		return nil, fmt.Errorf("%s: use either public keys or a passphrase", op)
+	}
+
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// Identities parses the age identities that decrypt a backup: the private keys in an age
+// identity file, or passphrase.
+func Identities(identityFile io.Reader, passphrase string) ([]age.Identity, error) {
+	const op = "services.backup.Identities"
+
+	var identities []age.Identity
+	if identityFile != nil {
+		parsed, err := age.ParseIdentities(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		identities = append(identities, parsed...)
+	}
+	if passphrase != "" {
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoRecipients)
+	}
+	return identities, nil
+}
+
+// Write snapshots the database and writes it to w compressed and encrypted to recipients.
+func Write(ctx context.Context, w io.Writer, db Snapshotter, recipients []age.Recipient) error {
+	const op = "services.backup.Write"
+
+	if len(recipients) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNoRecipients)
+	}
+
+	dir, err := os.MkdirTemp("", "passvault-backup-")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "passvault.db")
+	if err := db.Snapshot(ctx, snapshot); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	in, err := os.Open(snapshot)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer in.Close()
+
+	encrypted, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	compressed := gzip.NewWriter(encrypted)
+	if _, err := io.Copy(compressed, in); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := compressed.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := encrypted.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Restore decrypts the backup in r and replaces the database at path with it. The
+// restored database must pass an integrity check and have a schema version this binary
+// can migrate; otherwise the database at path is left alone. The replaced database, if
+// any, is kept next to it with a ".pre-restore" suffix. It returns the schema version
+// of the restored database. The server must not be running on path.
+func Restore(r io.Reader, identities []age.Identity, path string) (uint, error) {
+	const op = "services.backup.Restore"
+
+	decrypted, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	decompressed, err := gzip.NewReader(decrypted)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The restored file is written next to the database so the final rename stays on one filesystem.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".restore-")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, decompressed)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, err := check(tmp.Name())
+	if err != nil {
+		return version, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := replace(tmp.Name(), path); err != nil {
+		return version, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, nil
+}
+
+// check verifies the integrity and schema version of the database at path.
+func check(path string) (uint, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("%w: %s", ErrCorrupt, result)
+	}
+
+	m, err := schema.NewSQLite(path, schema.DefaultTable, migrations.SQLite)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	version, err := m.Check()
+	if err != nil {
+		return version, err
+	}
+	if version == 0 {
+		return 0, ErrNotVault
+	}
+	return version, nil
+}
+
+// replace moves the database at src to dst. The database at dst and its journal files
+// are moved aside first, since a stale write-ahead log would be replayed into src.
+func replace(src, dst string) error {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		aside := dst + ".pre-restore" + suffix
+		if err := os.Remove(aside); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Rename(dst+suffix, aside); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(src, dst)
+}
@@ -0,0 +1,215 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/backup"
+	"passvault/internal/storage"
+	"passvault/internal/storage/schema"
+	"passvault/internal/storage/sqlite"
+	"passvault/migrations"
+	"path/filepath"
+	"testing"
+)
+
+// newVault creates a migrated SQLite vault holding one entry with data.
+func newVault(t *testing.T, path string, data string) *sqlite.Storage {
+	m, err := schema.NewSQLite(path, schema.DefaultTable, migrations.SQLite)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	db, err := sqlite.New(path, storage.RevisionRetention{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.SaveEntry(context.Background(), 1, models.EntryTypeSecureNote, data)
+	require.NoError(t, err)
+	return db
+}
+
+func entryData(t *testing.T, path string) string {
+	db, err := sqlite.New(path, storage.RevisionRetention{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	entry, err := db.GetEntry(context.Background(), 1, 1)
+	require.NoError(t, err)
+	return entry.EntryData
+}
+
+func newIdentity(t *testing.T) *age.X25519Identity {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	return identity
+}
+
+func TestWriteAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	db := newVault(t, filepath.Join(dir, "source.db"), "backed up")
+	identity := newIdentity(t)
+
+	var archive bytes.Buffer
+	require.NoError(t, backup.Write(context.Background(), &archive, db, []age.Recipient{identity.Recipient()}))
+	require.NotContains(t, archive.String(), "backed up")
+
+	target := filepath.Join(dir, "target.db")
+	newVault(t, target, "replaced").Close()
+
+	version, err := backup.Restore(&archive, []age.Identity{identity}, target)
+	require.NoError(t, err)
+	require.Equal(t, uint(13), version)
+
+	require.Equal(t, "backed up", entryData(t, target))
+	require.Equal(t, "replaced", entryData(t, target+".pre-restore"))
+}
+
+func TestPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	db := newVault(t, filepath.Join(dir, "source.db"), "backed up")
+
+	recipient, err := age.NewScryptRecipient("correct horse")
+	require.NoError(t, err)
+	// Keep scrypt cheap in tests.
+	recipient.SetWorkFactor(10)
+
+	var archive bytes.Buffer
+	require.NoError(t, backup.Write(context.Background(), &archive, db, []age.Recipient{recipient}))
+
+	wrong, err := backup.Identities(nil, "battery staple")
+	require.NoError(t, err)
+	target := filepath.Join(dir, "target.db")
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), wrong, target)
+	require.Error(t, err)
+	require.NoFileExists(t, target)
+
+	right, err := backup.Identities(nil, "correct horse")
+	require.NoError(t, err)
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), right, target)
+	require.NoError(t, err)
+	require.Equal(t, "backed up", entryData(t, target))
+}
+
+func TestRestoreLeavesDatabaseOnFailure(t *testing.T) {
+	identity := newIdentity(t)
+
+	tests := []struct {
+		name    string
+		archive func(t *testing.T, dir string) io.Reader
+		wantErr error
+	}{
+		{
+			name: "newer schema",
+			archive: func(t *testing.T, dir string) io.Reader {
+				path := filepath.Join(dir, "newer.db")
+				db := newVault(t, path, "from the future")
+				m, err := schema.NewSQLite(path, schema.DefaultTable, migrations.SQLite)
+				require.NoError(t, err)
+				require.NoError(t, m.Force(m.Latest()+1))
+				require.NoError(t, m.Close())
+				return write(t, db, identity)
+			},
+			wantErr: schema.ErrTooNew,
+		},
+		{
+			name: "not a vault",
+			archive: func(t *testing.T, dir string) io.Reader {
+				db, err := sqlite.New(filepath.Join(dir, "empty.db"), storage.RevisionRetention{})
+				require.NoError(t, err)
+				defer db.Close()
+				return write(t, db, identity)
+			},
+			wantErr: backup.ErrNotVault,
+		},
+		{
+			name: "truncated",
+			archive: func(t *testing.T, dir string) io.Reader {
+				archive := write(t, newVault(t, filepath.Join(dir, "source.db"), "cut"), identity)
+				return io.LimitReader(archive, int64(archive.Len()/2))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "target.db")
+			newVault(t, target, "kept").Close()
+
+			_, err := backup.Restore(tt.archive(t, dir), []age.Identity{identity}, target)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+
+			require.Equal(t, "kept", entryData(t, target))
+			require.NoFileExists(t, target+".pre-restore")
+
+			// No temporary files are left next to the database.
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			for _, file := range files {
+				require.NotContains(t, file.Name(), ".restore-")
+			}
+		})
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	identity := newIdentity(t)
+
+	recipients, err := backup.Recipients([]string{identity.Recipient().String()}, "")
+	require.NoError(t, err)
+	require.Len(t, recipients, 1)
+
+	_, err = backup.Recipients(nil, "")
+	require.ErrorIs(t, err, backup.ErrNoRecipients)
+	_, err = backup.Recipients([]string{identity.Recipient().String()}, "passphrase")
+	require.Error(t, err)
+	_, err = backup.Recipients([]string{"age1invalid"}, "")
+	require.Error(t, err)
+}
+
+func TestSchedulerKeepsNewestBackups(t *testing.T) {
+	dir := t.TempDir()
+	db := newVault(t, filepath.Join(dir, "source.db"), "scheduled")
+	identity := newIdentity(t)
+
+	backups := filepath.Join(dir, "backups")
+	scheduler := backup.NewScheduler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, []age.Recipient{identity.Recipient()}, backups, 0, 2)
+
+	var paths []string
+	for range 3 {
+		path, err := scheduler.Backup(context.Background())
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+
+	files, err := os.ReadDir(backups)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.NoFileExists(t, paths[0])
+	require.FileExists(t, paths[2])
+
+	f, err := os.Open(paths[2])
+	require.NoError(t, err)
+	defer f.Close()
+
+	target := filepath.Join(dir, "target.db")
+	_, err = backup.Restore(f, []age.Identity{identity}, target)
+	require.NoError(t, err)
+	require.Equal(t, "scheduled", entryData(t, target))
+}
+
+func write(t *testing.T, db backup.Snapshotter, identity *age.X25519Identity) *bytes.Buffer {
+	var archive bytes.Buffer
+	require.NoError(t, backup.Write(context.Background(), &archive, db, []age.Recipient{identity.Recipient()}))
+	return &archive
+}
@@ -0,0 +1,136 @@
+package backup
+
+import (
+	"context"
+	"filippo.io/age"
+	"fmt"
+	"log/slog"
+	"os"
+	"passvault/internal/lib/logger/sl"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix = "passvault-"
+	fileSuffix = ".db.gz.age"
+	// fileTime names backups so that they sort by the time they were taken.
+	fileTime = "20060102T150405.000Z"
+)
+
+// Scheduler writes a backup into a directory at a fixed interval and keeps the newest ones.
+type Scheduler struct {
+	log        *slog.Logger
+	db         Snapshotter
+	recipients []age.Recipient
+	dir        string
+	interval   time.Duration
+	keep       int
+}
+
+// NewScheduler returns a scheduler writing backups of db into dir every interval.
+// Only the newest keep backups are kept; zero keeps them all.
+func NewScheduler(log *slog.Logger, db Snapshotter, recipients []age.Recipient, dir string, interval time.Duration, keep int) *Scheduler {
+	return &Scheduler{
+		log:        log,
+		db:         db,
+		recipients: recipients,
+		dir:        dir,
+		interval:   interval,
+		keep:       keep,
+	}
+}
+
+// Run writes backups until ctx is done. Failed backups are logged and retried at the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	const op = "services.backup.Run"
+
+	log := s.log.With(slog.String("op", op), slog.String("dir", s.dir))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path, err := s.Backup(ctx)
+			if err != nil {
+				log.Error("failed to back up storage", sl.Err(err))
+				continue
+			}
+			log.Info("storage backed up", slog.String("path", path))
+		}
+	}
+}
+
+// Backup writes a backup into the directory, removes the ones beyond the kept count
+// and returns the path of the new backup.
+func (s *Scheduler) Backup(ctx context.Context) (string, error) {
+	const op = "services.backup.Backup"
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	path := filepath.Join(s.dir, filePrefix+time.Now().UTC().Format(fileTime)+fileSuffix)
+
+	// Backups are written under a temporary name, so a crash never leaves a truncated
+	// file that looks like the newest backup.
+	tmp, err := os.CreateTemp(s.dir, ".backup-")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = Write(ctx, tmp, s.db, s.recipients)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.rotate(); err != nil {
+		return path, fmt.Errorf("%s: %w", op, err)
+	}
+	return path, nil
+}
+
+// rotate removes all but the newest kept backups.
+func (s *Scheduler) rotate() error {
+	if s.keep <= 0 {
+		return nil
+	}
+
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, file := range files {
+		name := file.Name()
+		if file.Type().IsRegular() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= s.keep {
+		return nil
+	}
+
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-s.keep] {
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Package breach checks passwords against a local index of breached password
+// hashes built by cmd/passvault-breach-index, so no password or hash prefix ever
+// leaves the server.
+package breach
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/pkg/vaultcrypto"
+)
+
+var ErrNotConfigured = errors.New("breach corpus is not configured")
+
+type Index interface {
+	ContainsPassword(password string) (bool, error)
+}
+
+type Service struct {
+	log   *slog.Logger
+	index Index
+}
+
+// New returns a service looking passwords up in index. A nil index disables the
+// checks: Check fails with ErrNotConfigured and CheckEntry finds nothing.
+func New(log *slog.Logger, index Index) *Service {
+	return &Service{
+		log:   log,
+		index: index,
+	}
+}
+
+// Enabled reports whether a breach corpus is loaded.
+func (s *Service) Enabled() bool {
+	return s.index != nil
+}
+
+// Check reports whether password appears in the breach corpus.
+func (s *Service) Check(password string) (bool, error) {
+	const op = "services.breach.Check"
+
+	if s.index == nil {
+		return false, fmt.Errorf("%s: %w", op, ErrNotConfigured)
+	}
+
+	breached, err := s.index.ContainsPassword(password)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return breached, nil
+}
+
+// CheckEntry reports whether the password of a login entry appears in the breach
+// corpus. Other entry types, logins without a password, entries encrypted by a
+// zero-knowledge client and a disabled service never report a breach.
+func (s *Service) CheckEntry(entryType, entryData string) (bool, error) {
+	const op = "services.breach.CheckEntry"
+
+	if s.index == nil || entryType != models.EntryTypeLogin || vaultcrypto.IsCiphertext(entryData) {
+		return false, nil
+	}
+
+	var login struct {
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal([]byte(entryData), &login); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if login.Password == "" {
+		return false, nil
+	}
+
+	breached, err := s.index.ContainsPassword(login.Password)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return breached, nil
+}
@@ -0,0 +1,91 @@
+package breach_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"passvault/internal/domain/models"
+	breachindex "passvault/internal/lib/breach"
+	"passvault/internal/services/breach"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newService(t *testing.T) *breach.Service {
+	hashes := [][]byte{}
+	for _, password := range []string{"hunter2", "password"} {
+		hash := breachindex.Hash(password)
+		hashes = append(hashes, hash[:])
+	}
+	if bytes.Compare(hashes[0], hashes[1]) > 0 {
+		hashes[0], hashes[1] = hashes[1], hashes[0]
+	}
+
+	var buf bytes.Buffer
+	w := breachindex.NewWriter(&buf)
+	for _, hash := range hashes {
+		require.NoError(t, w.Add([20]byte(hash)))
+	}
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "breach.idx")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	index, err := breachindex.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { index.Close() })
+
+	return breach.New(slog.New(slog.NewTextHandler(io.Discard, nil)), index)
+}
+
+func TestCheck(t *testing.T) {
+	service := newService(t)
+	require.True(t, service.Enabled())
+
+	breached, err := service.Check("hunter2")
+	require.NoError(t, err)
+	require.True(t, breached)
+
+	breached, err = service.Check("correct horse battery staple")
+	require.NoError(t, err)
+	require.False(t, breached)
+}
+
+func TestCheckEntry(t *testing.T) {
+	service := newService(t)
+
+	tests := []struct {
+		name      string
+		entryType string
+		entryData string
+		breached  bool
+	}{
+		{"breached login", models.EntryTypeLogin, `{"title":"mail","password":"password"}`, true},
+		{"safe login", models.EntryTypeLogin, `{"title":"mail","password":"v3ry-uncommon-s3cret"}`, false},
+		{"login without password", models.EntryTypeLogin, `{"title":"mail"}`, false},
+		{"client ciphertext", models.EntryTypeLogin, "zk1:" + strings.Repeat("A", 40), false},
+		{"note", models.EntryTypeSecureNote, `{"title":"note","content":"password"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breached, err := service.CheckEntry(tt.entryType, tt.entryData)
+			require.NoError(t, err)
+			require.Equal(t, tt.breached, breached)
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	service := breach.New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	require.False(t, service.Enabled())
+
+	_, err := service.Check("hunter2")
+	require.ErrorIs(t, err, breach.ErrNotConfigured)
+
+	breached, err := service.CheckEntry(models.EntryTypeLogin, `{"title":"mail","password":"hunter2"}`)
+	require.NoError(t, err)
+	require.False(t, breached)
+}
@@ -0,0 +1,331 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"passvault/internal/domain/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bitwarden item types.
+const (
+	bitwardenTypeLogin      = 1
+	bitwardenTypeSecureNote = 2
+	bitwardenTypeCard       = 3
+	bitwardenTypeIdentity   = 4
+	bitwardenTypeSSHKey     = 5
+)
+
+// Bitwarden custom field types.
+const (
+	bitwardenFieldText   = 0
+	bitwardenFieldHidden = 1
+)
+
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+type bitwardenItem struct {
+	ID         string               `json:"id"`
+	FolderID   *string              `json:"folderId"`
+	Type       int                  `json:"type"`
+	Name       string               `json:"name"`
+	Notes      *string              `json:"notes"`
+	Favorite   bool                 `json:"favorite"`
+	Fields     []bitwardenField     `json:"fields,omitempty"`
+	Login      *bitwardenLogin      `json:"login,omitempty"`
+	SecureNote *bitwardenSecureNote `json:"secureNote,omitempty"`
+	Card       map[string]string    `json:"card,omitempty"`
+	Identity   map[string]string    `json:"identity,omitempty"`
+	SSHKey     map[string]string    `json:"sshKey,omitempty"`
+}
+
+type bitwardenLogin struct {
+	URIs     []bitwardenURI `json:"uris"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	TOTP     string         `json:"totp"`
+}
+
+type bitwardenSecureNote struct {
+	Type int `json:"type"`
+}
+
+// entryData is the decoded entry_data of an entry.
+type entryData map[string]any
+
+func decodeEntryData(entry Entry) entryData {
+	var data entryData
+	if err := json.Unmarshal(entry.Data, &data); err != nil || data == nil {
+		// Entry data that is not an object is kept as the content of a note.
+		var text string
+		json.Unmarshal(entry.Data, &text)
+		return entryData{"content": text}
+	}
+	return data
+}
+
+func (d entryData) str(name string) string {
+	switch v := d[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (d entryData) list(name string) []string {
+	values, _ := d[name].([]any)
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok && s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// title returns the title of an entry, falling back to its type and ID for entries
+// saved before titles were required.
+func (d entryData) title(entry Entry) string {
+	if title := d.str("title"); title != "" {
+		return title
+	}
+	return fmt.Sprintf("%s %d", entry.EntryType, entry.ID)
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// folderPaths names folders by their path, as Bitwarden nests folders by name.
+func folderPaths(folders []Folder) map[int64]string {
+	byID := make(map[int64]Folder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+	}
+
+	paths := make(map[int64]string, len(folders))
+	for _, folder := range folders {
+		names := []string{folder.Name}
+		seen := map[int64]bool{folder.ID: true}
+		for parent := folder.ParentID; parent != nil && !seen[*parent]; {
+			p, ok := byID[*parent]
+			if !ok {
+				break
+			}
+			seen[p.ID] = true
+			names = append([]string{p.Name}, names...)
+			parent = p.ParentID
+		}
+		paths[folder.ID] = strings.Join(names, "/")
+	}
+	return paths
+}
+
+// fields turns the named fields of an entry into custom fields, for the fields
+// Bitwarden has no place for.
+func (d entryData) fields(hidden map[string]bool, names ...string) []bitwardenField {
+	var fields []bitwardenField
+	for _, name := range names {
+		if value := d.str(name); value != "" {
+			fieldType := bitwardenFieldText
+			if hidden[name] {
+				fieldType = bitwardenFieldHidden
+			}
+			fields = append(fields, bitwardenField{Name: name, Value: value, Type: fieldType})
+		}
+	}
+	return fields
+}
+
+func bitwardenEntry(entry Entry, folders map[int64]string) bitwardenItem {
+	data := decodeEntryData(entry)
+	item := bitwardenItem{
+		ID:    strconv.FormatInt(entry.ID, 10),
+		Name:  data.title(entry),
+		Notes: optional(data.str("notes")),
+	}
+	if entry.FolderID != nil {
+		if _, ok := folders[*entry.FolderID]; ok {
+			item.FolderID = optional(strconv.FormatInt(*entry.FolderID, 10))
+		}
+	}
+
+	switch entry.EntryType {
+	case models.EntryTypeLogin:
+		item.Type = bitwardenTypeLogin
+		item.Login = &bitwardenLogin{
+			URIs:     []bitwardenURI{},
+			Username: data.str("username"),
+			Password: data.str("password"),
+			TOTP:     data.str("totp"),
+		}
+		for _, uri := range data.list("uris") {
+			item.Login.URIs = append(item.Login.URIs, bitwardenURI{URI: uri})
+		}
+	case models.EntryTypeTOTP:
+		// Bitwarden keeps authenticator keys in logins.
+		item.Type = bitwardenTypeLogin
+		item.Login = &bitwardenLogin{URIs: []bitwardenURI{}, Username: data.str("account"), TOTP: data.str("secret")}
+		item.Fields = data.fields(nil, "issuer", "algorithm", "digits", "period")
+	case models.EntryTypeAPIToken:
+		// Bitwarden keeps API tokens as logins.
+		item.Type = bitwardenTypeLogin
+		item.Login = &bitwardenLogin{URIs: []bitwardenURI{}, Password: data.str("token")}
+		item.Fields = data.fields(nil, "service", "expires_at")
+	case models.EntryTypeCreditCard:
+		item.Type = bitwardenTypeCard
+		item.Card = map[string]string{
+			"cardholderName": data.str("cardholder_name"),
+			"brand":          data.str("brand"),
+			"number":         data.str("number"),
+			"expMonth":       data.str("exp_month"),
+			"expYear":        data.str("exp_year"),
+			"code":           data.str("cvv"),
+		}
+	case models.EntryTypeIdentity:
+		item.Type = bitwardenTypeIdentity
+		item.Identity = map[string]string{
+			"firstName":      data.str("first_name"),
+			"lastName":       data.str("last_name"),
+			"email":          data.str("email"),
+			"phone":          data.str("phone"),
+			"address1":       data.str("address"),
+			"company":        data.str("company"),
+			"passportNumber": data.str("passport_number"),
+			"licenseNumber":  data.str("license_number"),
+		}
+	case models.EntryTypeSSHKey:
+		item.Type = bitwardenTypeSSHKey
+		item.SSHKey = map[string]string{
+			"privateKey":     data.str("private_key"),
+			"publicKey":      data.str("public_key"),
+			"keyFingerprint": data.str("fingerprint"),
+		}
+		item.Fields = data.fields(map[string]bool{"passphrase": true}, "passphrase")
+	default:
+		// The content of a note is its Bitwarden notes; notes of its own become a field.
+		item.Type = bitwardenTypeSecureNote
+		item.SecureNote = &bitwardenSecureNote{}
+		item.Notes = optional(data.str("content"))
+		item.Fields = data.fields(nil, "notes")
+	}
+
+	return item
+}
+
+// writeBitwarden writes the unencrypted JSON export of Bitwarden. Tags have no
+// equivalent in Bitwarden and are left out.
+func writeBitwarden(w io.Writer, archive *Archive) error {
+	paths := folderPaths(archive.Folders)
+
+	export := bitwardenExport{
+		Folders: make([]bitwardenFolder, 0, len(archive.Folders)),
+		Items:   make([]bitwardenItem, 0, len(archive.Entries)),
+	}
+	for _, folder := range archive.Folders {
+		export.Folders = append(export.Folders, bitwardenFolder{
+			ID:   strconv.FormatInt(folder.ID, 10),
+			Name: paths[folder.ID],
+		})
+	}
+	for _, entry := range archive.Entries {
+		export.Items = append(export.Items, bitwardenEntry(entry, paths))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// csvHeader is the header of the CSV export of Bitwarden.
+var csvHeader = []string{"folder", "favorite", "type", "name", "notes", "fields", "reprompt", "login_uri", "login_username", "login_password", "login_totp"}
+
+// writeCSV writes the CSV export of Bitwarden. It only knows logins and notes, so the
+// fields of other entry types are written as the custom fields of a note.
+func writeCSV(w io.Writer, archive *Archive) error {
+	paths := folderPaths(archive.Folders)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range archive.Entries {
+		item := bitwardenEntry(entry, paths)
+
+		var folder string
+		if entry.FolderID != nil {
+			folder = paths[*entry.FolderID]
+		}
+		var notes string
+		if item.Notes != nil {
+			notes = *item.Notes
+		}
+
+		fields := item.Fields
+		for _, values := range []map[string]string{item.Card, item.Identity, item.SSHKey} {
+			names := make([]string, 0, len(values))
+			for name := range values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if values[name] != "" {
+					fields = append(fields, bitwardenField{Name: name, Value: values[name]})
+				}
+			}
+		}
+		lines := make([]string, 0, len(fields))
+		for _, field := range fields {
+			lines = append(lines, field.Name+": "+field.Value)
+		}
+
+		record := []string{folder, "", "note", item.Name, notes, strings.Join(lines, "\n"), "0", "", "", "", ""}
+		if item.Login != nil {
+			uris := make([]string, 0, len(item.Login.URIs))
+			for _, uri := range item.Login.URIs {
+				uris = append(uris, uri.URI)
+			}
+			record[2] = "login"
+			record[7] = strings.Join(uris, ",")
+			record[8] = item.Login.Username
+			record[9] = item.Login.Password
+			record[10] = item.Login.TOTP
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
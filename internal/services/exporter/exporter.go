@@ -0,0 +1,268 @@
+// Package exporter writes the personal vault of an account out of the service.
+//
+// The default export is an archive encrypted with a passphrase: an age file
+// (https://age-encryption.org) with an scrypt recipient holding one JSON Archive
+// document, which "age --decrypt" opens. The document looks like
+//
+//	{
+//	  "version": 1,
+//	  "exported_at": "2026-01-02T15:04:05Z",
+//	  "account_id": 1,
+//	  "folders": [{"id": 1, "parent_id": null, "name": "Work", "created_at": "...", "updated_at": "..."}],
+//	  "tags": [{"id": 1, "name": "urgent"}],
+//	  "entries": [{"id": 1, "entry_type": "login", "data": {"title": "mail", ...},
+//	    "folder_id": 1, "tag_ids": [1], "created_at": "...", "updated_at": "..."}]
+//	}
+//
+// where data is the entry_data of the entry as described by internal/lib/entryschema.
+//
+// Unencrypted exports in the JSON and CSV formats of Bitwarden are written only when
+// the caller confirms it wants the secrets in plain text.
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"filippo.io/age"
+	"fmt"
+	"io"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/pkg/vaultcrypto"
+	"sort"
+	"time"
+)
+
+const (
+	FormatEncrypted = "passvault"
+	FormatBitwarden = "bitwarden"
+	FormatCSV       = "csv"
+)
+
+// ArchiveVersion is the version of the Archive document written by this package.
+const ArchiveVersion = 1
+
+var (
+	ErrUnknownFormat      = errors.New("unknown export format")
+	ErrPassphraseRequired = errors.New("passphrase required to encrypt the export")
+	ErrNotConfirmed       = errors.New("plain text exports must be confirmed")
+	ErrClientEncrypted    = errors.New("entries encrypted by the client cannot be exported in plain text")
+)
+
+// Formats returns the supported export formats.
+func Formats() []string {
+	return []string{FormatEncrypted, FormatBitwarden, FormatCSV}
+}
+
+// Plain reports whether exports in format hold the secrets in plain text.
+func Plain(format string) bool {
+	return format == FormatBitwarden || format == FormatCSV
+}
+
+// Archive is the document inside encrypted exports.
+type Archive struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	AccountID  int64     `json:"account_id"`
+	Folders    []Folder  `json:"folders"`
+	Tags       []Tag     `json:"tags"`
+	Entries    []Entry   `json:"entries"`
+}
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Entry struct {
+	ID        int64           `json:"id"`
+	EntryType string          `json:"entry_type"`
+	Data      json.RawMessage `json:"data"`
+	FolderID  *int64          `json:"folder_id,omitempty"`
+	TagIDs    []int64         `json:"tag_ids,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Options select the format of an export.
+type Options struct {
+	Format string
+	// Passphrase encrypts FormatEncrypted exports.
+	Passphrase string
+	// ConfirmPlain has to be set for the plain text formats.
+	ConfirmPlain bool
+}
+
+type VaultReader interface {
+	ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error)
+	ListFolders(ctx context.Context, accountID int64) ([]models.Folder, error)
+	ListTags(ctx context.Context, accountID int64) ([]models.Tag, error)
+}
+
+type Service struct {
+	log         *slog.Logger
+	vaultReader VaultReader
+}
+
+func New(log *slog.Logger, vaultReader VaultReader) *Service {
+	return &Service{
+		log:         log,
+		vaultReader: vaultReader,
+	}
+}
+
+// Export writes the personal entries, folders and tags of the account to w in the
+// format of opts. Nothing is written when the options are refused.
+func (s *Service) Export(ctx context.Context, accountID int64, w io.Writer, opts Options) error {
+	const op = "services.exporter.Export"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID), slog.String("format", opts.Format))
+
+	switch {
+	case opts.Format == FormatEncrypted:
+		if opts.Passphrase == "" {
+			return fmt.Errorf("%s: %w", op, ErrPassphraseRequired)
+		}
+	case Plain(opts.Format):
+		if !opts.ConfirmPlain {
+			return fmt.Errorf("%s: %w", op, ErrNotConfirmed)
+		}
+	default:
+		return fmt.Errorf("%s: %w: %q", op, ErrUnknownFormat, opts.Format)
+	}
+
+	archive, err := s.archive(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The server cannot read entries of zero-knowledge accounts; encrypted archives
+	// carry their ciphertext as is, plain formats would lose it.
+	if Plain(opts.Format) {
+		for _, entry := range archive.Entries {
+			var data string
+			if json.Unmarshal(entry.Data, &data) == nil && vaultcrypto.IsCiphertext(data) {
+				return fmt.Errorf("%s: %w", op, ErrClientEncrypted)
+			}
+		}
+	}
+
+	switch opts.Format {
+	case FormatEncrypted:
+		err = writeEncrypted(w, archive, opts.Passphrase)
+	case FormatBitwarden:
+		err = writeBitwarden(w, archive)
+	case FormatCSV:
+		err = writeCSV(w, archive)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("vault exported", slog.Int("entries", len(archive.Entries)))
+
+	return nil
+}
+
+func (s *Service) archive(ctx context.Context, accountID int64) (*Archive, error) {
+	entries, err := s.vaultReader.ListEntries(ctx, accountID, models.EntryFilter{})
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.vaultReader.ListFolders(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.vaultReader.ListTags(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		AccountID:  accountID,
+		Folders:    make([]Folder, 0, len(folders)),
+		Tags:       make([]Tag, 0, len(tags)),
+		Entries:    make([]Entry, 0, len(entries)),
+	}
+	for _, folder := range folders {
+		archive.Folders = append(archive.Folders, Folder{
+			ID:        folder.ID,
+			ParentID:  folder.ParentID,
+			Name:      folder.Name,
+			CreatedAt: folder.CreatedAt,
+			UpdatedAt: folder.UpdatedAt,
+		})
+	}
+	for _, tag := range tags {
+		archive.Tags = append(archive.Tags, Tag{ID: tag.ID, Name: tag.Name})
+	}
+	for _, entry := range entries {
+		data := json.RawMessage(entry.EntryData)
+		if !json.Valid(data) {
+			// Entries saved before entry_data was validated may hold any text.
+			data, _ = json.Marshal(entry.EntryData)
+		}
+		archive.Entries = append(archive.Entries, Entry{
+			ID:        entry.ID,
+			EntryType: entry.EntryType,
+			Data:      data,
+			FolderID:  entry.FolderID,
+			TagIDs:    entry.TagIDs,
+			CreatedAt: entry.CreatedAt,
+			UpdatedAt: entry.UpdatedAt,
+		})
+	}
+
+	sort.Slice(archive.Folders, func(i, j int) bool { return archive.Folders[i].ID < archive.Folders[j].ID })
+	sort.Slice(archive.Tags, func(i, j int) bool { return archive.Tags[i].ID < archive.Tags[j].ID })
+	sort.Slice(archive.Entries, func(i, j int) bool { return archive.Entries[i].ID < archive.Entries[j].ID })
+
+	return archive, nil
+}
+
+func writeEncrypted(w io.Writer, archive *Archive, passphrase string) error {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := age.Encrypt(w, recipient)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(encrypted).Encode(archive); err != nil {
+		return err
+	}
+	return encrypted.Close()
+}
+
+// Open decrypts an encrypted export with passphrase and returns its archive.
+func Open(r io.Reader, passphrase string) (*Archive, error) {
+	const op = "services.exporter.Open"
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	decrypted, err := age.Decrypt(r, identity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var archive Archive
+	if err := json.NewDecoder(decrypted).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &archive, nil
+}
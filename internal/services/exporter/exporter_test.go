@@ -0,0 +1,215 @@
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/importer"
+	"passvault/internal/storage"
+	"passvault/internal/storage/memory"
+	"strings"
+	"testing"
+)
+
+const accountID = 1
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newVault returns a vault with a login in a nested folder, a tagged note and a card.
+func newVault(t *testing.T) *memory.Storage {
+	ctx := context.Background()
+	db := memory.New(storage.RevisionRetention{})
+
+	work, err := db.CreateFolder(ctx, accountID, nil, "Work")
+	require.NoError(t, err)
+	mail, err := db.CreateFolder(ctx, accountID, &work, "Mail")
+	require.NoError(t, err)
+	tag, err := db.CreateTag(ctx, accountID, "urgent")
+	require.NoError(t, err)
+
+	login, err := db.SaveEntry(ctx, accountID, models.EntryTypeLogin,
+		`{"title":"Mail","username":"alice","password":"s3cret","uris":["https://mail.example.com"]}`)
+	require.NoError(t, err)
+	require.NoError(t, db.SetEntryFolder(ctx, accountID, login, &mail))
+
+	note, err := db.SaveEntry(ctx, accountID, models.EntryTypeSecureNote, `{"title":"Wifi","content":"hunter2"}`)
+	require.NoError(t, err)
+	require.NoError(t, db.SetEntryTags(ctx, accountID, note, []int64{tag}))
+
+	_, err = db.SaveEntry(ctx, accountID, models.EntryTypeCreditCard,
+		`{"title":"Visa","number":"4111111111111111","exp_month":12,"exp_year":2030,"cvv":"123"}`)
+	require.NoError(t, err)
+
+	// Entries of other accounts are never exported.
+	_, err = db.SaveEntry(ctx, accountID+1, models.EntryTypeSecureNote, `{"title":"Other","content":"secret"}`)
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestExportEncrypted(t *testing.T) {
+	service := exporter.New(discard(), newVault(t))
+
+	var out bytes.Buffer
+	err := service.Export(context.Background(), accountID, &out, exporter.Options{
+		Format:     exporter.FormatEncrypted,
+		Passphrase: "correct horse",
+	})
+	require.NoError(t, err)
+	require.NotContains(t, out.String(), "s3cret")
+
+	_, err = exporter.Open(bytes.NewReader(out.Bytes()), "wrong horse")
+	require.Error(t, err)
+
+	archive, err := exporter.Open(bytes.NewReader(out.Bytes()), "correct horse")
+	require.NoError(t, err)
+	require.Equal(t, exporter.ArchiveVersion, archive.Version)
+	require.Equal(t, int64(accountID), archive.AccountID)
+
+	require.Len(t, archive.Folders, 2)
+	require.Equal(t, "Work", archive.Folders[0].Name)
+	require.Equal(t, &archive.Folders[0].ID, archive.Folders[1].ParentID)
+	require.Equal(t, []exporter.Tag{{ID: archive.Tags[0].ID, Name: "urgent"}}, archive.Tags)
+
+	require.Len(t, archive.Entries, 3)
+	require.Equal(t, models.EntryTypeLogin, archive.Entries[0].EntryType)
+	require.Equal(t, &archive.Folders[1].ID, archive.Entries[0].FolderID)
+	require.JSONEq(t,
+		`{"title":"Mail","username":"alice","password":"s3cret","uris":["https://mail.example.com"]}`,
+		string(archive.Entries[0].Data))
+	require.Equal(t, []int64{archive.Tags[0].ID}, archive.Entries[1].TagIDs)
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts exporter.Options
+		err  error
+	}{
+		{
+			name: "missing passphrase",
+			opts: exporter.Options{Format: exporter.FormatEncrypted},
+			err:  exporter.ErrPassphraseRequired,
+		},
+		{
+			name: "bitwarden not confirmed",
+			opts: exporter.Options{Format: exporter.FormatBitwarden},
+			err:  exporter.ErrNotConfirmed,
+		},
+		{
+			name: "csv not confirmed",
+			opts: exporter.Options{Format: exporter.FormatCSV, Passphrase: "correct horse"},
+			err:  exporter.ErrNotConfirmed,
+		},
+		{
+			name: "unknown format",
+			opts: exporter.Options{Format: "xml", ConfirmPlain: true},
+			err:  exporter.ErrUnknownFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := exporter.New(discard(), newVault(t))
+
+			var out bytes.Buffer
+			err := service.Export(context.Background(), accountID, &out, tt.opts)
+			require.ErrorIs(t, err, tt.err)
+			require.Zero(t, out.Len())
+		})
+	}
+}
+
+func TestExportClientEncrypted(t *testing.T) {
+	vault := newVault(t)
+	_, err := vault.SaveEntry(context.Background(), accountID, models.EntryTypeLogin, "zk1:"+strings.Repeat("A", 40))
+	require.NoError(t, err)
+	service := exporter.New(discard(), vault)
+
+	var out bytes.Buffer
+	err = service.Export(context.Background(), accountID, &out, exporter.Options{Format: exporter.FormatCSV, ConfirmPlain: true})
+	require.ErrorIs(t, err, exporter.ErrClientEncrypted)
+	require.Zero(t, out.Len())
+
+	require.NoError(t, service.Export(context.Background(), accountID, &out, exporter.Options{Format: exporter.FormatEncrypted, Passphrase: "correct horse"}))
+	archive, err := exporter.Open(&out, "correct horse")
+	require.NoError(t, err)
+	var data string
+	require.NoError(t, json.Unmarshal(archive.Entries[len(archive.Entries)-1].Data, &data))
+	require.Equal(t, "zk1:"+strings.Repeat("A", 40), data)
+}
+
+// TestExportPlainRoundTrip imports the plain exports into an empty vault.
+func TestExportPlainRoundTrip(t *testing.T) {
+	tests := []struct {
+		format       string
+		importFormat string
+		types        []string
+	}{
+		{
+			format:       exporter.FormatBitwarden,
+			importFormat: importer.FormatBitwarden,
+			types:        []string{models.EntryTypeLogin, models.EntryTypeSecureNote, models.EntryTypeCreditCard},
+		},
+		{
+			// The card is written as a note with its fields in the content.
+			format:       exporter.FormatCSV,
+			importFormat: importer.FormatCSV,
+			types:        []string{models.EntryTypeLogin, models.EntryTypeSecureNote, models.EntryTypeSecureNote},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			service := exporter.New(discard(), newVault(t))
+
+			var out bytes.Buffer
+			err := service.Export(context.Background(), accountID, &out, exporter.Options{Format: tt.format, ConfirmPlain: true})
+			require.NoError(t, err)
+
+			target := memory.New(storage.RevisionRetention{})
+			report, err := importer.New(discard(), target).Import(context.Background(), accountID, tt.importFormat, out.Bytes(), "", false)
+			require.NoError(t, err)
+			require.Empty(t, report.Skipped)
+
+			imported, err := target.ListEntries(context.Background(), accountID, models.EntryFilter{})
+			require.NoError(t, err)
+			types := make([]string, 0, len(imported))
+			byType := make(map[string]map[string]any)
+			for _, entry := range imported {
+				types = append(types, entry.EntryType)
+				var data map[string]any
+				require.NoError(t, json.Unmarshal([]byte(entry.EntryData), &data))
+				byType[entry.EntryType] = data
+			}
+			require.ElementsMatch(t, tt.types, types)
+			require.Equal(t, "s3cret", byType[models.EntryTypeLogin]["password"])
+			require.Equal(t, []any{"https://mail.example.com"}, byType[models.EntryTypeLogin]["uris"])
+		})
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	service := exporter.New(discard(), newVault(t))
+
+	var out bytes.Buffer
+	err := service.Export(context.Background(), accountID, &out, exporter.Options{Format: exporter.FormatCSV, ConfirmPlain: true})
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	require.Equal(t, "folder", records[0][0])
+	require.Equal(t, []string{"Work/Mail", "", "login", "Mail", "", "", "0", "https://mail.example.com", "alice", "s3cret", ""}, records[1])
+	require.Equal(t, []string{"", "", "note", "Wifi", "hunter2", "", "0", "", "", "", ""}, records[2])
+	require.Equal(t, "note", records[3][2])
+	require.Equal(t, "code: 123\nexpMonth: 12\nexpYear: 2030\nnumber: 4111111111111111", records[3][5])
+}
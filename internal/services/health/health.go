@@ -0,0 +1,339 @@
+// Package health reports on the passwords of the login entries of an account:
+// weak passwords, passwords reused across entries, passwords not changed for a
+// while and logins without a second factor.
+//
+// Reports hold entry IDs and scores only, never passwords. Reuse is found by
+// comparing SHA-256 digests of the passwords in memory.
+//
+// The age of a password is taken from the revisions of the entry rather than from
+// its last update, so renaming, moving or tagging a login does not make its password
+// look fresh. A password found in every revision dates from the creation of the entry
+// when the revision retention cannot have pruned any, and from its last update
+// otherwise, since the change may have been pruned.
+package health
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/strength"
+	"passvault/internal/storage"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WeakScore is the highest strength score counted as weak.
+const WeakScore = strength.ScoreSomewhatGuessable
+
+// Options tune the report.
+type Options struct {
+	// MaxAge is how long a password may go without being changed.
+	MaxAge time.Duration
+}
+
+// Report is the health of the login entries of an account. Score is the percentage of
+// logins without any finding.
+type Report struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Logins      int           `json:"logins"`
+	Score       int           `json:"score"`
+	Weak        []WeakEntry   `json:"weak"`
+	Reused      [][]int64     `json:"reused"`
+	Stale       []StaleEntry  `json:"stale"`
+	WithoutTOTP []int64       `json:"without_totp"`
+	Strength    map[int64]int `json:"strength"`
+}
+
+// WeakEntry is a login whose password scores at most WeakScore.
+type WeakEntry struct {
+	EntryID int64 `json:"entry_id"`
+	Score   int   `json:"score"`
+}
+
+// StaleEntry is a login whose password was not changed for longer than the MaxAge
+// of the report.
+type StaleEntry struct {
+	EntryID           int64     `json:"entry_id"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	AgeDays           int       `json:"age_days"`
+}
+
+type EntryLister interface {
+	ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error)
+}
+
+type RevisionLister interface {
+	PersonalRevisions(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.EntryRevision, error)
+}
+
+// revisionBatch is how many revisions are read at a time.
+const revisionBatch = 500
+
+type Service struct {
+	log            *slog.Logger
+	entryLister    EntryLister
+	revisionLister RevisionLister
+	retention      storage.RevisionRetention
+}
+
+// New returns a Service. retention is the revision retention of the storage, it tells
+// whether the revisions of an entry may have been pruned.
+func New(log *slog.Logger, entryLister EntryLister, revisionLister RevisionLister, retention storage.RevisionRetention) *Service {
+	return &Service{
+		log:            log,
+		entryLister:    entryLister,
+		revisionLister: revisionLister,
+		retention:      retention,
+	}
+}
+
+type login struct {
+	id        int64
+	createdAt time.Time
+	updatedAt time.Time
+	changedAt time.Time
+	title     string
+	username  string
+	password  string
+	totp      string
+	hosts     []string
+}
+
+// Report analyses the personal login entries of the account. Logins count as
+// having a second factor when they hold a TOTP secret, or when a totp entry is
+// titled or issued after their title or the host of one of their URIs.
+func (s *Service) Report(ctx context.Context, accountID int64, opts Options) (*Report, error) {
+	const op = "services.health.Report"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID))
+
+	entries, err := s.entryLister.ListEntries(ctx, accountID, models.EntryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var logins []login
+	authenticators := make(map[string]bool)
+	for _, entry := range entries {
+		var data struct {
+			Title    string   `json:"title"`
+			Username string   `json:"username"`
+			Password string   `json:"password"`
+			TOTP     string   `json:"totp"`
+			URIs     []string `json:"uris"`
+			Issuer   string   `json:"issuer"`
+		}
+		if err := json.Unmarshal([]byte(entry.EntryData), &data); err != nil {
+			continue
+		}
+
+		switch entry.EntryType {
+		case models.EntryTypeLogin:
+			l := login{
+				id:        entry.ID,
+				createdAt: entry.CreatedAt,
+				updatedAt: entry.UpdatedAt,
+				title:     data.Title,
+				username:  data.Username,
+				password:  data.Password,
+				totp:      data.TOTP,
+			}
+			for _, uri := range data.URIs {
+				if host := hostOf(uri); host != "" {
+					l.hosts = append(l.hosts, host)
+				}
+			}
+			logins = append(logins, l)
+		case models.EntryTypeTOTP:
+			for _, name := range []string{data.Title, data.Issuer} {
+				if name = normalize(name); name != "" {
+					authenticators[name] = true
+				}
+			}
+		}
+	}
+	sort.Slice(logins, func(i, j int) bool { return logins[i].id < logins[j].id })
+
+	now := time.Now().UTC()
+	if err := s.passwordChanges(ctx, accountID, logins, now); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := &Report{
+		GeneratedAt: now,
+		Logins:      len(logins),
+		Weak:        []WeakEntry{},
+		Reused:      [][]int64{},
+		Stale:       []StaleEntry{},
+		WithoutTOTP: []int64{},
+		Strength:    make(map[int64]int, len(logins)),
+	}
+
+	findings := make(map[int64]bool)
+	byDigest := make(map[[sha256.Size]byte][]int64)
+	for _, l := range logins {
+		if l.password != "" {
+			inputs := append([]string{l.title, l.username}, l.hosts...)
+			if local, _, ok := strings.Cut(l.username, "@"); ok {
+				inputs = append(inputs, local)
+			}
+			result := strength.Check(l.password, inputs...)
+			report.Strength[l.id] = result.Score
+			if result.Score <= WeakScore {
+				report.Weak = append(report.Weak, WeakEntry{EntryID: l.id, Score: result.Score})
+				findings[l.id] = true
+			}
+
+			digest := sha256.Sum256([]byte(l.password))
+			byDigest[digest] = append(byDigest[digest], l.id)
+		}
+
+		if age := now.Sub(l.changedAt); opts.MaxAge > 0 && age > opts.MaxAge {
+			report.Stale = append(report.Stale, StaleEntry{
+				EntryID:           l.id,
+				PasswordChangedAt: l.changedAt,
+				AgeDays:           int(math.Floor(age.Hours() / 24)),
+			})
+			findings[l.id] = true
+		}
+
+		if l.totp == "" && !hasAuthenticator(l, authenticators) {
+			report.WithoutTOTP = append(report.WithoutTOTP, l.id)
+			findings[l.id] = true
+		}
+	}
+
+	for _, ids := range byDigest {
+		if len(ids) > 1 {
+			report.Reused = append(report.Reused, ids)
+			for _, id := range ids {
+				findings[id] = true
+			}
+		}
+	}
+	sort.Slice(report.Reused, func(i, j int) bool { return report.Reused[i][0] < report.Reused[j][0] })
+
+	report.Score = 100
+	if len(logins) > 0 {
+		report.Score = int(math.Round(100 * float64(len(logins)-len(findings)) / float64(len(logins))))
+	}
+
+	log.Info("health report generated",
+		slog.Int("logins", len(logins)),
+		slog.Int("weak", len(report.Weak)),
+		slog.Int("reused", len(report.Reused)),
+		slog.Int("stale", len(report.Stale)),
+		slog.Int("without_totp", len(report.WithoutTOTP)),
+	)
+
+	return report, nil
+}
+
+// passwordChanges sets the changedAt of each login to when its current password
+// replaced a different one. A revision holds the data an update replaced, so the
+// newest revision with another password dates the change.
+func (s *Service) passwordChanges(ctx context.Context, accountID int64, logins []login, now time.Time) error {
+	current := make(map[int64][sha256.Size]byte, len(logins))
+	for _, l := range logins {
+		current[l.id] = sha256.Sum256([]byte(l.password))
+	}
+
+	changed := make(map[int64]time.Time)
+	revisionCount := make(map[int64]int)
+	var afterID int64
+	for {
+		revisions, err := s.revisionLister.PersonalRevisions(ctx, accountID, afterID, revisionBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, revision := range revisions {
+			digest, ok := current[revision.EntryID]
+			if !ok {
+				continue
+			}
+			revisionCount[revision.EntryID]++
+			var data struct {
+				Password string `json:"password"`
+			}
+			if err := json.Unmarshal([]byte(revision.EntryData), &data); err != nil {
+				continue
+			}
+			if sha256.Sum256([]byte(data.Password)) != digest {
+				// Revisions come in ID order, so the newest one wins.
+				changed[revision.EntryID] = revision.CreatedAt
+			}
+		}
+
+		if len(revisions) < revisionBatch {
+			break
+		}
+		afterID = revisions[len(revisions)-1].ID
+	}
+
+	for i := range logins {
+		l := &logins[i]
+		switch at, ok := changed[l.id]; {
+		case ok:
+			l.changedAt = at
+		case s.fullHistory(l.createdAt, revisionCount[l.id], now):
+			l.changedAt = l.createdAt
+		default:
+			// The revision that recorded the change may have been pruned.
+			l.changedAt = l.updatedAt
+		}
+	}
+	return nil
+}
+
+// fullHistory reports whether the retention kept every revision of an entry created
+// at createdAt that has count revisions left. Pruning by count leaves exactly MaxCount
+// revisions, and pruning by age only drops revisions older than MaxAge.
+func (s *Service) fullHistory(createdAt time.Time, count int, now time.Time) bool {
+	if s.retention.MaxCount > 0 && count >= s.retention.MaxCount {
+		return false
+	}
+	if s.retention.MaxAge > 0 && now.Sub(createdAt) >= s.retention.MaxAge {
+		return false
+	}
+	return true
+}
+
+func hasAuthenticator(l login, authenticators map[string]bool) bool {
+	if authenticators[normalize(l.title)] {
+		return true
+	}
+	for _, host := range l.hosts {
+		if authenticators[normalize(host)] {
+			return true
+		}
+		// "github.com" matches an authenticator issued by "GitHub".
+		labels := strings.Split(host, ".")
+		if len(labels) >= 2 && authenticators[normalize(labels[len(labels)-2])] {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func hostOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		// URIs saved without a scheme are parsed as paths.
+		u, err = url.Parse("https://" + uri)
+		if err != nil {
+			return ""
+		}
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
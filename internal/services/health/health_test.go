@@ -0,0 +1,146 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/health"
+	"passvault/internal/storage"
+	"passvault/internal/storage/memory"
+	"testing"
+	"time"
+)
+
+const accountID = 1
+
+func save(t *testing.T, db *memory.Storage, entryType, entryData string) int64 {
+	id, err := db.SaveEntry(context.Background(), accountID, entryType, entryData)
+	require.NoError(t, err)
+	return id
+}
+
+func TestReport(t *testing.T) {
+	db := memory.New(storage.RevisionRetention{})
+
+	weak := save(t, db, models.EntryTypeLogin, `{"title": "forum", "username": "alice", "password": "alice2024", "totp": "JBSWY3DPEHPK3PXP"}`)
+	reusedA := save(t, db, models.EntryTypeLogin, `{"title": "mail", "password": "x7Kq9#mP2vL!", "uris": ["https://mail.example.com"]}`)
+	reusedB := save(t, db, models.EntryTypeLogin, `{"title": "shop", "password": "x7Kq9#mP2vL!", "totp": "JBSWY3DPEHPK3PXP"}`)
+	github := save(t, db, models.EntryTypeLogin, `{"title": "code", "password": "Vr9!qZ2#wL5@", "uris": ["https://github.com/login"]}`)
+	save(t, db, models.EntryTypeTOTP, `{"title": "2FA", "issuer": "GitHub", "secret": "JBSWY3DPEHPK3PXP"}`)
+	save(t, db, models.EntryTypeSecureNote, `{"title": "wifi", "content": "password"}`)
+
+	// Entries of other accounts are not reported.
+	_, err := db.SaveEntry(context.Background(), accountID+1, models.EntryTypeLogin, `{"title": "other", "password": "x7Kq9#mP2vL!"}`)
+	require.NoError(t, err)
+
+	service := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, storage.RevisionRetention{})
+
+	report, err := service.Report(context.Background(), accountID, health.Options{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+
+	require.Equal(t, 4, report.Logins)
+	require.Equal(t, []health.WeakEntry{{EntryID: weak, Score: report.Strength[weak]}}, report.Weak)
+	require.LessOrEqual(t, report.Strength[weak], health.WeakScore)
+	require.Equal(t, [][]int64{{reusedA, reusedB}}, report.Reused)
+	require.Empty(t, report.Stale)
+	require.Equal(t, []int64{reusedA}, report.WithoutTOTP)
+	require.Len(t, report.Strength, 4)
+	require.Equal(t, 25, report.Score)
+	// The GitHub login has its second factor in the totp entry.
+	require.NotContains(t, report.WithoutTOTP, github)
+
+	// Reports hold no passwords.
+	raw, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "x7Kq9")
+	require.NotContains(t, string(raw), "alice2024")
+
+	time.Sleep(time.Millisecond)
+	report, err = service.Report(context.Background(), accountID, health.Options{MaxAge: time.Nanosecond})
+	require.NoError(t, err)
+	require.Len(t, report.Stale, 4)
+	require.Equal(t, 0, report.Score)
+}
+
+func TestReportEmpty(t *testing.T) {
+	db := memory.New(storage.RevisionRetention{})
+	service := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, storage.RevisionRetention{})
+
+	report, err := service.Report(context.Background(), accountID, health.Options{})
+	require.NoError(t, err)
+	require.Zero(t, report.Logins)
+	require.Equal(t, 100, report.Score)
+	require.Empty(t, report.Weak)
+}
+
+func TestReportPasswordAge(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New(storage.RevisionRetention{})
+
+	renamed := save(t, db, models.EntryTypeLogin, `{"title": "mail", "password": "x7Kq9#mP2vL!"}`)
+	require.NoError(t, db.UpdateEntry(ctx, accountID, renamed, models.EntryTypeLogin, `{"title": "webmail", "password": "x7Kq9#mP2vL!"}`))
+
+	changed := save(t, db, models.EntryTypeLogin, `{"title": "shop", "password": "Vr9!qZ2#wL5@"}`)
+	require.NoError(t, db.UpdateEntry(ctx, accountID, changed, models.EntryTypeLogin, `{"title": "shop", "password": "b4N#e8Lw!2Qz"}`))
+	require.NoError(t, db.UpdateEntry(ctx, accountID, changed, models.EntryTypeLogin, `{"title": "store", "password": "b4N#e8Lw!2Qz"}`))
+
+	service := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, storage.RevisionRetention{})
+
+	time.Sleep(time.Millisecond)
+	report, err := service.Report(ctx, accountID, health.Options{MaxAge: time.Nanosecond})
+	require.NoError(t, err)
+	require.Len(t, report.Stale, 2)
+
+	// Renaming keeps the password as old as the entry.
+	entry, err := db.GetEntry(ctx, accountID, renamed)
+	require.NoError(t, err)
+	require.Equal(t, renamed, report.Stale[0].EntryID)
+	require.Equal(t, entry.CreatedAt, report.Stale[0].PasswordChangedAt)
+
+	// The password dates from the update that replaced the previous one, not the rename after it.
+	revisions, err := db.ListRevisions(ctx, accountID, changed)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, changed, report.Stale[1].EntryID)
+	require.Equal(t, revisions[1].CreatedAt, report.Stale[1].PasswordChangedAt)
+}
+
+func TestReportPrunedPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	retention := storage.RevisionRetention{MaxCount: 2}
+	db := memory.New(retention)
+
+	// Renames after the password change push its revision out of the retention.
+	rotated := save(t, db, models.EntryTypeLogin, `{"title": "mail", "password": "x7Kq9#mP2vL!"}`)
+	require.NoError(t, db.UpdateEntry(ctx, accountID, rotated, models.EntryTypeLogin, `{"title": "mail", "password": "b4N#e8Lw!2Qz"}`))
+	require.NoError(t, db.UpdateEntry(ctx, accountID, rotated, models.EntryTypeLogin, `{"title": "webmail", "password": "b4N#e8Lw!2Qz"}`))
+	require.NoError(t, db.UpdateEntry(ctx, accountID, rotated, models.EntryTypeLogin, `{"title": "inbox", "password": "b4N#e8Lw!2Qz"}`))
+	revisions, err := db.ListRevisions(ctx, accountID, rotated)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	renamed := save(t, db, models.EntryTypeLogin, `{"title": "shop", "password": "Vr9!qZ2#wL5@"}`)
+	require.NoError(t, db.UpdateEntry(ctx, accountID, renamed, models.EntryTypeLogin, `{"title": "store", "password": "Vr9!qZ2#wL5@"}`))
+
+	service := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, retention)
+
+	time.Sleep(time.Millisecond)
+	report, err := service.Report(ctx, accountID, health.Options{MaxAge: time.Nanosecond})
+	require.NoError(t, err)
+	require.Len(t, report.Stale, 2)
+
+	// The change may have been pruned, so the password is as old as the last update.
+	entry, err := db.GetEntry(ctx, accountID, rotated)
+	require.NoError(t, err)
+	require.Equal(t, rotated, report.Stale[0].EntryID)
+	require.Equal(t, entry.UpdatedAt, report.Stale[0].PasswordChangedAt)
+
+	// Below the retention limit nothing was pruned and the password dates from the creation.
+	entry, err = db.GetEntry(ctx, accountID, renamed)
+	require.NoError(t, err)
+	require.Equal(t, renamed, report.Stale[1].EntryID)
+	require.Equal(t, entry.CreatedAt, report.Stale[1].PasswordChangedAt)
+}
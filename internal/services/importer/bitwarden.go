@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"passvault/internal/domain/models"
+	"strconv"
+	"strings"
+)
+
+// Bitwarden item types.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+	bitwardenSSHKey     = 5
+)
+
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type   int    `json:"type"`
+	Name   string `json:"name"`
+	Notes  string `json:"notes"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity *struct {
+		FirstName      string `json:"firstName"`
+		MiddleName     string `json:"middleName"`
+		LastName       string `json:"lastName"`
+		Address1       string `json:"address1"`
+		Address2       string `json:"address2"`
+		Address3       string `json:"address3"`
+		City           string `json:"city"`
+		State          string `json:"state"`
+		PostalCode     string `json:"postalCode"`
+		Country        string `json:"country"`
+		Company        string `json:"company"`
+		Email          string `json:"email"`
+		Phone          string `json:"phone"`
+		PassportNumber string `json:"passportNumber"`
+		LicenseNumber  string `json:"licenseNumber"`
+	} `json:"identity"`
+	SSHKey *struct {
+		PrivateKey     string `json:"privateKey"`
+		PublicKey      string `json:"publicKey"`
+		KeyFingerprint string `json:"keyFingerprint"`
+	} `json:"sshKey"`
+}
+
+// parseBitwarden reads the unencrypted JSON export of Bitwarden. Custom fields have
+// no place in the entry schemas and are kept in the notes.
+func parseBitwarden(data []byte) ([]item, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("%w: encrypted Bitwarden exports are not supported, export as unencrypted JSON", ErrInvalidFile)
+	}
+
+	items := make([]item, 0, len(export.Items))
+	for _, bw := range export.Items {
+		fields := make([]string, 0, len(bw.Fields))
+		for _, field := range bw.Fields {
+			fields = append(fields, field.Name+": "+field.Value)
+		}
+		data := map[string]any{
+			"title": bw.Name,
+			"notes": joinNotes(bw.Notes, fields...),
+		}
+
+		it := item{data: data}
+		switch {
+		case bw.Type == bitwardenLogin && bw.Login != nil:
+			it.entryType = models.EntryTypeLogin
+			uris := make([]string, 0, len(bw.Login.URIs))
+			for _, uri := range bw.Login.URIs {
+				if uri.URI != "" {
+					uris = append(uris, uri.URI)
+				}
+			}
+			data["username"] = bw.Login.Username
+			data["password"] = bw.Login.Password
+			data["totp"] = bw.Login.TOTP
+			data["uris"] = uris
+		case bw.Type == bitwardenSecureNote:
+			// The note is the content, custom fields stay in the notes.
+			it.entryType = models.EntryTypeSecureNote
+			data["content"] = bw.Notes
+			data["notes"] = joinNotes("", fields...)
+		case bw.Type == bitwardenCard && bw.Card != nil:
+			it.entryType = models.EntryTypeCreditCard
+			data["cardholder_name"] = bw.Card.CardholderName
+			data["brand"] = bw.Card.Brand
+			data["number"] = bw.Card.Number
+			data["exp_month"] = number(bw.Card.ExpMonth)
+			data["exp_year"] = number(bw.Card.ExpYear)
+			data["cvv"] = bw.Card.Code
+		case bw.Type == bitwardenIdentity && bw.Identity != nil:
+			id := bw.Identity
+			it.entryType = models.EntryTypeIdentity
+			data["first_name"] = joinWords(id.FirstName, id.MiddleName)
+			data["last_name"] = id.LastName
+			data["email"] = id.Email
+			data["phone"] = id.Phone
+			data["company"] = id.Company
+			data["passport_number"] = id.PassportNumber
+			data["license_number"] = id.LicenseNumber
+			data["address"] = joinAddress(id.Address1, id.Address2, id.Address3, joinWords(id.PostalCode, id.City), id.State, id.Country)
+		case bw.Type == bitwardenSSHKey && bw.SSHKey != nil:
+			it.entryType = models.EntryTypeSSHKey
+			data["private_key"] = bw.SSHKey.PrivateKey
+			data["public_key"] = bw.SSHKey.PublicKey
+			data["fingerprint"] = bw.SSHKey.KeyFingerprint
+		default:
+			it.reason = fmt.Sprintf("unsupported Bitwarden item type %d", bw.Type)
+		}
+		items = append(items, it)
+	}
+
+	return items, nil
+}
+
+// number turns a numeric string into an integer. Anything else is kept as is so
+// validation reports it.
+func number(s string) any {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return s
+}
+
+func joinWords(words ...string) string {
+	return joinNonEmpty(" ", words...)
+}
+
+func joinAddress(lines ...string) string {
+	return joinNonEmpty(", ", lines...)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"passvault/internal/domain/models"
+	"strings"
+)
+
+// csvColumns maps the header names of browser password exports onto login fields.
+// Chrome exports name, url, username, password and note; Firefox exports url,
+// username and password followed by metadata columns that are ignored. Bitwarden
+// prefixes the login columns with login_ and adds type and fields columns.
+var csvColumns = map[string]string{
+	"name":           "title",
+	"title":          "title",
+	"url":            "uris",
+	"login_uri":      "uris",
+	"username":       "username",
+	"login_username": "username",
+	"password":       "password",
+	"login_password": "password",
+	"login_totp":     "totp",
+	"note":           "notes",
+	"notes":          "notes",
+	"type":           "type",
+	"fields":         "fields",
+}
+
+// parseCSV reads the password exports of Chrome, Firefox and Bitwarden. Logins
+// without a name are titled after the host of their URL.
+func parseCSV(data []byte) ([]item, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: empty CSV file", ErrInvalidFile)
+	}
+
+	columns := make(map[int]string)
+	found := make(map[string]bool)
+	// Bitwarden joins the URIs of a login with commas.
+	joined := make(map[int]bool)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if field, ok := csvColumns[name]; ok {
+			columns[i] = field
+			found[field] = true
+			joined[i] = name == "login_uri"
+		}
+	}
+	if !found["uris"] || !found["password"] {
+		return nil, fmt.Errorf("%w: CSV header needs url and password columns", ErrInvalidFile)
+	}
+
+	items := make([]item, 0, len(records)-1)
+	for _, record := range records[1:] {
+		data := map[string]any{}
+		for i, value := range record {
+			field, ok := columns[i]
+			if !ok || value == "" {
+				continue
+			}
+			if field == "uris" {
+				if joined[i] {
+					data[field] = strings.Split(value, ",")
+				} else {
+					data[field] = []string{value}
+				}
+				continue
+			}
+			data[field] = value
+		}
+
+		// Custom fields have no column of their own and are kept in the notes.
+		if fields, ok := data["fields"].(string); ok {
+			delete(data, "fields")
+			if notes, _ := data["notes"].(string); notes != "" {
+				fields = notes + "\n" + fields
+			}
+			data["notes"] = fields
+		}
+
+		entryType, _ := data["type"].(string)
+		delete(data, "type")
+		if entryType == "note" {
+			// The notes of a Bitwarden note are its content.
+			data["content"] = data["notes"]
+			delete(data, "notes")
+			items = append(items, item{entryType: models.EntryTypeSecureNote, data: data})
+			continue
+		}
+
+		if title, _ := data["title"].(string); title == "" {
+			if uris, ok := data["uris"].([]string); ok {
+				data["title"] = hostOf(uris[0])
+			}
+		}
+		items = append(items, item{entryType: models.EntryTypeLogin, data: data})
+	}
+
+	return items, nil
+}
+
+func hostOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return uri
+	}
+	return u.Hostname()
+}
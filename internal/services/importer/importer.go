@@ -0,0 +1,244 @@
+// Package importer moves the entries exported from other password managers into a vault.
+//
+// Bitwarden JSON exports, KeePass XML exports and KDBX databases, 1Password 1PUX
+// archives and the CSV password exports of Chrome, Firefox and Bitwarden are mapped
+// onto the entry types of internal/lib/entryschema. Items that do not fit a schema are skipped
+// and items whose data matches an existing personal entry, or an earlier item of the
+// same import, are reported as duplicates instead of being saved twice.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/entryschema"
+	"passvault/internal/storage"
+	"strings"
+)
+
+const (
+	FormatBitwarden  = "bitwarden"
+	FormatKeePassXML = "keepass_xml"
+	FormatKDBX       = "kdbx"
+	Format1PUX       = "1pux"
+	FormatCSV        = "csv"
+)
+
+var (
+	ErrUnknownFormat    = errors.New("unknown import format")
+	ErrInvalidFile      = errors.New("invalid import file")
+	ErrPasswordRequired = errors.New("password required to open the import file")
+	ErrInvalidPassword  = errors.New("invalid password for the import file")
+)
+
+// Formats returns the supported import formats.
+func Formats() []string {
+	return []string{FormatBitwarden, FormatKeePassXML, FormatKDBX, Format1PUX, FormatCSV}
+}
+
+// Result describes what happened to one imported item.
+type Result struct {
+	Title     string `json:"title"`
+	EntryType string `json:"entry_type,omitempty"`
+	// EntryID is the created entry, or for duplicates the existing entry.
+	EntryID int64 `json:"entry_id,omitempty"`
+	// Reason explains why an item was skipped or is a duplicate.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report lists the items of an import by outcome. A dry run reports what an import
+// would do without saving anything, so created entries have no ID.
+type Report struct {
+	Format     string   `json:"format"`
+	DryRun     bool     `json:"dry_run"`
+	Created    []Result `json:"created"`
+	Skipped    []Result `json:"skipped"`
+	Duplicates []Result `json:"duplicates"`
+}
+
+// item is an entry parsed from an export.
+type item struct {
+	entryType string
+	data      map[string]any
+	// reason is set for items that cannot be imported.
+	reason string
+}
+
+func (i item) title() string {
+	title, _ := i.data["title"].(string)
+	return title
+}
+
+type EntryStorage interface {
+	ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error)
+	SaveEntries(ctx context.Context, accountID int64, entries []storage.NewEntry) ([]int64, error)
+}
+
+type Service struct {
+	log          *slog.Logger
+	entryStorage EntryStorage
+}
+
+func New(log *slog.Logger, entryStorage EntryStorage) *Service {
+	return &Service{
+		log:          log,
+		entryStorage: entryStorage,
+	}
+}
+
+// Import parses an export in format and saves its items as personal entries of the
+// account, all of them or none. password opens encrypted KDBX databases. With dryRun
+// nothing is saved and the report tells what the import would do.
+func (s *Service) Import(ctx context.Context, accountID int64, format string, data []byte, password string, dryRun bool) (*Report, error) {
+	const op = "services.importer.Import"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID), slog.String("format", format))
+
+	items, err := parse(ctx, format, data, password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := s.entryStorage.ListEntries(ctx, accountID, models.EntryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	seen := make(map[string]int64, len(existing))
+	for _, entry := range existing {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(entry.EntryData), &data); err != nil {
+			continue
+		}
+		seen[dedupKey(entry.EntryType, data)] = entry.ID
+	}
+
+	report := &Report{
+		Format:     format,
+		DryRun:     dryRun,
+		Created:    []Result{},
+		Skipped:    []Result{},
+		Duplicates: []Result{},
+	}
+
+	var entries []storage.NewEntry
+	for _, item := range items {
+		result := Result{Title: item.title(), EntryType: item.entryType}
+		if item.reason != "" {
+			result.Reason = item.reason
+			report.Skipped = append(report.Skipped, result)
+			continue
+		}
+
+		compact(item.data)
+		entryData, err := json.Marshal(item.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := entryschema.Validate(item.entryType, string(entryData)); err != nil {
+			result.Reason = err.Error()
+			report.Skipped = append(report.Skipped, result)
+			continue
+		}
+
+		key := dedupKey(item.entryType, item.data)
+		if id, ok := seen[key]; ok {
+			result.EntryID = id
+			if id == 0 {
+				result.Reason = "same as an earlier item of the import"
+			} else {
+				result.Reason = "same as an existing entry"
+			}
+			report.Duplicates = append(report.Duplicates, result)
+			continue
+		}
+		seen[key] = 0
+
+		entries = append(entries, storage.NewEntry{EntryType: item.entryType, EntryData: string(entryData)})
+		report.Created = append(report.Created, result)
+	}
+
+	if !dryRun && len(entries) > 0 {
+		ids, err := s.entryStorage.SaveEntries(ctx, accountID, entries)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		for i, id := range ids {
+			report.Created[i].EntryID = id
+		}
+	}
+
+	log.Info("entries imported",
+		slog.Bool("dry_run", dryRun),
+		slog.Int("created", len(report.Created)),
+		slog.Int("skipped", len(report.Skipped)),
+		slog.Int("duplicates", len(report.Duplicates)),
+	)
+
+	return report, nil
+}
+
+func parse(ctx context.Context, format string, data []byte, password string) ([]item, error) {
+	switch format {
+	case FormatBitwarden:
+		return parseBitwarden(data)
+	case FormatKeePassXML:
+		return parseKeePassXML(data)
+	case FormatKDBX:
+		return parseKDBX(ctx, data, password)
+	case Format1PUX:
+		return parse1PUX(data)
+	case FormatCSV:
+		return parseCSV(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// compact drops empty optional values, which exports fill in for every field.
+func compact(data map[string]any) {
+	for name, value := range data {
+		switch v := value.(type) {
+		case nil:
+			delete(data, name)
+		case string:
+			if strings.TrimSpace(v) == "" {
+				delete(data, name)
+			}
+		case []string:
+			if len(v) == 0 {
+				delete(data, name)
+			}
+		case []any:
+			if len(v) == 0 {
+				delete(data, name)
+			}
+		}
+	}
+}
+
+// dedupKey identifies entries of the same type carrying the same data, regardless of
+// the order of their fields.
+func dedupKey(entryType string, data map[string]any) string {
+	compact(data)
+	// Maps are encoded with sorted keys.
+	encoded, _ := json.Marshal(data)
+	return entryType + "\x00" + string(encoded)
+}
+
+// joinNotes appends lines to notes, one per line.
+func joinNotes(notes string, lines ...string) string {
+	parts := make([]string, 0, len(lines)+1)
+	if notes = strings.TrimSpace(notes); notes != "" {
+		parts = append(parts, notes)
+	}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,302 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/services/importer"
+	"passvault/internal/storage"
+	"passvault/internal/storage/memory"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+const accountID = 1
+
+func newService(t *testing.T) (*importer.Service, *memory.Storage) {
+	db := memory.New(storage.RevisionRetention{})
+	return importer.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db), db
+}
+
+func fixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return data
+}
+
+// onePUX packs the export.data fixture into a 1PUX archive.
+func onePUX(t *testing.T) []byte {
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	f, err := w.Create("export.data")
+	require.NoError(t, err)
+	_, err = f.Write(fixture(t, "1password/export.data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return archive.Bytes()
+}
+
+func titles(results []importer.Result) []string {
+	titles := make([]string, 0, len(results))
+	for _, result := range results {
+		titles = append(titles, result.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// entries returns the saved entry data by title.
+func entries(t *testing.T, db *memory.Storage) map[string]map[string]any {
+	list, err := db.ListEntries(context.Background(), accountID, models.EntryFilter{})
+	require.NoError(t, err)
+
+	byTitle := make(map[string]map[string]any, len(list))
+	for _, entry := range list {
+		var data map[string]any
+		require.NoError(t, json.Unmarshal([]byte(entry.EntryData), &data))
+		data["entry_type"] = entry.EntryType
+		byTitle[data["title"].(string)] = data
+	}
+	return byTitle
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		data       func(t *testing.T) []byte
+		password   string
+		created    []string
+		skipped    []string
+		duplicates []string
+		check      func(t *testing.T, saved map[string]map[string]any)
+	}{
+		{
+			name:       "bitwarden",
+			format:     importer.FormatBitwarden,
+			data:       func(t *testing.T) []byte { return fixture(t, "bitwarden.json") },
+			created:    []string{"Mail", "Me", "Visa", "Wifi"},
+			skipped:    []string{"Broken card"},
+			duplicates: []string{"Mail"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeLogin,
+					"title":      "Mail",
+					"username":   "alice@example.com",
+					"password":   "bw-s3cret",
+					"totp":       "JBSWY3DPEHPK3PXP",
+					"uris":       []any{"https://mail.example.com"},
+					"notes":      "Primary mailbox\nRecovery code: 1234-5678",
+				}, saved["Mail"])
+				require.Equal(t, "Guest network: hunter2", saved["Wifi"]["content"])
+				require.Equal(t, float64(7), saved["Visa"]["exp_month"])
+				require.Equal(t, "123", saved["Visa"]["cvv"])
+				require.Equal(t, "1 Main St, 12345 Springfield, US", saved["Me"]["address"])
+			},
+		},
+		{
+			name:    "keepass xml",
+			format:  importer.FormatKeePassXML,
+			data:    func(t *testing.T) []byte { return fixture(t, "keepass.xml") },
+			created: []string{"Git forge"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeLogin,
+					"title":      "Git forge",
+					"username":   "alice",
+					"password":   "kdbx-s3cret",
+					"totp":       "otpauth://totp/forge?secret=JBSWY3DPEHPK3PXP",
+					"uris":       []any{"https://git.example.com"},
+					"notes":      "Work account",
+				}, saved["Git forge"])
+			},
+		},
+		{
+			name:     "kdbx",
+			format:   importer.FormatKDBX,
+			data:     func(t *testing.T) []byte { return fixture(t, "keepass.kdbx") },
+			password: "correct horse",
+			created:  []string{"Git forge"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, "kdbx-s3cret", saved["Git forge"]["password"])
+				require.Equal(t, "otpauth://totp/forge?secret=JBSWY3DPEHPK3PXP", saved["Git forge"]["totp"])
+			},
+		},
+		{
+			name:    "1password",
+			format:  importer.Format1PUX,
+			data:    onePUX,
+			created: []string{"Alice", "Bank", "Mastercard", "Payments API", "Secret"},
+			skipped: []string{"Old login", "Passport scan"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeLogin,
+					"title":      "Bank",
+					"username":   "alice",
+					"password":   "op-s3cret",
+					"totp":       "otpauth://totp/bank?secret=JBSWY3DPEHPK3PXP",
+					"uris":       []any{"https://bank.example.com", "https://app.bank.example.com"},
+					"notes":      "Bank login\nPIN: 4321",
+				}, saved["Bank"])
+				require.Equal(t, float64(2028), saved["Mastercard"]["exp_year"])
+				require.Equal(t, float64(11), saved["Mastercard"]["exp_month"])
+				require.Equal(t, "The answer is 42", saved["Secret"]["content"])
+				require.Equal(t, "alice@example.com", saved["Alice"]["email"])
+				require.Equal(t, "555-0100", saved["Alice"]["phone"])
+				require.Equal(t, "birth date: 631152000", saved["Alice"]["notes"])
+				require.Equal(t, models.EntryTypeAPIToken, saved["Payments API"]["entry_type"])
+				require.Equal(t, "tok_live_abc123", saved["Payments API"]["token"])
+			},
+		},
+		{
+			name:    "chrome csv",
+			format:  importer.FormatCSV,
+			data:    func(t *testing.T) []byte { return fixture(t, "chrome.csv") },
+			created: []string{"example.com", "shop.example.org"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, "card on file", saved["shop.example.org"]["notes"])
+				require.Equal(t, "chrome-s3cret", saved["example.com"]["password"])
+			},
+		},
+		{
+			name:    "firefox csv",
+			format:  importer.FormatCSV,
+			data:    func(t *testing.T) []byte { return fixture(t, "firefox.csv") },
+			created: []string{"forum.example.net"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeLogin,
+					"title":      "forum.example.net",
+					"username":   "carol",
+					"password":   "firefox-s3cret",
+					"uris":       []any{"https://forum.example.net"},
+				}, saved["forum.example.net"])
+			},
+		},
+		{
+			name:    "bitwarden csv",
+			format:  importer.FormatCSV,
+			data:    func(t *testing.T) []byte { return fixture(t, "bitwarden.csv") },
+			created: []string{"Mail", "Wifi"},
+			check: func(t *testing.T, saved map[string]map[string]any) {
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeLogin,
+					"title":      "Mail",
+					"username":   "alice",
+					"password":   "bw-s3cret",
+					"totp":       "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+					"uris":       []any{"https://mail.example.com", "https://webmail.example.com"},
+				}, saved["Mail"])
+				require.Equal(t, map[string]any{
+					"entry_type": models.EntryTypeSecureNote,
+					"title":      "Wifi",
+					"content":    "The password is hunter2\nlocation: office",
+				}, saved["Wifi"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newService(t)
+			data := tt.data(t)
+
+			dryRun, err := service.Import(context.Background(), accountID, tt.format, data, tt.password, true)
+			require.NoError(t, err)
+			require.True(t, dryRun.DryRun)
+			require.Empty(t, entries(t, db))
+
+			report, err := service.Import(context.Background(), accountID, tt.format, data, tt.password, false)
+			require.NoError(t, err)
+			require.Equal(t, tt.created, titles(report.Created))
+			require.Equal(t, titles(dryRun.Created), titles(report.Created))
+			if tt.skipped == nil {
+				tt.skipped = []string{}
+			}
+			if tt.duplicates == nil {
+				tt.duplicates = []string{}
+			}
+			require.Equal(t, tt.skipped, titles(report.Skipped))
+			require.Equal(t, tt.duplicates, titles(report.Duplicates))
+			for _, created := range report.Created {
+				require.NotZero(t, created.EntryID)
+			}
+
+			saved := entries(t, db)
+			require.Len(t, saved, len(tt.created))
+			tt.check(t, saved)
+
+			// Importing the same file again only finds duplicates of the saved entries.
+			again, err := service.Import(context.Background(), accountID, tt.format, data, tt.password, false)
+			require.NoError(t, err)
+			require.Empty(t, again.Created)
+			require.Len(t, again.Duplicates, len(tt.created)+len(tt.duplicates))
+			require.Len(t, entries(t, db), len(tt.created))
+		})
+	}
+}
+
+// hostileKDBX returns the KeePass fixture with its Argon2 memory parameter set to memory
+// bytes, as an upload trying to exhaust the memory of the server would.
+func hostileKDBX(t *testing.T, memory uint64) []byte {
+	data := fixture(t, "keepass.kdbx")
+	param := []byte{0x05, 0x01, 0x00, 0x00, 0x00, 'M', 0x08, 0x00, 0x00, 0x00}
+	i := bytes.Index(data, param)
+	require.NotEqual(t, -1, i)
+	binary.LittleEndian.PutUint64(data[i+len(param):], memory)
+	return data
+}
+
+func TestImportErrors(t *testing.T) {
+	service, _ := newService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		format   string
+		data     []byte
+		password string
+		wantErr  error
+	}{
+		{"unknown format", "lastpass", []byte("{}"), "", importer.ErrUnknownFormat},
+		{"invalid json", importer.FormatBitwarden, []byte("{"), "", importer.ErrInvalidFile},
+		{"encrypted bitwarden", importer.FormatBitwarden, []byte(`{"encrypted":true,"items":[]}`), "", importer.ErrInvalidFile},
+		{"kdbx without password", importer.FormatKDBX, fixture(t, "keepass.kdbx"), "", importer.ErrPasswordRequired},
+		{"kdbx with wrong password", importer.FormatKDBX, fixture(t, "keepass.kdbx"), "battery staple", importer.ErrInvalidPassword},
+		{"not a kdbx", importer.FormatKDBX, fixture(t, "keepass.xml"), "correct horse", importer.ErrInvalidFile},
+		{"kdbx with hostile Argon2 memory", importer.FormatKDBX, hostileKDBX(t, 1<<42), "correct horse", importer.ErrInvalidFile},
+		{"not a 1pux", importer.Format1PUX, fixture(t, "bitwarden.json"), "", importer.ErrInvalidFile},
+		{"csv without passwords", importer.FormatCSV, []byte("name,url\nexample,https://example.com\n"), "", importer.ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Import(ctx, accountID, tt.format, tt.data, tt.password, false)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestImportSkipsExistingEntries(t *testing.T) {
+	service, db := newService(t)
+	ctx := context.Background()
+
+	// Field order and empty optional fields do not make an entry different.
+	id, err := db.SaveEntry(ctx, accountID, models.EntryTypeLogin,
+		`{"uris":["https://forum.example.net"],"password":"firefox-s3cret","username":"carol","title":"forum.example.net","notes":""}`)
+	require.NoError(t, err)
+
+	report, err := service.Import(ctx, accountID, importer.FormatCSV, fixture(t, "firefox.csv"), "", false)
+	require.NoError(t, err)
+	require.Empty(t, report.Created)
+	require.Len(t, report.Duplicates, 1)
+	require.Equal(t, id, report.Duplicates[0].EntryID)
+}
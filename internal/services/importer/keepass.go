@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/kdbx"
+)
+
+type keepassFile struct {
+	Meta struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keepassGroup struct {
+	UUID   string         `xml:"UUID"`
+	Groups []keepassGroup `xml:"Group"`
+	// Older versions of an entry are kept in its History element and are not imported.
+	Entries []struct {
+		Strings []struct {
+			Key   string `xml:"Key"`
+			Value string `xml:"Value"`
+		} `xml:"String"`
+	} `xml:"Entry"`
+}
+
+// parseKDBX decrypts a KeePass database and reads the XML document it holds.
+func parseKDBX(ctx context.Context, data []byte, password string) ([]item, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	document, err := kdbx.Decrypt(ctx, data, password)
+	if err != nil {
+		if errors.Is(err, kdbx.ErrInvalidCredentials) {
+			return nil, ErrInvalidPassword
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	return parseKeePassXML(document)
+}
+
+// parseKeePassXML reads the XML export of KeePass 2 and KeePassXC. Every entry
+// becomes a login; custom strings are kept in the notes. Entries in the recycle bin
+// are left out.
+func parseKeePassXML(data []byte) ([]item, error) {
+	var file keepassFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var items []item
+	var walk func(groups []keepassGroup)
+	walk = func(groups []keepassGroup) {
+		for _, group := range groups {
+			if file.Meta.RecycleBinUUID != "" && group.UUID == file.Meta.RecycleBinUUID {
+				continue
+			}
+
+			for _, entry := range group.Entries {
+				data := map[string]any{}
+				var notes string
+				var extra []string
+				for _, s := range entry.Strings {
+					switch s.Key {
+					case "Title":
+						data["title"] = s.Value
+					case "UserName":
+						data["username"] = s.Value
+					case "Password":
+						data["password"] = s.Value
+					case "URL":
+						if s.Value != "" {
+							data["uris"] = []string{s.Value}
+						}
+					case "Notes":
+						notes = s.Value
+					case "otp":
+						data["totp"] = s.Value
+					default:
+						if s.Value != "" {
+							extra = append(extra, s.Key+": "+s.Value)
+						}
+					}
+				}
+				data["notes"] = joinNotes(notes, extra...)
+				items = append(items, item{entryType: models.EntryTypeLogin, data: data})
+			}
+
+			walk(group.Groups)
+		}
+	}
+	walk(file.Root.Groups)
+
+	return items, nil
+}
@@ -0,0 +1,305 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"passvault/internal/domain/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 1Password item categories.
+const (
+	onePasswordLogin         = "001"
+	onePasswordCreditCard    = "002"
+	onePasswordSecureNote    = "003"
+	onePasswordIdentity      = "004"
+	onePasswordPassword      = "005"
+	onePasswordDocument      = "006"
+	onePasswordAPICredential = "112"
+	onePasswordSSHKey        = "114"
+)
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Overview     struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+	} `json:"overview"`
+	Details struct {
+		NotesPlain  string `json:"notesPlain"`
+		Password    string `json:"password"`
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		Sections []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				ID    string                     `json:"id"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+// onePasswordField is a section field of an item with its value decoded.
+type onePasswordField struct {
+	title string
+	kind  string
+	value string
+	// number holds the value of date and monthYear fields.
+	number int64
+	// sshKey holds the value of SSH key fields.
+	sshKey struct {
+		PrivateKey string `json:"privateKey"`
+		Metadata   struct {
+			PublicKey   string `json:"publicKey"`
+			Fingerprint string `json:"fingerprint"`
+		} `json:"metadata"`
+	}
+}
+
+// parse1PUX reads the export.data document of a 1Password 1PUX archive. Section
+// fields that have no place in the entry schemas are kept in the notes, items of other
+// categories are imported as secure notes and archived items are skipped.
+func parse1PUX(data []byte) ([]item, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	file, err := archive.Open("export.data")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer file.Close()
+
+	document, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var export onePasswordExport
+	if err := json.Unmarshal(document, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var items []item
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, op := range vault.Items {
+				items = append(items, onePasswordEntry(op))
+			}
+		}
+	}
+	return items, nil
+}
+
+func onePasswordEntry(op onePasswordItem) item {
+	data := map[string]any{"title": op.Overview.Title}
+	it := item{data: data}
+
+	if op.State == "archived" {
+		it.reason = "archived item"
+		return it
+	}
+
+	fields := make(map[string]onePasswordField)
+	var order []string
+	for _, section := range op.Details.Sections {
+		for _, f := range section.Fields {
+			field := decodeOnePasswordField(f.Title, f.Value)
+			fields[f.ID] = field
+			order = append(order, f.ID)
+		}
+	}
+
+	// take returns the value of a field and keeps it out of the notes.
+	take := func(ids ...string) string {
+		for _, id := range ids {
+			if field, ok := fields[id]; ok && field.value != "" {
+				delete(fields, id)
+				return field.value
+			}
+		}
+		return ""
+	}
+
+	switch op.CategoryUUID {
+	case onePasswordLogin, onePasswordPassword:
+		it.entryType = models.EntryTypeLogin
+		for _, field := range op.Details.LoginFields {
+			switch field.Designation {
+			case "username":
+				data["username"] = field.Value
+			case "password":
+				data["password"] = field.Value
+			}
+		}
+		if op.Details.Password != "" {
+			data["password"] = op.Details.Password
+		}
+
+		var uris []string
+		if op.Overview.URL != "" {
+			uris = append(uris, op.Overview.URL)
+		}
+		for _, u := range op.Overview.URLs {
+			if u.URL != "" && u.URL != op.Overview.URL {
+				uris = append(uris, u.URL)
+			}
+		}
+		data["uris"] = uris
+
+		for id, field := range fields {
+			if field.kind == "totp" {
+				data["totp"] = field.value
+				delete(fields, id)
+				break
+			}
+		}
+	case onePasswordCreditCard:
+		it.entryType = models.EntryTypeCreditCard
+		data["cardholder_name"] = take("cardholder")
+		data["number"] = take("ccnum")
+		data["cvv"] = take("cvv")
+		data["brand"] = take("type")
+		if expiry, ok := fields["expiry"]; ok && expiry.number > 0 {
+			data["exp_year"] = int(expiry.number / 100)
+			data["exp_month"] = int(expiry.number % 100)
+			delete(fields, "expiry")
+		}
+	case onePasswordSecureNote:
+		it.entryType = models.EntryTypeSecureNote
+		data["content"] = op.Details.NotesPlain
+		data["notes"] = onePasswordNotes("", fields, order)
+		return it
+	case onePasswordIdentity:
+		it.entryType = models.EntryTypeIdentity
+		data["first_name"] = joinWords(take("firstname"), take("initial"))
+		data["last_name"] = take("lastname")
+		data["email"] = take("email")
+		data["phone"] = take("defphone", "cellphone", "homephone", "busphone")
+		data["company"] = take("company")
+		data["address"] = take("address")
+	case onePasswordAPICredential:
+		it.entryType = models.EntryTypeAPIToken
+		data["token"] = take("credential")
+		data["service"] = take("hostname")
+		if expires, ok := fields["expires"]; ok && expires.number > 0 {
+			data["expires_at"] = time.Unix(expires.number, 0).UTC().Format(time.RFC3339)
+			delete(fields, "expires")
+		}
+	case onePasswordSSHKey:
+		it.entryType = models.EntryTypeSSHKey
+		for id, field := range fields {
+			if field.kind == "sshKey" {
+				data["private_key"] = field.sshKey.PrivateKey
+				data["public_key"] = field.sshKey.Metadata.PublicKey
+				data["fingerprint"] = field.sshKey.Metadata.Fingerprint
+				delete(fields, id)
+				break
+			}
+		}
+	case onePasswordDocument:
+		it.reason = "documents are not supported"
+		return it
+	default:
+		// Other categories keep all their fields in a secure note.
+		it.entryType = models.EntryTypeSecureNote
+		data["content"] = onePasswordNotes(op.Details.NotesPlain, fields, order)
+		return it
+	}
+
+	data["notes"] = onePasswordNotes(op.Details.NotesPlain, fields, order)
+	return it
+}
+
+// onePasswordNotes appends the remaining fields to notes in the order of the item.
+func onePasswordNotes(notes string, fields map[string]onePasswordField, order []string) string {
+	lines := make([]string, 0, len(fields))
+	for _, id := range order {
+		field, ok := fields[id]
+		if !ok || field.value == "" {
+			continue
+		}
+		title := field.title
+		if title == "" {
+			title = id
+		}
+		lines = append(lines, title+": "+field.value)
+	}
+	return joinNotes(notes, lines...)
+}
+
+// decodeOnePasswordField decodes a field value, an object with a single key naming
+// the kind of the value.
+func decodeOnePasswordField(title string, value map[string]json.RawMessage) onePasswordField {
+	field := onePasswordField{title: title}
+
+	kinds := make([]string, 0, len(value))
+	for kind := range value {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	if len(kinds) == 0 {
+		return field
+	}
+
+	field.kind = kinds[0]
+	raw := value[field.kind]
+	switch field.kind {
+	case "date", "monthYear":
+		json.Unmarshal(raw, &field.number)
+		field.value = string(raw)
+	case "email":
+		// Newer exports wrap the address in an object.
+		var email struct {
+			Address string `json:"email_address"`
+		}
+		if json.Unmarshal(raw, &email) == nil {
+			field.value = email.Address
+		} else {
+			json.Unmarshal(raw, &field.value)
+		}
+	case "address":
+		var address struct {
+			Street  string `json:"street"`
+			City    string `json:"city"`
+			State   string `json:"state"`
+			Zip     string `json:"zip"`
+			Country string `json:"country"`
+		}
+		json.Unmarshal(raw, &address)
+		field.value = joinAddress(address.Street, joinWords(address.Zip, address.City), address.State, address.Country)
+	case "sshKey":
+		json.Unmarshal(raw, &field.sshKey)
+		field.value = field.sshKey.PrivateKey
+	default:
+		if json.Unmarshal(raw, &field.value) != nil {
+			field.value = strings.Trim(string(raw), `"`)
+		}
+	}
+	if field.value == "null" {
+		field.value = ""
+	}
+	return field
+}
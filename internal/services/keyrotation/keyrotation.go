@@ -0,0 +1,362 @@
+// Package keyrotation rotates the key parts of accounts and organizations: it starts a
+// new key version and re-encrypts the existing data under it in the background. Rotating
+// every key part still derived with an older master key rotates the master key.
+package keyrotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/storage"
+	"time"
+)
+
+const (
+	// DefaultBatchSize is the number of rows rewrapped between two progress saves.
+	DefaultBatchSize = 100
+	// pollInterval is how often the worker looks for rotations started by other processes.
+	pollInterval = time.Minute
+	// retireAttempts is how many times the old key versions are swept before retiring
+	// them gives up on rows still sealed under them.
+	retireAttempts = 3
+)
+
+type Rotator interface {
+	StartKeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error)
+	KeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error)
+	ListKeyRotations(ctx context.Context, status string) ([]models.KeyRotation, error)
+	UpdateKeyRotation(ctx context.Context, rotation models.KeyRotation) error
+	RewrapEntries(ctx context.Context, accountID int64, afterID int64, limit int) (int64, int, error)
+	RewrapRevisions(ctx context.Context, accountID int64, afterID int64, limit int) (int64, int, error)
+	RetireKeyParts(ctx context.Context, accountID int64, keyVersion int) error
+
+	StartOrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error)
+	OrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error)
+	RewrapCollectionEntries(ctx context.Context, orgID int64, afterID int64, limit int) (int64, int, error)
+	RewrapCollectionRevisions(ctx context.Context, orgID int64, afterID int64, limit int) (int64, int, error)
+	RetireOrganizationKeyParts(ctx context.Context, orgID int64, keyVersion int) error
+
+	MasterKeyStatus(ctx context.Context) (*models.MasterKeyStatus, error)
+}
+
+type Service struct {
+	log       *slog.Logger
+	rotator   Rotator
+	batchSize int
+	wake      chan struct{}
+}
+
+// New returns a service rewrapping batchSize rows at a time; zero or less uses DefaultBatchSize.
+func New(log *slog.Logger, rotator Rotator, batchSize int) *Service {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Service{
+		log:       log,
+		rotator:   rotator,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start starts a key rotation for an account and hands it to the worker. A failed
+// rotation is resumed from where it stopped instead of starting another key version.
+// The recovery shares of the account cannot restore the new key part, so starting a
+// rotation removes them; the rotation counts them in SharesRemoved and the account
+// has to split its key part again.
+func (s *Service) Start(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	const op = "services.keyrotation.Start"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID))
+
+	rotation, err := s.start(ctx, log, accountID, s.rotator.KeyRotation, s.rotator.StartKeyRotation)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// StartOrganization is Start for the key shared by the collections of an organization.
+func (s *Service) StartOrganization(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	const op = "services.keyrotation.StartOrganization"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("organization_id", orgID))
+
+	rotation, err := s.start(ctx, log, orgID, s.rotator.OrganizationKeyRotation, s.rotator.StartOrganizationKeyRotation)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// StartMaster starts a key rotation for every account and organization with a key part
+// derived with an older master key, moving them to the current one. Accounts and
+// organizations already rotating are skipped, as are accounts whose key part awaits
+// recovery from its shares. A resumed failed rotation may still end on an older master
+// key, MasterStatus lists what is left once the rotations are done.
+func (s *Service) StartMaster(ctx context.Context) ([]models.KeyRotation, error) {
+	const op = "services.keyrotation.StartMaster"
+
+	log := s.log.With(slog.String("op", op))
+
+	status, err := s.rotator.MasterKeyStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rotations := make([]models.KeyRotation, 0, len(status.Accounts)+len(status.Organizations))
+	for _, accountID := range status.Accounts {
+		rotation, err := s.Start(ctx, accountID)
+		switch {
+		case errors.Is(err, storage.ErrKeyRotationRunning):
+			log.Info("key rotation already running", slog.Int64("account_id", accountID))
+		case errors.Is(err, storage.ErrEncryptionKeyNotFound):
+			log.Warn("key part awaits recovery, not rotated", slog.Int64("account_id", accountID))
+		case err != nil:
+			return rotations, fmt.Errorf("%s: %w", op, err)
+		default:
+			rotations = append(rotations, *rotation)
+		}
+	}
+	for _, orgID := range status.Organizations {
+		rotation, err := s.StartOrganization(ctx, orgID)
+		switch {
+		case errors.Is(err, storage.ErrKeyRotationRunning):
+			log.Info("key rotation already running", slog.Int64("organization_id", orgID))
+		case err != nil:
+			return rotations, fmt.Errorf("%s: %w", op, err)
+		default:
+			rotations = append(rotations, *rotation)
+		}
+	}
+
+	log.Info("master key rotation started", slog.Int("master_key_version", status.MasterKeyVersion), slog.Int("rotations", len(rotations)))
+
+	return rotations, nil
+}
+
+func (s *Service) start(
+	ctx context.Context,
+	log *slog.Logger,
+	id int64,
+	latest func(context.Context, int64) (*models.KeyRotation, error),
+	begin func(context.Context, int64) (*models.KeyRotation, error),
+) (*models.KeyRotation, error) {
+	rotation, err := latest(ctx, id)
+	switch {
+	case err == nil && rotation.Status == models.KeyRotationRunning:
+		return nil, storage.ErrKeyRotationRunning
+	case err == nil && rotation.Status == models.KeyRotationFailed:
+		rotation.Status = models.KeyRotationRunning
+		rotation.Error = ""
+		if err := s.rotator.UpdateKeyRotation(ctx, *rotation); err != nil {
+			return nil, err
+		}
+		log.Info("key rotation resumed", slog.Int64("rotation_id", rotation.ID))
+	case err != nil && !errors.Is(err, storage.ErrKeyRotationNotFound):
+		return nil, err
+	default:
+		rotation, err = begin(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("key rotation started", slog.Int64("rotation_id", rotation.ID), slog.Int("key_version", rotation.ToVersion),
+			slog.Int("shares_removed", rotation.SharesRemoved))
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return rotation, nil
+}
+
+// Status returns the latest key rotation of an account.
+func (s *Service) Status(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	const op = "services.keyrotation.Status"
+
+	rotation, err := s.rotator.KeyRotation(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// OrganizationStatus returns the latest key rotation of an organization.
+func (s *Service) OrganizationStatus(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	const op = "services.keyrotation.OrganizationStatus"
+
+	rotation, err := s.rotator.OrganizationKeyRotation(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// MasterStatus returns the accounts and organizations still keyed with an older master key.
+func (s *Service) MasterStatus(ctx context.Context) (*models.MasterKeyStatus, error) {
+	const op = "services.keyrotation.MasterStatus"
+
+	status, err := s.rotator.MasterKeyStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+// Run works through running key rotations until ctx is done. Rotations left running
+// by a crash are resumed on start, new ones as soon as Start hands them over.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.resume(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) resume(ctx context.Context) {
+	const op = "services.keyrotation.Run"
+
+	log := s.log.With(slog.String("op", op))
+
+	rotations, err := s.rotator.ListKeyRotations(ctx, models.KeyRotationRunning)
+	if err != nil {
+		log.Error("failed to list key rotations", sl.Err(err))
+		return
+	}
+
+	for _, rotation := range rotations {
+		if ctx.Err() != nil {
+			return
+		}
+
+		log := log.With(slog.Int64("account_id", rotation.AccountID), slog.Int64("organization_id", rotation.OrganizationID),
+			slog.Int64("rotation_id", rotation.ID))
+
+		done, err := s.Rotate(ctx, rotation)
+		if err != nil {
+			log.Error("failed to rotate key", sl.Err(err))
+			continue
+		}
+		log.Info("key rotated", slog.Int("entries", done.Entries), slog.Int("revisions", done.Revisions))
+	}
+}
+
+// Rotate rewraps the entries and revisions of a running rotation in batches, saving
+// its progress after each one, then retires the old key versions. A cancelled ctx
+// leaves the rotation running to be resumed later; any other error fails it.
+func (s *Service) Rotate(ctx context.Context, rotation models.KeyRotation) (*models.KeyRotation, error) {
+	const op = "services.keyrotation.Rotate"
+
+	if err := s.rotate(ctx, &rotation); err != nil {
+		if ctx.Err() == nil {
+			rotation.Status = models.KeyRotationFailed
+			rotation.Error = err.Error()
+			if updateErr := s.rotator.UpdateKeyRotation(ctx, rotation); updateErr != nil {
+				err = errors.Join(err, updateErr)
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &rotation, nil
+}
+
+func (s *Service) rotate(ctx context.Context, rotation *models.KeyRotation) error {
+	id, rewrapEntries, rewrapRevisions, retire := rotation.AccountID, s.rotator.RewrapEntries, s.rotator.RewrapRevisions, s.rotator.RetireKeyParts
+	if rotation.OrganizationID != 0 {
+		id, rewrapEntries, rewrapRevisions, retire = rotation.OrganizationID, s.rotator.RewrapCollectionEntries,
+			s.rotator.RewrapCollectionRevisions, s.rotator.RetireOrganizationKeyParts
+	}
+
+	for {
+		cursor, rewrapped, err := rewrapEntries(ctx, id, rotation.EntryCursor, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if cursor == rotation.EntryCursor {
+			break
+		}
+		rotation.EntryCursor = cursor
+		rotation.Entries += rewrapped
+		if err := s.rotator.UpdateKeyRotation(ctx, *rotation); err != nil {
+			return err
+		}
+	}
+
+	for {
+		cursor, rewrapped, err := rewrapRevisions(ctx, id, rotation.RevisionCursor, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if cursor == rotation.RevisionCursor {
+			break
+		}
+		rotation.RevisionCursor = cursor
+		rotation.Revisions += rewrapped
+		if err := s.rotator.UpdateKeyRotation(ctx, *rotation); err != nil {
+			return err
+		}
+	}
+
+	// Requests in flight when the rotation started may still have sealed rows under the
+	// old key behind the cursors. Another pass picks them up, and retire refuses while
+	// any are left, so the old key is never dropped from under them.
+	for attempt := 1; ; attempt++ {
+		entries, err := s.sweep(ctx, id, rewrapEntries)
+		if err != nil {
+			return err
+		}
+		revisions, err := s.sweep(ctx, id, rewrapRevisions)
+		if err != nil {
+			return err
+		}
+		rotation.Entries += entries
+		rotation.Revisions += revisions
+
+		err = retire(ctx, id, rotation.ToVersion)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, storage.ErrEncryptionKeyInUse) || attempt == retireAttempts {
+			return err
+		}
+	}
+
+	finishedAt := time.Now().UTC()
+	rotation.Status = models.KeyRotationDone
+	rotation.FinishedAt = &finishedAt
+	rotation.UpdatedAt = finishedAt
+	return s.rotator.UpdateKeyRotation(ctx, *rotation)
+}
+
+// sweep rewraps every row of an account or organization with rewrap and returns how many
+// were rewrapped.
+func (s *Service) sweep(ctx context.Context, id int64, rewrap func(context.Context, int64, int64, int) (int64, int, error)) (int, error) {
+	var cursor int64
+	total := 0
+	for {
+		next, rewrapped, err := rewrap(ctx, id, cursor, s.batchSize)
+		if err != nil {
+			return total, err
+		}
+		if next == cursor {
+			return total, nil
+		}
+		cursor = next
+		total += rewrapped
+	}
+}
@@ -0,0 +1,288 @@
+package keyrotation_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyRotator fails rewrapping entries once its budget of batches is used up.
+type flakyRotator struct {
+	*encrypted.Storage
+	batches int
+}
+
+func (f *flakyRotator) RewrapEntries(ctx context.Context, accountID int64, afterID int64, limit int) (int64, int, error) {
+	if f.batches == 0 {
+		return afterID, 0, errors.New("disk on fire")
+	}
+	f.batches--
+	return f.Storage.RewrapEntries(ctx, accountID, afterID, limit)
+}
+
+// lateRotator writes data sealed under the old key before retiring it, like a request
+// that loaded the keys before the rotation started and committed after the sweep.
+type lateRotator struct {
+	*encrypted.Storage
+	db      *memory.Storage
+	entryID int64
+	sealed  string
+	writes  int
+}
+
+func (l *lateRotator) RetireKeyParts(ctx context.Context, accountID int64, keyVersion int) error {
+	if l.writes > 0 {
+		l.writes--
+		if err := l.db.UpdateEntry(ctx, accountID, l.entryID, "login", l.sealed); err != nil {
+			return err
+		}
+	}
+	return l.Storage.RetireKeyParts(ctx, accountID, keyVersion)
+}
+
+func newStorage(t *testing.T) (*encrypted.Storage, *memory.Storage, []int64) {
+	t.Helper()
+
+	db := memory.New(storage.RevisionRetention{})
+	s := encrypted.New(db, envelope.NewMasterKeys(make([]byte, envelope.KeySize)))
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		id, err := s.SaveEntry(context.Background(), 123, "login", fmt.Sprintf(`{"title": "Entry %d"}`, i))
+		require.NoError(t, err)
+		require.NoError(t, s.UpdateEntry(context.Background(), 123, id, "login", fmt.Sprintf(`{"title": "Login %d"}`, i)))
+		ids = append(ids, id)
+	}
+	return s, db, ids
+}
+
+func requireKeyVersion(t *testing.T, db *memory.Storage, ids []int64, want int) {
+	t.Helper()
+
+	for _, id := range ids {
+		entry, err := db.GetEntry(context.Background(), 123, id)
+		require.NoError(t, err)
+		version, err := envelope.KeyVersion(entry.EntryData)
+		require.NoError(t, err)
+		require.Equal(t, want, version)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	s, db, ids := newStorage(t)
+	service := keyrotation.New(slog.New(slog.NewTextHandler(io.Discard, nil)), s, 2)
+
+	_, err := service.Status(ctx, 123)
+	require.ErrorIs(t, err, storage.ErrKeyRotationNotFound)
+	_, err = service.Start(ctx, 124)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	rotation, err := service.Start(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationRunning, rotation.Status)
+	require.Equal(t, 2, rotation.ToVersion)
+	_, err = service.Start(ctx, 123)
+	require.ErrorIs(t, err, storage.ErrKeyRotationRunning)
+
+	done, err := service.Rotate(ctx, *rotation)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationDone, done.Status)
+	require.Equal(t, 5, done.Entries)
+	require.Equal(t, 5, done.Revisions)
+	require.NotNil(t, done.FinishedAt)
+
+	status, err := service.Status(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationDone, status.Status)
+	require.Equal(t, ids[len(ids)-1], status.EntryCursor)
+
+	requireKeyVersion(t, db, ids, 2)
+	parts, err := db.KeyParts(ctx, 123)
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+
+	entry, err := s.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Login 0"}`, entry.EntryData)
+
+	// The next rotation moves on to another key version.
+	rotation, err = service.Start(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, 3, rotation.ToVersion)
+}
+
+func TestRotateResumesFailedRotation(t *testing.T) {
+	ctx := context.Background()
+	s, db, ids := newStorage(t)
+	rotator := &flakyRotator{Storage: s, batches: 1}
+	service := keyrotation.New(slog.New(slog.NewTextHandler(io.Discard, nil)), rotator, 2)
+
+	rotation, err := service.Start(ctx, 123)
+	require.NoError(t, err)
+
+	_, err = service.Rotate(ctx, *rotation)
+	require.ErrorContains(t, err, "disk on fire")
+
+	status, err := service.Status(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationFailed, status.Status)
+	require.Equal(t, "disk on fire", status.Error)
+	require.Equal(t, ids[1], status.EntryCursor)
+	require.Equal(t, 2, status.Entries)
+
+	// Both key versions stay readable until the rotation is done.
+	entry, err := s.GetEntry(ctx, 123, ids[4])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Login 4"}`, entry.EntryData)
+
+	rotator.batches = -1
+	resumed, err := service.Start(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, rotation.ID, resumed.ID)
+	require.Equal(t, models.KeyRotationRunning, resumed.Status)
+	require.Equal(t, ids[1], resumed.EntryCursor)
+
+	done, err := service.Rotate(ctx, *resumed)
+	require.NoError(t, err)
+	require.Equal(t, 5, done.Entries)
+	requireKeyVersion(t, db, ids, 2)
+}
+
+func TestRotateKeepsKeysInUse(t *testing.T) {
+	ctx := context.Background()
+	s, db, ids := newStorage(t)
+	entry, err := db.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	rotator := &lateRotator{Storage: s, db: db, entryID: ids[0], sealed: entry.EntryData, writes: 1}
+	service := keyrotation.New(slog.New(slog.NewTextHandler(io.Discard, nil)), rotator, 2)
+
+	rotation, err := service.Start(ctx, 123)
+	require.NoError(t, err)
+	done, err := service.Rotate(ctx, *rotation)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationDone, done.Status)
+	requireKeyVersion(t, db, ids, 2)
+	entry, err = s.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Login 0"}`, entry.EntryData)
+
+	// Rows that keep arriving under the old key fail the rotation rather than lose the key.
+	raw, err := db.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	rotator.sealed, rotator.writes = raw.EntryData, 100
+	rotation, err = service.Start(ctx, 123)
+	require.NoError(t, err)
+	_, err = service.Rotate(ctx, *rotation)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyInUse)
+	parts, err := db.KeyParts(ctx, 123)
+	require.NoError(t, err)
+	require.Len(t, parts, 2)
+	entry, err = s.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Login 0"}`, entry.EntryData)
+}
+
+func TestRunResumesRunningRotations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, db, ids := newStorage(t)
+	rotation, err := s.StartKeyRotation(ctx, 123)
+	require.NoError(t, err)
+
+	service := keyrotation.New(slog.New(slog.NewTextHandler(io.Discard, nil)), s, 2)
+	stopped := make(chan struct{})
+	go func() {
+		service.Run(ctx)
+		close(stopped)
+	}()
+
+	require.Eventually(t, func() bool {
+		status, err := service.Status(ctx, 123)
+		return err == nil && status.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+	requireKeyVersion(t, db, ids, rotation.ToVersion)
+
+	// Rotations started afterwards are picked up right away.
+	_, err = service.Start(ctx, 123)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		status, err := service.Status(ctx, 123)
+		return err == nil && status.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+	requireKeyVersion(t, db, ids, rotation.ToVersion+1)
+
+	cancel()
+	<-stopped
+}
+
+func TestStartMaster(t *testing.T) {
+	ctx := context.Background()
+	old, db, ids := newStorage(t)
+
+	orgID, err := old.CreateOrganization(ctx, 123, "acme")
+	require.NoError(t, err)
+	collectionID, err := old.CreateCollection(ctx, orgID, "infra")
+	require.NoError(t, err)
+	shared, err := old.SaveCollectionEntry(ctx, 123, collectionID, "login", `{"title": "db"}`)
+	require.NoError(t, err)
+	// The key part of 125 is lost, only its shares are left.
+	require.NoError(t, db.SaveKeyShares(ctx, 125, []models.EncryptionKey{
+		{KeyPart: "commitment", ShareIndex: 1, Threshold: 1, Holder: "alice", KeyVersion: 1, MasterKeyVersion: 1},
+	}))
+
+	oldKey := base64.StdEncoding.EncodeToString(make([]byte, envelope.KeySize))
+	newKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("n", envelope.KeySize)))
+	keys, err := envelope.ParseMasterKeys(2, newKey, map[int]string{1: oldKey})
+	require.NoError(t, err)
+	service := keyrotation.New(slog.New(slog.NewTextHandler(io.Discard, nil)), encrypted.New(db, keys), 2)
+
+	status, err := service.MasterStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{123, 125}, status.Accounts)
+	require.Equal(t, []int64{orgID}, status.Organizations)
+
+	rotations, err := service.StartMaster(ctx)
+	require.NoError(t, err)
+	require.Len(t, rotations, 2)
+	require.Equal(t, int64(123), rotations[0].AccountID)
+	require.Equal(t, orgID, rotations[1].OrganizationID)
+
+	for _, rotation := range rotations {
+		done, err := service.Rotate(ctx, rotation)
+		require.NoError(t, err)
+		require.Equal(t, models.KeyRotationDone, done.Status)
+	}
+	orgRotation, err := service.OrganizationStatus(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, 1, orgRotation.Entries)
+
+	status, err = service.MasterStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{125}, status.Accounts)
+	require.Empty(t, status.Organizations)
+
+	keys, err = envelope.ParseMasterKeys(2, newKey, nil)
+	require.NoError(t, err)
+	rotated := encrypted.New(db, keys)
+	entry, err := rotated.GetEntry(ctx, 123, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Login 0"}`, entry.EntryData)
+	entry, err = rotated.GetEntry(ctx, 123, shared)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "db"}`, entry.EntryData)
+}
@@ -0,0 +1,212 @@
+// Package keyshare splits account key parts into Shamir shares and recovers
+// them once enough share holders approve.
+package keyshare
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/logger/sl"
+	"passvault/internal/lib/shamir"
+	"passvault/internal/storage"
+	"strconv"
+)
+
+var (
+	ErrInvalidParams   = errors.New("invalid split parameters")
+	ErrNotEnoughShares = errors.New("not enough shares")
+	ErrInvalidShare    = errors.New("invalid share")
+	ErrKeyMismatch     = errors.New("recovered key does not match stored key")
+)
+
+// Share is a single Shamir share handed out to a share holder.
+type Share struct {
+	Index  int    `json:"index" validate:"required,min=1"`
+	Holder string `json:"holder" validate:"required"`
+	Value  string `json:"share" validate:"required"`
+}
+
+// KeyStorage keeps the account key parts and, for their Shamir shares, only a
+// commitment per share: the shares themselves exist solely with their holders.
+type KeyStorage interface {
+	KeyParts(ctx context.Context, accountID int64) ([]models.EncryptionKey, error)
+	RestoreKeyPart(ctx context.Context, accountID int64, keyVersion, masterKeyVersion int, keyPart string) error
+	SaveKeyShares(ctx context.Context, accountID int64, shares []models.EncryptionKey) error
+	KeyShares(ctx context.Context, accountID int64) ([]models.EncryptionKey, error)
+}
+
+type Service struct {
+	log        *slog.Logger
+	keyStorage KeyStorage
+}
+
+func New(log *slog.Logger, keyStorage KeyStorage) *Service {
+	return &Service{
+		log:        log,
+		keyStorage: keyStorage,
+	}
+}
+
+// Split divides the account key part into one share per holder with the given
+// threshold and returns the shares for distribution. Only a commitment binding
+// each share to its holder is stored; previously stored shares are replaced.
+func (s *Service) Split(ctx context.Context, accountID int64, holders []string, threshold int) ([]Share, error) {
+	const op = "services.keyshare.Split"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID))
+
+	seen := make(map[string]struct{}, len(holders))
+	for _, holder := range holders {
+		if _, ok := seen[holder]; ok || holder == "" {
+			return nil, fmt.Errorf("%s: %w: holders must be distinct and non-empty", op, ErrInvalidParams)
+		}
+		seen[holder] = struct{}{}
+	}
+
+	keyParts, err := s.keyStorage.KeyParts(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Shares taken mid-rotation would only restore one of the key versions in use.
+	if len(keyParts) > 1 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationRunning)
+	}
+	keyPart := keyParts[0]
+
+	secret, err := base64.StdEncoding.DecodeString(keyPart.KeyPart)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	raw, err := shamir.Split(secret, len(holders), threshold)
+	if err != nil {
+		if errors.Is(err, shamir.ErrInvalidParams) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidParams)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	commitments := make([]models.EncryptionKey, len(raw))
+	shares := make([]Share, len(raw))
+	for i, share := range raw {
+		index := i + 1
+		commitments[i] = models.EncryptionKey{
+			KeyPart:          commit(accountID, keyPart.KeyVersion, index, holders[i], share),
+			ShareIndex:       index,
+			Threshold:        threshold,
+			Holder:           holders[i],
+			KeyVersion:       keyPart.KeyVersion,
+			MasterKeyVersion: keyPart.MasterKeyVersion,
+		}
+		shares[i] = Share{Index: index, Holder: holders[i], Value: base64.StdEncoding.EncodeToString(share)}
+	}
+
+	if err := s.keyStorage.SaveKeyShares(ctx, accountID, commitments); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("key part split into shares", slog.Int("parts", len(holders)), slog.Int("threshold", threshold))
+
+	return shares, nil
+}
+
+// Recover rebuilds the account key part from the supplied shares. Every share
+// must match the commitment stored for its index and holder, and at least
+// threshold distinct shares are required. A missing key part is restored under
+// the key version and master key version the shares were taken from; an existing
+// one must match the recovered value.
+func (s *Service) Recover(ctx context.Context, accountID int64, supplied []Share) error {
+	const op = "services.keyshare.Recover"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("account_id", accountID))
+
+	stored, err := s.keyStorage.KeyShares(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	byIndex := make(map[int]models.EncryptionKey, len(stored))
+	for _, share := range stored {
+		byIndex[share.ShareIndex] = share
+	}
+	threshold, keyVersion := stored[0].Threshold, stored[0].KeyVersion
+
+	seen := make(map[int]struct{}, len(supplied))
+	raw := make([][]byte, 0, len(supplied))
+	for _, share := range supplied {
+		if _, ok := seen[share.Index]; ok {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(share.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, ErrInvalidShare)
+		}
+
+		expected, ok := byIndex[share.Index]
+		if !ok || expected.Holder != share.Holder ||
+			!shamir.Equal([]byte(expected.KeyPart), []byte(commit(accountID, keyVersion, share.Index, share.Holder, value))) {
+			log.Warn("share rejected", slog.Int("index", share.Index))
+			return fmt.Errorf("%s: %w", op, ErrInvalidShare)
+		}
+
+		seen[share.Index] = struct{}{}
+		raw = append(raw, value)
+	}
+
+	if len(raw) < threshold {
+		return fmt.Errorf("%s: %w: got %d, need %d", op, ErrNotEnoughShares, len(raw), threshold)
+	}
+
+	secret, err := shamir.Combine(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	recovered := base64.StdEncoding.EncodeToString(secret)
+
+	keyParts, err := s.keyStorage.KeyParts(ctx, accountID)
+	if errors.Is(err, storage.ErrEncryptionKeyNotFound) {
+		if err := s.keyStorage.RestoreKeyPart(ctx, accountID, keyVersion, stored[0].MasterKeyVersion, recovered); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("key part restored from shares", slog.Int("shares", len(raw)), slog.Int("key_version", keyVersion))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, keyPart := range keyParts {
+		if keyPart.KeyVersion == keyVersion && shamir.Equal([]byte(keyPart.KeyPart), []byte(recovered)) {
+			log.Info("key part verified against shares", slog.Int("shares", len(raw)))
+			return nil
+		}
+	}
+
+	log.Error("recovered key part differs from stored one", sl.Err(ErrKeyMismatch))
+	return fmt.Errorf("%s: %w", op, ErrKeyMismatch)
+}
+
+// commit returns the commitment stored in place of a share. It binds the share
+// to the account, key version, index and holder it was handed out with, so a
+// share is only accepted back from the holder it was issued to.
+func commit(accountID int64, keyVersion, index int, holder string, share []byte) string {
+	h := sha256.New()
+	fields := []string{
+		"passvault key share",
+		strconv.FormatInt(accountID, 10),
+		strconv.Itoa(keyVersion),
+		strconv.Itoa(index),
+		holder,
+	}
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	h.Write(share)
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package keyshare_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/services/keyshare"
+	"passvault/internal/storage"
+	"testing"
+)
+
+type fakeKeyStorage struct {
+	keyParts []models.EncryptionKey
+	shares   []models.EncryptionKey
+}
+
+func (f *fakeKeyStorage) KeyParts(_ context.Context, _ int64) ([]models.EncryptionKey, error) {
+	if len(f.keyParts) == 0 {
+		return nil, storage.ErrEncryptionKeyNotFound
+	}
+	return f.keyParts, nil
+}
+
+func (f *fakeKeyStorage) RestoreKeyPart(_ context.Context, _ int64, keyVersion, masterKeyVersion int, keyPart string) error {
+	if len(f.keyParts) > 0 {
+		return storage.ErrEncryptionKeyExists
+	}
+	f.keyParts = []models.EncryptionKey{{KeyPart: keyPart, KeyVersion: keyVersion, MasterKeyVersion: masterKeyVersion}}
+	return nil
+}
+
+func (f *fakeKeyStorage) SaveKeyShares(_ context.Context, _ int64, shares []models.EncryptionKey) error {
+	f.shares = shares
+	return nil
+}
+
+func (f *fakeKeyStorage) KeyShares(_ context.Context, _ int64) ([]models.EncryptionKey, error) {
+	if len(f.shares) == 0 {
+		return nil, storage.ErrKeySharesNotFound
+	}
+	return f.shares, nil
+}
+
+var holders = []string{"alice", "bob", "carol", "dave", "erin"}
+
+func TestSplitAndRecover(t *testing.T) {
+	ctx := context.Background()
+
+	keyPart, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+
+	keyStorage := &fakeKeyStorage{keyParts: []models.EncryptionKey{{KeyPart: keyPart, KeyVersion: 2, MasterKeyVersion: 3}}}
+	service := keyshare.New(slog.New(slog.NewTextHandler(os.Stdout, nil)), keyStorage)
+
+	shares, err := service.Split(ctx, 123, holders, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	// Only commitments are stored, never the shares themselves.
+	require.Len(t, keyStorage.shares, 5)
+	for i, stored := range keyStorage.shares {
+		require.Equal(t, holders[i], shares[i].Holder)
+		require.Equal(t, holders[i], stored.Holder)
+		require.Equal(t, 2, stored.KeyVersion)
+		require.NotEqual(t, shares[i].Value, stored.KeyPart)
+	}
+
+	// The key part is lost, two approvers are not enough to restore it.
+	keyStorage.keyParts = nil
+	err = service.Recover(ctx, 123, shares[:2])
+	require.ErrorIs(t, err, keyshare.ErrNotEnoughShares)
+
+	// Supplying the same share twice does not count twice.
+	err = service.Recover(ctx, 123, []keyshare.Share{shares[0], shares[0], shares[1]})
+	require.ErrorIs(t, err, keyshare.ErrNotEnoughShares)
+
+	forged := shares[2]
+	forged.Value = shares[3].Value
+	err = service.Recover(ctx, 123, []keyshare.Share{shares[0], shares[1], forged})
+	require.ErrorIs(t, err, keyshare.ErrInvalidShare)
+
+	// A share is only accepted from the holder it was issued to.
+	stolen := shares[2]
+	stolen.Holder = "mallory"
+	err = service.Recover(ctx, 123, []keyshare.Share{shares[0], shares[1], stolen})
+	require.ErrorIs(t, err, keyshare.ErrInvalidShare)
+
+	require.NoError(t, service.Recover(ctx, 123, []keyshare.Share{shares[4], shares[1], shares[3]}))
+	require.Equal(t, []models.EncryptionKey{{KeyPart: keyPart, KeyVersion: 2, MasterKeyVersion: 3}}, keyStorage.keyParts)
+
+	// With the key part in place, recovery only verifies it.
+	require.NoError(t, service.Recover(ctx, 123, shares))
+
+	other, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+	keyStorage.keyParts = []models.EncryptionKey{{KeyPart: other, KeyVersion: 2}}
+	err = service.Recover(ctx, 123, shares)
+	require.ErrorIs(t, err, keyshare.ErrKeyMismatch)
+}
+
+func TestSplitInvalidParams(t *testing.T) {
+	keyPart, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+
+	service := keyshare.New(slog.New(slog.NewTextHandler(os.Stdout, nil)), &fakeKeyStorage{
+		keyParts: []models.EncryptionKey{{KeyPart: keyPart, KeyVersion: 1}},
+	})
+
+	_, err = service.Split(context.Background(), 123, holders[:2], 3)
+	require.ErrorIs(t, err, keyshare.ErrInvalidParams)
+
+	_, err = service.Split(context.Background(), 123, []string{"alice", "bob", "alice"}, 2)
+	require.ErrorIs(t, err, keyshare.ErrInvalidParams)
+
+	_, err = service.Split(context.Background(), 123, []string{"alice", ""}, 2)
+	require.ErrorIs(t, err, keyshare.ErrInvalidParams)
+}
+
+func TestSplitDuringRotation(t *testing.T) {
+	oldPart, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+	newPart, err := envelope.NewKeyPart()
+	require.NoError(t, err)
+
+	service := keyshare.New(slog.New(slog.NewTextHandler(os.Stdout, nil)), &fakeKeyStorage{
+		keyParts: []models.EncryptionKey{{KeyPart: oldPart, KeyVersion: 1}, {KeyPart: newPart, KeyVersion: 2}},
+	})
+
+	_, err = service.Split(context.Background(), 123, holders, 3)
+	require.ErrorIs(t, err, storage.ErrKeyRotationRunning)
+}
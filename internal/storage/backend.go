@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"passvault/internal/domain/models"
+	"time"
+)
+
+// Backend is implemented by every storage backend. Entry data is stored as passed,
+// encryption is layered on top by the encrypted package. Backends return the errors
+// declared in this package, wrapped, so callers can match them with errors.Is.
+type Backend interface {
+	// Entries
+	SaveEntry(ctx context.Context, accountID int64, entryType, entryData string) (int64, error)
+	SaveEntries(ctx context.Context, accountID int64, entries []NewEntry) ([]int64, error)
+	GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error)
+	UpdateEntry(ctx context.Context, accountID int64, entryID int64, entryType, entryData string) error
+	DeleteEntry(ctx context.Context, accountID int64, entryID int64) error
+	ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error)
+	ListEntriesPage(ctx context.Context, accountID int64, query models.EntryQuery) (*models.EntryPage, error)
+	EntryAccess(ctx context.Context, accountID int64, entryID int64) (*models.EntryAccess, error)
+
+	// Revisions
+	ListRevisions(ctx context.Context, accountID int64, entryID int64) ([]models.EntryRevision, error)
+	GetRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) (*models.EntryRevision, error)
+	RestoreRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) error
+
+	// Key parts and commitments to their Shamir shares. RetrieveKeyPart returns the
+	// current key version, KeyParts all versions oldest first. Key parts record the
+	// version of the master key they derive keys with.
+	SaveKeyPart(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (int64, error)
+	RestoreKeyPart(ctx context.Context, accountID int64, keyVersion, masterKeyVersion int, keyPart string) error
+	RetrieveKeyPart(ctx context.Context, accountID int64) (string, error)
+	KeyParts(ctx context.Context, accountID int64) ([]models.EncryptionKey, error)
+	DeleteKeyPart(ctx context.Context, accountID int64) error
+	SaveKeyShares(ctx context.Context, accountID int64, shares []models.EncryptionKey) error
+	KeyShares(ctx context.Context, accountID int64) ([]models.EncryptionKey, error)
+
+	// Key rotation of accounts and organizations and the raw entries and revisions it
+	// rewraps. The Outdated methods list whose current key part derives keys with a
+	// master key version below masterKeyVersion.
+	StartKeyRotation(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error)
+	StartOrganizationKeyRotation(ctx context.Context, orgID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error)
+	KeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error)
+	OrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error)
+	ListKeyRotations(ctx context.Context, status string) ([]models.KeyRotation, error)
+	UpdateKeyRotation(ctx context.Context, rotation models.KeyRotation) error
+	RetireKeyParts(ctx context.Context, accountID int64, keyVersion int, sealedPrefix string) error
+	RetireOrganizationKeyParts(ctx context.Context, orgID int64, keyVersion int, sealedPrefix string) error
+	OutdatedAccounts(ctx context.Context, masterKeyVersion int) ([]int64, error)
+	OutdatedOrganizations(ctx context.Context, masterKeyVersion int) ([]int64, error)
+	PersonalEntries(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.Entry, error)
+	RewrapEntry(ctx context.Context, accountID int64, entryID int64, oldData, newData string, searchTokens []string) error
+	PersonalRevisions(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.EntryRevision, error)
+	RewrapRevision(ctx context.Context, accountID int64, revisionID int64, oldData, newData string) error
+	CollectionEntries(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.Entry, error)
+	RewrapCollectionEntry(ctx context.Context, orgID int64, entryID int64, oldData, newData string) error
+	CollectionRevisions(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.EntryRevision, error)
+	RewrapCollectionRevision(ctx context.Context, orgID int64, revisionID int64, oldData, newData string) error
+
+	// Zero-knowledge KDF parameters
+	SaveKDFParams(ctx context.Context, params models.KDFParams) error
+	KDFParams(ctx context.Context, accountID int64) (*models.KDFParams, error)
+
+	// Folders and tags
+	CreateFolder(ctx context.Context, accountID int64, parentID *int64, name string) (int64, error)
+	ListFolders(ctx context.Context, accountID int64) ([]models.Folder, error)
+	RenameFolder(ctx context.Context, accountID int64, folderID int64, name string) error
+	MoveFolder(ctx context.Context, accountID int64, folderID int64, parentID *int64) error
+	DeleteFolder(ctx context.Context, accountID int64, folderID int64) error
+	SetEntryFolder(ctx context.Context, accountID int64, entryID int64, folderID *int64) error
+	CreateTag(ctx context.Context, accountID int64, name string) (int64, error)
+	ListTags(ctx context.Context, accountID int64) ([]models.Tag, error)
+	RenameTag(ctx context.Context, accountID int64, tagID int64, name string) error
+	DeleteTag(ctx context.Context, accountID int64, tagID int64) error
+	SetEntryTags(ctx context.Context, accountID int64, entryID int64, tagIDs []int64) error
+
+	// Blind search index
+	SetSearchTokens(ctx context.Context, accountID int64, entryID int64, tokens []string) error
+	FindEntriesByTokens(ctx context.Context, accountID int64, tokens []string) ([]int64, error)
+
+	// Shares
+	ShareEntry(ctx context.Context, ownerID int64, entryID int64, accountID int64, permission string, expiresAt *time.Time) (int64, error)
+	ListShares(ctx context.Context, ownerID int64, entryID int64) ([]models.EntryShare, error)
+	ListReceivedShares(ctx context.Context, accountID int64) ([]models.EntryShare, error)
+	RevokeShare(ctx context.Context, ownerID int64, entryID int64, accountID int64) error
+
+	// Organizations and collections
+	CreateOrganization(ctx context.Context, ownerID int64, name, keyPart string, masterKeyVersion int) (int64, error)
+	ListOrganizations(ctx context.Context, accountID int64) ([]models.Organization, error)
+	DeleteOrganization(ctx context.Context, orgID int64) error
+	OrganizationKeyParts(ctx context.Context, orgID int64) ([]models.OrganizationKey, error)
+	MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error)
+	ListMembers(ctx context.Context, orgID int64) ([]models.OrganizationMember, error)
+	SetMemberRole(ctx context.Context, orgID int64, accountID int64, role string) error
+	RemoveMember(ctx context.Context, orgID int64, accountID int64) error
+	CreateCollection(ctx context.Context, orgID int64, name string) (int64, error)
+	ListCollections(ctx context.Context, orgID int64) ([]models.Collection, error)
+	DeleteCollection(ctx context.Context, orgID int64, collectionID int64) error
+	CollectionOrganization(ctx context.Context, collectionID int64) (int64, error)
+	SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error)
+	SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64, entryData string) error
+
+	// Audit log
+	AppendAuditEvent(ctx context.Context, event models.AuditEvent, chainKey []byte) (int64, error)
+	ListAuditEvents(ctx context.Context, accountID int64, filter models.AuditFilter) ([]models.AuditEvent, error)
+	AuditEvents(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error)
+
+	Close() error
+}
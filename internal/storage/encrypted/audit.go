@@ -0,0 +1,44 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+	"passvault/internal/lib/envelope"
+)
+
+// AppendAuditEvent appends an event to the audit log, chained with the audit key
+// derived from the current master key.
+func (s *Storage) AppendAuditEvent(ctx context.Context, event models.AuditEvent) (int64, error) {
+	const op = "storage.encrypted.AppendAuditEvent"
+
+	_, masterKey := s.masterKeys.Current()
+	key, err := envelope.DeriveAuditKey(masterKey)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.Backend.AppendAuditEvent(ctx, event, key)
+}
+
+// AuditVerifier returns a verifier of the audit log hash chain, keyed like AppendAuditEvent.
+// Events chained before a master key rotation verify with the audit key of the older
+// master key, as long as it is still configured.
+func (s *Storage) AuditVerifier() (*auditchain.Verifier, error) {
+	versions := s.masterKeys.Versions()
+	keys := make([][]byte, 0, len(versions))
+	for _, version := range versions {
+		masterKey, err := s.masterKeys.Key(version)
+		if err != nil {
+			return nil, fmt.Errorf("storage.encrypted.AuditVerifier: %w", err)
+		}
+		key, err := envelope.DeriveAuditKey(masterKey)
+		if err != nil {
+			return nil, fmt.Errorf("storage.encrypted.AuditVerifier: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return auditchain.NewVerifier(keys...), nil
+}
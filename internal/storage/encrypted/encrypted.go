@@ -0,0 +1,793 @@
+// Package encrypted wraps a storage backend with envelope encryption of entry
+// payloads. Every account gets a data key derived from the server master key
+// and the account's key_part row, so neither a copy of the database nor the
+// master key on its own is enough to read entry_data. Entries in the collections
+// of an organization are encrypted with a data key derived from the organization
+// key part instead, so every member can read them.
+//
+// The wrapper also keeps the blind search index of personal entry titles, usernames,
+// URIs and tag names in sync, keyed with a second per-account key derived the same way.
+//
+// Key parts of accounts and organizations are versioned. New data is sealed under the
+// current version and records it, older versions stay readable until a key rotation has
+// rewrapped everything sealed under them, see StartKeyRotation and RewrapEntries. Every
+// key part records the version of the master key it derives keys with, so the master key
+// is rotated by rotating the key parts still derived with an older one, see
+// MasterKeyStatus.
+//
+// Accounts in zero-knowledge mode, those with KDF parameters, encrypt entry data on
+// the client, see package vaultcrypto. Their personal entries only accept ciphertext
+// of the protocol, which is sealed again like any other entry data; only the tag
+// names of those entries are indexed for search. Collections are shared through organization keys the server
+// holds, so they never accept client ciphertext and zero-knowledge accounts cannot
+// write to them.
+package encrypted
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/blindindex"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/storage"
+	"passvault/pkg/vaultcrypto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Storage struct {
+	storage.Backend
+	masterKeys *envelope.MasterKeys
+}
+
+func New(backend storage.Backend, masterKeys *envelope.MasterKeys) *Storage {
+	return &Storage{
+		Backend:    backend,
+		masterKeys: masterKeys,
+	}
+}
+
+// SaveKeyPart saves the first key part of an account, to derive keys with the current
+// master key.
+func (s *Storage) SaveKeyPart(ctx context.Context, accountID int64, keyPart string) (int64, error) {
+	version, _ := s.masterKeys.Current()
+	return s.Backend.SaveKeyPart(ctx, accountID, keyPart, version)
+}
+
+// SaveEntry encrypts entryData with the account data key and saves the entry.
+// The account key part is created on first use.
+func (s *Storage) SaveEntry(ctx context.Context, accountID int64, entryType, entryData string) (int64, error) {
+	const op = "storage.encrypted.SaveEntry"
+
+	if err := s.checkProtocol(ctx, accountID, nil, entryData); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := s.seal(ctx, accountID, nil, entryData)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.Backend.SaveEntry(ctx, accountID, entryType, sealed)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.index(ctx, accountID, id, entryData); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SaveEntries encrypts the data of personal entries with the account data key and saves
+// them together with their search tokens, all or none of them.
+func (s *Storage) SaveEntries(ctx context.Context, accountID int64, entries []storage.NewEntry) ([]int64, error) {
+	const op = "storage.encrypted.SaveEntries"
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	for _, entry := range entries {
+		if err := s.checkProtocol(ctx, accountID, nil, entry.EntryData); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	keys, err := s.accountKeys(ctx, accountID, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	searchKey, err := s.searchKey(ctx, accountID, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed := make([]storage.NewEntry, 0, len(entries))
+	for _, entry := range entries {
+		data, err := keys.seal(entry.EntryData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sealed = append(sealed, storage.NewEntry{
+			EntryType:    entry.EntryType,
+			EntryData:    data,
+			SearchTokens: blindindex.IndexTokens(searchKey, searchableText(entry.EntryData)...),
+		})
+	}
+
+	return s.Backend.SaveEntries(ctx, accountID, sealed)
+}
+
+// GetEntry retrieves an entry and decrypts its data.
+func (s *Storage) GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error) {
+	const op = "storage.encrypted.GetEntry"
+
+	entry, err := s.Backend.GetEntry(ctx, accountID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.EntryData, err = s.open(ctx, entry.AccountId, entry.CollectionID, entry.EntryData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entry.ProtocolVersion = vaultcrypto.Version(entry.EntryData)
+
+	return entry, nil
+}
+
+// UpdateEntry encrypts entryData with the data key of the vault the entry is in and updates
+// the entry. Shared entries stay encrypted with the owner key.
+func (s *Storage) UpdateEntry(ctx context.Context, accountID int64, entryID int64, entryType, entryData string) error {
+	const op = "storage.encrypted.UpdateEntry"
+
+	current, err := s.Backend.GetEntry(ctx, accountID, entryID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkProtocol(ctx, current.AccountId, current.CollectionID, entryData); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := s.seal(ctx, current.AccountId, current.CollectionID, entryData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.Backend.UpdateEntry(ctx, accountID, entryID, entryType, sealed); err != nil {
+		return err
+	}
+
+	if current.CollectionID == nil {
+		if err := s.index(ctx, current.AccountId, entryID, entryData); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveCollectionEntry encrypts entryData with the organization data key and saves the
+// entry into the collection.
+func (s *Storage) SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error) {
+	const op = "storage.encrypted.SaveCollectionEntry"
+
+	if err := s.checkProtocol(ctx, accountID, &collectionID, entryData); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := s.seal(ctx, accountID, &collectionID, entryData)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.Backend.SaveCollectionEntry(ctx, accountID, collectionID, entryType, sealed)
+}
+
+// SetEntryCollection moves an entry between vaults, re-encrypting its data with the key
+// of the target vault. Entries moved out of a collection become personal entries of the
+// account and are indexed for search.
+func (s *Storage) SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64) error {
+	const op = "storage.encrypted.SetEntryCollection"
+
+	entry, err := s.GetEntry(ctx, accountID, entryID)
+	if err != nil {
+		return err
+	}
+
+	ownerID := entry.AccountId
+	if collectionID == nil {
+		ownerID = accountID
+	}
+	if err := s.checkProtocol(ctx, ownerID, collectionID, entry.EntryData); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	sealed, err := s.seal(ctx, ownerID, collectionID, entry.EntryData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.Backend.SetEntryCollection(ctx, accountID, entryID, collectionID, sealed); err != nil {
+		return err
+	}
+
+	if collectionID == nil {
+		if err := s.index(ctx, accountID, entryID, entry.EntryData); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateOrganization creates an organization with a new random key part.
+func (s *Storage) CreateOrganization(ctx context.Context, ownerID int64, name string) (int64, error) {
+	const op = "storage.encrypted.CreateOrganization"
+
+	keyPart, err := envelope.NewKeyPart()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, _ := s.masterKeys.Current()
+	return s.Backend.CreateOrganization(ctx, ownerID, name, keyPart, version)
+}
+
+// ListEntries retrieves the entries of an account matching filter and decrypts their data.
+func (s *Storage) ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error) {
+	const op = "storage.encrypted.ListEntries"
+
+	entries, err := s.Backend.ListEntries(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.openEntries(ctx, entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// ListEntriesPage retrieves one page of entries and decrypts their data. Entry names
+// only exist inside the encrypted data, so pages sorted by name are cut in memory from
+// all entries matching the filter.
+func (s *Storage) ListEntriesPage(ctx context.Context, accountID int64, query models.EntryQuery) (*models.EntryPage, error) {
+	const op = "storage.encrypted.ListEntriesPage"
+
+	if query.Sort != models.EntrySortName {
+		page, err := s.Backend.ListEntriesPage(ctx, accountID, query)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.openEntries(ctx, page.Entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return page, nil
+	}
+
+	entries, err := s.ListEntries(ctx, accountID, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := pageByName(entries, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return page, nil
+}
+
+// GetRevision retrieves a revision of an entry and decrypts its data.
+func (s *Storage) GetRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) (*models.EntryRevision, error) {
+	const op = "storage.encrypted.GetRevision"
+
+	revision, err := s.Backend.GetRevision(ctx, accountID, entryID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.EntryData, err = s.open(ctx, revision.AccountId, revision.CollectionID, revision.EntryData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revision, nil
+}
+
+// PersonalRevisions retrieves up to limit revisions of the personal entries of an account
+// with IDs above afterID, in ID order, and decrypts their data.
+func (s *Storage) PersonalRevisions(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.EntryRevision, error) {
+	const op = "storage.encrypted.PersonalRevisions"
+
+	revisions, err := s.Backend.PersonalRevisions(ctx, accountID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range revisions {
+		revisions[i].EntryData, err = s.open(ctx, revisions[i].AccountId, nil, revisions[i].EntryData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return revisions, nil
+}
+
+// RestoreRevision sets an entry back to one of its revisions and re-indexes personal entries for search.
+func (s *Storage) RestoreRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) error {
+	const op = "storage.encrypted.RestoreRevision"
+
+	revision, err := s.GetRevision(ctx, accountID, entryID, revisionID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkProtocol(ctx, revision.AccountId, revision.CollectionID, revision.EntryData); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.Backend.RestoreRevision(ctx, accountID, entryID, revisionID); err != nil {
+		return err
+	}
+
+	entry, err := s.GetEntry(ctx, accountID, entryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if entry.CollectionID != nil {
+		return nil
+	}
+
+	if err := s.index(ctx, entry.AccountId, entryID, entry.EntryData); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SearchEntries returns the IDs of the account entries whose title, username,
+// URIs or tag names contain words starting with every word of query. While a key
+// rotation runs, entries are indexed under either key version, so both are searched.
+func (s *Storage) SearchEntries(ctx context.Context, accountID int64, query string) ([]int64, error) {
+	const op = "storage.encrypted.SearchEntries"
+
+	words := blindindex.Words(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	keyParts, err := s.keyParts(ctx, accountID, false)
+	if errors.Is(err, storage.ErrEncryptionKeyNotFound) {
+		// Without a key part the account has no entries to find.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	found := make(map[int64]struct{})
+	for _, keyPart := range keyParts {
+		key, err := s.deriveSearchKey(keyPart, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		tokens := make([]string, 0, len(words))
+		for _, word := range words {
+			tokens = append(tokens, blindindex.Token(key, word))
+		}
+
+		ids, err := s.Backend.FindEntriesByTokens(ctx, accountID, tokens)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			found[id] = struct{}{}
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// index replaces the search tokens of a personal entry with those of its decrypted data
+// and the names of its tags.
+func (s *Storage) index(ctx context.Context, accountID int64, entryID int64, entryData string) error {
+	entry, err := s.Backend.GetEntry(ctx, accountID, entryID)
+	if err != nil {
+		return err
+	}
+	tagNames, err := s.tagNames(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	key, err := s.searchKey(ctx, accountID, true)
+	if err != nil {
+		return err
+	}
+
+	return s.Backend.SetSearchTokens(ctx, accountID, entryID, indexTokens(key, entryData, entry.TagIDs, tagNames))
+}
+
+// reindex replaces the search tokens of personal entries of an account whose tags changed.
+func (s *Storage) reindex(ctx context.Context, accountID int64, entryIDs []int64) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	tagNames, err := s.tagNames(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	key, err := s.searchKey(ctx, accountID, true)
+	if err != nil {
+		return err
+	}
+
+	for _, entryID := range entryIDs {
+		entry, err := s.GetEntry(ctx, accountID, entryID)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", entryID, err)
+		}
+		if entry.AccountId != accountID || entry.CollectionID != nil {
+			continue
+		}
+		if err := s.Backend.SetSearchTokens(ctx, accountID, entryID, indexTokens(key, entry.EntryData, entry.TagIDs, tagNames)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taggedEntries returns the IDs of the personal entries of an account carrying a tag.
+func (s *Storage) taggedEntries(ctx context.Context, accountID int64, tagID int64) ([]int64, error) {
+	entries, err := s.Backend.ListEntries(ctx, accountID, models.EntryFilter{TagIDs: []int64{tagID}})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids, nil
+}
+
+// tagNames returns the names of the tags of an account keyed by tag ID.
+func (s *Storage) tagNames(ctx context.Context, accountID int64) (map[int64]string, error) {
+	tags, err := s.Backend.ListTags(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int64]string, len(tags))
+	for _, tag := range tags {
+		names[tag.ID] = tag.Name
+	}
+	return names, nil
+}
+
+func (s *Storage) openEntries(ctx context.Context, entries []models.Entry) error {
+	for i := range entries {
+		var err error
+		entries[i].EntryData, err = s.open(ctx, entries[i].AccountId, entries[i].CollectionID, entries[i].EntryData)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", entries[i].ID, err)
+		}
+		entries[i].ProtocolVersion = vaultcrypto.Version(entries[i].EntryData)
+	}
+	return nil
+}
+
+// checkProtocol refuses entry data that does not fit the vault it is written to:
+// plaintext in the personal vault of a zero-knowledge account, and client ciphertext
+// anywhere else.
+func (s *Storage) checkProtocol(ctx context.Context, accountID int64, collectionID *int64, entryData string) error {
+	zeroKnowledge, err := s.ZeroKnowledge(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	ciphertext := vaultcrypto.IsCiphertext(entryData)
+	switch {
+	case ciphertext && (collectionID != nil || !zeroKnowledge):
+		return storage.ErrCiphertextRefused
+	case !ciphertext && zeroKnowledge:
+		return storage.ErrPlaintextRefused
+	}
+	return nil
+}
+
+// ZeroKnowledge reports whether an account has enabled zero-knowledge mode.
+func (s *Storage) ZeroKnowledge(ctx context.Context, accountID int64) (bool, error) {
+	_, err := s.Backend.KDFParams(ctx, accountID)
+	if errors.Is(err, storage.ErrKDFParamsNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Storage) seal(ctx context.Context, accountID int64, collectionID *int64, plaintext string) (string, error) {
+	keys, err := s.vaultKeys(ctx, accountID, collectionID, true)
+	if err != nil {
+		return "", err
+	}
+	return keys.seal(plaintext)
+}
+
+func (s *Storage) open(ctx context.Context, accountID int64, collectionID *int64, sealed string) (string, error) {
+	// Rows written before encryption was enabled are returned as is; they are
+	// encrypted the next time the entry is updated.
+	if !envelope.IsSealed(sealed) {
+		return sealed, nil
+	}
+
+	keys, err := s.vaultKeys(ctx, accountID, collectionID, false)
+	if err != nil {
+		return "", err
+	}
+	return keys.open(sealed)
+}
+
+// vaultKeys returns the data keys of the vault an entry is in: those of the organization
+// of its collection, or those of the account for personal entries. See accountKeys for create.
+func (s *Storage) vaultKeys(ctx context.Context, accountID int64, collectionID *int64, create bool) (*keyring, error) {
+	if collectionID == nil {
+		return s.accountKeys(ctx, accountID, create)
+	}
+
+	orgID, err := s.Backend.CollectionOrganization(ctx, *collectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.organizationKeys(ctx, orgID)
+}
+
+// organizationKeys derives the data keys of every key version of an organization.
+func (s *Storage) organizationKeys(ctx context.Context, orgID int64) (*keyring, error) {
+	keyParts, err := s.Backend.OrganizationKeyParts(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &keyring{keys: make(map[int][]byte, len(keyParts)), aad: []byte("organization:" + strconv.FormatInt(orgID, 10))}
+	for _, keyPart := range keyParts {
+		masterKey, err := s.masterKeys.Key(keyPart.MasterKeyVersion)
+		if err != nil {
+			return nil, err
+		}
+		key, err := envelope.DeriveOrganizationKey(masterKey, keyPart.KeyPart, orgID)
+		if err != nil {
+			return nil, err
+		}
+		keys.keys[keyPart.KeyVersion] = key
+		keys.current = keyPart.KeyVersion
+	}
+	return keys, nil
+}
+
+// accountKeys derives the data keys of every key version of an account. When create is
+// set and the account has no key part yet, a random one is generated and saved.
+func (s *Storage) accountKeys(ctx context.Context, accountID int64, create bool) (*keyring, error) {
+	keyParts, err := s.keyParts(ctx, accountID, create)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &keyring{keys: make(map[int][]byte, len(keyParts)), aad: additionalData(accountID)}
+	for _, keyPart := range keyParts {
+		masterKey, err := s.masterKeys.Key(keyPart.MasterKeyVersion)
+		if err != nil {
+			return nil, err
+		}
+		key, err := envelope.DeriveDataKey(masterKey, keyPart.KeyPart, accountID)
+		if err != nil {
+			return nil, err
+		}
+		keys.keys[keyPart.KeyVersion] = key
+		keys.current = keyPart.KeyVersion
+	}
+	return keys, nil
+}
+
+// searchKey derives the search index key of the current key version of an account,
+// see accountKeys.
+func (s *Storage) searchKey(ctx context.Context, accountID int64, create bool) ([]byte, error) {
+	keyParts, err := s.keyParts(ctx, accountID, create)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.deriveSearchKey(keyParts[len(keyParts)-1], accountID)
+}
+
+func (s *Storage) deriveSearchKey(keyPart models.EncryptionKey, accountID int64) ([]byte, error) {
+	masterKey, err := s.masterKeys.Key(keyPart.MasterKeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.DeriveSearchKey(masterKey, keyPart.KeyPart, accountID)
+}
+
+// keyParts returns the key versions of an account, oldest first.
+func (s *Storage) keyParts(ctx context.Context, accountID int64, create bool) ([]models.EncryptionKey, error) {
+	keyParts, err := s.Backend.KeyParts(ctx, accountID)
+	if errors.Is(err, storage.ErrEncryptionKeyNotFound) && create {
+		newKeyPart, genErr := envelope.NewKeyPart()
+		if genErr != nil {
+			return nil, genErr
+		}
+		// SaveKeyPart refuses while shares of a deleted key part await recovery, in
+		// which case KeyParts reports the key part as still missing.
+		_, err = s.SaveKeyPart(ctx, accountID, newKeyPart)
+		if err == nil || errors.Is(err, storage.ErrEncryptionKeyExists) {
+			// Another request may have created the key part concurrently, use that one.
+			keyParts, err = s.Backend.KeyParts(ctx, accountID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return keyParts, nil
+}
+
+// keyring holds the data keys of every key version of an account or organization and the
+// additional data binding ciphertexts to it.
+type keyring struct {
+	keys    map[int][]byte
+	current int
+	aad     []byte
+}
+
+// seal seals plaintext under the current key version.
+func (k *keyring) seal(plaintext string) (string, error) {
+	return envelope.SealVersion(k.keys[k.current], k.current, []byte(plaintext), k.aad)
+}
+
+// open opens data sealed under any of the key versions.
+func (k *keyring) open(sealed string) (string, error) {
+	version, err := envelope.KeyVersion(sealed)
+	if err != nil {
+		return "", err
+	}
+	key, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: key version %d", storage.ErrEncryptionKeyNotFound, version)
+	}
+
+	plaintext, err := envelope.Open(key, sealed, k.aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// rewrap opens data and seals it again under the current key version. sealed is empty
+// when data already is sealed under it.
+func (k *keyring) rewrap(data string) (plaintext string, sealed string, err error) {
+	plaintext = data
+	if envelope.IsSealed(data) {
+		version, err := envelope.KeyVersion(data)
+		if err != nil {
+			return "", "", err
+		}
+		if version == k.current {
+			return "", "", nil
+		}
+		if plaintext, err = k.open(data); err != nil {
+			return "", "", err
+		}
+	}
+
+	sealed, err = k.seal(plaintext)
+	if err != nil {
+		return "", "", err
+	}
+	return plaintext, sealed, nil
+}
+
+// additionalData binds ciphertexts to their account, so rows cannot be moved
+// between accounts without failing authentication.
+func additionalData(accountID int64) []byte {
+	return []byte("account:" + strconv.FormatInt(accountID, 10))
+}
+
+// pageByName sorts decrypted entries by their case-insensitive title, then by ID,
+// and cuts the page following the query cursor.
+func pageByName(entries []models.Entry, query models.EntryQuery) (*models.EntryPage, error) {
+	names := make(map[int64]string, len(entries))
+	for _, entry := range entries {
+		names[entry.ID] = entryName(entry.EntryData)
+	}
+
+	less := func(keyA string, idA int64, keyB string, idB int64) bool {
+		if keyA != keyB {
+			return keyA < keyB
+		}
+		return idA < idB
+	}
+	if query.Descending {
+		ascending := less
+		less = func(keyA string, idA int64, keyB string, idB int64) bool {
+			return ascending(keyB, idB, keyA, idA)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return less(names[entries[i].ID], entries[i].ID, names[entries[j].ID], entries[j].ID)
+	})
+
+	if query.Cursor != "" {
+		cursor, err := storage.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start := sort.Search(len(entries), func(i int) bool {
+			return less(cursor.Key, cursor.ID, names[entries[i].ID], entries[i].ID)
+		})
+		entries = entries[start:]
+	}
+
+	page := &models.EntryPage{Entries: entries}
+	if query.Limit > 0 && len(entries) > query.Limit {
+		page.Entries = entries[:query.Limit]
+		last := page.Entries[query.Limit-1]
+		page.NextCursor = storage.Cursor{Key: names[last.ID], ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// indexTokens returns the blind index tokens of decrypted entry data and of the names
+// of the tags with tagIDs.
+func indexTokens(key []byte, entryData string, tagIDs []int64, tagNames map[int64]string) []string {
+	text := searchableText(entryData)
+	for _, tagID := range tagIDs {
+		text = append(text, tagNames[tagID])
+	}
+	return blindindex.IndexTokens(key, text...)
+}
+
+// searchableText returns the values of the entry data fields covered by search.
+func searchableText(entryData string) []string {
+	var data struct {
+		Title    string   `json:"title"`
+		Username string   `json:"username"`
+		URIs     []string `json:"uris"`
+	}
+	if err := json.Unmarshal([]byte(entryData), &data); err != nil {
+		return nil
+	}
+	return append([]string{data.Title, data.Username}, data.URIs...)
+}
+
+// entryName returns the lower-cased title of decrypted entry data, or an empty
+// string for data that is not a JSON object.
+func entryName(entryData string) string {
+	var data struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(entryData), &data); err != nil {
+		return ""
+	}
+	return strings.ToLower(data.Title)
+}
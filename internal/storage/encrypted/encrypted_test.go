@@ -0,0 +1,863 @@
+package encrypted_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/services/keyshare"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/sqlite"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStorage(t *testing.T, retention storage.RevisionRetention) (*encrypted.Storage, *sql.DB) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "passvault.db")
+
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { raw.Close() })
+
+	files, err := filepath.Glob("../../../migrations/*.up.sql")
+	require.NoError(t, err)
+	sort.Slice(files, func(i, j int) bool { return migrationVersion(files[i]) < migrationVersion(files[j]) })
+
+	for _, file := range files {
+		schema, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = raw.Exec(string(schema))
+		require.NoError(t, err)
+	}
+
+	db, err := sqlite.New(path, retention)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return encrypted.New(db, envelope.NewMasterKeys(make([]byte, envelope.KeySize))), raw
+}
+
+func migrationVersion(file string) int {
+	version, _ := strconv.Atoi(strings.SplitN(filepath.Base(file), "_", 2)[0])
+	return version
+}
+
+func TestEntryDataIsEncryptedAtRest(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	id, err := s.SaveEntry(ctx, 123, "password", "supersecretpassword")
+	require.NoError(t, err)
+
+	var stored string
+	require.NoError(t, raw.QueryRow(`SELECT entry_data FROM entry WHERE id = ?`, id).Scan(&stored))
+	require.True(t, envelope.IsSealed(stored))
+	require.NotContains(t, stored, "supersecretpassword")
+
+	entry, err := s.GetEntry(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, "supersecretpassword", entry.EntryData)
+
+	require.NoError(t, s.UpdateEntry(ctx, 123, id, "password", "rotatedpassword"))
+
+	entries, err := s.ListEntries(ctx, 123, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "rotatedpassword", entries[0].EntryData)
+
+	_, err = s.GetEntry(ctx, 124, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 124, id, "password", "stolen"), storage.ErrEntryNotFound)
+
+	// The key part cannot go away while entries still depend on it.
+	require.ErrorIs(t, s.DeleteKeyPart(ctx, 123), storage.ErrEncryptionKeyInUse)
+	require.ErrorIs(t, s.DeleteEntry(ctx, 124, id), storage.ErrEntryNotFound)
+	require.NoError(t, s.DeleteEntry(ctx, 123, id))
+	require.ErrorIs(t, s.DeleteKeyPart(ctx, 123), storage.ErrEncryptionKeyInUse, "revisions still use the key")
+}
+
+func TestSaveEntries(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	ids, err := s.SaveEntries(ctx, 123, []storage.NewEntry{
+		{EntryType: models.EntryTypeLogin, EntryData: `{"title": "Imported mail", "password": "hunter2"}`},
+		{EntryType: models.EntryTypeSecureNote, EntryData: `{"title": "Imported note", "content": "secret"}`},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	var stored string
+	require.NoError(t, raw.QueryRow(`SELECT entry_data FROM entry WHERE id = ?`, ids[0]).Scan(&stored))
+	require.True(t, envelope.IsSealed(stored))
+	require.NotContains(t, stored, "hunter2")
+
+	entry, err := s.GetEntry(ctx, 123, ids[1])
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Imported note", "content": "secret"}`, entry.EntryData)
+
+	found, err := s.SearchEntries(ctx, 123, "imported")
+	require.NoError(t, err)
+	require.Equal(t, ids, found)
+}
+
+func TestEntriesCannotBeMovedBetweenAccounts(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	id, err := s.SaveEntry(ctx, 123, "password", "supersecretpassword")
+	require.NoError(t, err)
+	_, err = s.SaveEntry(ctx, 124, "password", "otherpassword")
+	require.NoError(t, err)
+
+	_, err = raw.Exec(`UPDATE entry SET account_id = 124 WHERE id = ?`, id)
+	require.NoError(t, err)
+
+	_, err = s.GetEntry(ctx, 124, id)
+	require.ErrorIs(t, err, envelope.ErrDecrypt)
+}
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{MaxCount: 2})
+
+	id, err := s.SaveEntry(ctx, 123, "password", "v1")
+	require.NoError(t, err)
+	for _, data := range []string{"v2", "v3", "v4"} {
+		require.NoError(t, s.UpdateEntry(ctx, 123, id, "password", data))
+	}
+
+	revisions, err := s.ListRevisions(ctx, 123, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2, "retention keeps the newest revisions only")
+	require.Empty(t, revisions[0].EntryData)
+
+	var stored string
+	require.NoError(t, raw.QueryRow(`SELECT entry_data FROM entry_revision WHERE id = ?`, revisions[0].ID).Scan(&stored))
+	require.True(t, envelope.IsSealed(stored), "revisions are encrypted at rest too")
+
+	revision, err := s.GetRevision(ctx, 123, id, revisions[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, "v2", revision.EntryData)
+
+	_, err = s.GetRevision(ctx, 124, id, revisions[1].ID)
+	require.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	personal, err := s.PersonalRevisions(ctx, 123, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, personal, 2)
+	require.Equal(t, "v2", personal[0].EntryData)
+	require.Equal(t, "v3", personal[1].EntryData)
+
+	require.NoError(t, s.RestoreRevision(ctx, 123, id, revisions[1].ID))
+	entry, err := s.GetEntry(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, "v2", entry.EntryData)
+
+	// A deleted entry comes back with its original ID.
+	require.NoError(t, s.DeleteEntry(ctx, 123, id))
+	revisions, err = s.ListRevisions(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, "delete", revisions[0].Action)
+
+	require.NoError(t, s.RestoreRevision(ctx, 123, id, revisions[0].ID))
+	entry, err = s.GetEntry(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, "v2", entry.EntryData)
+
+	_, err = s.ListRevisions(ctx, 123, id+1)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+}
+
+func TestFoldersAndTags(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStorage(t, storage.RevisionRetention{})
+
+	work, err := s.CreateFolder(ctx, 123, nil, "work")
+	require.NoError(t, err)
+	servers, err := s.CreateFolder(ctx, 123, &work, "servers")
+	require.NoError(t, err)
+
+	_, err = s.CreateFolder(ctx, 123, &work, "servers")
+	require.ErrorIs(t, err, storage.ErrFolderExists)
+	_, err = s.CreateFolder(ctx, 124, &work, "stolen")
+	require.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+	// A folder cannot become its own descendant.
+	require.ErrorIs(t, s.MoveFolder(ctx, 123, work, &servers), storage.ErrFolderCycle)
+	require.ErrorIs(t, s.MoveFolder(ctx, 123, work, &work), storage.ErrFolderCycle)
+	require.NoError(t, s.RenameFolder(ctx, 123, servers, "hosts"))
+
+	prod, err := s.CreateTag(ctx, 123, "prod")
+	require.NoError(t, err)
+	shared, err := s.CreateTag(ctx, 123, "shared")
+	require.NoError(t, err)
+	_, err = s.CreateTag(ctx, 123, "prod")
+	require.ErrorIs(t, err, storage.ErrTagExists)
+	foreign, err := s.CreateTag(ctx, 124, "foreign")
+	require.NoError(t, err)
+
+	first, err := s.SaveEntry(ctx, 123, "login", `{"title": "db"}`)
+	require.NoError(t, err)
+	second, err := s.SaveEntry(ctx, 123, "login", `{"title": "mail"}`)
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetEntryFolder(ctx, 123, first, &servers))
+	require.NoError(t, s.SetEntryTags(ctx, 123, first, []int64{prod, shared}))
+	require.NoError(t, s.SetEntryTags(ctx, 123, second, []int64{shared}))
+	require.ErrorIs(t, s.SetEntryTags(ctx, 123, second, []int64{foreign}), storage.ErrTagNotFound)
+	require.ErrorIs(t, s.SetEntryFolder(ctx, 124, first, nil), storage.ErrEntryNotFound)
+
+	entry, err := s.GetEntry(ctx, 123, first)
+	require.NoError(t, err)
+	require.Equal(t, &servers, entry.FolderID)
+	require.Equal(t, []int64{prod, shared}, entry.TagIDs)
+
+	entries, err := s.ListEntries(ctx, 123, models.EntryFilter{TagIDs: []int64{shared}})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, err = s.ListEntries(ctx, 123, models.EntryFilter{TagIDs: []int64{shared, prod}})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, first, entries[0].ID)
+
+	root := int64(0)
+	entries, err = s.ListEntries(ctx, 123, models.EntryFilter{FolderID: &root})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, second, entries[0].ID)
+
+	// Deleting a folder moves its content to the parent folder.
+	require.NoError(t, s.DeleteFolder(ctx, 123, servers))
+	entries, err = s.ListEntries(ctx, 123, models.EntryFilter{FolderID: &work})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, first, entries[0].ID)
+
+	require.NoError(t, s.DeleteTag(ctx, 123, prod))
+	entry, err = s.GetEntry(ctx, 123, first)
+	require.NoError(t, err)
+	require.Equal(t, []int64{shared}, entry.TagIDs)
+	require.ErrorIs(t, s.DeleteTag(ctx, 123, prod), storage.ErrTagNotFound)
+
+	folders, err := s.ListFolders(ctx, 123)
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	tags, err := s.ListTags(ctx, 124)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+}
+
+func TestListEntriesPage(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStorage(t, storage.RevisionRetention{})
+
+	titles := []string{"delta", "Alpha", "charlie", "bravo", "echo"}
+	for _, title := range titles {
+		_, err := s.SaveEntry(ctx, 123, "login", `{"title": "`+title+`"}`)
+		require.NoError(t, err)
+	}
+	_, err := s.SaveEntry(ctx, 123, "secure_note", `{"title": "note", "content": "wifi"}`)
+	require.NoError(t, err)
+	_, err = s.SaveEntry(ctx, 124, "login", `{"title": "other account"}`)
+	require.NoError(t, err)
+
+	collect := func(query models.EntryQuery) []int64 {
+		var ids []int64
+		for {
+			page, err := s.ListEntriesPage(ctx, 123, query)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Entries), query.Limit)
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	byCreation := collect(models.EntryQuery{Sort: models.EntrySortCreatedAt, Limit: 2})
+	require.Equal(t, []int64{1, 2, 3, 4, 5, 6}, byCreation)
+
+	newestFirst := collect(models.EntryQuery{Sort: models.EntrySortCreatedAt, Descending: true, Limit: 4})
+	require.Equal(t, []int64{6, 5, 4, 3, 2, 1}, newestFirst)
+
+	byName := collect(models.EntryQuery{Sort: models.EntrySortName, Limit: 2, Filter: models.EntryFilter{EntryType: "login"}})
+	require.Equal(t, []int64{2, 4, 3, 1, 5}, byName)
+
+	byNameDesc := collect(models.EntryQuery{Sort: models.EntrySortName, Descending: true, Limit: 3})
+	require.Equal(t, []int64{6, 5, 1, 3, 4, 2}, byNameDesc)
+
+	// Updating moves an entry to the end when sorting by updated_at.
+	require.NoError(t, s.UpdateEntry(ctx, 123, 1, "login", `{"title": "delta", "password": "rotated"}`))
+	byUpdate := collect(models.EntryQuery{Sort: models.EntrySortUpdatedAt, Limit: 5})
+	require.Equal(t, []int64{2, 3, 4, 5, 6, 1}, byUpdate)
+
+	future := time.Now().Add(time.Hour)
+	page, err := s.ListEntriesPage(ctx, 123, models.EntryQuery{Limit: 10, Filter: models.EntryFilter{CreatedAfter: &future}})
+	require.NoError(t, err)
+	require.Empty(t, page.Entries)
+
+	_, err = s.ListEntriesPage(ctx, 123, models.EntryQuery{Limit: 10, Cursor: "garbage"})
+	require.ErrorIs(t, err, storage.ErrInvalidCursor)
+}
+
+func TestSearchEntries(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	mail, err := s.SaveEntry(ctx, 123, "login", `{"title": "Mail", "username": "daria", "uris": ["https://mail.example.com"]}`)
+	require.NoError(t, err)
+	bank, err := s.SaveEntry(ctx, 123, "login", `{"title": "Bank", "username": "daria.s", "password": "mailbox"}`)
+	require.NoError(t, err)
+	other, err := s.SaveEntry(ctx, 124, "login", `{"title": "Mail"}`)
+	require.NoError(t, err)
+
+	search := func(accountID int64, query string) []int64 {
+		ids, err := s.SearchEntries(ctx, accountID, query)
+		require.NoError(t, err)
+		return ids
+	}
+
+	require.Equal(t, []int64{mail}, search(123, "mail"))
+	require.Equal(t, []int64{mail}, search(123, "EXAMPLE.com"))
+	require.Equal(t, []int64{mail, bank}, search(123, "dar"))
+	require.Equal(t, []int64{bank}, search(123, "daria bank"))
+	require.Equal(t, []int64{other}, search(124, "mail"))
+	// Passwords are not indexed.
+	require.Empty(t, search(123, "mailbox"))
+	require.Empty(t, search(125, "mail"))
+
+	// No plaintext words or prefixes end up in the index. Tokens are random base64, so
+	// only exact matches count: short words turn up inside them by chance.
+	leaked := func(words ...string) int {
+		var prefixes []any
+		for _, word := range words {
+			for i := 1; i <= len(word); i++ {
+				prefixes = append(prefixes, word[:i])
+			}
+		}
+		query := `SELECT COUNT(*) FROM search_token WHERE token IN (?` + strings.Repeat(", ?", len(prefixes)-1) + `)`
+		var count int
+		require.NoError(t, raw.QueryRow(query, prefixes...).Scan(&count))
+		return count
+	}
+	require.Zero(t, leaked("mail", "daria", "example.com"))
+
+	require.NoError(t, s.UpdateEntry(ctx, 123, bank, "login", `{"title": "Savings"}`))
+	require.Empty(t, search(123, "bank"))
+	require.Equal(t, []int64{bank}, search(123, "sav"))
+
+	tag, err := s.CreateTag(ctx, 123, "Finance")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryTags(ctx, 123, bank, []int64{tag}))
+	require.Equal(t, []int64{bank}, search(123, "fin"))
+	require.Equal(t, []int64{bank}, search(123, "finance sav"))
+	require.Zero(t, leaked("finance"))
+
+	require.NoError(t, s.RenameTag(ctx, 123, tag, "Money"))
+	require.Empty(t, search(123, "fin"))
+	require.Equal(t, []int64{bank}, search(123, "mon"))
+	// Updates keep the tags in the index.
+	require.NoError(t, s.UpdateEntry(ctx, 123, bank, "login", `{"title": "Savings"}`))
+	require.Equal(t, []int64{bank}, search(123, "mon"))
+
+	require.NoError(t, s.SetEntryTags(ctx, 123, bank, nil))
+	require.Empty(t, search(123, "mon"))
+	require.Equal(t, []int64{bank}, search(123, "sav"))
+
+	require.NoError(t, s.SetEntryTags(ctx, 123, bank, []int64{tag}))
+	require.Equal(t, []int64{bank}, search(123, "mon"))
+	require.NoError(t, s.DeleteTag(ctx, 123, tag))
+	require.Empty(t, search(123, "mon"))
+
+	require.NoError(t, s.DeleteEntry(ctx, 123, mail))
+	require.Empty(t, search(123, "mail"))
+
+	revisions, err := s.ListRevisions(ctx, 123, mail)
+	require.NoError(t, err)
+	require.NoError(t, s.RestoreRevision(ctx, 123, mail, revisions[0].ID))
+	require.Equal(t, []int64{mail}, search(123, "mail"))
+}
+
+func TestSharing(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	id, err := s.SaveEntry(ctx, 123, "login", `{"title": "ci", "password": "secret"}`)
+	require.NoError(t, err)
+
+	_, err = s.GetEntry(ctx, 124, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	_, err = s.ShareEntry(ctx, 123, id, 123, models.SharePermissionRead, nil)
+	require.ErrorIs(t, err, storage.ErrInvalidShare)
+	_, err = s.ShareEntry(ctx, 124, id, 125, models.SharePermissionRead, nil)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	_, err = s.ShareEntry(ctx, 123, id, 124, models.SharePermissionRead, nil)
+	require.NoError(t, err)
+
+	// The grantee reads the data decrypted with the owner key, but cannot change it.
+	entry, err := s.GetEntry(ctx, 124, id)
+	require.NoError(t, err)
+	require.Equal(t, int64(123), entry.AccountId)
+	require.Equal(t, `{"title": "ci", "password": "secret"}`, entry.EntryData)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 124, id, "login", `{"title": "ci", "password": "mine"}`), storage.ErrAccessDenied)
+	require.ErrorIs(t, s.DeleteEntry(ctx, 124, id), storage.ErrAccessDenied)
+
+	// Sharing again upgrades the permission.
+	_, err = s.ShareEntry(ctx, 123, id, 124, models.SharePermissionWrite, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateEntry(ctx, 124, id, "login", `{"title": "ci", "password": "rotated"}`))
+
+	entry, err = s.GetEntry(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "ci", "password": "rotated"}`, entry.EntryData)
+
+	// The grantee never got a key part of its own: data stays under the owner key.
+	_, err = s.RetrieveKeyPart(ctx, 124)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	revisions, err := s.ListRevisions(ctx, 123, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	shares, err := s.ListShares(ctx, 123, id)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	require.Equal(t, models.SharePermissionWrite, shares[0].Permission)
+
+	received, err := s.ListReceivedShares(ctx, 124)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+
+	// Expired shares grant nothing.
+	_, err = raw.Exec(`UPDATE entry_share SET expires_at = ?`, time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = s.GetEntry(ctx, 124, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+	received, err = s.ListReceivedShares(ctx, 124)
+	require.NoError(t, err)
+	require.Empty(t, received)
+
+	require.NoError(t, s.RevokeShare(ctx, 123, id, 124))
+	require.ErrorIs(t, s.RevokeShare(ctx, 123, id, 124), storage.ErrShareNotFound)
+}
+
+func TestOrganizations(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	orgID, err := s.CreateOrganization(ctx, 123, "acme")
+	require.NoError(t, err)
+	require.NoError(t, s.SetMemberRole(ctx, orgID, 124, models.OrgRoleViewer))
+	require.NoError(t, s.SetMemberRole(ctx, orgID, 125, models.OrgRoleEditor))
+	require.ErrorIs(t, s.SetMemberRole(ctx, orgID, 123, models.OrgRoleAdmin), storage.ErrLastOwner)
+	require.ErrorIs(t, s.RemoveMember(ctx, orgID, 123), storage.ErrLastOwner)
+
+	orgs, err := s.ListOrganizations(ctx, 124)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	require.Equal(t, models.OrgRoleViewer, orgs[0].Role)
+
+	collectionID, err := s.CreateCollection(ctx, orgID, "infra")
+	require.NoError(t, err)
+	_, err = s.CreateCollection(ctx, orgID, "infra")
+	require.ErrorIs(t, err, storage.ErrCollectionExists)
+
+	// Viewers cannot add entries, outsiders do not see the collection.
+	_, err = s.SaveCollectionEntry(ctx, 124, collectionID, "login", `{"title": "db"}`)
+	require.ErrorIs(t, err, storage.ErrAccessDenied)
+	_, err = s.SaveCollectionEntry(ctx, 126, collectionID, "login", `{"title": "db"}`)
+	require.ErrorIs(t, err, storage.ErrCollectionNotFound)
+
+	id, err := s.SaveCollectionEntry(ctx, 125, collectionID, "login", `{"title": "db", "password": "secret"}`)
+	require.NoError(t, err)
+
+	// Collection entries are encrypted with the organization key, not the creator's.
+	var stored string
+	require.NoError(t, raw.QueryRow(`SELECT entry_data FROM entry WHERE id = ?`, id).Scan(&stored))
+	require.True(t, envelope.IsSealed(stored))
+	_, err = s.RetrieveKeyPart(ctx, 125)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	entry, err := s.GetEntry(ctx, 124, id)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "db", "password": "secret"}`, entry.EntryData)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 124, id, "login", `{"title": "db"}`), storage.ErrAccessDenied)
+	require.ErrorIs(t, s.DeleteEntry(ctx, 124, id), storage.ErrAccessDenied)
+	_, err = s.GetEntry(ctx, 126, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	require.NoError(t, s.UpdateEntry(ctx, 123, id, "login", `{"title": "db", "password": "rotated"}`))
+	revisions, err := s.ListRevisions(ctx, 124, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	entries, err := s.ListEntries(ctx, 124, models.EntryFilter{CollectionID: &collectionID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entries, err = s.ListEntries(ctx, 126, models.EntryFilter{CollectionID: &collectionID})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	entries, err = s.ListEntries(ctx, 125, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Empty(t, entries, "collection entries are not personal entries of their creator")
+
+	// Personal entries move into a collection and back, re-encrypted on the way.
+	personal, err := s.SaveEntry(ctx, 123, "login", `{"title": "vpn"}`)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.SetEntryCollection(ctx, 124, personal, &collectionID), storage.ErrEntryNotFound)
+	require.NoError(t, s.SetEntryCollection(ctx, 123, personal, &collectionID))
+	entry, err = s.GetEntry(ctx, 124, personal)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "vpn"}`, entry.EntryData)
+	ids, err := s.SearchEntries(ctx, 123, "vpn")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	require.NoError(t, s.SetEntryCollection(ctx, 125, personal, nil))
+	entry, err = s.GetEntry(ctx, 125, personal)
+	require.NoError(t, err)
+	require.Equal(t, int64(125), entry.AccountId)
+	require.Nil(t, entry.CollectionID)
+	_, err = s.GetEntry(ctx, 124, personal)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	require.ErrorIs(t, s.DeleteCollection(ctx, orgID, collectionID), storage.ErrCollectionNotEmpty)
+	require.NoError(t, s.DeleteEntry(ctx, 125, id))
+	require.NoError(t, s.DeleteCollection(ctx, orgID, collectionID))
+	require.NoError(t, s.DeleteOrganization(ctx, orgID))
+	require.ErrorIs(t, s.DeleteOrganization(ctx, orgID), storage.ErrOrganizationNotFound)
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	entryID := int64(7)
+	for _, event := range []models.AuditEvent{
+		{AccountID: 123, Action: models.AuditEntryCreate, EntryID: &entryID, Status: 201},
+		{AccountID: 124, Action: models.AuditEntryRead, EntryID: &entryID, Status: 404},
+		{AccountID: 123, Action: models.AuditKeyRead, Status: 200},
+	} {
+		_, err := s.AppendAuditEvent(ctx, event)
+		require.NoError(t, err)
+	}
+
+	events, err := s.ListAuditEvents(ctx, 123, models.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, models.AuditKeyRead, events[0].Action, "newest first")
+
+	events, err = s.ListAuditEvents(ctx, 123, models.AuditFilter{EntryID: &entryID})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	all, err := s.AuditEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	v, err := s.AuditVerifier()
+	require.NoError(t, err)
+	for _, event := range all {
+		require.NoError(t, v.Next(event))
+	}
+	// The chain is keyed with the master key, a plain verifier does not accept it.
+	require.ErrorIs(t, auditchain.NewVerifier(nil).Next(all[0]), auditchain.ErrBrokenChain)
+
+	// The table is append-only.
+	_, err = raw.Exec(`UPDATE audit_event SET status = 200 WHERE id = 2`)
+	require.ErrorContains(t, err, "append-only")
+	_, err = raw.Exec(`DELETE FROM audit_event WHERE id = 2`)
+	require.ErrorContains(t, err, "append-only")
+
+	// Rewriting history behind the triggers' back is caught by the chain.
+	_, err = raw.Exec(`DROP TRIGGER audit_event_no_update`)
+	require.NoError(t, err)
+	_, err = raw.Exec(`UPDATE audit_event SET status = 200 WHERE id = 2`)
+	require.NoError(t, err)
+
+	all, err = s.AuditEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	v, err = s.AuditVerifier()
+	require.NoError(t, err)
+	require.NoError(t, v.Next(all[0]))
+	require.ErrorIs(t, v.Next(all[1]), auditchain.ErrBrokenChain)
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	keyVersion := func(table string, id int64) int {
+		var stored string
+		require.NoError(t, raw.QueryRow(`SELECT entry_data FROM `+table+` WHERE id = ?`, id).Scan(&stored))
+		version, err := envelope.KeyVersion(stored)
+		require.NoError(t, err)
+		return version
+	}
+
+	mail, err := s.SaveEntry(ctx, 123, "login", `{"title": "Mail"}`)
+	require.NoError(t, err)
+	bank, err := s.SaveEntry(ctx, 123, "login", `{"title": "Bank"}`)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateEntry(ctx, 123, bank, "login", `{"title": "Savings"}`))
+	tag, err := s.CreateTag(ctx, 123, "Finance")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryTags(ctx, 123, bank, []int64{tag}))
+	other, err := s.SaveEntry(ctx, 124, "login", `{"title": "Mail"}`)
+	require.NoError(t, err)
+
+	_, err = s.StartKeyRotation(ctx, 125)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	rotation, err := s.StartKeyRotation(ctx, 123)
+	require.NoError(t, err)
+	require.Equal(t, 1, rotation.FromVersion)
+	require.Equal(t, 2, rotation.ToVersion)
+	_, err = s.StartKeyRotation(ctx, 123)
+	require.ErrorIs(t, err, storage.ErrKeyRotationRunning)
+
+	// Both key versions are readable and searchable while the rotation runs, new data is
+	// sealed under the new one.
+	wiki, err := s.SaveEntry(ctx, 123, "login", `{"title": "Wiki"}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, keyVersion("entry", wiki))
+	require.Equal(t, 1, keyVersion("entry", mail))
+
+	entries, err := s.ListEntries(ctx, 123, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	search := func(query string) []int64 {
+		ids, err := s.SearchEntries(ctx, 123, query)
+		require.NoError(t, err)
+		return ids
+	}
+	require.Equal(t, []int64{mail}, search("mail"))
+	require.Equal(t, []int64{wiki}, search("wiki"))
+
+	cursor, rewrapped, err := s.RewrapEntries(ctx, 123, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, mail, cursor)
+	require.Equal(t, 1, rewrapped)
+	cursor, rewrapped, err = s.RewrapEntries(ctx, 123, cursor, 10)
+	require.NoError(t, err)
+	require.Equal(t, wiki, cursor)
+	require.Equal(t, 1, rewrapped)
+	cursor, rewrapped, err = s.RewrapEntries(ctx, 123, cursor, 10)
+	require.NoError(t, err)
+	require.Equal(t, wiki, cursor)
+	require.Zero(t, rewrapped)
+
+	revisions, err := s.ListRevisions(ctx, 123, bank)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, 1, keyVersion("entry_revision", revisions[0].ID))
+
+	cursor, rewrapped, err = s.RewrapRevisions(ctx, 123, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, revisions[0].ID, cursor)
+	require.Equal(t, 1, rewrapped)
+	require.Equal(t, 2, keyVersion("entry_revision", revisions[0].ID))
+
+	require.Equal(t, 2, keyVersion("entry", mail))
+	require.Equal(t, 2, keyVersion("entry", bank))
+	require.Equal(t, 1, keyVersion("entry", other))
+
+	require.NoError(t, s.RetireKeyParts(ctx, 123, rotation.ToVersion))
+
+	var parts int
+	require.NoError(t, raw.QueryRow(`SELECT COUNT(*) FROM encryption_key WHERE account_id = 123`).Scan(&parts))
+	require.Equal(t, 1, parts)
+
+	entry, err := s.GetEntry(ctx, 123, bank)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Savings"}`, entry.EntryData)
+	require.Equal(t, []int64{mail}, search("mail"))
+	require.Equal(t, []int64{bank}, search("sav"))
+	require.Equal(t, []int64{bank}, search("fin"))
+
+	revision, err := s.GetRevision(ctx, 123, bank, revisions[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Bank"}`, revision.EntryData)
+
+	entry, err = s.GetEntry(ctx, 124, other)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Mail"}`, entry.EntryData)
+}
+
+func TestOrganizationKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newStorage(t, storage.RevisionRetention{})
+
+	keyVersion := func(table string, id int64) int {
+		var stored string
+		require.NoError(t, raw.QueryRow(`SELECT entry_data FROM `+table+` WHERE id = ?`, id).Scan(&stored))
+		version, err := envelope.KeyVersion(stored)
+		require.NoError(t, err)
+		return version
+	}
+
+	orgID, err := s.CreateOrganization(ctx, 123, "acme")
+	require.NoError(t, err)
+	collectionID, err := s.CreateCollection(ctx, orgID, "infra")
+	require.NoError(t, err)
+	db, err := s.SaveCollectionEntry(ctx, 123, collectionID, "login", `{"title": "db"}`)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateEntry(ctx, 123, db, "login", `{"title": "db", "password": "rotated"}`))
+	require.Equal(t, 1, keyVersion("entry", db))
+
+	rotation, err := s.StartOrganizationKeyRotation(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, 2, rotation.ToVersion)
+
+	// Both key versions are readable while the rotation runs, new data is sealed under the new one.
+	vpn, err := s.SaveCollectionEntry(ctx, 123, collectionID, "login", `{"title": "vpn"}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, keyVersion("entry", vpn))
+	entries, err := s.ListEntries(ctx, 123, models.EntryFilter{CollectionID: &collectionID})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	cursor, rewrapped, err := s.RewrapCollectionEntries(ctx, orgID, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, vpn, cursor)
+	require.Equal(t, 1, rewrapped)
+	revisions, err := s.ListRevisions(ctx, 123, db)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	cursor, rewrapped, err = s.RewrapCollectionRevisions(ctx, orgID, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, revisions[0].ID, cursor)
+	require.Equal(t, 1, rewrapped)
+	require.NoError(t, s.RetireOrganizationKeyParts(ctx, orgID, rotation.ToVersion))
+
+	require.Equal(t, 2, keyVersion("entry", db))
+	require.Equal(t, 2, keyVersion("entry_revision", revisions[0].ID))
+	entry, err := s.GetEntry(ctx, 123, db)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "db", "password": "rotated"}`, entry.EntryData)
+	revision, err := s.GetRevision(ctx, 123, db, revisions[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "db"}`, revision.EntryData)
+}
+
+func TestMasterKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	old, _ := newStorage(t, storage.RevisionRetention{})
+
+	personal, err := old.SaveEntry(ctx, 123, "login", `{"title": "Mail"}`)
+	require.NoError(t, err)
+	orgID, err := old.CreateOrganization(ctx, 123, "acme")
+	require.NoError(t, err)
+	collectionID, err := old.CreateCollection(ctx, orgID, "infra")
+	require.NoError(t, err)
+	shared, err := old.SaveCollectionEntry(ctx, 123, collectionID, "login", `{"title": "db"}`)
+	require.NoError(t, err)
+	_, err = old.AppendAuditEvent(ctx, models.AuditEvent{AccountID: 123, Action: models.AuditEntryCreate, Status: 201})
+	require.NoError(t, err)
+
+	oldKey := base64.StdEncoding.EncodeToString(make([]byte, envelope.KeySize))
+	newKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("n", envelope.KeySize)))
+	keys, err := envelope.ParseMasterKeys(2, newKey, map[int]string{1: oldKey})
+	require.NoError(t, err)
+	s := encrypted.New(old.Backend, keys)
+
+	status, err := s.MasterKeyStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, status.MasterKeyVersion)
+	require.Equal(t, []int64{123}, status.Accounts)
+	require.Equal(t, []int64{orgID}, status.Organizations)
+
+	// Data keyed with the old master key stays readable while it is configured.
+	entry, err := s.GetEntry(ctx, 123, personal)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Mail"}`, entry.EntryData)
+	_, err = s.AppendAuditEvent(ctx, models.AuditEvent{AccountID: 123, Action: models.AuditKeyRead, Status: 200})
+	require.NoError(t, err)
+
+	rotation, err := s.StartKeyRotation(ctx, 123)
+	require.NoError(t, err)
+	_, _, err = s.RewrapEntries(ctx, 123, 0, 10)
+	require.NoError(t, err)
+	require.NoError(t, s.RetireKeyParts(ctx, 123, rotation.ToVersion))
+	rotation, err = s.StartOrganizationKeyRotation(ctx, orgID)
+	require.NoError(t, err)
+	_, _, err = s.RewrapCollectionEntries(ctx, orgID, 0, 10)
+	require.NoError(t, err)
+	require.NoError(t, s.RetireOrganizationKeyParts(ctx, orgID, rotation.ToVersion))
+
+	status, err = s.MasterKeyStatus(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status.Accounts)
+	require.Empty(t, status.Organizations)
+
+	// The old master key is no longer needed for the vault, nor accepted for it.
+	keys, err = envelope.ParseMasterKeys(2, newKey, nil)
+	require.NoError(t, err)
+	rotated := encrypted.New(old.Backend, keys)
+	entry, err = rotated.GetEntry(ctx, 123, personal)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "Mail"}`, entry.EntryData)
+	entry, err = rotated.GetEntry(ctx, 123, shared)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "db"}`, entry.EntryData)
+	_, err = old.GetEntry(ctx, 123, personal)
+	require.ErrorIs(t, err, envelope.ErrUnknownMasterKey)
+
+	// The audit chain spans both master keys, verifying it needs both.
+	events, err := s.AuditEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	v, err := s.AuditVerifier()
+	require.NoError(t, err)
+	for _, event := range events {
+		require.NoError(t, v.Next(event))
+	}
+	v, err = rotated.AuditVerifier()
+	require.NoError(t, err)
+	require.ErrorIs(t, v.Next(events[0]), auditchain.ErrBrokenChain)
+}
+
+func TestKeyRecovery(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStorage(t, storage.RevisionRetention{})
+
+	id, err := s.SaveEntry(ctx, 123, "password", "supersecretpassword")
+	require.NoError(t, err)
+
+	service := keyshare.New(slog.New(slog.NewTextHandler(io.Discard, nil)), s)
+	shares, err := service.Split(ctx, 123, []string{"alice", "bob", "carol"}, 2)
+	require.NoError(t, err)
+
+	// The lost key part is not replaced by a fresh one while its shares can restore it.
+	require.NoError(t, s.DeleteKeyPart(ctx, 123))
+	_, err = s.GetEntry(ctx, 123, id)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+	_, err = s.SaveEntry(ctx, 123, "password", "another")
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	require.NoError(t, service.Recover(ctx, 123, shares[1:]))
+
+	entry, err := s.GetEntry(ctx, 123, id)
+	require.NoError(t, err)
+	require.Equal(t, "supersecretpassword", entry.EntryData)
+}
@@ -0,0 +1,232 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/envelope"
+)
+
+// StartKeyRotation adds a new random key part as the next key version of an account.
+// New data is sealed under it right away; RewrapEntries and RewrapRevisions move the
+// existing data over.
+func (s *Storage) StartKeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	const op = "storage.encrypted.StartKeyRotation"
+
+	keyPart, err := envelope.NewKeyPart()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, _ := s.masterKeys.Current()
+	return s.Backend.StartKeyRotation(ctx, accountID, keyPart, version)
+}
+
+// StartOrganizationKeyRotation is StartKeyRotation for the key shared by the collections
+// of an organization; RewrapCollectionEntries and RewrapCollectionRevisions move the
+// existing data over.
+func (s *Storage) StartOrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	const op = "storage.encrypted.StartOrganizationKeyRotation"
+
+	keyPart, err := envelope.NewKeyPart()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, _ := s.masterKeys.Current()
+	return s.Backend.StartOrganizationKeyRotation(ctx, orgID, keyPart, version)
+}
+
+// MasterKeyStatus lists the accounts and organizations with key parts derived with an
+// older master key than the current one. Rotating their keys moves them to the current
+// master key; once none are left, the older master keys can be dropped from the config.
+func (s *Storage) MasterKeyStatus(ctx context.Context) (*models.MasterKeyStatus, error) {
+	const op = "storage.encrypted.MasterKeyStatus"
+
+	version, _ := s.masterKeys.Current()
+	accounts, err := s.Backend.OutdatedAccounts(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	orgs, err := s.Backend.OutdatedOrganizations(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.MasterKeyStatus{
+		MasterKeyVersion: version,
+		Accounts:         accounts,
+		Organizations:    orgs,
+	}, nil
+}
+
+// RewrapEntries seals up to limit personal entries of an account with IDs above afterID
+// under the current key version and indexes them with its search key. Entries already
+// sealed under it are skipped, plaintext rows written before encryption are sealed. It
+// returns the ID of the last entry looked at, afterID when none are left, and how many
+// entries were rewrapped.
+func (s *Storage) RewrapEntries(ctx context.Context, accountID int64, afterID int64, limit int) (int64, int, error) {
+	const op = "storage.encrypted.RewrapEntries"
+
+	entries, err := s.Backend.PersonalEntries(ctx, accountID, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+	if len(entries) == 0 {
+		return afterID, 0, nil
+	}
+
+	keys, err := s.accountKeys(ctx, accountID, false)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	searchKey, err := s.searchKey(ctx, accountID, false)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	tagNames, err := s.tagNames(ctx, accountID)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rewrapped := 0
+	for _, entry := range entries {
+		plaintext, sealed, err := keys.rewrap(entry.EntryData)
+		if err != nil {
+			return afterID, rewrapped, fmt.Errorf("%s: entry %d: %w", op, entry.ID, err)
+		}
+		if sealed == "" {
+			continue
+		}
+
+		tokens := indexTokens(searchKey, plaintext, entry.TagIDs, tagNames)
+		if err := s.Backend.RewrapEntry(ctx, accountID, entry.ID, entry.EntryData, sealed, tokens); err != nil {
+			return afterID, rewrapped, err
+		}
+		rewrapped++
+	}
+
+	return entries[len(entries)-1].ID, rewrapped, nil
+}
+
+// RewrapRevisions is RewrapEntries for the revisions of personal entries.
+func (s *Storage) RewrapRevisions(ctx context.Context, accountID int64, afterID int64, limit int) (int64, int, error) {
+	const op = "storage.encrypted.RewrapRevisions"
+
+	revisions, err := s.Backend.PersonalRevisions(ctx, accountID, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+	if len(revisions) == 0 {
+		return afterID, 0, nil
+	}
+
+	keys, err := s.accountKeys(ctx, accountID, false)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rewrapped := 0
+	for _, revision := range revisions {
+		_, sealed, err := keys.rewrap(revision.EntryData)
+		if err != nil {
+			return afterID, rewrapped, fmt.Errorf("%s: revision %d: %w", op, revision.ID, err)
+		}
+		if sealed == "" {
+			continue
+		}
+
+		if err := s.Backend.RewrapRevision(ctx, accountID, revision.ID, revision.EntryData, sealed); err != nil {
+			return afterID, rewrapped, err
+		}
+		rewrapped++
+	}
+
+	return revisions[len(revisions)-1].ID, rewrapped, nil
+}
+
+// RewrapCollectionEntries is RewrapEntries for the entries in the collections of an
+// organization. Collection entries are not in the search index.
+func (s *Storage) RewrapCollectionEntries(ctx context.Context, orgID int64, afterID int64, limit int) (int64, int, error) {
+	const op = "storage.encrypted.RewrapCollectionEntries"
+
+	entries, err := s.Backend.CollectionEntries(ctx, orgID, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+	if len(entries) == 0 {
+		return afterID, 0, nil
+	}
+
+	keys, err := s.organizationKeys(ctx, orgID)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rewrapped := 0
+	for _, entry := range entries {
+		_, sealed, err := keys.rewrap(entry.EntryData)
+		if err != nil {
+			return afterID, rewrapped, fmt.Errorf("%s: entry %d: %w", op, entry.ID, err)
+		}
+		if sealed == "" {
+			continue
+		}
+
+		if err := s.Backend.RewrapCollectionEntry(ctx, orgID, entry.ID, entry.EntryData, sealed); err != nil {
+			return afterID, rewrapped, err
+		}
+		rewrapped++
+	}
+
+	return entries[len(entries)-1].ID, rewrapped, nil
+}
+
+// RewrapCollectionRevisions is RewrapCollectionEntries for the revisions of collection
+// entries.
+func (s *Storage) RewrapCollectionRevisions(ctx context.Context, orgID int64, afterID int64, limit int) (int64, int, error) {
+	const op = "storage.encrypted.RewrapCollectionRevisions"
+
+	revisions, err := s.Backend.CollectionRevisions(ctx, orgID, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+	if len(revisions) == 0 {
+		return afterID, 0, nil
+	}
+
+	keys, err := s.organizationKeys(ctx, orgID)
+	if err != nil {
+		return afterID, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rewrapped := 0
+	for _, revision := range revisions {
+		_, sealed, err := keys.rewrap(revision.EntryData)
+		if err != nil {
+			return afterID, rewrapped, fmt.Errorf("%s: revision %d: %w", op, revision.ID, err)
+		}
+		if sealed == "" {
+			continue
+		}
+
+		if err := s.Backend.RewrapCollectionRevision(ctx, orgID, revision.ID, revision.EntryData, sealed); err != nil {
+			return afterID, rewrapped, err
+		}
+		rewrapped++
+	}
+
+	return revisions[len(revisions)-1].ID, rewrapped, nil
+}
+
+// RetireKeyParts removes the key versions of an account older than keyVersion. It fails
+// with storage.ErrEncryptionKeyInUse while personal data is still sealed under one of
+// them, for instance by a request that loaded the keys before the rotation started.
+func (s *Storage) RetireKeyParts(ctx context.Context, accountID int64, keyVersion int) error {
+	return s.Backend.RetireKeyParts(ctx, accountID, keyVersion, envelope.VersionPrefix(keyVersion))
+}
+
+// RetireOrganizationKeyParts is RetireKeyParts for the collections of an organization.
+func (s *Storage) RetireOrganizationKeyParts(ctx context.Context, orgID int64, keyVersion int) error {
+	return s.Backend.RetireOrganizationKeyParts(ctx, orgID, keyVersion, envelope.VersionPrefix(keyVersion))
+}
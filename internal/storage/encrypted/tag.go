@@ -0,0 +1,60 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tag names are part of the blind search index of the entries carrying them, so every
+// change to the tags of an entry or to the name of a tag re-indexes the entries affected.
+
+// SetEntryTags replaces the tags of a personal entry of an account and re-indexes it.
+func (s *Storage) SetEntryTags(ctx context.Context, accountID int64, entryID int64, tagIDs []int64) error {
+	const op = "storage.encrypted.SetEntryTags"
+
+	if err := s.Backend.SetEntryTags(ctx, accountID, entryID, tagIDs); err != nil {
+		return err
+	}
+
+	if err := s.reindex(ctx, accountID, []int64{entryID}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RenameTag renames a tag of an account and re-indexes the entries carrying it.
+func (s *Storage) RenameTag(ctx context.Context, accountID int64, tagID int64, name string) error {
+	const op = "storage.encrypted.RenameTag"
+
+	if err := s.Backend.RenameTag(ctx, accountID, tagID, name); err != nil {
+		return err
+	}
+
+	entryIDs, err := s.taggedEntries(ctx, accountID, tagID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.reindex(ctx, accountID, entryIDs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteTag deletes a tag of an account and re-indexes the entries that carried it.
+func (s *Storage) DeleteTag(ctx context.Context, accountID int64, tagID int64) error {
+	const op = "storage.encrypted.DeleteTag"
+
+	entryIDs, err := s.taggedEntries(ctx, accountID, tagID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.Backend.DeleteTag(ctx, accountID, tagID); err != nil {
+		return err
+	}
+
+	if err := s.reindex(ctx, accountID, entryIDs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// EntryAccess resolves what an account may do with an entry. Entries the account
+// cannot read are reported as storage.ErrEntryNotFound, so their existence is not revealed.
+func (s *Storage) EntryAccess(ctx context.Context, accountID int64, entryID int64) (*models.EntryAccess, error) {
+	const op = "storage.memory.EntryAccess"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	access, err := s.entryAccess(accountID, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !access.Read {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+	return access, nil
+}
+
+// entryAccess computes the access of an account to an existing entry. Personal entries
+// are fully accessible to their owner and readable, or writable, through an unexpired
+// share. Entries in a collection are readable by every member of the organization and
+// writable by editors and above.
+func (s *Storage) entryAccess(accountID int64, entryID int64) (*models.EntryAccess, error) {
+	entry, ok := s.entries[entryID]
+	if !ok {
+		return nil, storage.ErrEntryNotFound
+	}
+
+	access := models.EntryAccess{
+		EntryID:      entryID,
+		OwnerID:      entry.AccountId,
+		CollectionID: cloneID(entry.CollectionID),
+	}
+
+	switch {
+	case entry.CollectionID != nil:
+		var role string
+		if collection, ok := s.collections[*entry.CollectionID]; ok {
+			orgID := collection.OrganizationID
+			access.OrganizationID = &orgID
+			role = s.members[orgID][accountID].Role
+		}
+		access.Read = models.ValidOrgRole(role)
+		access.Write = models.OrgRoleAtLeast(role, models.OrgRoleEditor)
+		access.Delete = access.Write
+	case entry.AccountId == accountID:
+		access.Read, access.Write, access.Delete = true, true, true
+	default:
+		if share, ok := s.activeShare(entryID, entry.AccountId, accountID); ok {
+			access.Read = true
+			access.Write = share.Permission == models.SharePermissionWrite
+		}
+	}
+
+	return &access, nil
+}
+
+// checkEntryAccess returns the access of an account to an entry if it allows reading, and
+// writing or deleting when asked for. Entries the account can read but not change give
+// storage.ErrAccessDenied.
+func (s *Storage) checkEntryAccess(accountID int64, entryID int64, write, delete bool) (*models.EntryAccess, error) {
+	access, err := s.entryAccess(accountID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Read {
+		return nil, storage.ErrEntryNotFound
+	}
+	if (write && !access.Write) || (delete && !access.Delete) {
+		return nil, storage.ErrAccessDenied
+	}
+	return access, nil
+}
+
+// revisionOwner returns the account and collection the revisions of an entry are kept
+// under, if the account may read them, or restore them when write is set. The history
+// of personal entries is private to their owner, even when the entry is shared; that
+// of collection entries is available to the organization members. Revisions of a
+// deleted entry follow the access rules the entry had when it was deleted.
+func (s *Storage) revisionOwner(accountID int64, entryID int64, write bool) (int64, *int64, error) {
+	access, err := s.checkEntryAccess(accountID, entryID, write, false)
+	if err == nil {
+		if access.CollectionID == nil && access.OwnerID != accountID {
+			return 0, nil, storage.ErrEntryNotFound
+		}
+		return access.OwnerID, access.CollectionID, nil
+	}
+	if !errors.Is(err, storage.ErrEntryNotFound) {
+		return 0, nil, err
+	}
+	if _, ok := s.entries[entryID]; ok {
+		return 0, nil, storage.ErrEntryNotFound
+	}
+
+	var last *models.EntryRevision
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		if s.revisions[i].EntryID == entryID {
+			last = &s.revisions[i]
+			break
+		}
+	}
+	if last == nil {
+		return 0, nil, storage.ErrEntryNotFound
+	}
+
+	if last.CollectionID == nil {
+		if last.AccountId != accountID {
+			return 0, nil, storage.ErrEntryNotFound
+		}
+		return last.AccountId, nil, nil
+	}
+
+	role, err := s.collectionRole(accountID, *last.CollectionID)
+	if errors.Is(err, storage.ErrCollectionNotFound) {
+		return 0, nil, storage.ErrEntryNotFound
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if write && !models.OrgRoleAtLeast(role, models.OrgRoleEditor) {
+		return 0, nil, storage.ErrAccessDenied
+	}
+	return last.AccountId, cloneID(last.CollectionID), nil
+}
+
+// collectionRole returns the role of an account in the organization of a collection.
+// It returns storage.ErrCollectionNotFound when the account is not a member.
+func (s *Storage) collectionRole(accountID int64, collectionID int64) (string, error) {
+	collection, ok := s.collections[collectionID]
+	if !ok {
+		return "", storage.ErrCollectionNotFound
+	}
+	member, ok := s.members[collection.OrganizationID][accountID]
+	if !ok {
+		return "", storage.ErrCollectionNotFound
+	}
+	return member.Role, nil
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+)
+
+// AppendAuditEvent appends an event to the audit log, chained to the last event.
+// The ID, CreatedAt, PrevHash and Hash fields of event are set here, the hash keyed with chainKey.
+func (s *Storage) AppendAuditEvent(ctx context.Context, event models.AuditEvent, chainKey []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prevHash string
+	if n := len(s.audit); n > 0 {
+		prevHash = s.audit[n-1].Hash
+	}
+
+	event.ID = int64(len(s.audit)) + 1
+	event.CreatedAt = now()
+	event.EntryID = cloneID(event.EntryID)
+	event.PrevHash = prevHash
+	event.Hash = auditchain.Hash(chainKey, prevHash, event.ID, event)
+
+	s.audit = append(s.audit, event)
+	return event.ID, nil
+}
+
+// ListAuditEvents retrieves the audit events of an account matching filter, newest first
+func (s *Storage) ListAuditEvents(ctx context.Context, accountID int64, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []models.AuditEvent
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+
+		event := s.audit[i]
+		switch {
+		case event.AccountID != accountID,
+			filter.EntryID != nil && !sameID(event.EntryID, filter.EntryID),
+			filter.Action != "" && event.Action != filter.Action,
+			filter.Since != nil && event.CreatedAt.Before(*filter.Since),
+			filter.Until != nil && !event.CreatedAt.Before(*filter.Until),
+			filter.BeforeID > 0 && event.ID >= filter.BeforeID:
+			continue
+		}
+
+		event.EntryID = cloneID(event.EntryID)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// AuditEvents retrieves up to limit audit events of all accounts following afterID, oldest first.
+// It is meant for walking the whole chain.
+func (s *Storage) AuditEvents(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Event IDs are positions in the log, starting from 1.
+	start := min(max(afterID, 0), int64(len(s.audit)))
+	end := int64(len(s.audit))
+	if limit >= 0 {
+		end = min(end, start+int64(limit))
+	}
+
+	var events []models.AuditEvent
+	for _, event := range s.audit[start:end] {
+		event.EntryID = cloneID(event.EntryID)
+		events = append(events, event)
+	}
+	return events, nil
+}
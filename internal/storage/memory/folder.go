@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+)
+
+// CreateFolder stores a new folder of an account. A nil parentID creates a top level folder.
+func (s *Storage) CreateFolder(ctx context.Context, accountID int64, parentID *int64, name string) (int64, error) {
+	const op = "storage.memory.CreateFolder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if parentID != nil {
+		if err := s.checkFolder(accountID, *parentID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if s.folderNameTaken(accountID, parentID, name, 0) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+	}
+
+	now := now()
+	folder := models.Folder{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, AccountId: accountID, ParentID: cloneID(parentID), Name: name}
+	s.folders[folder.ID] = folder
+	return folder.ID, nil
+}
+
+// ListFolders retrieves all folders of an account ordered by name
+func (s *Storage) ListFolders(ctx context.Context, accountID int64) ([]models.Folder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var folders []models.Folder
+	for _, folder := range s.folders {
+		if folder.AccountId == accountID {
+			folder.ParentID = cloneID(folder.ParentID)
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].Name != folders[j].Name {
+			return folders[i].Name < folders[j].Name
+		}
+		return folders[i].ID < folders[j].ID
+	})
+	return folders, nil
+}
+
+// RenameFolder changes the name of a folder of an account
+func (s *Storage) RenameFolder(ctx context.Context, accountID int64, folderID int64, name string) error {
+	const op = "storage.memory.RenameFolder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFolder(accountID, folderID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	folder := s.folders[folderID]
+	if s.folderNameTaken(accountID, folder.ParentID, name, folderID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+	}
+
+	folder.Name = name
+	folder.UpdatedAt = now()
+	s.folders[folderID] = folder
+	return nil
+}
+
+// MoveFolder moves a folder of an account, with its subfolders, under another folder.
+// A nil parentID moves it to the top level. Moving a folder below itself fails with
+// storage.ErrFolderCycle.
+func (s *Storage) MoveFolder(ctx context.Context, accountID int64, folderID int64, parentID *int64) error {
+	const op = "storage.memory.MoveFolder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFolder(accountID, folderID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if parentID != nil {
+		if err := s.checkFolder(accountID, *parentID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		// The new parent must not be the folder itself or one of its descendants.
+		for id := parentID; id != nil; id = s.folders[*id].ParentID {
+			if *id == folderID {
+				return fmt.Errorf("%s: %w", op, storage.ErrFolderCycle)
+			}
+		}
+	}
+
+	folder := s.folders[folderID]
+	if s.folderNameTaken(accountID, parentID, folder.Name, folderID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+	}
+
+	folder.ParentID = cloneID(parentID)
+	folder.UpdatedAt = now()
+	s.folders[folderID] = folder
+	return nil
+}
+
+// DeleteFolder removes a folder of an account. Its subfolders and entries are moved to
+// the parent of the deleted folder, nothing is deleted with it.
+func (s *Storage) DeleteFolder(ctx context.Context, accountID int64, folderID int64) error {
+	const op = "storage.memory.DeleteFolder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFolder(accountID, folderID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	parentID := s.folders[folderID].ParentID
+
+	// Subfolders must not clash with the folders they join, the deleted one included.
+	var children []models.Folder
+	for _, folder := range s.folders {
+		if folder.AccountId == accountID && sameID(folder.ParentID, &folderID) {
+			children = append(children, folder)
+		}
+	}
+	for _, child := range children {
+		if s.folderNameTaken(accountID, parentID, child.Name, 0) {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+	}
+
+	now := now()
+	for _, child := range children {
+		child.ParentID = cloneID(parentID)
+		child.UpdatedAt = now
+		s.folders[child.ID] = child
+	}
+
+	for id, entry := range s.entries {
+		if entry.AccountId == accountID && sameID(entry.FolderID, &folderID) {
+			entry.FolderID = cloneID(parentID)
+			s.entries[id] = entry
+		}
+	}
+
+	delete(s.folders, folderID)
+	return nil
+}
+
+// SetEntryFolder puts a personal entry of an account into a folder. A nil folderID takes it
+// out of any folder.
+func (s *Storage) SetEntryFolder(ctx context.Context, accountID int64, entryID int64, folderID *int64) error {
+	const op = "storage.memory.SetEntryFolder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if folderID != nil {
+		if err := s.checkFolder(accountID, *folderID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	entry, ok := s.entries[entryID]
+	if !ok || entry.AccountId != accountID || entry.CollectionID != nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+
+	entry.FolderID = cloneID(folderID)
+	s.entries[entryID] = entry
+	return nil
+}
+
+// checkFolder returns storage.ErrFolderNotFound unless the account has the folder.
+func (s *Storage) checkFolder(accountID int64, folderID int64) error {
+	if folder, ok := s.folders[folderID]; !ok || folder.AccountId != accountID {
+		return storage.ErrFolderNotFound
+	}
+	return nil
+}
+
+// folderNameTaken reports whether another folder of the account than exceptID has
+// the name under parentID. Folder names are unique per parent.
+func (s *Storage) folderNameTaken(accountID int64, parentID *int64, name string, exceptID int64) bool {
+	for _, folder := range s.folders {
+		if folder.ID != exceptID && folder.AccountId == accountID && folder.Name == name && sameID(folder.ParentID, parentID) {
+			return true
+		}
+	}
+	return false
+}
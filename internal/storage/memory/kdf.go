@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// SaveKDFParams saves the zero-knowledge KDF parameters of an account. They are saved
+// once and never changed, and only while the account has no personal entries or
+// revisions: their data was written as plaintext.
+func (s *Storage) SaveKDFParams(ctx context.Context, params models.KDFParams) error {
+	const op = "storage.memory.SaveKDFParams"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.kdfParams[params.AccountID]; ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrKDFParamsExist)
+	}
+	if s.hasPersonalData(params.AccountID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrVaultNotEmpty)
+	}
+
+	params.CreatedAt = now()
+	s.kdfParams[params.AccountID] = params
+	return nil
+}
+
+// KDFParams retrieves the zero-knowledge KDF parameters of an account
+func (s *Storage) KDFParams(ctx context.Context, accountID int64) (*models.KDFParams, error) {
+	const op = "storage.memory.KDFParams"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	params, ok := s.kdfParams[accountID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKDFParamsNotFound)
+	}
+	return &params, nil
+}
@@ -0,0 +1,470 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StartKeyRotation adds keyPart, derived with the master key of masterKeyVersion, as the
+// next key version of an account and records a running rotation to it. The Shamir shares
+// of the account split the previous key part and cannot recover the new one, so they are
+// removed and counted in SharesRemoved. An account rotates one key at a time.
+func (s *Storage) StartKeyRotation(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error) {
+	const op = "storage.memory.StartKeyRotation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.keyParts[accountID]
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+	if s.rotationRunning(accountID, 0) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationRunning)
+	}
+
+	now := now()
+	current := keys[len(keys)-1].KeyVersion
+	s.keyParts[accountID] = append(keys, models.EncryptionKey{
+		ID:               s.nextID(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		AccountId:        accountID,
+		KeyPart:          keyPart,
+		KeyVersion:       current + 1,
+		MasterKeyVersion: masterKeyVersion,
+	})
+	sharesRemoved := len(s.keyShares[accountID])
+	delete(s.keyShares, accountID)
+
+	rotation := models.KeyRotation{
+		ID:            s.nextID(),
+		AccountID:     accountID,
+		FromVersion:   current,
+		ToVersion:     current + 1,
+		Status:        models.KeyRotationRunning,
+		SharesRemoved: sharesRemoved,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.rotations = append(s.rotations, rotation)
+	return &rotation, nil
+}
+
+// StartOrganizationKeyRotation adds keyPart, derived with the master key of
+// masterKeyVersion, as the next key version of an organization and records a running
+// rotation of its collection entries to it.
+func (s *Storage) StartOrganizationKeyRotation(ctx context.Context, orgID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error) {
+	const op = "storage.memory.StartOrganizationKeyRotation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, ok := s.orgs[orgID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+	if s.rotationRunning(0, orgID) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationRunning)
+	}
+
+	now := now()
+	current := org.keyParts[len(org.keyParts)-1].KeyVersion
+	org.keyParts = append(org.keyParts, models.OrganizationKey{
+		ID:               s.nextID(),
+		CreatedAt:        now,
+		OrganizationID:   orgID,
+		KeyPart:          keyPart,
+		KeyVersion:       current + 1,
+		MasterKeyVersion: masterKeyVersion,
+	})
+	s.orgs[orgID] = org
+
+	rotation := models.KeyRotation{
+		ID:             s.nextID(),
+		OrganizationID: orgID,
+		FromVersion:    current,
+		ToVersion:      current + 1,
+		Status:         models.KeyRotationRunning,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.rotations = append(s.rotations, rotation)
+	return &rotation, nil
+}
+
+// rotationRunning reports whether the account or organization has a running key rotation.
+func (s *Storage) rotationRunning(accountID int64, orgID int64) bool {
+	for _, rotation := range s.rotations {
+		if rotation.AccountID == accountID && rotation.OrganizationID == orgID && rotation.Status == models.KeyRotationRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyRotation retrieves the latest key rotation of an account
+func (s *Storage) KeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	const op = "storage.memory.KeyRotation"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.rotations) - 1; i >= 0; i-- {
+		if s.rotations[i].AccountID == accountID && s.rotations[i].OrganizationID == 0 {
+			rotation := cloneRotation(s.rotations[i])
+			return &rotation, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+}
+
+// OrganizationKeyRotation retrieves the latest key rotation of an organization
+func (s *Storage) OrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	const op = "storage.memory.OrganizationKeyRotation"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.rotations) - 1; i >= 0; i-- {
+		if s.rotations[i].OrganizationID == orgID && s.rotations[i].AccountID == 0 {
+			rotation := cloneRotation(s.rotations[i])
+			return &rotation, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+}
+
+// ListKeyRotations retrieves the key rotations with a status, oldest first
+func (s *Storage) ListKeyRotations(ctx context.Context, status string) ([]models.KeyRotation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rotations []models.KeyRotation
+	for _, rotation := range s.rotations {
+		if rotation.Status == status {
+			rotations = append(rotations, cloneRotation(rotation))
+		}
+	}
+	return rotations, nil
+}
+
+// UpdateKeyRotation saves the status and progress of a key rotation
+func (s *Storage) UpdateKeyRotation(ctx context.Context, rotation models.KeyRotation) error {
+	const op = "storage.memory.UpdateKeyRotation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, stored := range s.rotations {
+		if stored.ID != rotation.ID || stored.AccountID != rotation.AccountID || stored.OrganizationID != rotation.OrganizationID {
+			continue
+		}
+		if rotation.Status == models.KeyRotationRunning && stored.Status != models.KeyRotationRunning &&
+			s.rotationRunning(rotation.AccountID, rotation.OrganizationID) {
+			return fmt.Errorf("%s: %w", op, storage.ErrKeyRotationRunning)
+		}
+
+		stored.Status = rotation.Status
+		stored.EntryCursor = rotation.EntryCursor
+		stored.RevisionCursor = rotation.RevisionCursor
+		stored.Entries = rotation.Entries
+		stored.Revisions = rotation.Revisions
+		stored.Error = rotation.Error
+		stored.FinishedAt = cloneTime(rotation.FinishedAt)
+		stored.UpdatedAt = now()
+		s.rotations[i] = stored
+		return nil
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+}
+
+// RetireKeyParts removes the key versions of an account older than keyVersion. It
+// refuses with storage.ErrEncryptionKeyInUse while a personal entry or revision of the
+// account holds data that does not start with sealedPrefix.
+func (s *Storage) RetireKeyParts(ctx context.Context, accountID int64, keyVersion int, sealedPrefix string) error {
+	const op = "storage.memory.RetireKeyParts"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if entry.AccountId == accountID && entry.CollectionID == nil && !strings.HasPrefix(entry.EntryData, sealedPrefix) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+		}
+	}
+	for _, revision := range s.revisions {
+		if revision.AccountId == accountID && revision.CollectionID == nil && !strings.HasPrefix(revision.EntryData, sealedPrefix) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+		}
+	}
+
+	var kept []models.EncryptionKey
+	for _, key := range s.keyParts[accountID] {
+		if key.KeyVersion >= keyVersion {
+			kept = append(kept, key)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.keyParts, accountID)
+		return nil
+	}
+	s.keyParts[accountID] = kept
+	return nil
+}
+
+// RetireOrganizationKeyParts removes the key versions of an organization older than
+// keyVersion. Like RetireKeyParts, it refuses while an entry or revision in the
+// collections of the organization does not start with sealedPrefix.
+func (s *Storage) RetireOrganizationKeyParts(ctx context.Context, orgID int64, keyVersion int, sealedPrefix string) error {
+	const op = "storage.memory.RetireOrganizationKeyParts"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if s.inOrganization(entry.CollectionID, orgID) && !strings.HasPrefix(entry.EntryData, sealedPrefix) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+		}
+	}
+	for _, revision := range s.revisions {
+		if s.inOrganization(revision.CollectionID, orgID) && !strings.HasPrefix(revision.EntryData, sealedPrefix) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+		}
+	}
+
+	org, ok := s.orgs[orgID]
+	if !ok {
+		return nil
+	}
+	var kept []models.OrganizationKey
+	for _, key := range org.keyParts {
+		if key.KeyVersion >= keyVersion {
+			kept = append(kept, key)
+		}
+	}
+	org.keyParts = kept
+	s.orgs[orgID] = org
+	return nil
+}
+
+// OutdatedAccounts retrieves the IDs of the accounts with key parts, or commitments to
+// the shares of one, derived with a master key version below masterKeyVersion
+func (s *Storage) OutdatedAccounts(ctx context.Context, masterKeyVersion int) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outdated := make(map[int64]struct{})
+	for _, keys := range []map[int64][]models.EncryptionKey{s.keyParts, s.keyShares} {
+		for accountID, keys := range keys {
+			for _, key := range keys {
+				if key.MasterKeyVersion < masterKeyVersion {
+					outdated[accountID] = struct{}{}
+				}
+			}
+		}
+	}
+	return sortedIDs(outdated), nil
+}
+
+// OutdatedOrganizations retrieves the IDs of the organizations with key parts derived
+// with a master key version below masterKeyVersion
+func (s *Storage) OutdatedOrganizations(ctx context.Context, masterKeyVersion int) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outdated := make(map[int64]struct{})
+	for orgID, org := range s.orgs {
+		for _, key := range org.keyParts {
+			if key.MasterKeyVersion < masterKeyVersion {
+				outdated[orgID] = struct{}{}
+			}
+		}
+	}
+	return sortedIDs(outdated), nil
+}
+
+// PersonalEntries retrieves up to limit personal entries of an account with IDs above
+// afterID, in ID order, with their data as stored and their tags
+func (s *Storage) PersonalEntries(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []models.Entry
+	for _, entry := range s.entries {
+		if entry.AccountId == accountID && entry.CollectionID == nil && entry.ID > afterID {
+			entry := cloneEntry(entry)
+			entry.TagIDs = s.entryTagIDs(accountID, entry.ID)
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// RewrapEntry replaces the data of a personal entry with newData and its blind index
+// tokens with searchTokens, unless the entry changed since oldData was read. No revision
+// is kept and the entry keeps its update time.
+func (s *Storage) RewrapEntry(ctx context.Context, accountID int64, entryID int64, oldData, newData string, searchTokens []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[entryID]
+	if !ok || entry.AccountId != accountID || entry.CollectionID != nil || entry.EntryData != oldData {
+		return nil
+	}
+
+	entry.EntryData = newData
+	s.entries[entryID] = entry
+
+	key := tokenKey{accountID: accountID, entryID: entryID}
+	delete(s.tokens, key)
+	if len(searchTokens) > 0 {
+		set := make(map[string]struct{}, len(searchTokens))
+		for _, token := range searchTokens {
+			set[token] = struct{}{}
+		}
+		s.tokens[key] = set
+	}
+	return nil
+}
+
+// PersonalRevisions retrieves up to limit revisions of the personal entries of an account
+// with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) PersonalRevisions(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.EntryRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var revisions []models.EntryRevision
+	for _, revision := range s.revisions {
+		if revision.AccountId == accountID && revision.CollectionID == nil && revision.ID > afterID {
+			revisions = append(revisions, cloneRevision(revision))
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].ID < revisions[j].ID })
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+// RewrapRevision replaces the data of a revision with newData, unless it is no longer oldData
+func (s *Storage) RewrapRevision(ctx context.Context, accountID int64, revisionID int64, oldData, newData string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, revision := range s.revisions {
+		if revision.ID == revisionID && revision.AccountId == accountID && revision.CollectionID == nil && revision.EntryData == oldData {
+			s.revisions[i].EntryData = newData
+		}
+	}
+	return nil
+}
+
+// CollectionEntries retrieves up to limit entries in the collections of an organization
+// with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) CollectionEntries(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []models.Entry
+	for _, entry := range s.entries {
+		if entry.ID > afterID && s.inOrganization(entry.CollectionID, orgID) {
+			entries = append(entries, cloneEntry(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// RewrapCollectionEntry replaces the data of an entry in a collection of an organization
+// with newData, unless the entry changed since oldData was read. No revision is kept and
+// the entry keeps its update time.
+func (s *Storage) RewrapCollectionEntry(ctx context.Context, orgID int64, entryID int64, oldData, newData string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[entryID]
+	if !ok || !s.inOrganization(entry.CollectionID, orgID) || entry.EntryData != oldData {
+		return nil
+	}
+
+	entry.EntryData = newData
+	s.entries[entryID] = entry
+	return nil
+}
+
+// CollectionRevisions retrieves up to limit revisions made in the collections of an
+// organization with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) CollectionRevisions(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.EntryRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var revisions []models.EntryRevision
+	for _, revision := range s.revisions {
+		if revision.ID > afterID && s.inOrganization(revision.CollectionID, orgID) {
+			revisions = append(revisions, cloneRevision(revision))
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].ID < revisions[j].ID })
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+// RewrapCollectionRevision replaces the data of a revision made in a collection of an
+// organization with newData, unless it is no longer oldData
+func (s *Storage) RewrapCollectionRevision(ctx context.Context, orgID int64, revisionID int64, oldData, newData string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, revision := range s.revisions {
+		if revision.ID == revisionID && s.inOrganization(revision.CollectionID, orgID) && revision.EntryData == oldData {
+			s.revisions[i].EntryData = newData
+		}
+	}
+	return nil
+}
+
+// inOrganization reports whether collectionID is a collection of the organization.
+func (s *Storage) inOrganization(collectionID *int64, orgID int64) bool {
+	if collectionID == nil {
+		return false
+	}
+	collection, ok := s.collections[*collectionID]
+	return ok && collection.OrganizationID == orgID
+}
+
+func sortedIDs(set map[int64]struct{}) []int64 {
+	var ids []int64
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func cloneRotation(rotation models.KeyRotation) models.KeyRotation {
+	rotation.FinishedAt = cloneTime(rotation.FinishedAt)
+	return rotation
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
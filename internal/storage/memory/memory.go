@@ -0,0 +1,682 @@
+// Package memory keeps the vault in process memory. It behaves like the SQL backends
+// and is meant for tests and ephemeral development servers: everything is lost when
+// the process exits.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ storage.Backend = (*Storage)(nil)
+
+type Storage struct {
+	retention storage.RevisionRetention
+
+	// mu guards all the fields below. Methods take it once and call unexported
+	// helpers that expect it held, so every method is atomic like a transaction.
+	mu sync.RWMutex
+	// seq is the last ID handed out, shared by all tables.
+	seq int64
+
+	entries     map[int64]models.Entry
+	keyParts    map[int64][]models.EncryptionKey
+	keyShares   map[int64][]models.EncryptionKey
+	kdfParams   map[int64]models.KDFParams
+	revisions   []models.EntryRevision
+	rotations   []models.KeyRotation
+	folders     map[int64]models.Folder
+	tags        map[int64]models.Tag
+	entryTags   map[int64]map[int64]struct{}
+	tokens      map[tokenKey]map[string]struct{}
+	shares      map[int64]models.EntryShare
+	orgs        map[int64]organization
+	members     map[int64]map[int64]models.OrganizationMember
+	collections map[int64]models.Collection
+	audit       []models.AuditEvent
+}
+
+func New(retention storage.RevisionRetention) *Storage {
+	return &Storage{
+		retention:   retention,
+		entries:     make(map[int64]models.Entry),
+		keyParts:    make(map[int64][]models.EncryptionKey),
+		keyShares:   make(map[int64][]models.EncryptionKey),
+		kdfParams:   make(map[int64]models.KDFParams),
+		folders:     make(map[int64]models.Folder),
+		tags:        make(map[int64]models.Tag),
+		entryTags:   make(map[int64]map[int64]struct{}),
+		tokens:      make(map[tokenKey]map[string]struct{}),
+		shares:      make(map[int64]models.EntryShare),
+		orgs:        make(map[int64]organization),
+		members:     make(map[int64]map[int64]models.OrganizationMember),
+		collections: make(map[int64]models.Collection),
+	}
+}
+
+// Close does nothing, the data lives as long as the Storage.
+func (s *Storage) Close() error {
+	return nil
+}
+
+// SaveEntry stores a new entry
+func (s *Storage) SaveEntry(ctx context.Context, accountID int64, entryType, entryData string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertEntry(accountID, nil, entryType, entryData), nil
+}
+
+// SaveEntries inserts personal entries of an account along with their search tokens,
+// all or none of them.
+func (s *Storage) SaveEntries(ctx context.Context, accountID int64, entries []storage.NewEntry) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		id := s.insertEntry(accountID, nil, entry.EntryType, entry.EntryData)
+		if len(entry.SearchTokens) > 0 {
+			set := make(map[string]struct{}, len(entry.SearchTokens))
+			for _, token := range entry.SearchTokens {
+				set[token] = struct{}{}
+			}
+			s.tokens[tokenKey{accountID: accountID, entryID: id}] = set
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetEntry retrieves an entry by ID. The entry must belong to the account, be shared
+// with it or be in a collection of one of its organizations.
+func (s *Storage) GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error) {
+	const op = "storage.memory.GetEntry"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	access, err := s.checkEntryAccess(accountID, entryID, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entry := cloneEntry(s.entries[entryID])
+	if access.CollectionID != nil || access.OwnerID != accountID {
+		// Folders and tags are private to the owner of a personal entry.
+		entry.FolderID = nil
+		return &entry, nil
+	}
+
+	entry.TagIDs = s.entryTagIDs(accountID, entryID)
+	return &entry, nil
+}
+
+// UpdateEntry replaces the type and data of an entry. The account needs write access
+// to the entry, see EntryAccess. The previous version is kept as a revision of the owner.
+func (s *Storage) UpdateEntry(ctx context.Context, accountID int64, entryID int64, entryType, entryData string) error {
+	const op = "storage.memory.UpdateEntry"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	access, err := s.checkEntryAccess(accountID, entryID, true, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.saveRevision(access.OwnerID, entryID, models.RevisionActionUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	entry := s.entries[entryID]
+	entry.EntryType = entryType
+	entry.EntryData = entryData
+	entry.UpdatedAt = now()
+	s.entries[entryID] = entry
+
+	s.pruneRevisions(access.OwnerID, entryID)
+	return nil
+}
+
+// DeleteEntry removes an entry along with its tags, search tokens and shares. Personal
+// entries can only be deleted by their owner, entries in a collection by editors and
+// above. The deleted version is kept as a revision, so it can be restored.
+func (s *Storage) DeleteEntry(ctx context.Context, accountID int64, entryID int64) error {
+	const op = "storage.memory.DeleteEntry"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	access, err := s.checkEntryAccess(accountID, entryID, false, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	ownerID := access.OwnerID
+
+	if err := s.saveRevision(ownerID, entryID, models.RevisionActionDelete); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	delete(s.entries, entryID)
+	delete(s.entryTags, entryID)
+	delete(s.tokens, tokenKey{accountID: ownerID, entryID: entryID})
+	for id, share := range s.shares {
+		if share.EntryID == entryID && share.OwnerID == ownerID {
+			delete(s.shares, id)
+		}
+	}
+
+	s.pruneRevisions(ownerID, entryID)
+	return nil
+}
+
+// ListEntries retrieves all entries of an account matching filter in ID order. With
+// filter.CollectionID set it lists the entries of that collection instead, if the
+// account is a member of its organization.
+func (s *Storage) ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.filterEntries(accountID, filter)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// ListEntriesPage retrieves one page of the entries of an account matching the query filter,
+// sorted by created_at or updated_at. Sorting by name needs the decrypted entry data and
+// is not supported here.
+func (s *Storage) ListEntriesPage(ctx context.Context, accountID int64, query models.EntryQuery) (*models.EntryPage, error) {
+	const op = "storage.memory.ListEntriesPage"
+
+	sortKey := func(entry models.Entry) time.Time { return entry.CreatedAt }
+	switch query.Sort {
+	case "", models.EntrySortCreatedAt:
+	case models.EntrySortUpdatedAt:
+		sortKey = func(entry models.Entry) time.Time { return entry.UpdatedAt }
+	default:
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidSort)
+	}
+
+	// before reports whether a sorts before b in the requested order.
+	before := func(aKey time.Time, aID int64, bKey time.Time, bID int64) bool {
+		if !aKey.Equal(bKey) {
+			return aKey.Before(bKey) != query.Descending
+		}
+		if query.Descending {
+			return aID > bID
+		}
+		return aID < bID
+	}
+
+	var cursor *storage.Cursor
+	var cursorKey time.Time
+	if query.Cursor != "" {
+		c, err := storage.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		cursorKey, err = time.Parse(time.RFC3339Nano, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidCursor)
+		}
+		cursor = &c
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []models.Entry
+	for _, entry := range s.filterEntries(accountID, query.Filter) {
+		if cursor == nil || before(cursorKey, cursor.ID, sortKey(entry), entry.ID) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return before(sortKey(entries[i]), entries[i].ID, sortKey(entries[j]), entries[j].ID)
+	})
+
+	page := &models.EntryPage{Entries: entries}
+	if limit := query.Limit; limit > 0 && len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		page.NextCursor = storage.Cursor{Key: sortKey(last).UTC().Format(time.RFC3339Nano), ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// filterEntries returns the entries of an account matching filter, with their tags, in
+// no particular order. Folders and tags belong to accounts, so within a collection they
+// match nothing.
+func (s *Storage) filterEntries(accountID int64, filter models.EntryFilter) []models.Entry {
+	var entries []models.Entry
+	for _, entry := range s.entries {
+		if filter.CollectionID != nil {
+			if !sameID(entry.CollectionID, filter.CollectionID) {
+				continue
+			}
+			if _, err := s.collectionRole(accountID, *filter.CollectionID); err != nil {
+				continue
+			}
+		} else if entry.AccountId != accountID || entry.CollectionID != nil {
+			continue
+		}
+
+		if filter.FolderID != nil {
+			if *filter.FolderID == 0 {
+				if entry.FolderID != nil {
+					continue
+				}
+			} else if !sameID(entry.FolderID, filter.FolderID) {
+				continue
+			}
+		}
+		if !s.hasTags(entry.ID, filter.TagIDs) {
+			continue
+		}
+		if filter.EntryType != "" && entry.EntryType != filter.EntryType {
+			continue
+		}
+		if !inRange(entry.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) ||
+			!inRange(entry.UpdatedAt, filter.UpdatedAfter, filter.UpdatedBefore) {
+			continue
+		}
+
+		entry = cloneEntry(entry)
+		entry.TagIDs = s.entryTagIDs(accountID, entry.ID)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (s *Storage) hasTags(entryID int64, tagIDs []int64) bool {
+	for _, tagID := range tagIDs {
+		if _, ok := s.entryTags[entryID][tagID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange reports whether t is within [after, before), nil bounds are open.
+func inRange(t time.Time, after, before *time.Time) bool {
+	if after != nil && t.Before(*after) {
+		return false
+	}
+	if before != nil && !t.Before(*before) {
+		return false
+	}
+	return true
+}
+
+// entryTagIDs returns the IDs of the tags of the account on an entry in ascending order.
+func (s *Storage) entryTagIDs(accountID int64, entryID int64) []int64 {
+	var tagIDs []int64
+	for tagID := range s.entryTags[entryID] {
+		if s.tags[tagID].AccountId == accountID {
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	sort.Slice(tagIDs, func(i, j int) bool { return tagIDs[i] < tagIDs[j] })
+	return tagIDs
+}
+
+// SaveKeyPart stores the key part of an account. An account has at most one key part,
+// its first key version; later versions are added by StartKeyRotation. While shares
+// of a deleted key part remain, only RestoreKeyPart brings it back.
+func (s *Storage) SaveKeyPart(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (int64, error) {
+	const op = "storage.memory.SaveKeyPart"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.keyParts[accountID]) > 0 || len(s.keyShares[accountID]) > 0 {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+	}
+
+	now := now()
+	key := models.EncryptionKey{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, AccountId: accountID, KeyPart: keyPart, KeyVersion: 1,
+		MasterKeyVersion: masterKeyVersion}
+	s.keyParts[accountID] = []models.EncryptionKey{key}
+	return key.ID, nil
+}
+
+// RetrieveKeyPart retrieves the key part of the current key version of an account
+func (s *Storage) RetrieveKeyPart(ctx context.Context, accountID int64) (string, error) {
+	const op = "storage.memory.RetrieveKeyPart"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := s.keyParts[accountID]
+	if len(keys) == 0 {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+	return keys[len(keys)-1].KeyPart, nil
+}
+
+// KeyParts retrieves every key version of an account, oldest first
+func (s *Storage) KeyParts(ctx context.Context, accountID int64) ([]models.EncryptionKey, error) {
+	const op = "storage.memory.KeyParts"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.keyParts[accountID]) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+	return append([]models.EncryptionKey(nil), s.keyParts[accountID]...), nil
+}
+
+// RestoreKeyPart stores a key part recovered from its Shamir shares under the key version
+// and master key version it was split from. Only an account without key parts can get one
+// restored.
+func (s *Storage) RestoreKeyPart(ctx context.Context, accountID int64, keyVersion, masterKeyVersion int, keyPart string) error {
+	const op = "storage.memory.RestoreKeyPart"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.keyParts[accountID]) > 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+	}
+
+	now := now()
+	key := models.EncryptionKey{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, AccountId: accountID, KeyPart: keyPart, KeyVersion: keyVersion,
+		MasterKeyVersion: masterKeyVersion}
+	s.keyParts[accountID] = []models.EncryptionKey{key}
+	return nil
+}
+
+// DeleteKeyPart removes the key part of an account. Commitments to the Shamir shares of
+// the key part are kept, so it can still be recovered. The key part of an account that
+// still has entries or revisions is only deleted when it can be: the account has a
+// single key version and shares of it. Entries in collections are encrypted with the
+// organization key and do not count.
+func (s *Storage) DeleteKeyPart(ctx context.Context, accountID int64) error {
+	const op = "storage.memory.DeleteKeyPart"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.keyParts[accountID]
+	if len(keys) == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+
+	shares := s.keyShares[accountID]
+	recoverable := len(keys) == 1 && len(shares) > 0 && shares[0].KeyVersion == keys[0].KeyVersion
+	if !recoverable && s.hasPersonalData(accountID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+	}
+
+	delete(s.keyParts, accountID)
+	return nil
+}
+
+// hasPersonalData reports whether an account has personal entries or revisions.
+func (s *Storage) hasPersonalData(accountID int64) bool {
+	for _, entry := range s.entries {
+		if entry.AccountId == accountID && entry.CollectionID == nil {
+			return true
+		}
+	}
+	for _, revision := range s.revisions {
+		if revision.AccountId == accountID && revision.CollectionID == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveKeyShares replaces the commitments to the Shamir shares of an account key part.
+func (s *Storage) SaveKeyShares(ctx context.Context, accountID int64, shares []models.EncryptionKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := now()
+	keys := make([]models.EncryptionKey, 0, len(shares))
+	for _, share := range shares {
+		share.ID = s.nextID()
+		share.CreatedAt = now
+		share.UpdatedAt = now
+		share.AccountId = accountID
+		keys = append(keys, share)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ShareIndex < keys[j].ShareIndex })
+	s.keyShares[accountID] = keys
+	return nil
+}
+
+// KeyShares retrieves the commitments to the Shamir shares of an account key part ordered
+// by share index
+func (s *Storage) KeyShares(ctx context.Context, accountID int64) ([]models.EncryptionKey, error) {
+	const op = "storage.memory.KeyShares"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.keyShares[accountID]) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKeySharesNotFound)
+	}
+	return append([]models.EncryptionKey(nil), s.keyShares[accountID]...), nil
+}
+
+// ListRevisions retrieves the revisions of an entry, newest first. Only revisions made
+// while the entry was in its current vault are listed. Revision data is not loaded,
+// use GetRevision for that.
+func (s *Storage) ListRevisions(ctx context.Context, accountID int64, entryID int64) ([]models.EntryRevision, error) {
+	const op = "storage.memory.ListRevisions"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ownerID, collectionID, err := s.revisionOwner(accountID, entryID, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var revisions []models.EntryRevision
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		revision := s.revisions[i]
+		if revision.AccountId == ownerID && revision.EntryID == entryID && sameID(revision.CollectionID, collectionID) {
+			revision = cloneRevision(revision)
+			revision.EntryData = ""
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves a single revision of an entry
+func (s *Storage) GetRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) (*models.EntryRevision, error) {
+	const op = "storage.memory.GetRevision"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ownerID, collectionID, err := s.revisionOwner(accountID, entryID, false)
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, err := s.getRevision(ownerID, collectionID, entryID, revisionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &revision, nil
+}
+
+// RestoreRevision sets an entry back to one of its revisions. A deleted entry is
+// recreated with its original ID, in the collection it was deleted from. The version
+// being replaced is kept as a new revision.
+func (s *Storage) RestoreRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) error {
+	const op = "storage.memory.RestoreRevision"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ownerID, collectionID, err := s.revisionOwner(accountID, entryID, true)
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		return fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, err := s.getRevision(ownerID, collectionID, entryID, revisionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := now()
+	err = s.saveRevision(ownerID, entryID, models.RevisionActionRestore)
+	switch {
+	case err == nil:
+		entry := s.entries[entryID]
+		entry.EntryType = revision.EntryType
+		entry.EntryData = revision.EntryData
+		entry.UpdatedAt = now
+		s.entries[entryID] = entry
+	case errors.Is(err, storage.ErrEntryNotFound):
+		s.entries[entryID] = models.Entry{
+			ID:           entryID,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			AccountId:    ownerID,
+			EntryType:    revision.EntryType,
+			EntryData:    revision.EntryData,
+			CollectionID: cloneID(collectionID),
+		}
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.pruneRevisions(ownerID, entryID)
+	return nil
+}
+
+func (s *Storage) getRevision(accountID int64, collectionID *int64, entryID int64, revisionID int64) (models.EntryRevision, error) {
+	for _, revision := range s.revisions {
+		if revision.ID == revisionID && revision.AccountId == accountID && revision.EntryID == entryID &&
+			sameID(revision.CollectionID, collectionID) {
+			return cloneRevision(revision), nil
+		}
+	}
+	return models.EntryRevision{}, storage.ErrRevisionNotFound
+}
+
+// saveRevision keeps the current version of an entry as a revision. It returns
+// storage.ErrEntryNotFound when the account has no such entry.
+func (s *Storage) saveRevision(accountID int64, entryID int64, action string) error {
+	entry, ok := s.entries[entryID]
+	if !ok || entry.AccountId != accountID {
+		return storage.ErrEntryNotFound
+	}
+
+	s.revisions = append(s.revisions, models.EntryRevision{
+		ID:           s.nextID(),
+		CreatedAt:    now(),
+		EntryID:      entryID,
+		AccountId:    accountID,
+		Action:       action,
+		EntryType:    entry.EntryType,
+		EntryData:    entry.EntryData,
+		CollectionID: cloneID(entry.CollectionID),
+	})
+	return nil
+}
+
+// pruneRevisions applies the revision retention: only the newest MaxCount revisions
+// of the entry are kept, and revisions older than MaxAge are dropped for all entries.
+func (s *Storage) pruneRevisions(accountID int64, entryID int64) {
+	var cutoff time.Time
+	if s.retention.MaxAge > 0 {
+		cutoff = now().Add(-s.retention.MaxAge)
+	}
+
+	kept := 0
+	revisions := make([]models.EntryRevision, 0, len(s.revisions))
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		revision := s.revisions[i]
+		if revision.AccountId == accountID && revision.EntryID == entryID {
+			kept++
+			if s.retention.MaxCount > 0 && kept > s.retention.MaxCount {
+				continue
+			}
+		}
+		if revision.CreatedAt.Before(cutoff) {
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+
+	// Revisions were collected newest first.
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	s.revisions = revisions
+}
+
+func (s *Storage) insertEntry(accountID int64, collectionID *int64, entryType, entryData string) int64 {
+	now := now()
+	entry := models.Entry{
+		ID:           s.nextID(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		AccountId:    accountID,
+		EntryType:    entryType,
+		EntryData:    entryData,
+		CollectionID: cloneID(collectionID),
+	}
+	s.entries[entry.ID] = entry
+	return entry.ID
+}
+
+func (s *Storage) nextID() int64 {
+	s.seq++
+	return s.seq
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// sameID reports whether two optional IDs are both nil or equal.
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// cloneID copies an optional ID, so callers never share pointers with the stored data.
+func cloneID(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
+
+func cloneEntry(entry models.Entry) models.Entry {
+	entry.FolderID = cloneID(entry.FolderID)
+	entry.CollectionID = cloneID(entry.CollectionID)
+	entry.TagIDs = nil
+	return entry
+}
+
+func cloneRevision(revision models.EntryRevision) models.EntryRevision {
+	revision.CollectionID = cloneID(revision.CollectionID)
+	return revision
+}
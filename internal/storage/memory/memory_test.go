@@ -0,0 +1,63 @@
+package memory_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+	"passvault/internal/storage"
+	"passvault/internal/storage/memory"
+	"passvault/internal/storage/storagetest"
+	"sync"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, retention storage.RevisionRetention) storage.Backend {
+		return memory.New(retention)
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New(storage.RevisionRetention{MaxCount: 3})
+
+	const workers, rounds = 8, 50
+	chainKey := []byte("0123456789abcdef0123456789abcdef")
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(accountID int64) {
+			defer wg.Done()
+
+			// require must not be used outside the test goroutine.
+			for i := 0; i < rounds; i++ {
+				id, err := s.SaveEntry(ctx, accountID, models.EntryTypeLogin, "data")
+				assert.NoError(t, err)
+				assert.NoError(t, s.UpdateEntry(ctx, accountID, id, models.EntryTypeLogin, "updated"))
+				_, err = s.ListEntries(ctx, accountID, models.EntryFilter{})
+				assert.NoError(t, err)
+				_, err = s.AppendAuditEvent(ctx, models.AuditEvent{AccountID: accountID, Action: models.AuditEntryCreate, Status: 201}, chainKey)
+				assert.NoError(t, err)
+			}
+		}(int64(w + 1))
+	}
+	wg.Wait()
+
+	for accountID := int64(1); accountID <= workers; accountID++ {
+		entries, err := s.ListEntries(ctx, accountID, models.EntryFilter{})
+		require.NoError(t, err)
+		require.Len(t, entries, rounds)
+	}
+
+	events, err := s.AuditEvents(ctx, 0, -1)
+	require.NoError(t, err)
+	require.Len(t, events, workers*rounds)
+
+	verifier := auditchain.NewVerifier(chainKey)
+	for _, event := range events {
+		require.NoError(t, verifier.Next(event))
+	}
+}
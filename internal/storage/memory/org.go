@@ -0,0 +1,357 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+)
+
+// organization is a stored organization with the key parts of its collections, oldest first.
+type organization struct {
+	models.Organization
+	keyParts []models.OrganizationKey
+}
+
+// CreateOrganization stores a new organization with the account as its owner.
+// keyPart derives the data key of the organization collections with the master key
+// of masterKeyVersion and is stored as key version 1.
+func (s *Storage) CreateOrganization(ctx context.Context, ownerID int64, name, keyPart string, masterKeyVersion int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := now()
+	org := organization{
+		Organization: models.Organization{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, Name: name},
+	}
+	org.keyParts = []models.OrganizationKey{{
+		ID:               s.nextID(),
+		CreatedAt:        now,
+		OrganizationID:   org.ID,
+		KeyPart:          keyPart,
+		KeyVersion:       1,
+		MasterKeyVersion: masterKeyVersion,
+	}}
+	s.orgs[org.ID] = org
+	s.members[org.ID] = map[int64]models.OrganizationMember{
+		ownerID: {OrganizationID: org.ID, AccountID: ownerID, Role: models.OrgRoleOwner, CreatedAt: now},
+	}
+	return org.ID, nil
+}
+
+// ListOrganizations retrieves the organizations an account is a member of, with its role in each.
+func (s *Storage) ListOrganizations(ctx context.Context, accountID int64) ([]models.Organization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var orgs []models.Organization
+	for orgID, members := range s.members {
+		member, ok := members[accountID]
+		if !ok {
+			continue
+		}
+		org := s.orgs[orgID].Organization
+		org.Role = member.Role
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		if orgs[i].Name != orgs[j].Name {
+			return orgs[i].Name < orgs[j].Name
+		}
+		return orgs[i].ID < orgs[j].ID
+	})
+	return orgs, nil
+}
+
+// DeleteOrganization removes an organization and its members. Collections must be
+// deleted first, so no entry loses the key it is encrypted with.
+func (s *Storage) DeleteOrganization(ctx context.Context, orgID int64) error {
+	const op = "storage.memory.DeleteOrganization"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, collection := range s.collections {
+		if collection.OrganizationID == orgID {
+			return fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotEmpty)
+		}
+	}
+
+	if _, ok := s.orgs[orgID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+	delete(s.orgs, orgID)
+	delete(s.members, orgID)
+	return nil
+}
+
+// OrganizationKeyParts retrieves every key version of an organization, oldest first
+func (s *Storage) OrganizationKeyParts(ctx context.Context, orgID int64) ([]models.OrganizationKey, error) {
+	const op = "storage.memory.OrganizationKeyParts"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	org, ok := s.orgs[orgID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+	return append([]models.OrganizationKey(nil), org.keyParts...), nil
+}
+
+// MemberRole retrieves the role of an account in an organization. It returns
+// storage.ErrMemberNotFound when the account is not a member.
+func (s *Storage) MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error) {
+	const op = "storage.memory.MemberRole"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.members[orgID][accountID]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrMemberNotFound)
+	}
+	return member.Role, nil
+}
+
+// ListMembers retrieves the members of an organization ordered by account ID
+func (s *Storage) ListMembers(ctx context.Context, orgID int64) ([]models.OrganizationMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var members []models.OrganizationMember
+	for _, member := range s.members[orgID] {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].AccountID < members[j].AccountID })
+	return members, nil
+}
+
+// SetMemberRole adds an account to an organization or changes its role. The last
+// owner of an organization cannot be demoted.
+func (s *Storage) SetMemberRole(ctx context.Context, orgID int64, accountID int64, role string) error {
+	const op = "storage.memory.SetMemberRole"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if role != models.OrgRoleOwner {
+		if err := s.checkNotLastOwner(orgID, accountID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	member, ok := s.members[orgID][accountID]
+	if !ok {
+		member = models.OrganizationMember{OrganizationID: orgID, AccountID: accountID, CreatedAt: now()}
+	}
+	member.Role = role
+
+	if s.members[orgID] == nil {
+		s.members[orgID] = make(map[int64]models.OrganizationMember)
+	}
+	s.members[orgID][accountID] = member
+	return nil
+}
+
+// RemoveMember removes an account from an organization. The last owner cannot be removed.
+func (s *Storage) RemoveMember(ctx context.Context, orgID int64, accountID int64) error {
+	const op = "storage.memory.RemoveMember"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkNotLastOwner(orgID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, ok := s.members[orgID][accountID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrMemberNotFound)
+	}
+	delete(s.members[orgID], accountID)
+	return nil
+}
+
+// CreateCollection stores a new collection of an organization. Collection names are
+// unique per organization.
+func (s *Storage) CreateCollection(ctx context.Context, orgID int64, name string) (int64, error) {
+	const op = "storage.memory.CreateCollection"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, collection := range s.collections {
+		if collection.OrganizationID == orgID && collection.Name == name {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrCollectionExists)
+		}
+	}
+
+	now := now()
+	collection := models.Collection{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, OrganizationID: orgID, Name: name}
+	s.collections[collection.ID] = collection
+	return collection.ID, nil
+}
+
+// ListCollections retrieves the collections of an organization ordered by name
+func (s *Storage) ListCollections(ctx context.Context, orgID int64) ([]models.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var collections []models.Collection
+	for _, collection := range s.collections {
+		if collection.OrganizationID == orgID {
+			collections = append(collections, collection)
+		}
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i].Name != collections[j].Name {
+			return collections[i].Name < collections[j].Name
+		}
+		return collections[i].ID < collections[j].ID
+	})
+	return collections, nil
+}
+
+// DeleteCollection removes an empty collection of an organization along with the
+// revisions of entries deleted from it.
+func (s *Storage) DeleteCollection(ctx context.Context, orgID int64, collectionID int64) error {
+	const op = "storage.memory.DeleteCollection"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if sameID(entry.CollectionID, &collectionID) {
+			return fmt.Errorf("%s: %w", op, storage.ErrCollectionNotEmpty)
+		}
+	}
+
+	if collection, ok := s.collections[collectionID]; !ok || collection.OrganizationID != orgID {
+		return fmt.Errorf("%s: %w", op, storage.ErrCollectionNotFound)
+	}
+	delete(s.collections, collectionID)
+
+	revisions := s.revisions[:0]
+	for _, revision := range s.revisions {
+		if !sameID(revision.CollectionID, &collectionID) {
+			revisions = append(revisions, revision)
+		}
+	}
+	s.revisions = revisions
+	return nil
+}
+
+// CollectionOrganization returns the organization a collection belongs to
+func (s *Storage) CollectionOrganization(ctx context.Context, collectionID int64) (int64, error) {
+	const op = "storage.memory.CollectionOrganization"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collection, ok := s.collections[collectionID]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrCollectionNotFound)
+	}
+	return collection.OrganizationID, nil
+}
+
+// SaveCollectionEntry stores a new entry in a collection. The account needs the editor
+// role or above in the organization of the collection and is recorded as the entry creator.
+func (s *Storage) SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error) {
+	const op = "storage.memory.SaveCollectionEntry"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkCollectionEditor(accountID, collectionID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.insertEntry(accountID, &collectionID, entryType, entryData), nil
+}
+
+// SetEntryCollection moves an entry into a collection, or out of its collection into the
+// personal vault of the account when collectionID is nil. entryData must be the entry data
+// encrypted for the target vault. Personal entries can only be moved by their owner and
+// collection entries by editors and above; moving into a collection needs the editor role
+// there too. Folders, tags, search tokens and shares are personal and are dropped.
+func (s *Storage) SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64, entryData string) error {
+	const op = "storage.memory.SetEntryCollection"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	access, err := s.checkEntryAccess(accountID, entryID, true, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if access.CollectionID == nil && access.OwnerID != accountID {
+		return fmt.Errorf("%s: %w", op, storage.ErrAccessDenied)
+	}
+
+	if collectionID != nil {
+		if err := s.checkCollectionEditor(accountID, *collectionID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.saveRevision(access.OwnerID, entryID, models.RevisionActionUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Entries moved out of a collection become personal entries of the account moving them.
+	entry := s.entries[entryID]
+	if collectionID == nil {
+		entry.AccountId = accountID
+	}
+	entry.CollectionID = cloneID(collectionID)
+	entry.FolderID = nil
+	entry.EntryData = entryData
+	entry.UpdatedAt = now()
+	s.entries[entryID] = entry
+
+	delete(s.entryTags, entryID)
+	for key := range s.tokens {
+		if key.entryID == entryID {
+			delete(s.tokens, key)
+		}
+	}
+	for id, share := range s.shares {
+		if share.EntryID == entryID {
+			delete(s.shares, id)
+		}
+	}
+
+	s.pruneRevisions(access.OwnerID, entryID)
+	return nil
+}
+
+// checkNotLastOwner returns storage.ErrLastOwner when the account is the only owner of the organization.
+func (s *Storage) checkNotLastOwner(orgID int64, accountID int64) error {
+	member, ok := s.members[orgID][accountID]
+	if !ok || member.Role != models.OrgRoleOwner {
+		return nil
+	}
+
+	for _, other := range s.members[orgID] {
+		if other.AccountID != accountID && other.Role == models.OrgRoleOwner {
+			return nil
+		}
+	}
+	return storage.ErrLastOwner
+}
+
+// checkCollectionEditor returns storage.ErrCollectionNotFound unless the account is a member
+// of the organization of the collection, and storage.ErrAccessDenied unless it is an editor
+// or above there.
+func (s *Storage) checkCollectionEditor(accountID int64, collectionID int64) error {
+	role, err := s.collectionRole(accountID, collectionID)
+	if err != nil {
+		return err
+	}
+	if !models.OrgRoleAtLeast(role, models.OrgRoleEditor) {
+		return storage.ErrAccessDenied
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sort"
+)
+
+// tokenKey identifies the search tokens of an entry stored for an account.
+type tokenKey struct {
+	accountID int64
+	entryID   int64
+}
+
+// SetSearchTokens replaces the blind index tokens of an entry of an account
+func (s *Storage) SetSearchTokens(ctx context.Context, accountID int64, entryID int64, tokens []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := tokenKey{accountID: accountID, entryID: entryID}
+	if len(tokens) == 0 {
+		delete(s.tokens, key)
+		return nil
+	}
+
+	set := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		set[token] = struct{}{}
+	}
+	s.tokens[key] = set
+	return nil
+}
+
+// FindEntriesByTokens returns the IDs of the personal entries of an account having all
+// blind index tokens, in ID order. Entries in collections are not indexed.
+func (s *Storage) FindEntriesByTokens(ctx context.Context, accountID int64, tokens []string) ([]int64, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []int64
+	for _, entry := range s.entries {
+		if entry.AccountId != accountID || entry.CollectionID != nil {
+			continue
+		}
+
+		indexed := s.tokens[tokenKey{accountID: accountID, entryID: entry.ID}]
+		matches := true
+		for _, token := range tokens {
+			if _, ok := indexed[token]; !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			ids = append(ids, entry.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+	"time"
+)
+
+// ShareEntry grants another account access to an entry of the owner. Sharing an entry
+// again with the same account replaces the permission and expiry of the previous share.
+func (s *Storage) ShareEntry(ctx context.Context, ownerID int64, entryID int64, accountID int64, permission string, expiresAt *time.Time) (int64, error) {
+	const op = "storage.memory.ShareEntry"
+
+	if ownerID == accountID {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrInvalidShare)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOwner(ownerID, entryID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	for id, share := range s.shares {
+		if share.EntryID == entryID && share.AccountID == accountID {
+			share.Permission = permission
+			share.ExpiresAt = expiresAt
+			s.shares[id] = share
+			return id, nil
+		}
+	}
+
+	share := models.EntryShare{
+		ID:         s.nextID(),
+		CreatedAt:  now(),
+		EntryID:    entryID,
+		OwnerID:    ownerID,
+		AccountID:  accountID,
+		Permission: permission,
+		ExpiresAt:  expiresAt,
+	}
+	s.shares[share.ID] = share
+	return share.ID, nil
+}
+
+// ListShares retrieves the shares of an entry of the owner, expired ones included
+func (s *Storage) ListShares(ctx context.Context, ownerID int64, entryID int64) ([]models.EntryShare, error) {
+	const op = "storage.memory.ListShares"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkOwner(ownerID, entryID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.filterShares(func(share models.EntryShare) bool {
+		return share.EntryID == entryID && share.OwnerID == ownerID
+	}), nil
+}
+
+// ListReceivedShares retrieves the shares granted to an account that have not expired
+func (s *Storage) ListReceivedShares(ctx context.Context, accountID int64) ([]models.EntryShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := now()
+	return s.filterShares(func(share models.EntryShare) bool {
+		return share.AccountID == accountID && (share.ExpiresAt == nil || share.ExpiresAt.After(now))
+	}), nil
+}
+
+// RevokeShare removes the access of an account to an entry of the owner
+func (s *Storage) RevokeShare(ctx context.Context, ownerID int64, entryID int64, accountID int64) error {
+	const op = "storage.memory.RevokeShare"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, share := range s.shares {
+		if share.EntryID == entryID && share.OwnerID == ownerID && share.AccountID == accountID {
+			delete(s.shares, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrShareNotFound)
+}
+
+// filterShares returns the shares matching keep in ID order.
+func (s *Storage) filterShares(keep func(models.EntryShare) bool) []models.EntryShare {
+	var shares []models.EntryShare
+	for _, share := range s.shares {
+		if keep(share) {
+			if share.ExpiresAt != nil {
+				expiresAt := *share.ExpiresAt
+				share.ExpiresAt = &expiresAt
+			}
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares
+}
+
+// activeShare returns the unexpired share of an entry of the owner with an account.
+func (s *Storage) activeShare(entryID int64, ownerID int64, accountID int64) (models.EntryShare, bool) {
+	now := now()
+	for _, share := range s.shares {
+		if share.EntryID == entryID && share.OwnerID == ownerID && share.AccountID == accountID &&
+			(share.ExpiresAt == nil || share.ExpiresAt.After(now)) {
+			return share, true
+		}
+	}
+	return models.EntryShare{}, false
+}
+
+// checkOwner returns storage.ErrEntryNotFound unless the account owns the entry as a
+// personal entry. Entries in collections are shared through the organization instead.
+func (s *Storage) checkOwner(ownerID int64, entryID int64) error {
+	if entry, ok := s.entries[entryID]; !ok || entry.AccountId != ownerID || entry.CollectionID != nil {
+		return storage.ErrEntryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"sort"
+)
+
+// CreateTag stores a new tag of an account. Tag names are unique per account.
+func (s *Storage) CreateTag(ctx context.Context, accountID int64, name string) (int64, error) {
+	const op = "storage.memory.CreateTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tagNameTaken(accountID, name, 0) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+
+	now := now()
+	tag := models.Tag{ID: s.nextID(), CreatedAt: now, UpdatedAt: now, AccountId: accountID, Name: name}
+	s.tags[tag.ID] = tag
+	return tag.ID, nil
+}
+
+// ListTags retrieves all tags of an account ordered by name
+func (s *Storage) ListTags(ctx context.Context, accountID int64) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tags []models.Tag
+	for _, tag := range s.tags {
+		if tag.AccountId == accountID {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// RenameTag changes the name of a tag of an account
+func (s *Storage) RenameTag(ctx context.Context, accountID int64, tagID int64, name string) error {
+	const op = "storage.memory.RenameTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkTag(accountID, tagID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if s.tagNameTaken(accountID, name, tagID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+	}
+
+	tag := s.tags[tagID]
+	tag.Name = name
+	tag.UpdatedAt = now()
+	s.tags[tagID] = tag
+	return nil
+}
+
+// DeleteTag removes a tag of an account and takes it off all entries carrying it
+func (s *Storage) DeleteTag(ctx context.Context, accountID int64, tagID int64) error {
+	const op = "storage.memory.DeleteTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkTag(accountID, tagID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	delete(s.tags, tagID)
+	for _, tagIDs := range s.entryTags {
+		delete(tagIDs, tagID)
+	}
+	return nil
+}
+
+// SetEntryTags replaces the tags of a personal entry of an account. All tags must belong to the account.
+func (s *Storage) SetEntryTags(ctx context.Context, accountID int64, entryID int64, tagIDs []int64) error {
+	const op = "storage.memory.SetEntryTags"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[entryID]
+	if !ok || entry.AccountId != accountID || entry.CollectionID != nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+
+	set := make(map[int64]struct{}, len(tagIDs))
+	for _, tagID := range tagIDs {
+		if err := s.checkTag(accountID, tagID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		set[tagID] = struct{}{}
+	}
+
+	s.entryTags[entryID] = set
+	return nil
+}
+
+// checkTag returns storage.ErrTagNotFound unless the account has the tag.
+func (s *Storage) checkTag(accountID int64, tagID int64) error {
+	if tag, ok := s.tags[tagID]; !ok || tag.AccountId != accountID {
+		return storage.ErrTagNotFound
+	}
+	return nil
+}
+
+func (s *Storage) tagNameTaken(accountID int64, name string, exceptID int64) bool {
+	for _, tag := range s.tags {
+		if tag.ID != exceptID && tag.AccountId == accountID && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,540 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+const keyRotationColumns = `id, account_id, organization_id, from_version, to_version, status, entry_cursor, revision_cursor,
+	entries, revisions, shares_removed, error, created_at, updated_at, finished_at`
+
+// StartKeyRotation adds keyPart, derived with the master key of masterKeyVersion, as the
+// next key version of an account and records a running rotation to it in the key_rotation
+// table. The Shamir shares of the account split the previous key part and cannot recover
+// the new one, so they are removed and counted in SharesRemoved. An account rotates one
+// key at a time.
+func (s *Storage) StartKeyRotation(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error) {
+	const op = "storage.postgres.StartKeyRotation"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current sql.NullInt64
+	query := `SELECT MAX(key_version) FROM encryption_key WHERE account_id = $1 AND share_index = 0`
+	if err := tx.QueryRowContext(ctx, query, accountID).Scan(&current); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !current.Valid {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM encryption_key WHERE account_id = $1 AND share_index > 0`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sharesRemoved, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rotation := models.KeyRotation{
+		AccountID:     accountID,
+		FromVersion:   int(current.Int64),
+		ToVersion:     int(current.Int64) + 1,
+		SharesRemoved: int(sharesRemoved),
+	}
+	if err := insertKeyRotation(ctx, tx, &rotation); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `INSERT INTO encryption_key (account_id, key_part, key_version, master_key_version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)`
+	if _, err := tx.ExecContext(ctx, query, accountID, keyPart, rotation.ToVersion, masterKeyVersion, rotation.CreatedAt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &rotation, nil
+}
+
+// StartOrganizationKeyRotation adds keyPart, derived with the master key of
+// masterKeyVersion, as the next key version of an organization and records a running
+// rotation of its collection entries to it in the key_rotation table.
+func (s *Storage) StartOrganizationKeyRotation(ctx context.Context, orgID int64, keyPart string, masterKeyVersion int) (*models.KeyRotation, error) {
+	const op = "storage.postgres.StartOrganizationKeyRotation"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current sql.NullInt64
+	if err := tx.QueryRowContext(ctx, `SELECT MAX(key_version) FROM organization_key WHERE organization_id = $1`, orgID).Scan(&current); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !current.Valid {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+
+	rotation := models.KeyRotation{
+		OrganizationID: orgID,
+		FromVersion:    int(current.Int64),
+		ToVersion:      int(current.Int64) + 1,
+	}
+	if err := insertKeyRotation(ctx, tx, &rotation); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `INSERT INTO organization_key (organization_id, key_part, key_version, master_key_version, created_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.ExecContext(ctx, query, orgID, keyPart, rotation.ToVersion, masterKeyVersion, rotation.CreatedAt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &rotation, nil
+}
+
+// insertKeyRotation inserts rotation as running and sets its ID and times.
+func insertKeyRotation(ctx context.Context, tx *sql.Tx, rotation *models.KeyRotation) error {
+	now := now()
+	rotation.Status = models.KeyRotationRunning
+	rotation.CreatedAt = now
+	rotation.UpdatedAt = now
+
+	query := `INSERT INTO key_rotation (account_id, organization_id, from_version, to_version, status, shares_removed, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7) RETURNING id`
+	err := tx.QueryRowContext(ctx, query, rotation.AccountID, rotation.OrganizationID, rotation.FromVersion, rotation.ToVersion,
+		rotation.Status, rotation.SharesRemoved, now).Scan(&rotation.ID)
+	if isUniqueViolation(err) {
+		return storage.ErrKeyRotationRunning
+	}
+	return err
+}
+
+// KeyRotation retrieves the latest key rotation of an account from the key_rotation table
+func (s *Storage) KeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	const op = "storage.postgres.KeyRotation"
+
+	query := `SELECT ` + keyRotationColumns + ` FROM key_rotation WHERE account_id = $1 AND organization_id = 0 ORDER BY id DESC LIMIT 1`
+	rotation, err := scanKeyRotation(s.db.QueryRowContext(ctx, query, accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// OrganizationKeyRotation retrieves the latest key rotation of an organization from the key_rotation table
+func (s *Storage) OrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	const op = "storage.postgres.OrganizationKeyRotation"
+
+	query := `SELECT ` + keyRotationColumns + ` FROM key_rotation WHERE organization_id = $1 AND account_id = 0 ORDER BY id DESC LIMIT 1`
+	rotation, err := scanKeyRotation(s.db.QueryRowContext(ctx, query, orgID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rotation, nil
+}
+
+// ListKeyRotations retrieves the key rotations with a status from the key_rotation table, oldest first
+func (s *Storage) ListKeyRotations(ctx context.Context, status string) ([]models.KeyRotation, error) {
+	const op = "storage.postgres.ListKeyRotations"
+
+	query := `SELECT ` + keyRotationColumns + ` FROM key_rotation WHERE status = $1 ORDER BY id`
+	rows, err := s.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var rotations []models.KeyRotation
+	for rows.Next() {
+		rotation, err := scanKeyRotation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rotations = append(rotations, *rotation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rotations, nil
+}
+
+// UpdateKeyRotation saves the status and progress of a key rotation in the key_rotation table
+func (s *Storage) UpdateKeyRotation(ctx context.Context, rotation models.KeyRotation) error {
+	const op = "storage.postgres.UpdateKeyRotation"
+
+	query := `UPDATE key_rotation SET status = $1, entry_cursor = $2, revision_cursor = $3, entries = $4, revisions = $5,
+		error = $6, finished_at = $7, updated_at = $8 WHERE id = $9 AND account_id = $10 AND organization_id = $11`
+	result, err := s.db.ExecContext(ctx, query, rotation.Status, rotation.EntryCursor, rotation.RevisionCursor,
+		rotation.Entries, rotation.Revisions, rotation.Error, rotation.FinishedAt, now(), rotation.ID, rotation.AccountID, rotation.OrganizationID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrKeyRotationRunning)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrKeyRotationNotFound)
+	}
+	return nil
+}
+
+// RetireKeyParts removes the key versions of an account older than keyVersion from the
+// encryption_key table. It refuses with storage.ErrEncryptionKeyInUse while a personal
+// entry or revision of the account holds data that does not start with sealedPrefix,
+// the prefix of values sealed under keyVersion, since that data needs an older key.
+func (s *Storage) RetireKeyParts(ctx context.Context, accountID int64, keyVersion int, sealedPrefix string) error {
+	const op = "storage.postgres.RetireKeyParts"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	query := `SELECT EXISTS (SELECT 1 FROM entry WHERE account_id = $1 AND collection_id IS NULL AND left(entry_data, length($2::text)) <> $2)
+		OR EXISTS (SELECT 1 FROM entry_revision WHERE account_id = $1 AND collection_id IS NULL AND left(entry_data, length($2::text)) <> $2)`
+	err = tx.QueryRowContext(ctx, query, accountID, sealedPrefix).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inUse {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+	}
+
+	query = `DELETE FROM encryption_key WHERE account_id = $1 AND share_index = 0 AND key_version < $2`
+	if _, err := tx.ExecContext(ctx, query, accountID, keyVersion); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RetireOrganizationKeyParts removes the key versions of an organization older than
+// keyVersion from the organization_key table. Like RetireKeyParts, it refuses while an
+// entry or revision in the collections of the organization is not sealed under keyVersion.
+func (s *Storage) RetireOrganizationKeyParts(ctx context.Context, orgID int64, keyVersion int, sealedPrefix string) error {
+	const op = "storage.postgres.RetireOrganizationKeyParts"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	query := `SELECT EXISTS (SELECT 1 FROM entry e JOIN collection c ON c.id = e.collection_id
+			WHERE c.organization_id = $1 AND left(e.entry_data, length($2::text)) <> $2)
+		OR EXISTS (SELECT 1 FROM entry_revision r JOIN collection c ON c.id = r.collection_id
+			WHERE c.organization_id = $1 AND left(r.entry_data, length($2::text)) <> $2)`
+	err = tx.QueryRowContext(ctx, query, orgID, sealedPrefix).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inUse {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+	}
+
+	query = `DELETE FROM organization_key WHERE organization_id = $1 AND key_version < $2`
+	if _, err := tx.ExecContext(ctx, query, orgID, keyVersion); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// OutdatedAccounts retrieves the IDs of the accounts with key parts, or commitments to
+// the shares of one, derived with a master key version below masterKeyVersion
+func (s *Storage) OutdatedAccounts(ctx context.Context, masterKeyVersion int) ([]int64, error) {
+	const op = "storage.postgres.OutdatedAccounts"
+
+	ids, err := queryIDs(ctx, s.db, `SELECT DISTINCT account_id FROM encryption_key WHERE master_key_version < $1 ORDER BY account_id`, masterKeyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// OutdatedOrganizations retrieves the IDs of the organizations with key parts derived
+// with a master key version below masterKeyVersion
+func (s *Storage) OutdatedOrganizations(ctx context.Context, masterKeyVersion int) ([]int64, error) {
+	const op = "storage.postgres.OutdatedOrganizations"
+
+	ids, err := queryIDs(ctx, s.db, `SELECT DISTINCT organization_id FROM organization_key WHERE master_key_version < $1 ORDER BY organization_id`, masterKeyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// PersonalEntries retrieves up to limit personal entries of an account with IDs above
+// afterID, in ID order, with their data as stored and their tags
+func (s *Storage) PersonalEntries(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.Entry, error) {
+	const op = "storage.postgres.PersonalEntries"
+
+	query := `SELECT id, account_id, entry_type, entry_data, folder_id, collection_id, created_at, updated_at FROM entry
+		WHERE account_id = $1 AND collection_id IS NULL AND id > $2 ORDER BY id LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, query, accountID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []models.Entry
+	for rows.Next() {
+		var entry models.Entry
+		if err := rows.Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.FolderID, &entry.CollectionID, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	tags, err := s.entryTags(ctx, accountID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for i := range entries {
+		entries[i].TagIDs = tags[entries[i].ID]
+	}
+
+	return entries, nil
+}
+
+// RewrapEntry replaces the data of a personal entry with newData and its blind index
+// tokens with searchTokens, unless the entry changed since oldData was read. No revision
+// is kept and the entry keeps its update time.
+func (s *Storage) RewrapEntry(ctx context.Context, accountID int64, entryID int64, oldData, newData string, searchTokens []string) error {
+	const op = "storage.postgres.RewrapEntry"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE entry SET entry_data = $1 WHERE id = $2 AND account_id = $3 AND collection_id IS NULL AND entry_data = $4`
+	result, err := tx.ExecContext(ctx, query, newData, entryID, accountID, oldData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_token WHERE entry_id = $1 AND account_id = $2`, entryID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, token := range searchTokens {
+		query := `INSERT INTO search_token (account_id, token, entry_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, accountID, token, entryID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// PersonalRevisions retrieves up to limit revisions of the personal entries of an account
+// with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) PersonalRevisions(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.EntryRevision, error) {
+	const op = "storage.postgres.PersonalRevisions"
+
+	query := `SELECT id, created_at, entry_id, account_id, action, entry_type, entry_data, collection_id FROM entry_revision
+		WHERE account_id = $1 AND collection_id IS NULL AND id > $2 ORDER BY id LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, query, accountID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []models.EntryRevision
+	for rows.Next() {
+		var revision models.EntryRevision
+		if err := rows.Scan(&revision.ID, &revision.CreatedAt, &revision.EntryID, &revision.AccountId, &revision.Action, &revision.EntryType, &revision.EntryData, &revision.CollectionID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// RewrapRevision replaces the data of a revision with newData, unless it is no longer oldData
+func (s *Storage) RewrapRevision(ctx context.Context, accountID int64, revisionID int64, oldData, newData string) error {
+	const op = "storage.postgres.RewrapRevision"
+
+	query := `UPDATE entry_revision SET entry_data = $1 WHERE id = $2 AND account_id = $3 AND collection_id IS NULL AND entry_data = $4`
+	if _, err := s.db.ExecContext(ctx, query, newData, revisionID, accountID, oldData); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CollectionEntries retrieves up to limit entries in the collections of an organization
+// with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) CollectionEntries(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.Entry, error) {
+	const op = "storage.postgres.CollectionEntries"
+
+	query := `SELECT e.id, e.account_id, e.entry_type, e.entry_data, e.folder_id, e.collection_id, e.created_at, e.updated_at FROM entry e
+		JOIN collection c ON c.id = e.collection_id WHERE c.organization_id = $1 AND e.id > $2 ORDER BY e.id LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, query, orgID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []models.Entry
+	for rows.Next() {
+		var entry models.Entry
+		if err := rows.Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.FolderID, &entry.CollectionID, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// RewrapCollectionEntry replaces the data of an entry in a collection of an organization
+// with newData, unless the entry changed since oldData was read. No revision is kept and
+// the entry keeps its update time.
+func (s *Storage) RewrapCollectionEntry(ctx context.Context, orgID int64, entryID int64, oldData, newData string) error {
+	const op = "storage.postgres.RewrapCollectionEntry"
+
+	query := `UPDATE entry SET entry_data = $1 WHERE id = $2 AND entry_data = $3
+		AND collection_id IN (SELECT id FROM collection WHERE organization_id = $4)`
+	if _, err := s.db.ExecContext(ctx, query, newData, entryID, oldData, orgID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CollectionRevisions retrieves up to limit revisions made in the collections of an
+// organization with IDs above afterID, in ID order and with their data as stored
+func (s *Storage) CollectionRevisions(ctx context.Context, orgID int64, afterID int64, limit int) ([]models.EntryRevision, error) {
+	const op = "storage.postgres.CollectionRevisions"
+
+	query := `SELECT r.id, r.created_at, r.entry_id, r.account_id, r.action, r.entry_type, r.entry_data, r.collection_id FROM entry_revision r
+		JOIN collection c ON c.id = r.collection_id WHERE c.organization_id = $1 AND r.id > $2 ORDER BY r.id LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, query, orgID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []models.EntryRevision
+	for rows.Next() {
+		var revision models.EntryRevision
+		if err := rows.Scan(&revision.ID, &revision.CreatedAt, &revision.EntryID, &revision.AccountId, &revision.Action, &revision.EntryType, &revision.EntryData, &revision.CollectionID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// RewrapCollectionRevision replaces the data of a revision made in a collection of an
+// organization with newData, unless it is no longer oldData
+func (s *Storage) RewrapCollectionRevision(ctx context.Context, orgID int64, revisionID int64, oldData, newData string) error {
+	const op = "storage.postgres.RewrapCollectionRevision"
+
+	query := `UPDATE entry_revision SET entry_data = $1 WHERE id = $2 AND entry_data = $3
+		AND collection_id IN (SELECT id FROM collection WHERE organization_id = $4)`
+	if _, err := s.db.ExecContext(ctx, query, newData, revisionID, oldData, orgID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// queryIDs runs a query selecting a single ID column.
+func queryIDs(ctx context.Context, db *sql.DB, query string, args ...any) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKeyRotation(row scanner) (*models.KeyRotation, error) {
+	var rotation models.KeyRotation
+	err := row.Scan(&rotation.ID, &rotation.AccountID, &rotation.OrganizationID, &rotation.FromVersion, &rotation.ToVersion,
+		&rotation.Status, &rotation.EntryCursor, &rotation.RevisionCursor, &rotation.Entries, &rotation.Revisions,
+		&rotation.SharesRemoved, &rotation.Error, &rotation.CreatedAt, &rotation.UpdatedAt, &rotation.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
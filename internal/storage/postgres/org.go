@@ -0,0 +1,467 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// CreateOrganization inserts a new organization into the organization table with the
+// account as its owner. keyPart derives the data key of the organization collections
+// with the master key of masterKeyVersion and is saved as key version 1.
+func (s *Storage) CreateOrganization(ctx context.Context, ownerID int64, name, keyPart string, masterKeyVersion int) (int64, error) {
+	const op = "storage.postgres.CreateOrganization"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO organization (name, created_at, updated_at) VALUES ($1, $2, $2) RETURNING id`
+	var orgID int64
+	if err := tx.QueryRowContext(ctx, query, name, now()).Scan(&orgID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `INSERT INTO organization_key (organization_id, key_part, key_version, master_key_version, created_at) VALUES ($1, $2, 1, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, orgID, keyPart, masterKeyVersion, now()); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `INSERT INTO organization_member (organization_id, account_id, role, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, orgID, ownerID, models.OrgRoleOwner, now()); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgID, nil
+}
+
+// ListOrganizations retrieves the organizations an account is a member of, with its role in each.
+func (s *Storage) ListOrganizations(ctx context.Context, accountID int64) ([]models.Organization, error) {
+	const op = "storage.postgres.ListOrganizations"
+	query := `SELECT o.id, o.name, m.role, o.created_at, o.updated_at FROM organization o
+		JOIN organization_member m ON m.organization_id = o.id WHERE m.account_id = $1 ORDER BY o.name, o.id`
+	rows, err := s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var orgs []models.Organization
+	for rows.Next() {
+		var org models.Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.Role, &org.CreatedAt, &org.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgs, nil
+}
+
+// DeleteOrganization removes an organization, its key parts and its members. Collections must be
+// deleted first, so no entry loses the key it is encrypted with.
+func (s *Storage) DeleteOrganization(ctx context.Context, orgID int64) error {
+	const op = "storage.postgres.DeleteOrganization"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var collections int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM collection WHERE organization_id = $1`, orgID).Scan(&collections); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if collections > 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotEmpty)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM organization WHERE id = $1`, orgID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_member WHERE organization_id = $1`, orgID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_key WHERE organization_id = $1`, orgID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// OrganizationKeyParts retrieves every key version of an organization from the
+// organization_key table, oldest first
+func (s *Storage) OrganizationKeyParts(ctx context.Context, orgID int64) ([]models.OrganizationKey, error) {
+	const op = "storage.postgres.OrganizationKeyParts"
+
+	query := `SELECT id, organization_id, key_part, key_version, master_key_version, created_at FROM organization_key
+		WHERE organization_id = $1 ORDER BY key_version`
+	rows, err := s.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.OrganizationKey
+	for rows.Next() {
+		var key models.OrganizationKey
+		if err := rows.Scan(&key.ID, &key.OrganizationID, &key.KeyPart, &key.KeyVersion, &key.MasterKeyVersion, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrganizationNotFound)
+	}
+
+	return keys, nil
+}
+
+// MemberRole retrieves the role of an account in an organization. It returns
+// storage.ErrMemberNotFound when the account is not a member.
+func (s *Storage) MemberRole(ctx context.Context, orgID int64, accountID int64) (string, error) {
+	const op = "storage.postgres.MemberRole"
+
+	role, err := memberRole(ctx, s.db, orgID, accountID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return role, nil
+}
+
+// ListMembers retrieves the members of an organization ordered by account ID
+func (s *Storage) ListMembers(ctx context.Context, orgID int64) ([]models.OrganizationMember, error) {
+	const op = "storage.postgres.ListMembers"
+	query := `SELECT organization_id, account_id, role, created_at FROM organization_member WHERE organization_id = $1 ORDER BY account_id`
+	rows, err := s.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.OrganizationMember
+	for rows.Next() {
+		var member models.OrganizationMember
+		if err := rows.Scan(&member.OrganizationID, &member.AccountID, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// SetMemberRole adds an account to an organization or changes its role. The last
+// owner of an organization cannot be demoted.
+func (s *Storage) SetMemberRole(ctx context.Context, orgID int64, accountID int64, role string) error {
+	const op = "storage.postgres.SetMemberRole"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if role != models.OrgRoleOwner {
+		if err := checkNotLastOwner(ctx, tx, orgID, accountID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	query := `INSERT INTO organization_member (organization_id, account_id, role, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, account_id) DO UPDATE SET role = excluded.role`
+	if _, err := tx.ExecContext(ctx, query, orgID, accountID, role, now()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RemoveMember removes an account from an organization. The last owner cannot be removed.
+func (s *Storage) RemoveMember(ctx context.Context, orgID int64, accountID int64) error {
+	const op = "storage.postgres.RemoveMember"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotLastOwner(ctx, tx, orgID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM organization_member WHERE organization_id = $1 AND account_id = $2`, orgID, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMemberNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CreateCollection inserts a new collection of an organization into the collection table
+func (s *Storage) CreateCollection(ctx context.Context, orgID int64, name string) (int64, error) {
+	const op = "storage.postgres.CreateCollection"
+
+	query := `INSERT INTO collection (organization_id, name, created_at, updated_at) VALUES ($1, $2, $3, $3) RETURNING id`
+	var collectionID int64
+	if err := s.db.QueryRowContext(ctx, query, orgID, name, now()).Scan(&collectionID); err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrCollectionExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return collectionID, nil
+}
+
+// ListCollections retrieves the collections of an organization ordered by name
+func (s *Storage) ListCollections(ctx context.Context, orgID int64) ([]models.Collection, error) {
+	const op = "storage.postgres.ListCollections"
+	query := `SELECT id, organization_id, name, created_at, updated_at FROM collection WHERE organization_id = $1 ORDER BY name, id`
+	rows, err := s.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var collections []models.Collection
+	for rows.Next() {
+		var collection models.Collection
+		if err := rows.Scan(&collection.ID, &collection.OrganizationID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		collections = append(collections, collection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return collections, nil
+}
+
+// DeleteCollection removes an empty collection of an organization along with the
+// revisions of entries deleted from it.
+func (s *Storage) DeleteCollection(ctx context.Context, orgID int64, collectionID int64) error {
+	const op = "storage.postgres.DeleteCollection"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var entries int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM entry WHERE collection_id = $1`, collectionID).Scan(&entries); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if entries > 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCollectionNotEmpty)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM collection WHERE id = $1 AND organization_id = $2`, collectionID, orgID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCollectionNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_revision WHERE collection_id = $1`, collectionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CollectionOrganization returns the organization a collection belongs to
+func (s *Storage) CollectionOrganization(ctx context.Context, collectionID int64) (int64, error) {
+	const op = "storage.postgres.CollectionOrganization"
+
+	var orgID int64
+	err := s.db.QueryRowContext(ctx, `SELECT organization_id FROM collection WHERE id = $1`, collectionID).Scan(&orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrCollectionNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgID, nil
+}
+
+// SaveCollectionEntry inserts a new entry into a collection. The account needs the editor
+// role or above in the organization of the collection and is recorded as the entry creator.
+func (s *Storage) SaveCollectionEntry(ctx context.Context, accountID int64, collectionID int64, entryType, entryData string) (int64, error) {
+	const op = "storage.postgres.SaveCollectionEntry"
+
+	if err := checkCollectionEditor(ctx, s.db, accountID, collectionID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `INSERT INTO entry (account_id, collection_id, entry_type, entry_data, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`
+	var entryID int64
+	if err := s.db.QueryRowContext(ctx, query, accountID, collectionID, entryType, entryData, now()).Scan(&entryID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return entryID, nil
+}
+
+// SetEntryCollection moves an entry into a collection, or out of its collection into the
+// personal vault of the account when collectionID is nil. entryData must be the entry data
+// encrypted for the target vault. Personal entries can only be moved by their owner and
+// collection entries by editors and above; moving into a collection needs the editor role
+// there too. Folders, tags, search tokens and shares are personal and are dropped.
+func (s *Storage) SetEntryCollection(ctx context.Context, accountID int64, entryID int64, collectionID *int64, entryData string) error {
+	const op = "storage.postgres.SetEntryCollection"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	access, err := checkEntryAccess(ctx, tx, accountID, entryID, true, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if access.CollectionID == nil && access.OwnerID != accountID {
+		return fmt.Errorf("%s: %w", op, storage.ErrAccessDenied)
+	}
+
+	if collectionID != nil {
+		if err := checkCollectionEditor(ctx, tx, accountID, *collectionID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.saveRevision(ctx, tx, access.OwnerID, entryID, models.RevisionActionUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Entries moved out of a collection become personal entries of the account moving them.
+	ownerID := access.OwnerID
+	if collectionID == nil {
+		ownerID = accountID
+	}
+	query := `UPDATE entry SET account_id = $1, collection_id = $2, folder_id = NULL, entry_data = $3, updated_at = $4 WHERE id = $5`
+	if _, err := tx.ExecContext(ctx, query, ownerID, collectionID, entryData, now(), entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, query := range []string{
+		`DELETE FROM entry_tag WHERE entry_id = $1`,
+		`DELETE FROM search_token WHERE entry_id = $1`,
+		`DELETE FROM entry_share WHERE entry_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, entryID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.pruneRevisions(ctx, tx, access.OwnerID, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func memberRole(ctx context.Context, q querier, orgID int64, accountID int64) (string, error) {
+	var role string
+	query := `SELECT role FROM organization_member WHERE organization_id = $1 AND account_id = $2`
+	if err := q.QueryRowContext(ctx, query, orgID, accountID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrMemberNotFound
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// checkNotLastOwner returns storage.ErrLastOwner when the account is the only owner of the organization.
+func checkNotLastOwner(ctx context.Context, q querier, orgID int64, accountID int64) error {
+	role, err := memberRole(ctx, q, orgID, accountID)
+	if errors.Is(err, storage.ErrMemberNotFound) {
+		return nil
+	}
+	if err != nil || role != models.OrgRoleOwner {
+		return err
+	}
+
+	var others int
+	query := `SELECT COUNT(*) FROM organization_member WHERE organization_id = $1 AND role = $2 AND account_id <> $3`
+	if err := q.QueryRowContext(ctx, query, orgID, models.OrgRoleOwner, accountID).Scan(&others); err != nil {
+		return err
+	}
+	if others == 0 {
+		return storage.ErrLastOwner
+	}
+	return nil
+}
+
+// checkCollectionEditor returns storage.ErrCollectionNotFound unless the account is a member
+// of the organization of the collection, and storage.ErrAccessDenied unless it is an editor
+// or above there.
+func checkCollectionEditor(ctx context.Context, q querier, accountID int64, collectionID int64) error {
+	role, err := collectionRole(ctx, q, accountID, collectionID)
+	if err != nil {
+		return err
+	}
+	if !models.OrgRoleAtLeast(role, models.OrgRoleEditor) {
+		return storage.ErrAccessDenied
+	}
+	return nil
+}
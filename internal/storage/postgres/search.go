@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetSearchTokens replaces the blind index tokens of an entry of an account
+func (s *Storage) SetSearchTokens(ctx context.Context, accountID int64, entryID int64, tokens []string) error {
+	const op = "storage.postgres.SetSearchTokens"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_token WHERE entry_id = $1 AND account_id = $2`, entryID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO search_token (account_id, token, entry_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, token := range tokens {
+		if _, err := stmt.ExecContext(ctx, accountID, token, entryID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// FindEntriesByTokens returns the IDs of the personal entries of an account having all
+// blind index tokens, in ID order. Entries in collections are not indexed.
+func (s *Storage) FindEntriesByTokens(ctx context.Context, accountID int64, tokens []string) ([]int64, error) {
+	const op = "storage.postgres.FindEntriesByTokens"
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var a args
+	account := a.add(accountID)
+	query := `SELECT id FROM entry WHERE account_id = ` + account + ` AND collection_id IS NULL`
+	for _, token := range tokens {
+		query += ` AND id IN (SELECT entry_id FROM search_token WHERE account_id = ` + account + ` AND token = ` + a.add(token) + `)`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, a...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
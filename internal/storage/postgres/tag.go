@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// CreateTag inserts a new tag of an account into the tag table. Tag names are unique per account.
+func (s *Storage) CreateTag(ctx context.Context, accountID int64, name string) (int64, error) {
+	const op = "storage.postgres.CreateTag"
+
+	query := `INSERT INTO tag (account_id, name, created_at, updated_at) VALUES ($1, $2, $3, $3) RETURNING id`
+	var tagID int64
+	if err := s.db.QueryRowContext(ctx, query, accountID, name, now()).Scan(&tagID); err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return tagID, nil
+}
+
+// ListTags retrieves all tags of an account from the tag table
+func (s *Storage) ListTags(ctx context.Context, accountID int64) ([]models.Tag, error) {
+	const op = "storage.postgres.ListTags"
+	query := `SELECT id, account_id, name, created_at, updated_at FROM tag WHERE account_id = $1 ORDER BY name`
+	rows, err := s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.AccountId, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// RenameTag changes the name of a tag of an account
+func (s *Storage) RenameTag(ctx context.Context, accountID int64, tagID int64, name string) error {
+	const op = "storage.postgres.RenameTag"
+
+	query := `UPDATE tag SET name = $1, updated_at = $2 WHERE id = $3 AND account_id = $4`
+	result, err := s.db.ExecContext(ctx, query, name, now(), tagID, accountID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag of an account from the tag table and from all entries carrying it
+func (s *Storage) DeleteTag(ctx context.Context, accountID int64, tagID int64) error {
+	const op = "storage.postgres.DeleteTag"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM tag WHERE id = $1 AND account_id = $2`, tagID, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_tag WHERE tag_id = $1`, tagID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetEntryTags replaces the tags of a personal entry of an account. All tags must belong to the account.
+func (s *Storage) SetEntryTags(ctx context.Context, accountID int64, entryID int64, tagIDs []int64) error {
+	const op = "storage.postgres.SetEntryTags"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var entries int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM entry WHERE id = $1 AND account_id = $2 AND collection_id IS NULL`, entryID, accountID).Scan(&entries); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if entries == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_tag WHERE entry_id = $1`, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, tagID := range tagIDs {
+		query := `INSERT INTO entry_tag (entry_id, tag_id) SELECT $1::bigint, id FROM tag WHERE id = $2 AND account_id = $3 ON CONFLICT DO NOTHING`
+		result, err := tx.ExecContext(ctx, query, entryID, tagID, accountID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if affected == 0 {
+			// Either the tag does not exist or it was passed twice.
+			if err := checkTag(ctx, tx, accountID, tagID); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// checkTag returns storage.ErrTagNotFound unless the account has the tag.
+func checkTag(ctx context.Context, q querier, accountID int64, tagID int64) error {
+	var count int
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM tag WHERE id = $1 AND account_id = $2`, tagID, accountID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return storage.ErrTagNotFound
+	}
+	return nil
+}
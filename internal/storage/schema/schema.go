@@ -0,0 +1,240 @@
+// Package schema applies the versioned schema migrations of the SQL storage backends.
+package schema
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"io/fs"
+	"os"
+)
+
+// DefaultTable is the table the schema version is recorded in.
+const DefaultTable = "migrations"
+
+var (
+	ErrDirty    = errors.New("schema is dirty, a migration failed halfway and must be fixed by hand")
+	ErrTooNew   = errors.New("schema is newer than the migrations this binary knows")
+	ErrNoChange = errors.New("no change")
+)
+
+// Migrator moves a database between schema versions. Its Close closes the
+// database connection it was opened with.
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+// NewSQLite opens the SQLite database at path for migrations from source.
+func NewSQLite(path string, table string, source fs.FS) (*Migrator, error) {
+	const op = "storage.schema.NewSQLite"
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{MigrationsTable: table})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := newMigrator("sqlite3", driver, source)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return m, nil
+}
+
+// NewPostgres connects to the PostgreSQL database at dsn for migrations from source.
+func NewPostgres(dsn string, table string, source fs.FS) (*Migrator, error) {
+	const op = "storage.schema.NewPostgres"
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := pgx.WithInstance(db, &pgx.Config{MigrationsTable: table})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := newMigrator("pgx5", driver, source)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return m, nil
+}
+
+func newMigrator(driverName string, driver database.Driver, fsys fs.FS) (*Migrator, error) {
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := latestVersion(src)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, driverName, driver)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// latestVersion returns the version of the last migration in src, or 0 when it has none.
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Latest returns the version of the last known migration.
+func (m *Migrator) Latest() uint {
+	return m.latest
+}
+
+// Version returns the current schema version, 0 for an empty database, and whether
+// the last migration failed halfway.
+func (m *Migrator) Version() (uint, bool, error) {
+	const op = "storage.schema.Version"
+
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, dirty, nil
+}
+
+// Up applies the pending migrations and returns the schema version the database is on.
+// It returns ErrDirty for a dirty schema and ErrTooNew for a schema written by a newer
+// binary, leaving the database untouched in both cases.
+func (m *Migrator) Up() (uint, error) {
+	const op = "storage.schema.Up"
+
+	version, err := m.Check()
+	if err != nil {
+		return version, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return version, fmt.Errorf("%s: %w", op, err)
+	}
+	return m.latest, nil
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	const op = "storage.schema.Down"
+
+	if steps <= 0 {
+		return fmt.Errorf("%s: steps must be positive", op)
+	}
+	if _, err := m.Check(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := m.m.Steps(-steps); err != nil {
+		return fmt.Errorf("%s: %w", op, noChange(err))
+	}
+	return nil
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	const op = "storage.schema.Goto"
+
+	if version > m.latest {
+		return fmt.Errorf("%s: no migration with version %d", op, version)
+	}
+	if _, err := m.Check(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version == 0 {
+		if err := m.m.Down(); err != nil {
+			return fmt.Errorf("%s: %w", op, noChange(err))
+		}
+		return nil
+	}
+	if err := m.m.Migrate(version); err != nil {
+		return fmt.Errorf("%s: %w", op, noChange(err))
+	}
+	return nil
+}
+
+// Force records version as the current clean schema version without running any
+// migration. It is the way out of a dirty schema once it was repaired by hand.
+func (m *Migrator) Force(version uint) error {
+	const op = "storage.schema.Force"
+
+	target := int(version)
+	if version == 0 {
+		target = database.NilVersion
+	}
+	if err := m.m.Force(target); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Check returns the current schema version. It returns ErrDirty or ErrTooNew when
+// migrations must not run on it.
+func (m *Migrator) Check() (uint, error) {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return version, fmt.Errorf("version %d: %w", version, ErrDirty)
+	}
+	if version > m.latest {
+		return version, fmt.Errorf("version %d, latest known %d: %w", version, m.latest, ErrTooNew)
+	}
+	return version, nil
+}
+
+func noChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return ErrNoChange
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package schema_test
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"passvault/internal/storage/schema"
+	"passvault/migrations"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func newSQLite(t *testing.T) (*schema.Migrator, string) {
+	path := filepath.Join(t.TempDir(), "passvault.db")
+	m, err := schema.NewSQLite(path, schema.DefaultTable, migrations.SQLite)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+	return m, path
+}
+
+func TestUp(t *testing.T) {
+	m, _ := newSQLite(t)
+
+	version, dirty, err := m.Version()
+	require.NoError(t, err)
+	require.Zero(t, version)
+	require.False(t, dirty)
+
+	version, err = m.Up()
+	require.NoError(t, err)
+	require.Equal(t, m.Latest(), version)
+	require.NotZero(t, version)
+
+	version, err = m.Up()
+	require.NoError(t, err)
+	require.Equal(t, m.Latest(), version)
+}
+
+func TestUpRefusesDirtySchema(t *testing.T) {
+	m, path := newSQLite(t)
+
+	_, err := m.Up()
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`UPDATE migrations SET dirty = 1`)
+	require.NoError(t, err)
+
+	_, err = m.Up()
+	require.ErrorIs(t, err, schema.ErrDirty)
+	require.ErrorIs(t, m.Down(1), schema.ErrDirty)
+
+	require.NoError(t, m.Force(m.Latest()))
+	_, err = m.Up()
+	require.NoError(t, err)
+}
+
+func TestUpRefusesNewerSchema(t *testing.T) {
+	m, _ := newSQLite(t)
+
+	require.NoError(t, m.Force(m.Latest()+1))
+
+	version, err := m.Up()
+	require.ErrorIs(t, err, schema.ErrTooNew)
+	require.Equal(t, m.Latest()+1, version)
+}
+
+func TestDownAndGoto(t *testing.T) {
+	m, _ := newSQLite(t)
+
+	require.NoError(t, m.Goto(2))
+	version, _, err := m.Version()
+	require.NoError(t, err)
+	require.Equal(t, uint(2), version)
+
+	require.NoError(t, m.Goto(4))
+	require.NoError(t, m.Down(1))
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	require.Equal(t, uint(3), version)
+
+	require.NoError(t, m.Goto(0))
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	require.Zero(t, version)
+
+	require.ErrorIs(t, m.Goto(0), schema.ErrNoChange)
+	require.Error(t, m.Goto(m.Latest()+1))
+	require.Error(t, m.Down(0))
+}
+
+func TestLatest(t *testing.T) {
+	source := fstest.MapFS{
+		"1_a.up.sql":    {Data: []byte(`CREATE TABLE a (id INTEGER);`)},
+		"1_a.down.sql":  {Data: []byte(`DROP TABLE a;`)},
+		"10_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER);`)},
+		"10_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+	}
+	m, err := schema.NewSQLite(filepath.Join(t.TempDir(), "passvault.db"), "schema_version", source)
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.Equal(t, uint(10), m.Latest())
+	version, err := m.Up()
+	require.NoError(t, err)
+	require.Equal(t, uint(10), version)
+}
@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// EntryAccess resolves what an account may do with an entry. Entries the account
+// cannot read are reported as storage.ErrEntryNotFound, so their existence is not revealed.
+func (s *Storage) EntryAccess(ctx context.Context, accountID int64, entryID int64) (*models.EntryAccess, error) {
+	const op = "storage.sqlite.EntryAccess"
+
+	access, err := entryAccess(ctx, s.db, accountID, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !access.Read {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+	return access, nil
+}
+
+// entryAccess computes the access of an account to an existing entry. Personal entries
+// are fully accessible to their owner and readable, or writable, through an unexpired
+// share. Entries in a collection are readable by every member of the organization and
+// writable by editors and above.
+func entryAccess(ctx context.Context, q querier, accountID int64, entryID int64) (*models.EntryAccess, error) {
+	query := `SELECT e.account_id, e.collection_id, c.organization_id,
+			(SELECT m.role FROM organization_member m WHERE m.organization_id = c.organization_id AND m.account_id = ?),
+			(SELECT sh.permission FROM entry_share sh WHERE sh.entry_id = e.id AND sh.owner_id = e.account_id AND sh.account_id = ?
+				AND (sh.expires_at IS NULL OR sh.expires_at > ?))
+		FROM entry e LEFT JOIN collection c ON c.id = e.collection_id
+		WHERE e.id = ?`
+
+	access := models.EntryAccess{EntryID: entryID}
+	var role, permission sql.NullString
+	err := q.QueryRowContext(ctx, query, accountID, accountID, now(), entryID).
+		Scan(&access.OwnerID, &access.CollectionID, &access.OrganizationID, &role, &permission)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrEntryNotFound
+		}
+		return nil, err
+	}
+
+	switch {
+	case access.CollectionID != nil:
+		access.Read = models.ValidOrgRole(role.String)
+		access.Write = models.OrgRoleAtLeast(role.String, models.OrgRoleEditor)
+		access.Delete = access.Write
+	case access.OwnerID == accountID:
+		access.Read, access.Write, access.Delete = true, true, true
+	case permission.Valid:
+		access.Read = true
+		access.Write = permission.String == models.SharePermissionWrite
+	}
+
+	return &access, nil
+}
+
+// checkEntryAccess returns the access of an account to an entry if it allows reading, and
+// writing or deleting when asked for. Entries the account can read but not change give
+// storage.ErrAccessDenied.
+func checkEntryAccess(ctx context.Context, q querier, accountID int64, entryID int64, write, delete bool) (*models.EntryAccess, error) {
+	access, err := entryAccess(ctx, q, accountID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Read {
+		return nil, storage.ErrEntryNotFound
+	}
+	if (write && !access.Write) || (delete && !access.Delete) {
+		return nil, storage.ErrAccessDenied
+	}
+	return access, nil
+}
+
+// revisionOwner returns the account and collection the revisions of an entry are kept
+// under, if the account may read them, or restore them when write is set. The history
+// of personal entries is private to their owner, even when the entry is shared; that
+// of collection entries is available to the organization members. Revisions of a
+// deleted entry follow the access rules the entry had when it was deleted.
+func revisionOwner(ctx context.Context, q querier, accountID int64, entryID int64, write bool) (int64, *int64, error) {
+	access, err := checkEntryAccess(ctx, q, accountID, entryID, write, false)
+	if err == nil {
+		if access.CollectionID == nil && access.OwnerID != accountID {
+			return 0, nil, storage.ErrEntryNotFound
+		}
+		return access.OwnerID, access.CollectionID, nil
+	}
+	if !errors.Is(err, storage.ErrEntryNotFound) {
+		return 0, nil, err
+	}
+
+	var ownerID int64
+	var collectionID *int64
+	query := `SELECT account_id, collection_id FROM entry_revision
+		WHERE entry_id = ? AND NOT EXISTS (SELECT 1 FROM entry WHERE id = ?) ORDER BY id DESC LIMIT 1`
+	if err := q.QueryRowContext(ctx, query, entryID, entryID).Scan(&ownerID, &collectionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, storage.ErrEntryNotFound
+		}
+		return 0, nil, err
+	}
+
+	if collectionID == nil {
+		if ownerID != accountID {
+			return 0, nil, storage.ErrEntryNotFound
+		}
+		return ownerID, nil, nil
+	}
+
+	role, err := collectionRole(ctx, q, accountID, *collectionID)
+	if errors.Is(err, storage.ErrCollectionNotFound) {
+		return 0, nil, storage.ErrEntryNotFound
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if write && !models.OrgRoleAtLeast(role, models.OrgRoleEditor) {
+		return 0, nil, storage.ErrAccessDenied
+	}
+	return ownerID, collectionID, nil
+}
+
+// collectionRole returns the role of an account in the organization of a collection.
+// It returns storage.ErrCollectionNotFound when the account is not a member.
+func collectionRole(ctx context.Context, q querier, accountID int64, collectionID int64) (string, error) {
+	query := `SELECT m.role FROM collection c JOIN organization_member m ON m.organization_id = c.organization_id
+		WHERE c.id = ? AND m.account_id = ?`
+
+	var role string
+	if err := q.QueryRowContext(ctx, query, collectionID, accountID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrCollectionNotFound
+		}
+		return "", err
+	}
+	return role, nil
+}
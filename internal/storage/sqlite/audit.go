@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+)
+
+// AppendAuditEvent appends an event to the audit_event table, chained to the last event.
+// The ID, CreatedAt, PrevHash and Hash fields of event are set here, the hash keyed with chainKey.
+func (s *Storage) AppendAuditEvent(ctx context.Context, event models.AuditEvent, chainKey []byte) (int64, error) {
+	const op = "storage.sqlite.AppendAuditEvent"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var lastID int64
+	var prevHash string
+	err = tx.QueryRowContext(ctx, `SELECT id, hash FROM audit_event ORDER BY id DESC LIMIT 1`).Scan(&lastID, &prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Events are never deleted, so IDs have no gaps and the next one is known up front.
+	event.ID = lastID + 1
+	event.CreatedAt = now()
+	event.PrevHash = prevHash
+	event.Hash = auditchain.Hash(chainKey, prevHash, event.ID, event)
+
+	query := `INSERT INTO audit_event (id, created_at, account_id, action, entry_id, request_id, ip, user_agent, status, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query, event.ID, event.CreatedAt, event.AccountID, event.Action, event.EntryID,
+		event.RequestID, event.IP, event.UserAgent, event.Status, event.PrevHash, event.Hash)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return event.ID, nil
+}
+
+// ListAuditEvents retrieves the audit events of an account matching filter, newest first
+func (s *Storage) ListAuditEvents(ctx context.Context, accountID int64, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	const op = "storage.sqlite.ListAuditEvents"
+
+	where := `account_id = ?`
+	args := []any{accountID}
+	if filter.EntryID != nil {
+		where += ` AND entry_id = ?`
+		args = append(args, *filter.EntryID)
+	}
+	if filter.Action != "" {
+		where += ` AND action = ?`
+		args = append(args, filter.Action)
+	}
+	if filter.Since != nil {
+		where += ` AND created_at >= ?`
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		where += ` AND created_at < ?`
+		args = append(args, filter.Until.UTC())
+	}
+	if filter.BeforeID > 0 {
+		where += ` AND id < ?`
+		args = append(args, filter.BeforeID)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	events, err := s.queryAuditEvents(ctx, `WHERE `+where+` ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return events, nil
+}
+
+// AuditEvents retrieves up to limit audit events of all accounts following afterID, oldest first.
+// It is meant for walking the whole chain.
+func (s *Storage) AuditEvents(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error) {
+	const op = "storage.sqlite.AuditEvents"
+
+	events, err := s.queryAuditEvents(ctx, `WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return events, nil
+}
+
+func (s *Storage) queryAuditEvents(ctx context.Context, clause string, args ...any) ([]models.AuditEvent, error) {
+	query := `SELECT id, created_at, account_id, action, entry_id, request_id, ip, user_agent, status, prev_hash, hash FROM audit_event ` + clause
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := rows.Scan(&event.ID, &event.CreatedAt, &event.AccountID, &event.Action, &event.EntryID, &event.RequestID,
+			&event.IP, &event.UserAgent, &event.Status, &event.PrevHash, &event.Hash); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
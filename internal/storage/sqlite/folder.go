@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// CreateFolder inserts a new folder of an account into the folder table.
+// A nil parentID creates a top level folder.
+func (s *Storage) CreateFolder(ctx context.Context, accountID int64, parentID *int64, name string) (int64, error) {
+	const op = "storage.sqlite.CreateFolder"
+
+	if parentID != nil {
+		if err := checkFolder(ctx, s.db, accountID, *parentID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	query := `INSERT INTO folder (account_id, parent_id, name, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := s.db.ExecContext(ctx, query, accountID, parentID, name, now(), now())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	folderID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return folderID, nil
+}
+
+// ListFolders retrieves all folders of an account from the folder table
+func (s *Storage) ListFolders(ctx context.Context, accountID int64) ([]models.Folder, error) {
+	const op = "storage.sqlite.ListFolders"
+	query := `SELECT id, account_id, parent_id, name, created_at, updated_at FROM folder WHERE account_id = ? ORDER BY name, id`
+	rows, err := s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var folders []models.Folder
+	for rows.Next() {
+		var folder models.Folder
+		if err := rows.Scan(&folder.ID, &folder.AccountId, &folder.ParentID, &folder.Name, &folder.CreatedAt, &folder.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		folders = append(folders, folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return folders, nil
+}
+
+// RenameFolder changes the name of a folder of an account
+func (s *Storage) RenameFolder(ctx context.Context, accountID int64, folderID int64, name string) error {
+	const op = "storage.sqlite.RenameFolder"
+
+	query := `UPDATE folder SET name = ?, updated_at = ? WHERE id = ? AND account_id = ?`
+	result, err := s.db.ExecContext(ctx, query, name, now(), folderID, accountID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+	}
+	return nil
+}
+
+// MoveFolder moves a folder of an account, with its subfolders, under another folder.
+// A nil parentID moves it to the top level. Moving a folder below itself fails with
+// storage.ErrFolderCycle.
+func (s *Storage) MoveFolder(ctx context.Context, accountID int64, folderID int64, parentID *int64) error {
+	const op = "storage.sqlite.MoveFolder"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFolder(ctx, tx, accountID, folderID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if parentID != nil {
+		if err := checkFolder(ctx, tx, accountID, *parentID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		// The new parent must not be the folder itself or one of its descendants.
+		query := `WITH RECURSIVE ancestor(id, parent_id) AS (
+				SELECT id, parent_id FROM folder WHERE id = ?
+				UNION ALL
+				SELECT f.id, f.parent_id FROM folder f JOIN ancestor a ON f.id = a.parent_id
+			)
+			SELECT COUNT(*) FROM ancestor WHERE id = ?`
+		var cycles int
+		if err := tx.QueryRowContext(ctx, query, *parentID, folderID).Scan(&cycles); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if cycles > 0 {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderCycle)
+		}
+	}
+
+	query := `UPDATE folder SET parent_id = ?, updated_at = ? WHERE id = ? AND account_id = ?`
+	if _, err := tx.ExecContext(ctx, query, parentID, now(), folderID, accountID); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteFolder removes a folder of an account from the folder table. Its subfolders
+// and entries are moved to the parent of the deleted folder, nothing is deleted with it.
+func (s *Storage) DeleteFolder(ctx context.Context, accountID int64, folderID int64) error {
+	const op = "storage.sqlite.DeleteFolder"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var parentID *int64
+	err = tx.QueryRowContext(ctx, `SELECT parent_id FROM folder WHERE id = ? AND account_id = ?`, folderID, accountID).Scan(&parentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `UPDATE folder SET parent_id = ?, updated_at = ? WHERE parent_id = ? AND account_id = ?`
+	if _, err := tx.ExecContext(ctx, query, parentID, now(), folderID, accountID); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `UPDATE entry SET folder_id = ? WHERE folder_id = ? AND account_id = ?`
+	if _, err := tx.ExecContext(ctx, query, parentID, folderID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM folder WHERE id = ? AND account_id = ?`, folderID, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetEntryFolder puts a personal entry of an account into a folder. A nil folderID takes it
+// out of any folder.
+func (s *Storage) SetEntryFolder(ctx context.Context, accountID int64, entryID int64, folderID *int64) error {
+	const op = "storage.sqlite.SetEntryFolder"
+
+	if folderID != nil {
+		if err := checkFolder(ctx, s.db, accountID, *folderID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE entry SET folder_id = ? WHERE id = ? AND account_id = ? AND collection_id IS NULL`, folderID, entryID, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	}
+	return nil
+}
+
+// checkFolder returns storage.ErrFolderNotFound unless the account has the folder.
+func checkFolder(ctx context.Context, q querier, accountID int64, folderID int64) error {
+	var id int64
+	err := q.QueryRowContext(ctx, `SELECT id FROM folder WHERE id = ? AND account_id = ?`, folderID, accountID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrFolderNotFound
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+)
+
+// SaveKDFParams saves the zero-knowledge KDF parameters of an account into the
+// kdf_params table. They are saved once and never changed, and only while the account
+// has no personal entries or revisions: their data was written as plaintext.
+func (s *Storage) SaveKDFParams(ctx context.Context, params models.KDFParams) error {
+	const op = "storage.sqlite.SaveKDFParams"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM kdf_params WHERE account_id = ?)`, params.AccountID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrKDFParamsExist)
+	}
+
+	var hasEntries bool
+	query := `SELECT EXISTS (SELECT 1 FROM entry WHERE account_id = ? AND collection_id IS NULL)
+		OR EXISTS (SELECT 1 FROM entry_revision WHERE account_id = ? AND collection_id IS NULL)`
+	if err := tx.QueryRowContext(ctx, query, params.AccountID, params.AccountID).Scan(&hasEntries); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if hasEntries {
+		return fmt.Errorf("%s: %w", op, storage.ErrVaultNotEmpty)
+	}
+
+	query = `INSERT INTO kdf_params (account_id, created_at, algorithm, iterations, memory, parallelism, salt, key_check, protocol_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query, params.AccountID, now(), params.Algorithm, params.Iterations, params.Memory,
+		params.Parallelism, params.Salt, params.KeyCheck, params.ProtocolVersion)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrKDFParamsExist)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// KDFParams retrieves the zero-knowledge KDF parameters of an account from the kdf_params table
+func (s *Storage) KDFParams(ctx context.Context, accountID int64) (*models.KDFParams, error) {
+	const op = "storage.sqlite.KDFParams"
+
+	query := `SELECT account_id, created_at, algorithm, iterations, memory, parallelism, salt, key_check, protocol_version
+		FROM kdf_params WHERE account_id = ?`
+	var params models.KDFParams
+	err := s.db.QueryRowContext(ctx, query, accountID).Scan(&params.AccountID, &params.CreatedAt, &params.Algorithm,
+		&params.Iterations, &params.Memory, &params.Parallelism, &params.Salt, &params.KeyCheck, &params.ProtocolVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrKDFParamsNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &params, nil
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"passvault/internal/domain/models"
+	"passvault/internal/storage"
+	"time"
+)
+
+// ShareEntry grants another account access to an entry of the owner. Sharing an entry
+// again with the same account replaces the permission and expiry of the previous share.
+func (s *Storage) ShareEntry(ctx context.Context, ownerID int64, entryID int64, accountID int64, permission string, expiresAt *time.Time) (int64, error) {
+	const op = "storage.sqlite.ShareEntry"
+
+	if ownerID == accountID {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrInvalidShare)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkOwner(ctx, tx, ownerID, entryID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	query := `INSERT INTO entry_share (entry_id, owner_id, account_id, permission, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (entry_id, account_id) DO UPDATE SET permission = excluded.permission, expires_at = excluded.expires_at
+		RETURNING id`
+	var shareID int64
+	if err := tx.QueryRowContext(ctx, query, entryID, ownerID, accountID, permission, expiresAt, now()).Scan(&shareID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return shareID, nil
+}
+
+// ListShares retrieves the shares of an entry of the owner, expired ones included
+func (s *Storage) ListShares(ctx context.Context, ownerID int64, entryID int64) ([]models.EntryShare, error) {
+	const op = "storage.sqlite.ListShares"
+
+	if err := checkOwner(ctx, s.db, ownerID, entryID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	shares, err := s.queryShares(ctx, `WHERE entry_id = ? AND owner_id = ? ORDER BY id`, entryID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return shares, nil
+}
+
+// ListReceivedShares retrieves the shares granted to an account that have not expired
+func (s *Storage) ListReceivedShares(ctx context.Context, accountID int64) ([]models.EntryShare, error) {
+	const op = "storage.sqlite.ListReceivedShares"
+
+	shares, err := s.queryShares(ctx, `WHERE account_id = ? AND (expires_at IS NULL OR expires_at > ?) ORDER BY id`, accountID, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return shares, nil
+}
+
+// RevokeShare removes the access of an account to an entry of the owner
+func (s *Storage) RevokeShare(ctx context.Context, ownerID int64, entryID int64, accountID int64) error {
+	const op = "storage.sqlite.RevokeShare"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM entry_share WHERE entry_id = ? AND owner_id = ? AND account_id = ?`, entryID, ownerID, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrShareNotFound)
+	}
+	return nil
+}
+
+func (s *Storage) queryShares(ctx context.Context, where string, args ...any) ([]models.EntryShare, error) {
+	query := `SELECT id, created_at, entry_id, owner_id, account_id, permission, expires_at FROM entry_share ` + where
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []models.EntryShare
+	for rows.Next() {
+		var share models.EntryShare
+		if err := rows.Scan(&share.ID, &share.CreatedAt, &share.EntryID, &share.OwnerID, &share.AccountID, &share.Permission, &share.ExpiresAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// checkOwner returns storage.ErrEntryNotFound unless the account owns the entry as a
+// personal entry. Entries in collections are shared through the organization instead.
+func checkOwner(ctx context.Context, q querier, ownerID int64, entryID int64) error {
+	var count int
+	query := `SELECT COUNT(*) FROM entry WHERE id = ? AND account_id = ? AND collection_id IS NULL`
+	if err := q.QueryRowContext(ctx, query, entryID, ownerID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return storage.ErrEntryNotFound
+	}
+	return nil
+}
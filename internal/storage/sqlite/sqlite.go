@@ -1,4 +1,5 @@
-// Main migrations init script: go run ./cmd/migrator --storage-path=./storage/sso.db --migrations-path=./migrations
+// The server applies the embedded migrations on startup. To manage them by hand:
+// go run ./cmd/migrator --storage-path=./storage/sso.db [up|down N|goto V|force V|version]
 // Test migrations init script: go run ./cmd/migrator --storage-path=./storage/sso.db --migrations-path=./tests/migrations --migrations-table=migrations_test
 package sqlite
 
@@ -7,21 +8,27 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/mattn/go-sqlite3"
 	"passvault/internal/domain/models"
-	"passvault/internal/http-server/handlers/entry/get"
 	"passvault/internal/storage"
+	"sync"
 	"time"
 )
 
+var _ storage.Backend = (*Storage)(nil)
+
 type Storage struct {
-	db *sql.DB
+	db        *sql.DB
+	retention storage.RevisionRetention
+	// auditMu serializes audit appends, which read the chain head before writing.
+	auditMu sync.Mutex
 }
 
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
-func New(storagePath string) (*Storage, error) {
+func New(storagePath string, retention storage.RevisionRetention) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
 	db, err := sql.Open("sqlite3", storagePath)
@@ -29,7 +36,7 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, retention: retention}, nil
 }
 
 // SaveEntry inserts a new entry into the Entry table
@@ -42,7 +49,7 @@ func (s *Storage) SaveEntry(ctx context.Context, accountID int64, entryType, ent
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, accountID, entryType, entryData, time.Now(), time.Now())
+	result, err := stmt.ExecContext(ctx, accountID, entryType, entryData, now(), now())
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -53,10 +60,66 @@ func (s *Storage) SaveEntry(ctx context.Context, accountID int64, entryType, ent
 	return entryID, nil
 }
 
-// GetEntry retrieves a entry from the entry table by entry ID
-func (s *Storage) GetEntry(ctx context.Context, accountID int64, entryID int64) (*get.Entry, error) {
+// SaveEntries inserts personal entries of an account along with their search tokens
+// in a single transaction
+func (s *Storage) SaveEntries(ctx context.Context, accountID int64, entries []storage.NewEntry) ([]int64, error) {
+	const op = "storage.sqlite.SaveEntries"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	entryStmt, err := tx.PrepareContext(ctx, `INSERT INTO entry (account_id, entry_type, entry_data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer entryStmt.Close()
+
+	tokenStmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO search_token (account_id, token, entry_id) VALUES (?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tokenStmt.Close()
+
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		now := now()
+		result, err := entryStmt.ExecContext(ctx, accountID, entry.EntryType, entry.EntryData, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entryID, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, token := range entry.SearchTokens {
+			if _, err := tokenStmt.ExecContext(ctx, accountID, token, entryID); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		ids = append(ids, entryID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// GetEntry retrieves a entry from the entry table by entry ID. The entry must belong
+// to the account, be shared with it or be in a collection of one of its organizations.
+func (s *Storage) GetEntry(ctx context.Context, accountID int64, entryID int64) (*models.Entry, error) {
 	const op = "storage.sqlite.GetEntry"
-	query := `SELECT id, account_id, entry_type, entry_data, created_at, updated_at FROM entry WHERE id = ?`
+
+	access, err := checkEntryAccess(ctx, s.db, accountID, entryID, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `SELECT id, account_id, entry_type, entry_data, folder_id, collection_id, created_at, updated_at FROM entry WHERE id = ?`
 	stmt, err := s.db.Prepare(query)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -64,98 +127,334 @@ func (s *Storage) GetEntry(ctx context.Context, accountID int64, entryID int64)
 	defer stmt.Close()
 
 	var entry models.Entry
-	err = stmt.QueryRowContext(ctx, entryID).Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.CreatedAt, &entry.UpdatedAt)
+	err = stmt.QueryRowContext(ctx, entryID).Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.FolderID, &entry.CollectionID, &entry.CreatedAt, &entry.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if entry.AccountId != accountID {
-		return nil, fmt.Errorf("%s: %w", op, storage.ErrEntryNotFound)
+	if access.CollectionID != nil || access.OwnerID != accountID {
+		// Folders and tags are private to the owner of a personal entry.
+		entry.FolderID = nil
+		return &entry, nil
+	}
+
+	tags, err := s.entryTags(ctx, accountID, &entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &get.Entry{
-		ID:        entry.ID,
-		AccountId: entry.AccountId,
-		EntryType: entry.EntryType,
-		EntryData: entry.EntryData,
-	}, nil
+	entry.TagIDs = tags[entry.ID]
+
+	return &entry, nil
 }
 
-// UpdateEntries updates an existing entry in the entry table by ID
-func (s *Storage) UpdateEntry(ctx context.Context, entryID int64, entryType, entryData string) error {
+// UpdateEntries updates an existing entry in the entry table by ID. The account needs
+// write access to the entry, see EntryAccess. The previous version is kept in the
+// entry_revision table of the owner.
+func (s *Storage) UpdateEntry(ctx context.Context, accountID int64, entryID int64, entryType, entryData string) error {
 	const op = "storage.sqlite.UpdateEntry"
-	query := `UPDATE entry SET entry_type = ?, entry_data = ?, updated_at = ? WHERE id = ?`
-	stmt, err := s.db.Prepare(query)
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(ctx, entryType, entryData, time.Now(), entryID)
+	access, err := checkEntryAccess(ctx, tx, accountID, entryID, true, false)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	ownerID := access.OwnerID
+
+	if err := s.saveRevision(ctx, tx, ownerID, entryID, models.RevisionActionUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `UPDATE entry SET entry_type = ?, entry_data = ?, updated_at = ? WHERE id = ? AND account_id = ?`
+	if _, err := tx.ExecContext(ctx, query, entryType, entryData, now(), entryID, ownerID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.pruneRevisions(ctx, tx, ownerID, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
 
-// DeleteEntries removes a entry from the entry table by ID
-func (s *Storage) DeleteEntry(ctx context.Context, entryID int64) error {
+// DeleteEntries removes an entry from the entry table by ID, along with its shares.
+// Personal entries can only be deleted by their owner, entries in a collection by
+// editors and above. The deleted version is kept in the entry_revision table, so it
+// can be restored.
+func (s *Storage) DeleteEntry(ctx context.Context, accountID int64, entryID int64) error {
 	const op = "storage.sqlite.DeleteEntry"
-	query := `DELETE FROM entry WHERE id = ?`
-	stmt, err := s.db.Prepare(query)
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(ctx, entryID)
+	access, err := checkEntryAccess(ctx, tx, accountID, entryID, false, true)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	ownerID := access.OwnerID
+
+	if err := s.saveRevision(ctx, tx, ownerID, entryID, models.RevisionActionDelete); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry WHERE id = ? AND account_id = ?`, entryID, ownerID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_tag WHERE entry_id = ?`, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_token WHERE entry_id = ? AND account_id = ?`, entryID, ownerID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_share WHERE entry_id = ? AND owner_id = ?`, entryID, ownerID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.pruneRevisions(ctx, tx, ownerID, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
 
-// ListEntries retrieves all entries for a given userId from the entry table
-func (s *Storage) ListEntries(ctx context.Context, accountID int64) ([]models.Entry, error) {
+// ListEntries retrieves all entries of an account matching filter from the entry table.
+// With filter.CollectionID set it lists the entries of that collection instead, if the
+// account is a member of its organization.
+func (s *Storage) ListEntries(ctx context.Context, accountID int64, filter models.EntryFilter) ([]models.Entry, error) {
 	const op = "storage.sqlite.ListEntries"
-	query := `SELECT id, user_id, entry_type, entry_data, created_at, updated_at FROM entry WHERE account_id = ?`
-	rows, err := s.db.QueryContext(ctx, query, accountID)
+
+	where, args := entryFilter(accountID, filter)
+	query := `SELECT id, account_id, entry_type, entry_data, folder_id, collection_id, created_at, updated_at FROM entry WHERE ` + where + ` ORDER BY id`
+
+	entries, err := s.queryEntries(ctx, accountID, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// ListEntriesPage retrieves one page of the entries of an account matching the query filter,
+// sorted by created_at or updated_at. Sorting by name needs the decrypted entry data and
+// is not supported here.
+func (s *Storage) ListEntriesPage(ctx context.Context, accountID int64, query models.EntryQuery) (*models.EntryPage, error) {
+	const op = "storage.sqlite.ListEntriesPage"
+
+	var column string
+	switch query.Sort {
+	case "", models.EntrySortCreatedAt:
+		column = "created_at"
+	case models.EntrySortUpdatedAt:
+		column = "updated_at"
+	default:
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidSort)
+	}
+
+	order, cmp := "ASC", ">"
+	if query.Descending {
+		order, cmp = "DESC", "<"
+	}
+
+	where, args := entryFilter(accountID, query.Filter)
+	if query.Cursor != "" {
+		cursor, err := storage.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		key, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidCursor)
+		}
+		where += fmt.Sprintf(` AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))`, column, cmp)
+		args = append(args, key.UTC(), key.UTC(), cursor.ID)
+	}
+
+	// One extra row tells whether there is a next page. A negative limit lifts the limit.
+	limit := query.Limit
+	if limit > 0 {
+		args = append(args, limit+1)
+	} else {
+		args = append(args, -1)
+	}
+	q := fmt.Sprintf(`SELECT id, account_id, entry_type, entry_data, folder_id, collection_id, created_at, updated_at FROM entry WHERE %s ORDER BY %s %s, id %s LIMIT ?`,
+		where, column, order, order)
+
+	entries, err := s.queryEntries(ctx, accountID, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+
+	page := &models.EntryPage{Entries: entries}
+	if limit > 0 && len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		key := last.CreatedAt
+		if column == "updated_at" {
+			key = last.UpdatedAt
+		}
+		page.NextCursor = storage.Cursor{Key: key.UTC().Format(time.RFC3339Nano), ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// entryFilter builds the WHERE clause selecting the entries of an account matching filter.
+// Folders and tags belong to accounts, so within a collection they match nothing.
+func entryFilter(accountID int64, filter models.EntryFilter) (string, []any) {
+	where := `account_id = ? AND collection_id IS NULL`
+	args := []any{accountID}
+	if filter.CollectionID != nil {
+		where = `collection_id = ? AND EXISTS (SELECT 1 FROM collection c JOIN organization_member m
+			ON m.organization_id = c.organization_id WHERE c.id = entry.collection_id AND m.account_id = ?)`
+		args = []any{*filter.CollectionID, accountID}
+	}
+
+	if filter.FolderID != nil {
+		if *filter.FolderID == 0 {
+			where += ` AND folder_id IS NULL`
+		} else {
+			where += ` AND folder_id = ?`
+			args = append(args, *filter.FolderID)
+		}
+	}
+	for _, tagID := range filter.TagIDs {
+		where += ` AND id IN (SELECT entry_id FROM entry_tag WHERE tag_id = ?)`
+		args = append(args, tagID)
+	}
+	if filter.EntryType != "" {
+		where += ` AND entry_type = ?`
+		args = append(args, filter.EntryType)
+	}
+	if filter.CreatedAfter != nil {
+		where += ` AND created_at >= ?`
+		args = append(args, filter.CreatedAfter.UTC())
+	}
+	if filter.CreatedBefore != nil {
+		where += ` AND created_at < ?`
+		args = append(args, filter.CreatedBefore.UTC())
+	}
+	if filter.UpdatedAfter != nil {
+		where += ` AND updated_at >= ?`
+		args = append(args, filter.UpdatedAfter.UTC())
+	}
+	if filter.UpdatedBefore != nil {
+		where += ` AND updated_at < ?`
+		args = append(args, filter.UpdatedBefore.UTC())
+	}
+
+	return where, args
+}
+
+// queryEntries runs an entry query and loads the tags of the returned entries.
+func (s *Storage) queryEntries(ctx context.Context, accountID int64, query string, args ...any) ([]models.Entry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var entries []models.Entry
 	for rows.Next() {
 		var entry models.Entry
-		if err := rows.Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+		if err := rows.Scan(&entry.ID, &entry.AccountId, &entry.EntryType, &entry.EntryData, &entry.FolderID, &entry.CollectionID, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, err
 		}
 		entries = append(entries, entry)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	tags, err := s.entryTags(ctx, accountID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].TagIDs = tags[entries[i].ID]
 	}
 
 	return entries, nil
 }
 
-// StoreKeyPart inserts a new key part for a user into the encryption_key table
-func (s *Storage) SaveKeyPart(ctx context.Context, accountID int64, keyPart string) (int64, error) {
+// entryTags returns the tag IDs of the account entries keyed by entry ID,
+// limited to a single entry when entryID is set.
+func (s *Storage) entryTags(ctx context.Context, accountID int64, entryID *int64) (map[int64][]int64, error) {
+	query := `SELECT et.entry_id, et.tag_id FROM entry_tag et JOIN tag t ON t.id = et.tag_id WHERE t.account_id = ?`
+	args := []any{accountID}
+	if entryID != nil {
+		query += ` AND et.entry_id = ?`
+		args = append(args, *entryID)
+	}
+	query += ` ORDER BY et.tag_id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int64][]int64)
+	for rows.Next() {
+		var entry, tag int64
+		if err := rows.Scan(&entry, &tag); err != nil {
+			return nil, err
+		}
+		tags[entry] = append(tags[entry], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// StoreKeyPart inserts a new key part for a user into the encryption_key table.
+// An account has at most one key part, its first key version; later versions are
+// added by StartKeyRotation. While shares of a deleted key part remain, only
+// RestoreKeyPart brings it back.
+func (s *Storage) SaveKeyPart(ctx context.Context, accountID int64, keyPart string, masterKeyVersion int) (int64, error) {
 	const op = "storage.sqlite.SaveKeyPart"
-	query := `INSERT INTO encryption_key (account_id, key_part, created_at, updated_at) VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO encryption_key (account_id, key_part, key_version, master_key_version, created_at, updated_at)
+		SELECT ?, ?, 1, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM encryption_key WHERE account_id = ?)`
 	stmt, err := s.db.Prepare(query)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, accountID, keyPart, time.Now(), time.Now())
+	result, err := stmt.ExecContext(ctx, accountID, keyPart, masterKeyVersion, now(), now(), accountID)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+		}
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+	}
 	keyID, err := result.LastInsertId()
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -163,10 +462,11 @@ func (s *Storage) SaveKeyPart(ctx context.Context, accountID int64, keyPart stri
 	return keyID, nil
 }
 
-// RetrieveKeyPart retrieves a key part for an account from the encryption_key table
+// RetrieveKeyPart retrieves the key part of the current key version of an account from
+// the encryption_key table
 func (s *Storage) RetrieveKeyPart(ctx context.Context, accountID int64) (string, error) {
 	const op = "storage.sqlite.RetrieveKeyPart"
-	query := `SELECT key_part FROM encryption_key WHERE account_id = ?`
+	query := `SELECT key_part FROM encryption_key WHERE account_id = ? AND share_index = 0 ORDER BY key_version DESC LIMIT 1`
 	stmt, err := s.db.Prepare(query)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
@@ -184,19 +484,356 @@ func (s *Storage) RetrieveKeyPart(ctx context.Context, accountID int64) (string,
 	return keyPart, nil
 }
 
-// DeleteKeyPart removes a key part for an account from the encryption_key table
+// KeyParts retrieves every key version of an account from the encryption_key table, oldest first
+func (s *Storage) KeyParts(ctx context.Context, accountID int64) ([]models.EncryptionKey, error) {
+	const op = "storage.sqlite.KeyParts"
+	query := `SELECT id, account_id, key_part, key_version, master_key_version, created_at, updated_at FROM encryption_key
+		WHERE account_id = ? AND share_index = 0 ORDER BY key_version`
+	rows, err := s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.EncryptionKey
+	for rows.Next() {
+		var key models.EncryptionKey
+		if err := rows.Scan(&key.ID, &key.AccountId, &key.KeyPart, &key.KeyVersion, &key.MasterKeyVersion, &key.CreatedAt, &key.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+
+	return keys, nil
+}
+
+// RestoreKeyPart inserts a key part recovered from its Shamir shares into the encryption_key
+// table under the key version and master key version it was split from. Only an account
+// without key parts can get one restored.
+func (s *Storage) RestoreKeyPart(ctx context.Context, accountID int64, keyVersion, masterKeyVersion int, keyPart string) error {
+	const op = "storage.sqlite.RestoreKeyPart"
+
+	now := now()
+	query := `INSERT INTO encryption_key (account_id, key_part, key_version, master_key_version, created_at, updated_at)
+		SELECT ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM encryption_key WHERE account_id = ? AND share_index = 0)`
+	result, err := s.db.ExecContext(ctx, query, accountID, keyPart, keyVersion, masterKeyVersion, now, now, accountID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyExists)
+	}
+	return nil
+}
+
+// DeleteKeyPart removes a key part for an account from the encryption_key table.
+// Commitments to the Shamir shares of the key part are kept, so it can still be
+// recovered. The key part of an account that still has entries or revisions is only
+// deleted when it can be: the account has a single key version and shares of it.
+// Entries in collections are encrypted with the organization key and do not count.
 func (s *Storage) DeleteKeyPart(ctx context.Context, accountID int64) error {
 	const op = "storage.sqlite.DeleteKeyPart"
-	query := `DELETE FROM encryption_key WHERE account_id = ?`
-	stmt, err := s.db.Prepare(query)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var entries int
+	query := `SELECT (SELECT COUNT(*) FROM entry WHERE account_id = ? AND collection_id IS NULL) +
+		(SELECT COUNT(*) FROM entry_revision WHERE account_id = ? AND collection_id IS NULL)`
+	if err := tx.QueryRowContext(ctx, query, accountID, accountID).Scan(&entries); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if entries > 0 {
+		var recoverable bool
+		query := `SELECT (SELECT COUNT(*) FROM encryption_key WHERE account_id = ? AND share_index = 0) = 1
+			AND EXISTS (SELECT 1 FROM encryption_key s JOIN encryption_key k ON k.account_id = s.account_id AND k.key_version = s.key_version
+				WHERE s.account_id = ? AND s.share_index > 0 AND k.share_index = 0)`
+		if err := tx.QueryRowContext(ctx, query, accountID, accountID).Scan(&recoverable); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !recoverable {
+			return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyInUse)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM encryption_key WHERE account_id = ? AND share_index = 0`, accountID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEncryptionKeyNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SaveKeyShares replaces the commitments to the Shamir shares of an account key part in the
+// encryption_key table.
+func (s *Storage) SaveKeyShares(ctx context.Context, accountID int64, shares []models.EncryptionKey) error {
+	const op = "storage.sqlite.SaveKeyShares"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM encryption_key WHERE account_id = ? AND share_index > 0`, accountID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO encryption_key (account_id, key_part, share_index, threshold, holder, key_version,
+		master_key_version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, accountID)
+	now := now()
+	for _, share := range shares {
+		if _, err := stmt.ExecContext(ctx, accountID, share.KeyPart, share.ShareIndex, share.Threshold, share.Holder, share.KeyVersion,
+			share.MasterKeyVersion, now, now); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// KeyShares retrieves the commitments to the Shamir shares of an account key part from the
+// encryption_key table
+func (s *Storage) KeyShares(ctx context.Context, accountID int64) ([]models.EncryptionKey, error) {
+	const op = "storage.sqlite.KeyShares"
+	query := `SELECT id, account_id, key_part, share_index, threshold, holder, key_version, master_key_version, created_at, updated_at
+		FROM encryption_key WHERE account_id = ? AND share_index > 0 ORDER BY share_index`
+	rows, err := s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var shares []models.EncryptionKey
+	for rows.Next() {
+		var share models.EncryptionKey
+		if err := rows.Scan(&share.ID, &share.AccountId, &share.KeyPart, &share.ShareIndex, &share.Threshold, &share.Holder,
+			&share.KeyVersion, &share.MasterKeyVersion, &share.CreatedAt, &share.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrKeySharesNotFound)
+	}
+
+	return shares, nil
+}
+
+// ListRevisions retrieves the revisions of an entry from the entry_revision table, newest first.
+// Only revisions made while the entry was in its current vault are listed. Revision data is
+// not loaded, use GetRevision for that.
+func (s *Storage) ListRevisions(ctx context.Context, accountID int64, entryID int64) ([]models.EntryRevision, error) {
+	const op = "storage.sqlite.ListRevisions"
+
+	ownerID, collectionID, err := revisionOwner(ctx, s.db, accountID, entryID, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `SELECT id, created_at, entry_id, account_id, action, entry_type, collection_id FROM entry_revision
+		WHERE account_id = ? AND entry_id = ? AND collection_id IS ? ORDER BY id DESC`
+	rows, err := s.db.QueryContext(ctx, query, ownerID, entryID, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []models.EntryRevision
+	for rows.Next() {
+		var revision models.EntryRevision
+		if err := rows.Scan(&revision.ID, &revision.CreatedAt, &revision.EntryID, &revision.AccountId, &revision.Action, &revision.EntryType, &revision.CollectionID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves a single revision of an entry from the entry_revision table
+func (s *Storage) GetRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) (*models.EntryRevision, error) {
+	const op = "storage.sqlite.GetRevision"
+
+	ownerID, collectionID, err := revisionOwner(ctx, s.db, accountID, entryID, false)
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, err := getRevision(ctx, s.db, ownerID, collectionID, entryID, revisionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revision, nil
+}
+
+// RestoreRevision sets an entry back to one of its revisions. A deleted entry is
+// recreated with its original ID, in the collection it was deleted from. The version
+// being replaced is kept as a new revision.
+func (s *Storage) RestoreRevision(ctx context.Context, accountID int64, entryID int64, revisionID int64) error {
+	const op = "storage.sqlite.RestoreRevision"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	ownerID, collectionID, err := revisionOwner(ctx, tx, accountID, entryID, true)
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		return fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revision, err := getRevision(ctx, tx, ownerID, collectionID, entryID, revisionID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	err = s.saveRevision(ctx, tx, ownerID, entryID, models.RevisionActionRestore)
+	switch {
+	case err == nil:
+		query := `UPDATE entry SET entry_type = ?, entry_data = ?, updated_at = ? WHERE id = ? AND account_id = ?`
+		if _, err := tx.ExecContext(ctx, query, revision.EntryType, revision.EntryData, now(), entryID, ownerID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	case errors.Is(err, storage.ErrEntryNotFound):
+		query := `INSERT INTO entry (id, account_id, entry_type, entry_data, collection_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, entryID, ownerID, revision.EntryType, revision.EntryData, collectionID, now(), now()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.pruneRevisions(ctx, tx, ownerID, entryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// now returns the current time in UTC. Timestamps are stored as text, so they
+// must share a time zone to compare and sort correctly.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+func getRevision(ctx context.Context, q querier, accountID int64, collectionID *int64, entryID int64, revisionID int64) (*models.EntryRevision, error) {
+	query := `SELECT id, created_at, entry_id, account_id, action, entry_type, entry_data, collection_id FROM entry_revision
+		WHERE id = ? AND account_id = ? AND entry_id = ? AND collection_id IS ?`
+
+	var revision models.EntryRevision
+	err := q.QueryRowContext(ctx, query, revisionID, accountID, entryID, collectionID).
+		Scan(&revision.ID, &revision.CreatedAt, &revision.EntryID, &revision.AccountId, &revision.Action, &revision.EntryType, &revision.EntryData, &revision.CollectionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// saveRevision copies the current version of an entry into the entry_revision table.
+// It returns storage.ErrEntryNotFound when the account has no such entry.
+func (s *Storage) saveRevision(ctx context.Context, tx *sql.Tx, accountID int64, entryID int64, action string) error {
+	query := `INSERT INTO entry_revision (entry_id, account_id, action, entry_type, entry_data, collection_id, created_at)
+		SELECT id, account_id, ?, entry_type, entry_data, collection_id, ? FROM entry WHERE id = ? AND account_id = ?`
+	result, err := tx.ExecContext(ctx, query, action, now(), entryID, accountID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrEntryNotFound
+	}
+	return nil
+}
+
+// pruneRevisions applies the revision retention: only the newest MaxCount revisions
+// of the entry are kept, and revisions older than MaxAge are dropped for all entries.
+func (s *Storage) pruneRevisions(ctx context.Context, tx *sql.Tx, accountID int64, entryID int64) error {
+	if s.retention.MaxCount > 0 {
+		query := `DELETE FROM entry_revision WHERE account_id = ? AND entry_id = ? AND id NOT IN
+			(SELECT id FROM entry_revision WHERE account_id = ? AND entry_id = ? ORDER BY id DESC LIMIT ?)`
+		if _, err := tx.ExecContext(ctx, query, accountID, entryID, accountID, entryID, s.retention.MaxCount); err != nil {
+			return err
+		}
+	}
+
+	if s.retention.MaxAge > 0 {
+		query := `DELETE FROM entry_revision WHERE created_at < ?`
+		if _, err := tx.ExecContext(ctx, query, now().Add(-s.retention.MaxAge)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
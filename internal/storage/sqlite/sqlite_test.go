@@ -0,0 +1,28 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"passvault/internal/storage"
+	"passvault/internal/storage/sqlite"
+	"passvault/internal/storage/storagetest"
+	"path/filepath"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, retention storage.RevisionRetention) storage.Backend {
+		path := filepath.Join(t.TempDir(), "passvault.db")
+
+		raw, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		storagetest.ApplyMigrations(t, raw, "../../../migrations")
+		require.NoError(t, raw.Close())
+
+		s, err := sqlite.New(path, retention)
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
@@ -1,8 +1,80 @@
 package storage
 
-import "errors"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 var (
 	ErrEntryNotFound         = errors.New("entry not found")
 	ErrEncryptionKeyNotFound = errors.New("encryption key not found")
+	ErrEncryptionKeyExists   = errors.New("encryption key already exists")
+	ErrEncryptionKeyInUse    = errors.New("encryption key is in use")
+	ErrKeySharesNotFound     = errors.New("key shares not found")
+	ErrRevisionNotFound      = errors.New("revision not found")
+	ErrFolderNotFound        = errors.New("folder not found")
+	ErrFolderExists          = errors.New("folder already exists")
+	ErrFolderCycle           = errors.New("folder cannot be moved into itself")
+	ErrTagNotFound           = errors.New("tag not found")
+	ErrTagExists             = errors.New("tag already exists")
+	ErrShareNotFound         = errors.New("share not found")
+	ErrInvalidShare          = errors.New("entries cannot be shared with their owner")
+	ErrAccessDenied          = errors.New("access denied")
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationNotEmpty  = errors.New("organization still has collections")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrLastOwner             = errors.New("organization must keep an owner")
+	ErrCollectionNotFound    = errors.New("collection not found")
+	ErrCollectionExists      = errors.New("collection already exists")
+	ErrCollectionNotEmpty    = errors.New("collection still has entries")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrInvalidSort           = errors.New("invalid sort")
+	ErrKDFParamsNotFound     = errors.New("KDF parameters not found")
+	ErrKDFParamsExist        = errors.New("KDF parameters already exist")
+	ErrVaultNotEmpty         = errors.New("vault already has entries")
+	ErrPlaintextRefused      = errors.New("account only accepts client-side encrypted entries")
+	ErrCiphertextRefused     = errors.New("client-side encrypted entries are not accepted here")
+	ErrKeyRotationNotFound   = errors.New("key rotation not found")
+	ErrKeyRotationRunning    = errors.New("key rotation already running")
 )
+
+// RevisionRetention limits how many previous entry versions are kept.
+// Zero values disable the corresponding limit.
+type RevisionRetention struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+// NewEntry is a personal entry saved by SaveEntries together with its blind index tokens.
+type NewEntry struct {
+	EntryType    string
+	EntryData    string
+	SearchTokens []string
+}
+
+// Cursor marks the position after the last entry of a page: the sort key and
+// the ID of that entry. Clients get it as an opaque string.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  int64  `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor made by Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,982 @@
+// Package storagetest is the conformance suite of storage backends. Every backend runs
+// it from its own tests, so they all behave the same behind storage.Backend.
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/lib/auditchain"
+	"passvault/internal/storage"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// NewBackend returns an empty backend with the given revision retention. The backend
+// must be closed when the test ends.
+type NewBackend func(t *testing.T, retention storage.RevisionRetention) storage.Backend
+
+// Run runs the conformance suite against the backends made by newBackend.
+func Run(t *testing.T, newBackend NewBackend) {
+	t.Run("Entries", func(t *testing.T) { testEntries(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("SaveEntries", func(t *testing.T) { testSaveEntries(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("KeyParts", func(t *testing.T) { testKeyParts(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("KeyRotation", func(t *testing.T) { testKeyRotation(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("OrganizationKeyRotation", func(t *testing.T) { testOrganizationKeyRotation(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("MasterKeyVersions", func(t *testing.T) { testMasterKeyVersions(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("KDFParams", func(t *testing.T) { testKDFParams(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("Revisions", func(t *testing.T) { testRevisions(t, newBackend(t, storage.RevisionRetention{MaxCount: 2})) })
+	t.Run("Folders", func(t *testing.T) { testFolders(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("Shares", func(t *testing.T) { testShares(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("Organizations", func(t *testing.T) { testOrganizations(t, newBackend(t, storage.RevisionRetention{})) })
+	t.Run("AuditLog", func(t *testing.T) { testAuditLog(t, newBackend(t, storage.RevisionRetention{})) })
+}
+
+// ApplyMigrations executes the up migrations found in dir in version order.
+func ApplyMigrations(t *testing.T, db *sql.DB, dir string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	sort.Slice(files, func(i, j int) bool { return migrationVersion(files[i]) < migrationVersion(files[j]) })
+
+	for _, file := range files {
+		schema, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = db.Exec(string(schema))
+		require.NoError(t, err, file)
+	}
+}
+
+func migrationVersion(file string) int {
+	version, _ := strconv.Atoi(strings.SplitN(filepath.Base(file), "_", 2)[0])
+	return version
+}
+
+func testEntries(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	id, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "first")
+	require.NoError(t, err)
+
+	entry, err := s.GetEntry(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, id, entry.ID)
+	require.Equal(t, int64(1), entry.AccountId)
+	require.Equal(t, models.EntryTypeLogin, entry.EntryType)
+	require.Equal(t, "first", entry.EntryData)
+	require.Nil(t, entry.CollectionID)
+
+	_, err = s.GetEntry(ctx, 2, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 2, id, models.EntryTypeLogin, "stolen"), storage.ErrEntryNotFound)
+	require.ErrorIs(t, s.DeleteEntry(ctx, 2, id), storage.ErrEntryNotFound)
+
+	require.NoError(t, s.UpdateEntry(ctx, 1, id, models.EntryTypeLogin, "updated"))
+	entry, err = s.GetEntry(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, "updated", entry.EntryData)
+	require.False(t, entry.UpdatedAt.Before(entry.CreatedAt))
+
+	second, err := s.SaveEntry(ctx, 1, models.EntryTypeSecureNote, "second")
+	require.NoError(t, err)
+	_, err = s.SaveEntry(ctx, 2, models.EntryTypeLogin, "other account")
+	require.NoError(t, err)
+
+	entries, err := s.ListEntries(ctx, 1, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []int64{id, second}, entryIDs(entries))
+
+	entries, err = s.ListEntries(ctx, 1, models.EntryFilter{EntryType: models.EntryTypeSecureNote})
+	require.NoError(t, err)
+	require.Equal(t, []int64{second}, entryIDs(entries))
+
+	page, err := s.ListEntriesPage(ctx, 1, models.EntryQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []int64{id}, entryIDs(page.Entries))
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = s.ListEntriesPage(ctx, 1, models.EntryQuery{Limit: 1, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, []int64{second}, entryIDs(page.Entries))
+	require.Empty(t, page.NextCursor)
+
+	page, err = s.ListEntriesPage(ctx, 1, models.EntryQuery{Descending: true})
+	require.NoError(t, err)
+	require.Equal(t, []int64{second, id}, entryIDs(page.Entries))
+	require.Empty(t, page.NextCursor)
+
+	_, err = s.ListEntriesPage(ctx, 1, models.EntryQuery{Sort: models.EntrySortName})
+	require.ErrorIs(t, err, storage.ErrInvalidSort)
+	_, err = s.ListEntriesPage(ctx, 1, models.EntryQuery{Cursor: "garbage"})
+	require.ErrorIs(t, err, storage.ErrInvalidCursor)
+
+	access, err := s.EntryAccess(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, models.EntryAccess{EntryID: id, OwnerID: 1, Read: true, Write: true, Delete: true}, *access)
+	_, err = s.EntryAccess(ctx, 2, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	require.NoError(t, s.DeleteEntry(ctx, 1, id))
+	_, err = s.GetEntry(ctx, 1, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+}
+
+func testSaveEntries(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	ids, err := s.SaveEntries(ctx, 1, nil)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	ids, err = s.SaveEntries(ctx, 1, []storage.NewEntry{
+		{EntryType: models.EntryTypeLogin, EntryData: "first", SearchTokens: []string{"a", "b"}},
+		{EntryType: models.EntryTypeSecureNote, EntryData: "second"},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	entries, err := s.ListEntries(ctx, 1, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Equal(t, ids, entryIDs(entries))
+	require.Equal(t, "first", entries[0].EntryData)
+	require.Equal(t, models.EntryTypeSecureNote, entries[1].EntryType)
+
+	found, err := s.FindEntriesByTokens(ctx, 1, []string{"b"})
+	require.NoError(t, err)
+	require.Equal(t, ids[:1], found)
+
+	entries, err = s.ListEntries(ctx, 2, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func testKeyParts(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	_, err := s.RetrieveKeyPart(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	_, err = s.SaveKeyPart(ctx, 1, "part", 1)
+	require.NoError(t, err)
+	_, err = s.SaveKeyPart(ctx, 1, "another part", 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyExists)
+
+	_, err = s.KeyShares(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrKeySharesNotFound)
+
+	require.NoError(t, s.SaveKeyShares(ctx, 1, keyShares(1, 2, "a", "b", "c")))
+	require.NoError(t, s.SaveKeyShares(ctx, 1, keyShares(1, 2, "d", "e")))
+	shares, err := s.KeyShares(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	for i, share := range shares {
+		require.Equal(t, i+1, share.ShareIndex)
+		require.Equal(t, 2, share.Threshold)
+		require.Equal(t, 1, share.KeyVersion)
+		require.Equal(t, "holder "+strconv.Itoa(i+1), share.Holder)
+	}
+	require.Equal(t, "d", shares[0].KeyPart)
+
+	keyPart, err := s.RetrieveKeyPart(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "part", keyPart)
+
+	// A key part in use can only be deleted while its shares can restore it.
+	_, err = s.SaveEntry(ctx, 1, models.EntryTypeLogin, "data")
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteKeyPart(ctx, 1))
+	_, err = s.RetrieveKeyPart(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+	_, err = s.KeyShares(ctx, 1)
+	require.NoError(t, err)
+
+	// Only the shares can bring the key part back, a fresh one would not decrypt the entries.
+	_, err = s.SaveKeyPart(ctx, 1, "fresh part", 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyExists)
+
+	require.NoError(t, s.RestoreKeyPart(ctx, 1, 1, 1, "part"))
+	require.ErrorIs(t, s.RestoreKeyPart(ctx, 1, 1, 1, "part"), storage.ErrEncryptionKeyExists)
+	keys, err := s.KeyParts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, 1, keys[0].KeyVersion)
+	require.Equal(t, "part", keys[0].KeyPart)
+
+	// Shares of another key version cannot restore the current one.
+	require.NoError(t, s.SaveKeyShares(ctx, 1, keyShares(3, 2, "f", "g")))
+	require.ErrorIs(t, s.DeleteKeyPart(ctx, 1), storage.ErrEncryptionKeyInUse)
+	require.ErrorIs(t, s.DeleteKeyPart(ctx, 2), storage.ErrEncryptionKeyNotFound)
+
+	_, err = s.SaveEntry(ctx, 2, models.EntryTypeLogin, "data")
+	require.NoError(t, err)
+	_, err = s.SaveKeyPart(ctx, 2, "part of 2", 1)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.DeleteKeyPart(ctx, 2), storage.ErrEncryptionKeyInUse)
+
+	_, err = s.SaveKeyPart(ctx, 3, "unused part", 1)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteKeyPart(ctx, 3))
+	_, err = s.RetrieveKeyPart(ctx, 3)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+
+	// Restored key parts keep the key version they were split from.
+	require.NoError(t, s.RestoreKeyPart(ctx, 3, 4, 1, "restored part"))
+	keys, err = s.KeyParts(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, 4, keys[0].KeyVersion)
+}
+
+// keyShares returns share commitments of keyVersion with indexes and holders numbered from 1.
+func keyShares(keyVersion, threshold int, commitments ...string) []models.EncryptionKey {
+	shares := make([]models.EncryptionKey, len(commitments))
+	for i, commitment := range commitments {
+		shares[i] = models.EncryptionKey{
+			KeyPart:    commitment,
+			ShareIndex: i + 1,
+			Threshold:  threshold,
+			Holder:     "holder " + strconv.Itoa(i+1),
+			KeyVersion: keyVersion,
+		}
+	}
+	return shares
+}
+
+func testKeyRotation(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	_, err := s.StartKeyRotation(ctx, 1, "part 2", 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyNotFound)
+	_, err = s.KeyRotation(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrKeyRotationNotFound)
+
+	_, err = s.SaveKeyPart(ctx, 1, "part 1", 1)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveKeyShares(ctx, 1, keyShares(1, 2, "a", "b")))
+
+	rotation, err := s.StartKeyRotation(ctx, 1, "part 2", 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, rotation.FromVersion)
+	require.Equal(t, 2, rotation.ToVersion)
+	require.Equal(t, models.KeyRotationRunning, rotation.Status)
+	require.Equal(t, 2, rotation.SharesRemoved)
+	_, err = s.StartKeyRotation(ctx, 1, "part 3", 1)
+	require.ErrorIs(t, err, storage.ErrKeyRotationRunning)
+
+	// The new version is current, the old one stays until it is retired, and the
+	// shares of the old key part are gone.
+	keyPart, err := s.RetrieveKeyPart(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "part 2", keyPart)
+	keys, err := s.KeyParts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, []int{1, 2}, []int{keys[0].KeyVersion, keys[1].KeyVersion})
+	require.Equal(t, "part 1", keys[0].KeyPart)
+	_, err = s.KeyShares(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrKeySharesNotFound)
+	_, err = s.SaveKeyPart(ctx, 1, "part", 1)
+	require.ErrorIs(t, err, storage.ErrEncryptionKeyExists)
+
+	first, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "old 1")
+	require.NoError(t, err)
+	second, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "old 2")
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateEntry(ctx, 1, second, models.EntryTypeLogin, "old 3"))
+	_, err = s.SaveEntry(ctx, 2, models.EntryTypeLogin, "other account")
+	require.NoError(t, err)
+	tag, err := s.CreateTag(ctx, 1, "work")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryTags(ctx, 1, first, []int64{tag}))
+
+	entries, err := s.PersonalEntries(ctx, 1, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, first, entries[0].ID)
+	require.Equal(t, []int64{tag}, entries[0].TagIDs)
+	entries, err = s.PersonalEntries(ctx, 1, first, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "old 3", entries[0].EntryData)
+
+	require.NoError(t, s.RewrapEntry(ctx, 1, first, "old 1", "new 1", []string{"token"}))
+	// Entries changed since they were read are left alone.
+	require.NoError(t, s.RewrapEntry(ctx, 1, second, "old 2", "new 2", nil))
+	entry, err := s.GetEntry(ctx, 1, first)
+	require.NoError(t, err)
+	require.Equal(t, "new 1", entry.EntryData)
+	entry, err = s.GetEntry(ctx, 1, second)
+	require.NoError(t, err)
+	require.Equal(t, "old 3", entry.EntryData)
+	ids, err := s.FindEntriesByTokens(ctx, 1, []string{"token"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{first}, ids)
+
+	revisions, err := s.PersonalRevisions(ctx, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, "old 2", revisions[0].EntryData)
+	require.NoError(t, s.RewrapRevision(ctx, 1, revisions[0].ID, "old 2", "new 2"))
+	revision, err := s.GetRevision(ctx, 1, second, revisions[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, "new 2", revision.EntryData)
+
+	finished := time.Now().UTC().Truncate(time.Second)
+	rotation.Status = models.KeyRotationDone
+	rotation.EntryCursor = second
+	rotation.RevisionCursor = revisions[0].ID
+	rotation.Entries = 2
+	rotation.Revisions = 1
+	rotation.FinishedAt = &finished
+	require.NoError(t, s.UpdateKeyRotation(ctx, *rotation))
+
+	// The entry updated behind the cursor is still sealed under the old key, as is one
+	// written by a request that loaded the keys before the rotation started.
+	late, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "old 4")
+	require.NoError(t, err)
+	require.ErrorIs(t, s.RetireKeyParts(ctx, 1, rotation.ToVersion, "new"), storage.ErrEncryptionKeyInUse)
+	keys, err = s.KeyParts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	require.NoError(t, s.RewrapEntry(ctx, 1, second, "old 3", "new 3", nil))
+	require.NoError(t, s.RewrapEntry(ctx, 1, late, "old 4", "new 4", nil))
+	require.NoError(t, s.RetireKeyParts(ctx, 1, rotation.ToVersion, "new"))
+
+	keys, err = s.KeyParts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, 2, keys[0].KeyVersion)
+
+	stored, err := s.KeyRotation(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationDone, stored.Status)
+	require.Equal(t, 2, stored.Entries)
+	require.Equal(t, 2, stored.SharesRemoved)
+	require.Equal(t, revisions[0].ID, stored.RevisionCursor)
+	require.NotNil(t, stored.FinishedAt)
+	require.True(t, finished.Equal(*stored.FinishedAt))
+
+	running, err := s.ListKeyRotations(ctx, models.KeyRotationRunning)
+	require.NoError(t, err)
+	require.Empty(t, running)
+
+	rotation, err = s.StartKeyRotation(ctx, 1, "part 3", 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, rotation.ToVersion)
+	running, err = s.ListKeyRotations(ctx, models.KeyRotationRunning)
+	require.NoError(t, err)
+	require.Len(t, running, 1)
+	require.Equal(t, rotation.ID, running[0].ID)
+
+	// A finished rotation cannot run again next to the running one.
+	stored.Status = models.KeyRotationRunning
+	require.ErrorIs(t, s.UpdateKeyRotation(ctx, *stored), storage.ErrKeyRotationRunning)
+	stored.ID = rotation.ID + 100
+	require.ErrorIs(t, s.UpdateKeyRotation(ctx, *stored), storage.ErrKeyRotationNotFound)
+}
+
+func testOrganizationKeyRotation(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	_, err := s.StartOrganizationKeyRotation(ctx, 1, "org part 2", 1)
+	require.ErrorIs(t, err, storage.ErrOrganizationNotFound)
+
+	orgID, err := s.CreateOrganization(ctx, 1, "Acme", "org part 1", 1)
+	require.NoError(t, err)
+	collection, err := s.CreateCollection(ctx, orgID, "Shared")
+	require.NoError(t, err)
+	first, err := s.SaveCollectionEntry(ctx, 1, collection, models.EntryTypeLogin, "old 1")
+	require.NoError(t, err)
+	second, err := s.SaveCollectionEntry(ctx, 1, collection, models.EntryTypeLogin, "old 2")
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateEntry(ctx, 1, second, models.EntryTypeLogin, "old 3"))
+	_, err = s.SaveEntry(ctx, 1, models.EntryTypeLogin, "personal")
+	require.NoError(t, err)
+
+	// Organization rotations run next to the rotations of accounts with the same ID.
+	_, err = s.SaveKeyPart(ctx, orgID, "part 1", 1)
+	require.NoError(t, err)
+	_, err = s.StartKeyRotation(ctx, orgID, "part 2", 1)
+	require.NoError(t, err)
+
+	rotation, err := s.StartOrganizationKeyRotation(ctx, orgID, "org part 2", 2)
+	require.NoError(t, err)
+	require.Equal(t, orgID, rotation.OrganizationID)
+	require.Zero(t, rotation.AccountID)
+	require.Equal(t, 2, rotation.ToVersion)
+	_, err = s.StartOrganizationKeyRotation(ctx, orgID, "org part 3", 2)
+	require.ErrorIs(t, err, storage.ErrKeyRotationRunning)
+
+	keyParts, err := s.OrganizationKeyParts(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, keyParts, 2)
+	require.Equal(t, "org part 2", keyParts[1].KeyPart)
+	require.Equal(t, 2, keyParts[1].MasterKeyVersion)
+
+	entries, err := s.CollectionEntries(ctx, orgID, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, []int64{first}, entryIDs(entries))
+	entries, err = s.CollectionEntries(ctx, orgID, first, 10)
+	require.NoError(t, err)
+	require.Equal(t, []int64{second}, entryIDs(entries))
+	entries, err = s.CollectionEntries(ctx, orgID+100, 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, s.RewrapCollectionEntry(ctx, orgID, first, "old 1", "new 1"))
+	// Entries changed since they were read are left alone.
+	require.NoError(t, s.RewrapCollectionEntry(ctx, orgID, second, "old 2", "new 2"))
+	entry, err := s.GetEntry(ctx, 1, first)
+	require.NoError(t, err)
+	require.Equal(t, "new 1", entry.EntryData)
+	entry, err = s.GetEntry(ctx, 1, second)
+	require.NoError(t, err)
+	require.Equal(t, "old 3", entry.EntryData)
+
+	revisions, err := s.CollectionRevisions(ctx, orgID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, "old 2", revisions[0].EntryData)
+	require.NoError(t, s.RewrapCollectionRevision(ctx, orgID, revisions[0].ID, "old 2", "new 2"))
+	revision, err := s.GetRevision(ctx, 1, second, revisions[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, "new 2", revision.EntryData)
+
+	rotation.Status = models.KeyRotationDone
+	require.NoError(t, s.UpdateKeyRotation(ctx, *rotation))
+	require.ErrorIs(t, s.RetireOrganizationKeyParts(ctx, orgID, rotation.ToVersion, "new"), storage.ErrEncryptionKeyInUse)
+	keyParts, err = s.OrganizationKeyParts(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, keyParts, 2)
+	// The personal entry is not the organization's and does not hold the key back.
+	require.NoError(t, s.RewrapCollectionEntry(ctx, orgID, second, "old 3", "new 3"))
+	require.NoError(t, s.RetireOrganizationKeyParts(ctx, orgID, rotation.ToVersion, "new"))
+	keyParts, err = s.OrganizationKeyParts(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, keyParts, 1)
+	require.Equal(t, 2, keyParts[0].KeyVersion)
+
+	stored, err := s.OrganizationKeyRotation(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, rotation.ID, stored.ID)
+	require.Equal(t, models.KeyRotationDone, stored.Status)
+	accountRotation, err := s.KeyRotation(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, models.KeyRotationRunning, accountRotation.Status)
+	_, err = s.OrganizationKeyRotation(ctx, orgID+100)
+	require.ErrorIs(t, err, storage.ErrKeyRotationNotFound)
+}
+
+func testMasterKeyVersions(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	_, err := s.SaveKeyPart(ctx, 1, "part 1", 1)
+	require.NoError(t, err)
+	_, err = s.SaveKeyPart(ctx, 2, "part 2", 2)
+	require.NoError(t, err)
+	_, err = s.SaveKeyPart(ctx, 3, "part 3", 1)
+	require.NoError(t, err)
+	// Accounts whose key part is awaiting recovery are outdated through their shares.
+	shares := keyShares(1, 2, "a", "b")
+	for i := range shares {
+		shares[i].MasterKeyVersion = 1
+	}
+	require.NoError(t, s.SaveKeyShares(ctx, 4, shares))
+	_, err = s.CreateOrganization(ctx, 1, "Old", "org part", 1)
+	require.NoError(t, err)
+	current, err := s.CreateOrganization(ctx, 1, "Current", "org part", 2)
+	require.NoError(t, err)
+
+	keys, err := s.KeyParts(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, keys[0].MasterKeyVersion)
+
+	accounts, err := s.OutdatedAccounts(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 3, 4}, accounts)
+	accounts, err = s.OutdatedAccounts(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	orgs, err := s.OutdatedOrganizations(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	require.NotEqual(t, current, orgs[0])
+
+	// Rotating moves an account to the master key of its new key part once the old one is retired.
+	rotation, err := s.StartKeyRotation(ctx, 1, "part 1 rotated", 2)
+	require.NoError(t, err)
+	require.NoError(t, s.RetireKeyParts(ctx, 1, rotation.ToVersion, "v1:k2:"))
+	accounts, err = s.OutdatedAccounts(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 4}, accounts)
+
+	// Key parts restored from shares keep the master key version they were split under.
+	require.NoError(t, s.RestoreKeyPart(ctx, 4, 1, 1, "restored"))
+	keys, err = s.KeyParts(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, 1, keys[0].MasterKeyVersion)
+}
+
+func testKDFParams(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	_, err := s.KDFParams(ctx, 1)
+	require.ErrorIs(t, err, storage.ErrKDFParamsNotFound)
+
+	want := models.KDFParams{
+		AccountID:       1,
+		Algorithm:       "argon2id",
+		Iterations:      3,
+		Memory:          65536,
+		Parallelism:     4,
+		Salt:            "c2FsdHNhbHRzYWx0c2FsdA==",
+		KeyCheck:        "zk1:check",
+		ProtocolVersion: 1,
+	}
+
+	// Entries and their revisions saved before hold plaintext, so only empty vaults opt in.
+	id, err := s.SaveEntry(ctx, 2, models.EntryTypeLogin, "plaintext")
+	require.NoError(t, err)
+	other := want
+	other.AccountID = 2
+	require.ErrorIs(t, s.SaveKDFParams(ctx, other), storage.ErrVaultNotEmpty)
+	require.NoError(t, s.DeleteEntry(ctx, 2, id))
+	require.ErrorIs(t, s.SaveKDFParams(ctx, other), storage.ErrVaultNotEmpty)
+
+	require.NoError(t, s.SaveKDFParams(ctx, want))
+
+	other = want
+	other.Salt = "b3RoZXJvdGhlcm90aGVyIQ=="
+	require.ErrorIs(t, s.SaveKDFParams(ctx, other), storage.ErrKDFParamsExist)
+
+	params, err := s.KDFParams(ctx, 1)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), params.CreatedAt, time.Minute)
+	want.CreatedAt = params.CreatedAt
+	require.Equal(t, want, *params)
+
+	_, err = s.KDFParams(ctx, 2)
+	require.ErrorIs(t, err, storage.ErrKDFParamsNotFound)
+}
+
+func testRevisions(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	id, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "v1")
+	require.NoError(t, err)
+	for _, data := range []string{"v2", "v3", "v4"} {
+		require.NoError(t, s.UpdateEntry(ctx, 1, id, models.EntryTypeLogin, data))
+	}
+
+	// Only the two newest revisions are kept.
+	revisions, err := s.ListRevisions(ctx, 1, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, models.RevisionActionUpdate, revisions[0].Action)
+	require.Empty(t, revisions[0].EntryData)
+
+	revision, err := s.GetRevision(ctx, 1, id, revisions[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, "v2", revision.EntryData)
+
+	_, err = s.GetRevision(ctx, 2, id, revisions[1].ID)
+	require.ErrorIs(t, err, storage.ErrRevisionNotFound)
+	require.ErrorIs(t, s.RestoreRevision(ctx, 1, id, revisions[1].ID+100), storage.ErrRevisionNotFound)
+
+	require.NoError(t, s.RestoreRevision(ctx, 1, id, revisions[1].ID))
+	entry, err := s.GetEntry(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, "v2", entry.EntryData)
+
+	require.NoError(t, s.DeleteEntry(ctx, 1, id))
+	revisions, err = s.ListRevisions(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, models.RevisionActionDelete, revisions[0].Action)
+	_, err = s.ListRevisions(ctx, 2, id)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	// Deleted entries come back with their ID.
+	require.NoError(t, s.RestoreRevision(ctx, 1, id, revisions[0].ID))
+	entry, err = s.GetEntry(ctx, 1, id)
+	require.NoError(t, err)
+	require.Equal(t, "v2", entry.EntryData)
+}
+
+func testFolders(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	parent, err := s.CreateFolder(ctx, 1, nil, "Work")
+	require.NoError(t, err)
+	child, err := s.CreateFolder(ctx, 1, &parent, "Servers")
+	require.NoError(t, err)
+
+	_, err = s.CreateFolder(ctx, 1, nil, "Work")
+	require.ErrorIs(t, err, storage.ErrFolderExists)
+	_, err = s.CreateFolder(ctx, 2, &parent, "Stolen")
+	require.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+	require.ErrorIs(t, s.MoveFolder(ctx, 1, parent, &child), storage.ErrFolderCycle)
+	require.ErrorIs(t, s.RenameFolder(ctx, 2, child, "Hosts"), storage.ErrFolderNotFound)
+	require.NoError(t, s.RenameFolder(ctx, 1, child, "Hosts"))
+	require.NoError(t, s.MoveFolder(ctx, 1, child, nil))
+	require.NoError(t, s.MoveFolder(ctx, 1, child, &parent))
+
+	entry, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "data")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryFolder(ctx, 1, entry, &child))
+	require.ErrorIs(t, s.SetEntryFolder(ctx, 2, entry, nil), storage.ErrEntryNotFound)
+
+	entries, err := s.ListEntries(ctx, 1, models.EntryFilter{FolderID: &child})
+	require.NoError(t, err)
+	require.Equal(t, []int64{entry}, entryIDs(entries))
+	none := int64(0)
+	entries, err = s.ListEntries(ctx, 1, models.EntryFilter{FolderID: &none})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Entries of a deleted folder move up to its parent.
+	require.NoError(t, s.DeleteFolder(ctx, 1, child))
+	require.ErrorIs(t, s.DeleteFolder(ctx, 1, child), storage.ErrFolderNotFound)
+	got, err := s.GetEntry(ctx, 1, entry)
+	require.NoError(t, err)
+	require.Equal(t, &parent, got.FolderID)
+
+	folders, err := s.ListFolders(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	require.Equal(t, "Work", folders[0].Name)
+	require.Nil(t, folders[0].ParentID)
+}
+
+func testTags(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	alpha, err := s.CreateTag(ctx, 1, "alpha")
+	require.NoError(t, err)
+	beta, err := s.CreateTag(ctx, 1, "beta")
+	require.NoError(t, err)
+	_, err = s.CreateTag(ctx, 1, "alpha")
+	require.ErrorIs(t, err, storage.ErrTagExists)
+	other, err := s.CreateTag(ctx, 2, "gamma")
+	require.NoError(t, err)
+
+	entry, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "data")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryTags(ctx, 1, entry, []int64{beta, alpha, beta}))
+	require.ErrorIs(t, s.SetEntryTags(ctx, 1, entry, []int64{other}), storage.ErrTagNotFound)
+
+	got, err := s.GetEntry(ctx, 1, entry)
+	require.NoError(t, err)
+	require.Equal(t, []int64{alpha, beta}, got.TagIDs)
+
+	entries, err := s.ListEntries(ctx, 1, models.EntryFilter{TagIDs: []int64{beta}})
+	require.NoError(t, err)
+	require.Equal(t, []int64{entry}, entryIDs(entries))
+
+	require.ErrorIs(t, s.RenameTag(ctx, 1, beta, "alpha"), storage.ErrTagExists)
+	require.NoError(t, s.RenameTag(ctx, 1, beta, "bravo"))
+
+	require.NoError(t, s.DeleteTag(ctx, 1, alpha))
+	require.ErrorIs(t, s.DeleteTag(ctx, 1, alpha), storage.ErrTagNotFound)
+	got, err = s.GetEntry(ctx, 1, entry)
+	require.NoError(t, err)
+	require.Equal(t, []int64{beta}, got.TagIDs)
+
+	tags, err := s.ListTags(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	require.Equal(t, "bravo", tags[0].Name)
+}
+
+func testSearch(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	first, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "first")
+	require.NoError(t, err)
+	second, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "second")
+	require.NoError(t, err)
+	other, err := s.SaveEntry(ctx, 2, models.EntryTypeLogin, "other")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetSearchTokens(ctx, 1, first, []string{"t1", "t2", "t2"}))
+	require.NoError(t, s.SetSearchTokens(ctx, 1, second, []string{"t2"}))
+	require.NoError(t, s.SetSearchTokens(ctx, 2, other, []string{"t1"}))
+
+	ids, err := s.FindEntriesByTokens(ctx, 1, []string{"t2"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{first, second}, ids)
+
+	ids, err = s.FindEntriesByTokens(ctx, 1, []string{"t1", "t2"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{first}, ids)
+
+	// Tag names are only found through the tokens indexed for them.
+	tag, err := s.CreateTag(ctx, 1, "t1")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryTags(ctx, 1, second, []int64{tag}))
+	ids, err = s.FindEntriesByTokens(ctx, 1, []string{"t1"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{first}, ids)
+
+	require.NoError(t, s.SetSearchTokens(ctx, 1, first, nil))
+	ids, err = s.FindEntriesByTokens(ctx, 1, []string{"t1"})
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
+
+func testShares(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	entry, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "data")
+	require.NoError(t, err)
+
+	_, err = s.ShareEntry(ctx, 1, entry, 1, models.SharePermissionRead, nil)
+	require.ErrorIs(t, err, storage.ErrInvalidShare)
+	_, err = s.ShareEntry(ctx, 2, entry, 3, models.SharePermissionRead, nil)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	shareID, err := s.ShareEntry(ctx, 1, entry, 2, models.SharePermissionRead, nil)
+	require.NoError(t, err)
+
+	got, err := s.GetEntry(ctx, 2, entry)
+	require.NoError(t, err)
+	require.Equal(t, "data", got.EntryData)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 2, entry, models.EntryTypeLogin, "changed"), storage.ErrAccessDenied)
+	require.ErrorIs(t, s.DeleteEntry(ctx, 2, entry), storage.ErrAccessDenied)
+
+	access, err := s.EntryAccess(ctx, 2, entry)
+	require.NoError(t, err)
+	require.True(t, access.Read)
+	require.False(t, access.Write)
+
+	// Sharing again replaces the permission.
+	again, err := s.ShareEntry(ctx, 1, entry, 2, models.SharePermissionWrite, nil)
+	require.NoError(t, err)
+	require.Equal(t, shareID, again)
+	require.NoError(t, s.UpdateEntry(ctx, 2, entry, models.EntryTypeLogin, "changed"))
+	got, err = s.GetEntry(ctx, 1, entry)
+	require.NoError(t, err)
+	require.Equal(t, "changed", got.EntryData)
+
+	_, err = s.ListRevisions(ctx, 2, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	expired := time.Now().Add(-time.Hour)
+	_, err = s.ShareEntry(ctx, 1, entry, 3, models.SharePermissionRead, &expired)
+	require.NoError(t, err)
+	_, err = s.GetEntry(ctx, 3, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	shares, err := s.ListShares(ctx, 1, entry)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	require.NotNil(t, shares[1].ExpiresAt)
+	_, err = s.ListShares(ctx, 2, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	received, err := s.ListReceivedShares(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	require.Equal(t, models.SharePermissionWrite, received[0].Permission)
+	received, err = s.ListReceivedShares(ctx, 3)
+	require.NoError(t, err)
+	require.Empty(t, received)
+
+	require.NoError(t, s.RevokeShare(ctx, 1, entry, 2))
+	require.ErrorIs(t, s.RevokeShare(ctx, 1, entry, 2), storage.ErrShareNotFound)
+	_, err = s.GetEntry(ctx, 2, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+}
+
+func testOrganizations(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	orgID, err := s.CreateOrganization(ctx, 1, "Acme", "org part", 1)
+	require.NoError(t, err)
+
+	orgs, err := s.ListOrganizations(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	require.Equal(t, "Acme", orgs[0].Name)
+	require.Equal(t, models.OrgRoleOwner, orgs[0].Role)
+	orgs, err = s.ListOrganizations(ctx, 2)
+	require.NoError(t, err)
+	require.Empty(t, orgs)
+
+	keyParts, err := s.OrganizationKeyParts(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, keyParts, 1)
+	require.Equal(t, "org part", keyParts[0].KeyPart)
+	require.Equal(t, 1, keyParts[0].KeyVersion)
+	require.Equal(t, 1, keyParts[0].MasterKeyVersion)
+	_, err = s.OrganizationKeyParts(ctx, orgID+100)
+	require.ErrorIs(t, err, storage.ErrOrganizationNotFound)
+
+	_, err = s.MemberRole(ctx, orgID, 2)
+	require.ErrorIs(t, err, storage.ErrMemberNotFound)
+	require.NoError(t, s.SetMemberRole(ctx, orgID, 2, models.OrgRoleEditor))
+	require.NoError(t, s.SetMemberRole(ctx, orgID, 3, models.OrgRoleViewer))
+	role, err := s.MemberRole(ctx, orgID, 2)
+	require.NoError(t, err)
+	require.Equal(t, models.OrgRoleEditor, role)
+
+	members, err := s.ListMembers(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	require.Equal(t, int64(1), members[0].AccountID)
+
+	require.ErrorIs(t, s.SetMemberRole(ctx, orgID, 1, models.OrgRoleAdmin), storage.ErrLastOwner)
+	require.ErrorIs(t, s.RemoveMember(ctx, orgID, 1), storage.ErrLastOwner)
+
+	collection, err := s.CreateCollection(ctx, orgID, "Shared")
+	require.NoError(t, err)
+	_, err = s.CreateCollection(ctx, orgID, "Shared")
+	require.ErrorIs(t, err, storage.ErrCollectionExists)
+	owner, err := s.CollectionOrganization(ctx, collection)
+	require.NoError(t, err)
+	require.Equal(t, orgID, owner)
+
+	entry, err := s.SaveCollectionEntry(ctx, 2, collection, models.EntryTypeLogin, "shared")
+	require.NoError(t, err)
+	_, err = s.SaveCollectionEntry(ctx, 3, collection, models.EntryTypeLogin, "viewer")
+	require.ErrorIs(t, err, storage.ErrAccessDenied)
+	_, err = s.SaveCollectionEntry(ctx, 4, collection, models.EntryTypeLogin, "outsider")
+	require.ErrorIs(t, err, storage.ErrCollectionNotFound)
+
+	got, err := s.GetEntry(ctx, 3, entry)
+	require.NoError(t, err)
+	require.Equal(t, &collection, got.CollectionID)
+	_, err = s.GetEntry(ctx, 4, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+	require.ErrorIs(t, s.UpdateEntry(ctx, 3, entry, models.EntryTypeLogin, "viewer"), storage.ErrAccessDenied)
+	require.NoError(t, s.UpdateEntry(ctx, 1, entry, models.EntryTypeLogin, "owner"))
+
+	revisions, err := s.ListRevisions(ctx, 3, entry)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	entries, err := s.ListEntries(ctx, 3, models.EntryFilter{CollectionID: &collection})
+	require.NoError(t, err)
+	require.Equal(t, []int64{entry}, entryIDs(entries))
+	entries, err = s.ListEntries(ctx, 4, models.EntryFilter{CollectionID: &collection})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	entries, err = s.ListEntries(ctx, 2, models.EntryFilter{})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	personal, err := s.SaveEntry(ctx, 1, models.EntryTypeLogin, "personal")
+	require.NoError(t, err)
+	require.NoError(t, s.SetEntryCollection(ctx, 1, personal, &collection, "moved"))
+	_, err = s.GetEntry(ctx, 3, personal)
+	require.NoError(t, err)
+
+	// Entries moved out of a collection belong to the account moving them.
+	require.ErrorIs(t, s.SetEntryCollection(ctx, 3, entry, nil, "viewer"), storage.ErrAccessDenied)
+	require.NoError(t, s.SetEntryCollection(ctx, 2, entry, nil, "mine"))
+	got, err = s.GetEntry(ctx, 2, entry)
+	require.NoError(t, err)
+	require.Nil(t, got.CollectionID)
+	require.Equal(t, int64(2), got.AccountId)
+	_, err = s.GetEntry(ctx, 3, entry)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	require.ErrorIs(t, s.DeleteCollection(ctx, orgID, collection), storage.ErrCollectionNotEmpty)
+	require.ErrorIs(t, s.DeleteOrganization(ctx, orgID), storage.ErrOrganizationNotEmpty)
+
+	require.NoError(t, s.DeleteEntry(ctx, 2, personal))
+	require.NoError(t, s.DeleteCollection(ctx, orgID, collection))
+	require.ErrorIs(t, s.DeleteCollection(ctx, orgID, collection), storage.ErrCollectionNotFound)
+
+	require.NoError(t, s.RemoveMember(ctx, orgID, 3))
+	require.ErrorIs(t, s.RemoveMember(ctx, orgID, 3), storage.ErrMemberNotFound)
+	require.NoError(t, s.DeleteOrganization(ctx, orgID))
+	require.ErrorIs(t, s.DeleteOrganization(ctx, orgID), storage.ErrOrganizationNotFound)
+}
+
+func testAuditLog(t *testing.T, s storage.Backend) {
+	ctx := context.Background()
+
+	chainKey := []byte("0123456789abcdef0123456789abcdef")
+	entryID := int64(7)
+	events := []models.AuditEvent{
+		{AccountID: 1, Action: models.AuditEntryCreate, EntryID: &entryID, Status: 201},
+		{AccountID: 2, Action: models.AuditKeyRead, Status: 200},
+		{AccountID: 1, Action: models.AuditEntryRead, EntryID: &entryID, Status: 200},
+		{AccountID: 1, Action: models.AuditKeyRead, Status: 404},
+	}
+	for i, event := range events {
+		event.RequestID = "request-" + strconv.Itoa(i)
+		event.IP = "192.0.2.1"
+		event.UserAgent = "storagetest"
+
+		id, err := s.AppendAuditEvent(ctx, event, chainKey)
+		require.NoError(t, err)
+		require.Equal(t, int64(i+1), id)
+	}
+
+	listed, err := s.ListAuditEvents(ctx, 1, models.AuditFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []int64{4, 3, 1}, auditIDs(listed))
+	require.Equal(t, "request-3", listed[0].RequestID)
+	require.Equal(t, 404, listed[0].Status)
+
+	listed, err = s.ListAuditEvents(ctx, 1, models.AuditFilter{EntryID: &entryID, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, auditIDs(listed))
+
+	listed, err = s.ListAuditEvents(ctx, 1, models.AuditFilter{Action: models.AuditKeyRead, BeforeID: 4})
+	require.NoError(t, err)
+	require.Empty(t, listed)
+
+	future := time.Now().Add(time.Hour)
+	listed, err = s.ListAuditEvents(ctx, 1, models.AuditFilter{Since: &future})
+	require.NoError(t, err)
+	require.Empty(t, listed)
+	listed, err = s.ListAuditEvents(ctx, 1, models.AuditFilter{Until: &future})
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+
+	verifier := auditchain.NewVerifier(chainKey)
+	var afterID int64
+	for {
+		batch, err := s.AuditEvents(ctx, afterID, 3)
+		require.NoError(t, err)
+		for _, event := range batch {
+			require.NoError(t, verifier.Next(event))
+			afterID = event.ID
+		}
+		if len(batch) < 3 {
+			break
+		}
+	}
+	require.Equal(t, len(events), verifier.Count())
+}
+
+func entryIDs(entries []models.Entry) []int64 {
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func auditIDs(events []models.AuditEvent) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
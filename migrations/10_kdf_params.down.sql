@@ -0,0 +1 @@
+DROP TABLE IF EXISTS kdf_params;
@@ -0,0 +1,14 @@
+-- KDFParams Table: key derivation parameters of accounts in zero-knowledge mode.
+-- Entries of these accounts are encrypted by clients, see package vaultcrypto.
+CREATE TABLE IF NOT EXISTS kdf_params
+(
+    account_id       BIGINT PRIMARY KEY,
+    created_at       TIMESTAMP NOT NULL,
+    algorithm        TEXT NOT NULL,
+    iterations       INTEGER NOT NULL,
+    memory           INTEGER NOT NULL,
+    parallelism      INTEGER NOT NULL,
+    salt             TEXT NOT NULL,
+    key_check        TEXT NOT NULL,
+    protocol_version INTEGER NOT NULL
+    );
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_key_rotation_running;
+DROP INDEX IF EXISTS idx_key_rotation_account;
+DROP TABLE IF EXISTS key_rotation;
+
+-- Entries rewrapped under a newer key version cannot be read without it; keep the newest
+-- key part of every account.
+DELETE FROM encryption_key
+WHERE share_index = 0
+  AND key_version < (SELECT MAX(k.key_version) FROM encryption_key k WHERE k.account_id = encryption_key.account_id AND k.share_index = 0);
+
+DROP INDEX IF EXISTS idx_encryption_key_version;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_account ON encryption_key (account_id) WHERE share_index = 0;
+ALTER TABLE encryption_key DROP COLUMN key_version;
@@ -0,0 +1,27 @@
+-- Key parts are versioned: a key rotation adds the next version of the account key part
+-- and rewraps the entries sealed under older versions, which are removed afterwards.
+ALTER TABLE encryption_key ADD COLUMN key_version INTEGER NOT NULL DEFAULT 1;
+
+DROP INDEX IF EXISTS idx_encryption_key_account;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_version ON encryption_key (account_id, key_version) WHERE share_index = 0;
+
+-- KeyRotation Table: progress of the re-encryption of an account under key version to_version
+CREATE TABLE IF NOT EXISTS key_rotation
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL,
+    finished_at     TIMESTAMP,
+    account_id      BIGINT NOT NULL,
+    from_version    INTEGER NOT NULL,
+    to_version      INTEGER NOT NULL,
+    status          TEXT NOT NULL,
+    entry_cursor    BIGINT NOT NULL DEFAULT 0,
+    revision_cursor BIGINT NOT NULL DEFAULT 0,
+    entries         INTEGER NOT NULL DEFAULT 0,
+    revisions       INTEGER NOT NULL DEFAULT 0,
+    error           TEXT NOT NULL DEFAULT ''
+    );
+
+CREATE INDEX IF NOT EXISTS idx_key_rotation_account ON key_rotation (account_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_key_rotation_running ON key_rotation (account_id) WHERE status = 'running';
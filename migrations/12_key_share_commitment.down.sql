@@ -0,0 +1,4 @@
+-- Commitments cannot be turned back into shares.
+DELETE FROM encryption_key WHERE share_index > 0;
+
+ALTER TABLE encryption_key DROP COLUMN holder;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_encryption_key_share;
+DELETE FROM encryption_key WHERE share_index > 0;
+ALTER TABLE encryption_key DROP COLUMN threshold;
+ALTER TABLE encryption_key DROP COLUMN share_index;
@@ -0,0 +1,6 @@
+-- Shamir shares of an account key part are stored next to it with share_index > 0.
+-- The key part itself keeps share_index = 0.
+ALTER TABLE encryption_key ADD COLUMN share_index INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE encryption_key ADD COLUMN threshold INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_share ON encryption_key (account_id, share_index) WHERE share_index > 0;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_encryption_key_account;
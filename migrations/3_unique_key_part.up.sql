@@ -0,0 +1,7 @@
+-- Only the oldest key part of an account was ever used for encryption, drop the others
+-- and make sure every account has at most one.
+DELETE FROM encryption_key
+WHERE share_index = 0
+  AND id NOT IN (SELECT MIN(id) FROM encryption_key WHERE share_index = 0 GROUP BY account_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_account ON encryption_key (account_id) WHERE share_index = 0;
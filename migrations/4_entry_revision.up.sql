@@ -0,0 +1,14 @@
+-- EntryRevision Table: previous versions of entries, written on every update and delete
+CREATE TABLE IF NOT EXISTS entry_revision
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    entry_id     BIGINT NOT NULL,
+    account_id   BIGINT NOT NULL,
+    action       TEXT NOT NULL,
+    entry_type   TEXT NOT NULL,
+    entry_data   TEXT NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_entry_revision_entry ON entry_revision (account_id, entry_id, id);
+CREATE INDEX IF NOT EXISTS idx_entry_revision_created_at ON entry_revision (created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_entry_account_folder;
+ALTER TABLE entry DROP COLUMN folder_id;
+DROP TABLE IF EXISTS entry_tag;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS folder;
@@ -0,0 +1,38 @@
+-- Folder Table: nested per account, parent_id is NULL for top level folders
+CREATE TABLE IF NOT EXISTS folder
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    account_id   BIGINT NOT NULL,
+    parent_id    BIGINT,
+    name         TEXT NOT NULL
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folder_name ON folder (account_id, IFNULL(parent_id, 0), name);
+
+-- Tag Table
+CREATE TABLE IF NOT EXISTS tag
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    account_id   BIGINT NOT NULL,
+    name         TEXT NOT NULL
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_name ON tag (account_id, name);
+
+-- EntryTag Table: many-to-many link between entries and tags
+CREATE TABLE IF NOT EXISTS entry_tag
+(
+    entry_id     BIGINT NOT NULL,
+    tag_id       BIGINT NOT NULL,
+    PRIMARY KEY (entry_id, tag_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_entry_tag_tag ON entry_tag (tag_id);
+
+ALTER TABLE entry ADD COLUMN folder_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_entry_account_folder ON entry (account_id, folder_id);
@@ -0,0 +1,10 @@
+-- SearchToken Table: blind index of entry metadata, tokens are keyed HMACs of words
+CREATE TABLE IF NOT EXISTS search_token
+(
+    account_id   BIGINT NOT NULL,
+    token        TEXT NOT NULL,
+    entry_id     BIGINT NOT NULL,
+    PRIMARY KEY (account_id, token, entry_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_search_token_entry ON search_token (entry_id);
@@ -0,0 +1,14 @@
+-- EntryShare Table: access granted by the owner of an entry to another account
+CREATE TABLE IF NOT EXISTS entry_share
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    entry_id     BIGINT NOT NULL,
+    owner_id     BIGINT NOT NULL,
+    account_id   BIGINT NOT NULL,
+    permission   TEXT NOT NULL,
+    expires_at   TIMESTAMP
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_entry_share_entry_account ON entry_share (entry_id, account_id);
+CREATE INDEX IF NOT EXISTS idx_entry_share_account ON entry_share (account_id);
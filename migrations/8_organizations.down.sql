@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_entry_collection;
+ALTER TABLE entry_revision DROP COLUMN collection_id;
+ALTER TABLE entry DROP COLUMN collection_id;
+DROP TABLE IF EXISTS collection;
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,38 @@
+-- Organization Table: key_part derives the data key of all entries in the organization collections
+CREATE TABLE IF NOT EXISTS organization
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name         TEXT NOT NULL,
+    key_part     TEXT NOT NULL
+    );
+
+-- OrganizationMember Table: role is one of owner, admin, editor, viewer
+CREATE TABLE IF NOT EXISTS organization_member
+(
+    organization_id BIGINT NOT NULL,
+    account_id      BIGINT NOT NULL,
+    role            TEXT NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, account_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_organization_member_account ON organization_member (account_id);
+
+-- Collection Table: shared vaults of an organization
+CREATE TABLE IF NOT EXISTS collection
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    organization_id BIGINT NOT NULL,
+    name            TEXT NOT NULL
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_name ON collection (organization_id, name);
+
+ALTER TABLE entry ADD COLUMN collection_id BIGINT;
+ALTER TABLE entry_revision ADD COLUMN collection_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_entry_collection ON entry (collection_id);
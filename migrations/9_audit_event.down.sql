@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS audit_event_no_delete;
+DROP TRIGGER IF EXISTS audit_event_no_update;
+DROP INDEX IF EXISTS idx_audit_event_entry;
+DROP INDEX IF EXISTS idx_audit_event_account;
+DROP INDEX IF EXISTS idx_audit_event_prev_hash;
+DROP TABLE IF EXISTS audit_event;
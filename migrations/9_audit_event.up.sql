@@ -0,0 +1,31 @@
+-- AuditEvent Table: append-only log of vault access. hash covers the row and the
+-- hash of the previous row, so any change to the history breaks the chain.
+CREATE TABLE IF NOT EXISTS audit_event
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at  TIMESTAMP NOT NULL,
+    account_id  BIGINT NOT NULL,
+    action      TEXT NOT NULL,
+    entry_id    BIGINT,
+    request_id  TEXT NOT NULL,
+    ip          TEXT NOT NULL,
+    user_agent  TEXT NOT NULL,
+    status      INTEGER NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+    );
+
+-- A row can only follow one other row, so the chain cannot fork.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_event_prev_hash ON audit_event (prev_hash);
+CREATE INDEX IF NOT EXISTS idx_audit_event_account ON audit_event (account_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_event_entry ON audit_event (entry_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_event_no_update BEFORE UPDATE ON audit_event
+BEGIN
+    SELECT RAISE(ABORT, 'audit_event is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_event_no_delete BEFORE DELETE ON audit_event
+BEGIN
+    SELECT RAISE(ABORT, 'audit_event is append-only');
+END;
@@ -0,0 +1,28 @@
+// Package migrations embeds the schema migrations of the storage backends, so the
+// server and cmd/migrator carry them in the binary.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var sqlite embed.FS
+
+//go:embed postgres/*.sql
+var postgres embed.FS
+
+// SQLite holds the migrations of the SQLite backend.
+var SQLite fs.FS = sqlite
+
+// Postgres holds the migrations of the PostgreSQL backend.
+var Postgres fs.FS = mustSub(postgres, "postgres")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
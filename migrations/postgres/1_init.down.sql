@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS audit_event;
+DROP FUNCTION IF EXISTS audit_event_append_only();
+DROP TABLE IF EXISTS collection;
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
+DROP TABLE IF EXISTS entry_share;
+DROP TABLE IF EXISTS search_token;
+DROP TABLE IF EXISTS entry_tag;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS folder;
+DROP TABLE IF EXISTS entry_revision;
+DROP TABLE IF EXISTS entry;
+DROP TABLE IF EXISTS encryption_key;
@@ -0,0 +1,176 @@
+-- PostgreSQL schema of passvault, equivalent to the SQLite migrations in the parent directory.
+
+-- EncryptionKey Table: the key part of an account has share_index = 0, its Shamir shares share_index > 0
+CREATE TABLE IF NOT EXISTS encryption_key
+(
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    account_id   BIGINT NOT NULL,
+    key_part     TEXT NOT NULL,
+    share_index  INTEGER NOT NULL DEFAULT 0,
+    threshold    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_share ON encryption_key (account_id, share_index) WHERE share_index > 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encryption_key_account ON encryption_key (account_id) WHERE share_index = 0;
+
+-- Entry Table
+CREATE TABLE IF NOT EXISTS entry
+(
+    id            BIGSERIAL PRIMARY KEY,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    account_id    BIGINT NOT NULL,
+    entry_type    TEXT NOT NULL,
+    entry_data    TEXT NOT NULL,
+    folder_id     BIGINT,
+    collection_id BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_account_folder ON entry (account_id, folder_id);
+CREATE INDEX IF NOT EXISTS idx_entry_collection ON entry (collection_id);
+
+-- EntryRevision Table: previous versions of entries, written on every update and delete
+CREATE TABLE IF NOT EXISTS entry_revision
+(
+    id            BIGSERIAL PRIMARY KEY,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    entry_id      BIGINT NOT NULL,
+    account_id    BIGINT NOT NULL,
+    action        TEXT NOT NULL,
+    entry_type    TEXT NOT NULL,
+    entry_data    TEXT NOT NULL,
+    collection_id BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_revision_entry ON entry_revision (account_id, entry_id, id);
+CREATE INDEX IF NOT EXISTS idx_entry_revision_created_at ON entry_revision (created_at);
+
+-- Folder Table: nested per account, parent_id is NULL for top level folders
+CREATE TABLE IF NOT EXISTS folder
+(
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    account_id   BIGINT NOT NULL,
+    parent_id    BIGINT,
+    name         TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folder_name ON folder (account_id, COALESCE(parent_id, 0), name);
+
+-- Tag Table
+CREATE TABLE IF NOT EXISTS tag
+(
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    account_id   BIGINT NOT NULL,
+    name         TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_name ON tag (account_id, name);
+
+-- EntryTag Table: many-to-many link between entries and tags
+CREATE TABLE IF NOT EXISTS entry_tag
+(
+    entry_id     BIGINT NOT NULL,
+    tag_id       BIGINT NOT NULL,
+    PRIMARY KEY (entry_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_tag_tag ON entry_tag (tag_id);
+
+-- SearchToken Table: blind index of entry metadata, tokens are keyed HMACs of words
+CREATE TABLE IF NOT EXISTS search_token
+(
+    account_id   BIGINT NOT NULL,
+    token        TEXT NOT NULL,
+    entry_id     BIGINT NOT NULL,
+    PRIMARY KEY (account_id, token, entry_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_token_entry ON search_token (entry_id);
+
+-- EntryShare Table: access granted by the owner of an entry to another account
+CREATE TABLE IF NOT EXISTS entry_share
+(
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    entry_id     BIGINT NOT NULL,
+    owner_id     BIGINT NOT NULL,
+    account_id   BIGINT NOT NULL,
+    permission   TEXT NOT NULL,
+    expires_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_entry_share_entry_account ON entry_share (entry_id, account_id);
+CREATE INDEX IF NOT EXISTS idx_entry_share_account ON entry_share (account_id);
+
+-- Organization Table: key_part derives the data key of all entries in the organization collections
+CREATE TABLE IF NOT EXISTS organization
+(
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    name         TEXT NOT NULL,
+    key_part     TEXT NOT NULL
+);
+
+-- OrganizationMember Table: role is one of owner, admin, editor, viewer
+CREATE TABLE IF NOT EXISTS organization_member
+(
+    organization_id BIGINT NOT NULL,
+    account_id      BIGINT NOT NULL,
+    role            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (organization_id, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_member_account ON organization_member (account_id);
+
+-- Collection Table: shared vaults of an organization
+CREATE TABLE IF NOT EXISTS collection
+(
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    organization_id BIGINT NOT NULL,
+    name            TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_name ON collection (organization_id, name);
+
+-- AuditEvent Table: append-only log of vault access. IDs are assigned by the application
+-- without gaps, hash covers the row and the hash of the previous row.
+CREATE TABLE IF NOT EXISTS audit_event
+(
+    id          BIGINT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL,
+    account_id  BIGINT NOT NULL,
+    action      TEXT NOT NULL,
+    entry_id    BIGINT,
+    request_id  TEXT NOT NULL,
+    ip          TEXT NOT NULL,
+    user_agent  TEXT NOT NULL,
+    status      INTEGER NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_event_prev_hash ON audit_event (prev_hash);
+CREATE INDEX IF NOT EXISTS idx_audit_event_account ON audit_event (account_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_event_entry ON audit_event (entry_id);
+
+CREATE OR REPLACE FUNCTION audit_event_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_event_no_change BEFORE UPDATE OR DELETE ON audit_event
+    FOR EACH ROW EXECUTE FUNCTION audit_event_append_only();
+
+CREATE TRIGGER audit_event_no_truncate BEFORE TRUNCATE ON audit_event
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_event_append_only();
@@ -0,0 +1,6 @@
+-- Share rows keep a commitment to their share in key_part instead of the share itself,
+-- bound to the holder the share was handed to and to the key version it was split from.
+-- Shares stored before were plain text and are dropped; accounts have to split again.
+DELETE FROM encryption_key WHERE share_index > 0;
+
+ALTER TABLE encryption_key ADD COLUMN IF NOT EXISTS holder TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,19 @@
+-- Key parts derived under a master key version above 1 need that master key to be
+-- configured as the only one afterwards.
+DROP INDEX IF EXISTS idx_key_rotation_organization;
+DROP INDEX IF EXISTS idx_key_rotation_running;
+DELETE FROM key_rotation WHERE organization_id > 0;
+ALTER TABLE key_rotation DROP COLUMN IF EXISTS shares_removed;
+ALTER TABLE key_rotation DROP COLUMN IF EXISTS organization_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_key_rotation_running ON key_rotation (account_id) WHERE status = 'running';
+
+-- Collection entries rewrapped under a newer organization key version cannot be read
+-- without it; keep the newest key part of every organization.
+ALTER TABLE organization ADD COLUMN IF NOT EXISTS key_part TEXT NOT NULL DEFAULT '';
+UPDATE organization SET key_part = (
+    SELECT k.key_part FROM organization_key k WHERE k.organization_id = organization.id ORDER BY k.key_version DESC LIMIT 1
+);
+
+DROP TABLE IF EXISTS organization_key;
+
+ALTER TABLE encryption_key DROP COLUMN IF EXISTS master_key_version;
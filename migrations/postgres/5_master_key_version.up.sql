@@ -0,0 +1,32 @@
+-- Key parts record the version of the server master key they derive keys with, so the
+-- master key can be rotated: a master key rotation rotates every key part still derived
+-- under an older version.
+ALTER TABLE encryption_key ADD COLUMN IF NOT EXISTS master_key_version INTEGER NOT NULL DEFAULT 1;
+
+-- OrganizationKey Table: versions of the key part of an organization, like encryption_key
+CREATE TABLE IF NOT EXISTS organization_key
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ NOT NULL,
+    organization_id    BIGINT NOT NULL,
+    key_part           TEXT NOT NULL,
+    key_version        INTEGER NOT NULL,
+    master_key_version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_key_version ON organization_key (organization_id, key_version);
+
+INSERT INTO organization_key (created_at, organization_id, key_part, key_version)
+SELECT created_at, id, key_part, 1 FROM organization;
+
+ALTER TABLE organization DROP COLUMN IF EXISTS key_part;
+
+-- Rotations of an organization key have an organization_id and no account_id.
+-- shares_removed counts the Shamir shares of the previous account key part dropped
+-- when the rotation started.
+ALTER TABLE key_rotation ADD COLUMN IF NOT EXISTS organization_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE key_rotation ADD COLUMN IF NOT EXISTS shares_removed INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_key_rotation_running;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_key_rotation_running ON key_rotation (account_id, organization_id) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_key_rotation_organization ON key_rotation (organization_id, id);
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"passvault/internal/domain/models"
+	rotationstart "passvault/internal/http-server/handlers/key-rotation/start"
+	masterrotationstart "passvault/internal/http-server/handlers/master-key-rotation/start"
+	orgrotationstart "passvault/internal/http-server/handlers/organization-key-rotation/start"
+)
+
+// StartKeyRotation starts rotating the key of an account, or resumes its failed
+// rotation. The server re-encrypts the vault in the background; KeyRotation reports
+// its progress. The recovery shares of the account are removed, the rotation counts
+// them in SharesRemoved. Only admin accounts may.
+func (c *Client) StartKeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	var res rotationstart.Response
+	if err := c.do(ctx, http.MethodPost, pathf("/admin/accounts/%d/key-rotation", accountID), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Rotation, nil
+}
+
+// KeyRotation returns the latest key rotation of an account. Only admin accounts may.
+func (c *Client) KeyRotation(ctx context.Context, accountID int64) (*models.KeyRotation, error) {
+	var rotation models.KeyRotation
+	if err := c.do(ctx, http.MethodGet, pathf("/admin/accounts/%d/key-rotation", accountID), nil, nil, &rotation); err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// StartOrganizationKeyRotation starts rotating the key shared by the collections of an
+// organization, or resumes its failed rotation. Only admin accounts may.
+func (c *Client) StartOrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	var res orgrotationstart.Response
+	if err := c.do(ctx, http.MethodPost, pathf("/admin/organizations/%d/key-rotation", orgID), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Rotation, nil
+}
+
+// OrganizationKeyRotation returns the latest key rotation of an organization. Only admin
+// accounts may.
+func (c *Client) OrganizationKeyRotation(ctx context.Context, orgID int64) (*models.KeyRotation, error) {
+	var rotation models.KeyRotation
+	if err := c.do(ctx, http.MethodGet, pathf("/admin/organizations/%d/key-rotation", orgID), nil, nil, &rotation); err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// StartMasterKeyRotation starts rotating the keys of every account and organization still
+// keyed with an older master key than the server's current one. MasterKeyStatus reports
+// what is left. Only admin accounts may.
+func (c *Client) StartMasterKeyRotation(ctx context.Context) ([]models.KeyRotation, error) {
+	var res masterrotationstart.Response
+	if err := c.do(ctx, http.MethodPost, "/admin/master-key-rotation", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Rotations, nil
+}
+
+// MasterKeyStatus returns the accounts and organizations still keyed with an older master
+// key. Only admin accounts may.
+func (c *Client) MasterKeyStatus(ctx context.Context) (*models.MasterKeyStatus, error) {
+	var status models.MasterKeyStatus
+	if err := c.do(ctx, http.MethodGet, "/admin/master-key-rotation", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,310 @@
+// Package client is the Go client of the passvault HTTP API.
+//
+// A Client sends the bearer token of an account with every request and decodes the
+// responses into the request and response types of the API handlers, so callers do
+// not have to know the resp.Response envelope:
+//
+//	c, err := client.New("https://vault.example.com", client.WithToken(token))
+//	created, err := c.CreateEntry(ctx, save.Request{EntryType: "login", EntryData: data})
+//	entry, err := c.GetEntry(ctx, created.ID)
+//
+// Error responses are returned as *Error. It unwraps to the storage error the server
+// reported, so errors.Is(err, storage.ErrEntryNotFound) works like it does next to the
+// storage, and to ErrUnauthorized for missing, invalid or expired tokens.
+//
+// Requests that can safely be sent again are retried with exponential backoff when the
+// connection fails or the server is unavailable. Every method honours the cancellation
+// and deadline of its context, including while it waits for a retry.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiPrefix is the path of the API below the base URL of a server.
+	apiPrefix = "/api/v1"
+
+	DefaultRetries    = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+
+	// maxErrorBody bounds how much of an error response is read.
+	maxErrorBody = 64 << 10
+)
+
+// TokenSource returns the token sent with a request. It is called for every attempt,
+// so it can refresh tokens that expire.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Client calls the API of one passvault server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      TokenSource
+	userAgent  string
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with httpClient instead of http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates every request with a fixed token.
+func WithToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource authenticates every request with the token returned by source.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.token = source
+	}
+}
+
+// WithUserAgent sets the User-Agent header, which the server writes to the audit log.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetries retries a failed request up to retries times, waiting from minBackoff
+// up to maxBackoff between attempts. Zero retries disables them.
+func WithRetries(retries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New returns a client of the server at baseURL, such as https://vault.example.com.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: must be an http or https URL", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(u.String(), "/"),
+		httpClient: http.DefaultClient,
+		userAgent:  "passvault-go-client",
+		retries:    DefaultRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request is an API request that can be sent more than once.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	contentType string
+}
+
+// jsonRequest returns a request with body encoded as JSON, if it is set.
+func jsonRequest(method, path string, body any) (*request, error) {
+	req := &request{method: method, path: path}
+	if body == nil {
+		return req, nil
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req.body = payload
+	req.contentType = "application/json"
+	return req, nil
+}
+
+// do sends body as JSON and decodes the response into out when it is set.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	req, err := jsonRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	req.query = query
+
+	return c.send(ctx, req, func(res *http.Response) error {
+		return decode(res, out)
+	})
+}
+
+// send sends req, retrying it while that is safe, and passes a successful response to
+// handle. Error responses are returned as *Error.
+func (c *Client) send(ctx context.Context, req *request, handle func(*http.Response) error) error {
+	for attempt := 0; ; attempt++ {
+		res, err := c.roundTrip(ctx, req)
+		if err == nil && res.StatusCode < http.StatusBadRequest {
+			defer res.Body.Close()
+			return handle(res)
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(res.Header.Get("Retry-After"))
+			err = readError(res)
+			res.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= c.retries || !retryable(req.method, err) {
+			return err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) roundTrip(ctx context.Context, req *request) (*http.Response, error) {
+	u := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// backoff returns the wait before retry attempt+1: an exponentially growing delay
+// with jitter, so clients that failed together do not retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.maxBackoff
+	if attempt < 32 {
+		if d := c.minBackoff << attempt; d > 0 && d < c.maxBackoff {
+			wait = d
+		}
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryable reports whether a request that failed with err may be sent again.
+// Requests the server turned away are retried whatever their method; requests that
+// may have reached it only when sending them twice does no harm.
+func retryable(method string, err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent(method)
+		default:
+			return false
+		}
+	}
+	return idempotent(method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// decode decodes a JSON response into out, or discards it when out is nil.
+func decode(res *http.Response, out any) error {
+	if out == nil {
+		_, err := io.Copy(io.Discard, res.Body)
+		return err
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func pathf(format string, ids ...int64) string {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,403 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/entry/save"
+	"passvault/internal/http-server/handlers/entry/update"
+	foldercreate "passvault/internal/http-server/handlers/folder/create"
+	"passvault/internal/http-server/handlers/generator/generate"
+	sharecreate "passvault/internal/http-server/handlers/share/create"
+	"passvault/internal/http-server/router"
+	breachindex "passvault/internal/lib/breach"
+	"passvault/internal/lib/envelope"
+	"passvault/internal/lib/jwt"
+	"passvault/internal/services/breach"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/health"
+	"passvault/internal/services/keyrotation"
+	"passvault/internal/storage"
+	"passvault/internal/storage/encrypted"
+	"passvault/internal/storage/memory"
+	"passvault/pkg/client"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const secret = "test_secret"
+
+// adminID is the account allowed to use the admin API of test servers.
+const adminID = 1
+
+type fakeRegisterer struct{}
+
+func (fakeRegisterer) RegisterClient(ctx context.Context, appName string, secret string, redirectUrl string) (int64, error) {
+	return 42, nil
+}
+
+// newRouter returns the whole API on an empty in-memory vault.
+func newRouter(t *testing.T) http.Handler {
+	retention := storage.RevisionRetention{MaxCount: 10}
+	db := memory.New(retention)
+	vault := encrypted.New(db, envelope.NewMasterKeys(bytes.Repeat([]byte{7}, 32)))
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var buf bytes.Buffer
+	w := breachindex.NewWriter(&buf)
+	require.NoError(t, w.Add(breachindex.Hash("password")))
+	require.NoError(t, w.Close())
+	path := filepath.Join(t.TempDir(), "breach.idx")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	index, err := breachindex.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { index.Close() })
+
+	rotations := keyrotation.New(log, vault, 2)
+	ctx, stopRotations := context.WithCancel(context.Background())
+	go rotations.Run(ctx)
+	t.Cleanup(stopRotations)
+
+	return router.New(log, secret, time.Second, db, vault, breach.New(log, index), rotations, health.New(log, vault, vault, retention), []int64{adminID}, fakeRegisterer{})
+}
+
+// newServer serves handler and returns a client of it authenticated as accountID,
+// or unauthenticated when accountID is zero.
+func newServer(t *testing.T, handler http.Handler, accountID int64, opts ...client.Option) *client.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return as(t, srv.URL, accountID, opts...)
+}
+
+func as(t *testing.T, url string, accountID int64, opts ...client.Option) *client.Client {
+	if accountID != 0 {
+		opts = append([]client.Option{client.WithToken(token(t, accountID))}, opts...)
+	}
+	c, err := client.New(url, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func token(t *testing.T, accountID int64) string {
+	claims := jwt.CustomClaims{
+		AccountID: accountID,
+		Email:     fmt.Sprintf("user%d@example.com", accountID),
+		AppID:     1,
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	signed, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestNew(t *testing.T) {
+	for _, baseURL := range []string{"", "vault.example.com", "ftp://vault.example.com", "http://"} {
+		_, err := client.New(baseURL)
+		require.Error(t, err, baseURL)
+	}
+
+	_, err := client.New("https://vault.example.com/")
+	require.NoError(t, err)
+}
+
+func TestEntries(t *testing.T) {
+	ctx := context.Background()
+	alice := newServer(t, newRouter(t), 1)
+
+	created, err := alice.CreateEntry(ctx, save.Request{EntryType: models.EntryTypeLogin, EntryData: `{"title": "mail", "password": "password"}`})
+	require.NoError(t, err)
+	require.NotZero(t, created.ID)
+	require.True(t, created.Breached)
+
+	data := `{"title": "webmail"}`
+	_, err = alice.UpdateEntry(ctx, created.ID, update.Request{EntryData: &data})
+	require.NoError(t, err)
+
+	entry, err := alice.GetEntry(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, data, entry.EntryData)
+
+	ids, err := alice.SearchEntries(ctx, "webmail")
+	require.NoError(t, err)
+	require.Equal(t, []int64{created.ID}, ids)
+
+	folderID, err := alice.CreateFolder(ctx, foldercreate.Request{Name: "work"})
+	require.NoError(t, err)
+	require.NoError(t, alice.MoveEntry(ctx, created.ID, &folderID))
+
+	page, err := alice.ListEntries(ctx, models.EntryQuery{Filter: models.EntryFilter{FolderID: &folderID}})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+
+	revisions, err := alice.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, revisions)
+
+	require.NoError(t, alice.DeleteEntry(ctx, created.ID))
+	_, err = alice.GetEntry(ctx, created.ID)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	require.NoError(t, alice.RestoreRevision(ctx, created.ID, revisions[0].ID))
+	entry, err = alice.GetEntry(ctx, created.ID)
+	require.NoError(t, err)
+	require.Contains(t, entry.EntryData, "mail")
+}
+
+func TestSharing(t *testing.T) {
+	ctx := context.Background()
+	handler := newRouter(t)
+	alice, bob := newServer(t, handler, 1), newServer(t, handler, 2)
+
+	created, err := alice.CreateEntry(ctx, save.Request{EntryType: models.EntryTypeLogin, EntryData: `{"title": "mail"}`})
+	require.NoError(t, err)
+
+	_, err = bob.GetEntry(ctx, created.ID)
+	require.ErrorIs(t, err, storage.ErrEntryNotFound)
+
+	_, err = alice.ShareEntry(ctx, created.ID, sharecreate.Request{AccountID: 2, Permission: models.SharePermissionRead})
+	require.NoError(t, err)
+
+	entry, err := bob.GetEntry(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "mail"}`, entry.EntryData)
+
+	err = bob.DeleteEntry(ctx, created.ID)
+	require.ErrorIs(t, err, storage.ErrAccessDenied)
+}
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+	alice := newServer(t, newRouter(t), 1)
+
+	types, err := alice.EntryTypes(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, types)
+
+	generated, err := alice.Generate(ctx, generate.Request{Type: "password", Length: 24})
+	require.NoError(t, err)
+	require.Len(t, generated.Value, 24)
+
+	breached, err := alice.CheckBreach(ctx, "password")
+	require.NoError(t, err)
+	require.True(t, breached)
+
+	_, err = alice.CreateEntry(ctx, save.Request{EntryType: models.EntryTypeLogin, EntryData: `{"title": "mail"}`})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = alice.Export(ctx, exporter.Options{Format: exporter.FormatCSV}, &out)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.NoError(t, alice.Export(ctx, exporter.Options{Format: exporter.FormatCSV, ConfirmPlain: true}, &out))
+	require.Contains(t, out.String(), "mail")
+
+	events, err := alice.AuditEvents(ctx, models.AuditFilter{Action: models.AuditExportPlain})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	handler := newRouter(t)
+	admin, alice := newServer(t, handler, adminID), newServer(t, handler, 2)
+
+	_, err := alice.CreateEntry(ctx, save.Request{EntryType: models.EntryTypeLogin, EntryData: `{"title": "mail"}`})
+	require.NoError(t, err)
+
+	_, err = alice.StartKeyRotation(ctx, 2)
+	require.ErrorIs(t, err, storage.ErrAccessDenied)
+	_, err = admin.KeyRotation(ctx, 2)
+	require.ErrorIs(t, err, storage.ErrKeyRotationNotFound)
+
+	started, err := admin.StartKeyRotation(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, started.ToVersion)
+
+	require.Eventually(t, func() bool {
+		rotation, err := admin.KeyRotation(ctx, 2)
+		return err == nil && rotation.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	orgID, err := alice.CreateOrganization(ctx, "acme")
+	require.NoError(t, err)
+	_, err = alice.StartOrganizationKeyRotation(ctx, orgID)
+	require.ErrorIs(t, err, storage.ErrAccessDenied)
+	started, err = admin.StartOrganizationKeyRotation(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, orgID, started.OrganizationID)
+	require.Eventually(t, func() bool {
+		rotation, err := admin.OrganizationKeyRotation(ctx, orgID)
+		return err == nil && rotation.Status == models.KeyRotationDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Everything is keyed with the only master key already.
+	rotations, err := admin.StartMasterKeyRotation(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rotations)
+	status, err := admin.MasterKeyStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.MasterKeyVersion)
+	require.Empty(t, status.Accounts)
+}
+
+func TestUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(newRouter(t))
+	t.Cleanup(srv.Close)
+
+	anonymous := as(t, srv.URL, 0)
+	_, err := anonymous.ListTags(context.Background())
+	require.ErrorIs(t, err, client.ErrUnauthorized)
+
+	forged := as(t, srv.URL, 0, client.WithToken("not-a-token"))
+	_, err = forged.ListTags(context.Background())
+	require.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+// unavailable answers the first failures requests with 503 before passing them to next.
+func unavailable(failures int32, next http.Handler) (http.Handler, *atomic.Int32) {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), &requests
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	retries := client.WithRetries(3, time.Millisecond, 5*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		failures int32
+		call     func(c *client.Client) error
+		wantErr  bool
+		requests int32
+	}{
+		{
+			name:     "GET retried until it succeeds",
+			failures: 2,
+			call: func(c *client.Client) error {
+				_, err := c.ListTags(ctx)
+				return err
+			},
+			requests: 3,
+		},
+		{
+			name:     "GET gives up after the retries",
+			failures: 10,
+			call: func(c *client.Client) error {
+				_, err := c.ListTags(ctx)
+				return err
+			},
+			wantErr:  true,
+			requests: 4,
+		},
+		{
+			name:     "POST not retried",
+			failures: 1,
+			call: func(c *client.Client) error {
+				_, err := c.CreateTag(ctx, "work")
+				return err
+			},
+			wantErr:  true,
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, requests := unavailable(tt.failures, newRouter(t))
+			c := newServer(t, handler, 1, retries)
+
+			err := tt.call(c)
+			if tt.wantErr {
+				var apiErr *client.Error
+				require.ErrorAs(t, err, &apiErr)
+				require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.requests, requests.Load())
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"status": "OK", "id": 7}`)
+	})
+	c := newServer(t, handler, 1, client.WithRetries(1, time.Millisecond, time.Millisecond))
+
+	start := time.Now()
+	id, err := c.CreateTag(context.Background(), "work")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), id)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestCancel(t *testing.T) {
+	handler, requests := unavailable(100, http.NotFoundHandler())
+	c := newServer(t, handler, 1, client.WithRetries(5, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.ListTags(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Equal(t, int32(1), requests.Load())
+}
+
+func TestTokenSource(t *testing.T) {
+	var authorization atomic.Value
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		io.WriteString(w, `[]`)
+	})
+
+	calls := 0
+	c := newServer(t, handler, 0, client.WithTokenSource(func(context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("token-%d", calls), nil
+	}))
+
+	for i := 1; i <= 2; i++ {
+		_, err := c.ListTags(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("Bearer token-%d", i), authorization.Load())
+	}
+
+	failing := newServer(t, handler, 0, client.WithTokenSource(func(context.Context) (string, error) {
+		return "", errors.New("token expired")
+	}), client.WithRetries(0, 0, 0))
+	_, err := failing.ListTags(context.Background())
+	require.ErrorContains(t, err, "token expired")
+	require.NotErrorIs(t, err, client.ErrUnauthorized)
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"passvault/internal/domain/models"
+	"passvault/internal/http-server/handlers/entry/bulkimport"
+	entrycollection "passvault/internal/http-server/handlers/entry/collection"
+	"passvault/internal/http-server/handlers/entry/move"
+	"passvault/internal/http-server/handlers/entry/save"
+	"passvault/internal/http-server/handlers/entry/search"
+	entrytag "passvault/internal/http-server/handlers/entry/tag"
+	"passvault/internal/http-server/handlers/entry/totp"
+	"passvault/internal/http-server/handlers/entry/update"
+	"passvault/internal/services/importer"
+	"strconv"
+	"time"
+)
+
+// CreateEntry saves a new entry, in a collection when req.CollectionID is set. The
+// response reports the ID of the entry and whether its password is known to be breached.
+func (c *Client) CreateEntry(ctx context.Context, req save.Request) (*save.Response, error) {
+	var res save.Response
+	if err := c.do(ctx, http.MethodPost, "/entries", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetEntry returns an entry the account can read.
+func (c *Client) GetEntry(ctx context.Context, entryID int64) (*models.Entry, error) {
+	var entry models.Entry
+	if err := c.do(ctx, http.MethodGet, pathf("/entries/%d", entryID), nil, nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListEntries returns a page of entries. Zero fields of query keep the defaults of the
+// server; pass the NextCursor of a page as query.Cursor to get the next one.
+func (c *Client) ListEntries(ctx context.Context, query models.EntryQuery) (*models.EntryPage, error) {
+	var page models.EntryPage
+	if err := c.do(ctx, http.MethodGet, "/entries", entryQuery(query), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SearchEntries returns the IDs of the entries whose title, username, URIs or tags match query.
+func (c *Client) SearchEntries(ctx context.Context, query string) ([]int64, error) {
+	var res search.Response
+	if err := c.do(ctx, http.MethodGet, "/entries/search", url.Values{"q": {query}}, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.IDs, nil
+}
+
+// UpdateEntry changes the fields of an entry that are set in req.
+func (c *Client) UpdateEntry(ctx context.Context, entryID int64, req update.Request) (*update.Response, error) {
+	var res update.Response
+	if err := c.do(ctx, http.MethodPatch, pathf("/entries/%d", entryID), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteEntry deletes an entry; its revisions are kept.
+func (c *Client) DeleteEntry(ctx context.Context, entryID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/entries/%d", entryID), nil, nil, nil)
+}
+
+// MoveEntry moves an entry into a folder, or out of any folder when folderID is nil.
+func (c *Client) MoveEntry(ctx context.Context, entryID int64, folderID *int64) error {
+	return c.do(ctx, http.MethodPut, pathf("/entries/%d/folder", entryID), nil, move.Request{FolderID: folderID}, nil)
+}
+
+// SetEntryTags replaces the tags of an entry.
+func (c *Client) SetEntryTags(ctx context.Context, entryID int64, tagIDs []int64) error {
+	if tagIDs == nil {
+		tagIDs = []int64{}
+	}
+	return c.do(ctx, http.MethodPut, pathf("/entries/%d/tags", entryID), nil, entrytag.Request{TagIDs: tagIDs}, nil)
+}
+
+// SetEntryCollection moves an entry into an organization collection, or back into the
+// personal vault of its owner when collectionID is nil.
+func (c *Client) SetEntryCollection(ctx context.Context, entryID int64, collectionID *int64) error {
+	return c.do(ctx, http.MethodPut, pathf("/entries/%d/collection", entryID), nil, entrycollection.Request{CollectionID: collectionID}, nil)
+}
+
+// EntryTOTP returns the current code of a TOTP entry.
+func (c *Client) EntryTOTP(ctx context.Context, entryID int64) (*totp.Response, error) {
+	var res totp.Response
+	if err := c.do(ctx, http.MethodGet, pathf("/entries/%d/totp", entryID), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ImportRequest is a file exported from another password manager.
+type ImportRequest struct {
+	// Format is one of the formats of package importer, such as bitwarden or csv.
+	Format string
+	File   io.Reader
+	// Password opens KDBX databases.
+	Password string
+	// DryRun reports what would be imported without saving anything.
+	DryRun bool
+}
+
+// ImportEntries imports the entries of a file into the personal vault.
+func (c *Client) ImportEntries(ctx context.Context, req ImportRequest) (*importer.Report, error) {
+	// The form is buffered so that the request can be retried.
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"format":  req.Format,
+		"dry_run": strconv.FormatBool(req.DryRun),
+	}
+	if req.Password != "" {
+		fields["password"] = req.Password
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	file, err := form.CreateFormFile("file", "import")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, req.File); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var res bulkimport.Response
+	err = c.send(ctx, &request{
+		method:      http.MethodPost,
+		path:        "/entries/import",
+		body:        body.Bytes(),
+		contentType: form.FormDataContentType(),
+	}, func(r *http.Response) error {
+		return decode(r, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Report, nil
+}
+
+// ListRevisions returns the previous versions of an entry, newest first.
+func (c *Client) ListRevisions(ctx context.Context, entryID int64) ([]models.EntryRevision, error) {
+	var revisions []models.EntryRevision
+	if err := c.do(ctx, http.MethodGet, pathf("/entries/%d/revisions", entryID), nil, nil, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetRevision returns a previous version of an entry.
+func (c *Client) GetRevision(ctx context.Context, entryID int64, revisionID int64) (*models.EntryRevision, error) {
+	var revision models.EntryRevision
+	if err := c.do(ctx, http.MethodGet, pathf("/entries/%d/revisions/%d", entryID, revisionID), nil, nil, &revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// RestoreRevision brings an entry back to a previous version, undeleting it if needed.
+func (c *Client) RestoreRevision(ctx context.Context, entryID int64, revisionID int64) error {
+	return c.do(ctx, http.MethodPost, pathf("/entries/%d/revisions/%d/restore", entryID, revisionID), nil, nil, nil)
+}
+
+// entryQuery encodes query as the query parameters of GET /entries.
+func entryQuery(query models.EntryQuery) url.Values {
+	values := url.Values{}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Cursor != "" {
+		values.Set("cursor", query.Cursor)
+	}
+	if query.Sort != "" {
+		values.Set("sort", query.Sort)
+	}
+	if query.Descending {
+		values.Set("order", "desc")
+	}
+
+	filter := query.Filter
+	if filter.EntryType != "" {
+		values.Set("entry_type", filter.EntryType)
+	}
+	times := map[string]*time.Time{
+		"created_after":  filter.CreatedAfter,
+		"created_before": filter.CreatedBefore,
+		"updated_after":  filter.UpdatedAfter,
+		"updated_before": filter.UpdatedBefore,
+	}
+	for name, t := range times {
+		if t != nil {
+			values.Set(name, t.Format(time.RFC3339Nano))
+		}
+	}
+	if filter.FolderID != nil {
+		values.Set("folder_id", strconv.FormatInt(*filter.FolderID, 10))
+	}
+	if filter.CollectionID != nil {
+		values.Set("collection_id", strconv.FormatInt(*filter.CollectionID, 10))
+	}
+	for _, tagID := range filter.TagIDs {
+		values.Add("tag_id", strconv.FormatInt(tagID, 10))
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	resp "passvault/internal/lib/api/response"
+	"passvault/internal/storage"
+	"strings"
+)
+
+// ErrUnauthorized is reported for requests without a valid, unexpired token.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	// Message is the error the server reported.
+	Message string
+
+	err error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("passvault: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("passvault: %s (%d)", e.Message, e.StatusCode)
+}
+
+// Unwrap returns the storage error or ErrUnauthorized the response stands for, if any.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// storageErrors are the storage errors handlers report under their own message.
+var storageErrors = []error{
+	storage.ErrEntryNotFound,
+	storage.ErrEncryptionKeyNotFound,
+	storage.ErrEncryptionKeyExists,
+	storage.ErrEncryptionKeyInUse,
+	storage.ErrKeySharesNotFound,
+	storage.ErrRevisionNotFound,
+	storage.ErrFolderNotFound,
+	storage.ErrFolderExists,
+	storage.ErrFolderCycle,
+	storage.ErrTagNotFound,
+	storage.ErrTagExists,
+	storage.ErrShareNotFound,
+	storage.ErrInvalidShare,
+	storage.ErrAccessDenied,
+	storage.ErrOrganizationNotFound,
+	storage.ErrOrganizationNotEmpty,
+	storage.ErrMemberNotFound,
+	storage.ErrLastOwner,
+	storage.ErrCollectionNotFound,
+	storage.ErrCollectionExists,
+	storage.ErrCollectionNotEmpty,
+	storage.ErrInvalidCursor,
+	storage.ErrInvalidSort,
+	storage.ErrKDFParamsNotFound,
+	storage.ErrKDFParamsExist,
+	storage.ErrVaultNotEmpty,
+	storage.ErrPlaintextRefused,
+	storage.ErrCiphertextRefused,
+	storage.ErrKeyRotationNotFound,
+	storage.ErrKeyRotationRunning,
+}
+
+// errorMessages maps the messages of error responses to the errors they report.
+var errorMessages = func() map[string]error {
+	messages := map[string]error{
+		"encryption key is still used by entries and has no recovery shares": storage.ErrEncryptionKeyInUse,
+		"invalid share":           storage.ErrInvalidShare,
+		"parent folder not found": storage.ErrFolderNotFound,
+		"parent folder already has a subfolder with the same name": storage.ErrFolderExists,
+		"only owners may manage owners":                            storage.ErrAccessDenied,
+		"zero-knowledge mode is already enabled":                   storage.ErrKDFParamsExist,
+		"zero-knowledge mode is not enabled":                       storage.ErrKDFParamsNotFound,
+	}
+	for _, err := range storageErrors {
+		messages[err.Error()] = err
+	}
+	return messages
+}()
+
+// readError reads an error response. Handlers answer with a resp.Response, the auth
+// middleware with plain text.
+func readError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+
+	apiErr := &Error{StatusCode: res.StatusCode}
+
+	var response resp.Response
+	if err := json.Unmarshal(body, &response); err == nil && response.Error != "" {
+		apiErr.Message = response.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		apiErr.err = ErrUnauthorized
+	case errorMessages[apiErr.Message] != nil:
+		apiErr.err = errorMessages[apiErr.Message]
+	case res.StatusCode == http.StatusForbidden:
+		apiErr.err = storage.ErrAccessDenied
+	}
+	return apiErr
+}
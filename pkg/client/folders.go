@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"passvault/internal/domain/models"
+	foldercreate "passvault/internal/http-server/handlers/folder/create"
+	foldermove "passvault/internal/http-server/handlers/folder/move"
+	folderrename "passvault/internal/http-server/handlers/folder/rename"
+)
+
+// CreateFolder creates a folder, inside req.ParentID when it is set, and returns its ID.
+func (c *Client) CreateFolder(ctx context.Context, req foldercreate.Request) (int64, error) {
+	var res foldercreate.Response
+	if err := c.do(ctx, http.MethodPost, "/folders", nil, req, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// ListFolders returns the folders of the account.
+func (c *Client) ListFolders(ctx context.Context) ([]models.Folder, error) {
+	var folders []models.Folder
+	if err := c.do(ctx, http.MethodGet, "/folders", nil, nil, &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// RenameFolder renames a folder.
+func (c *Client) RenameFolder(ctx context.Context, folderID int64, name string) error {
+	return c.do(ctx, http.MethodPatch, pathf("/folders/%d", folderID), nil, folderrename.Request{Name: name}, nil)
+}
+
+// MoveFolder moves a folder into another one, or to the top level when parentID is nil.
+func (c *Client) MoveFolder(ctx context.Context, folderID int64, parentID *int64) error {
+	return c.do(ctx, http.MethodPost, pathf("/folders/%d/move", folderID), nil, foldermove.Request{ParentID: parentID}, nil)
+}
+
+// DeleteFolder deletes a folder; its entries and subfolders move up to its parent.
+func (c *Client) DeleteFolder(ctx context.Context, folderID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/folders/%d", folderID), nil, nil, nil)
+}
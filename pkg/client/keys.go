@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	keyget "passvault/internal/http-server/handlers/encryption-key/get"
+	keyprelogin "passvault/internal/http-server/handlers/encryption-key/prelogin"
+	keyrecover "passvault/internal/http-server/handlers/encryption-key/recover"
+	keysave "passvault/internal/http-server/handlers/encryption-key/save"
+	keysplit "passvault/internal/http-server/handlers/encryption-key/split"
+	keyzeroknowledge "passvault/internal/http-server/handlers/encryption-key/zeroknowledge"
+	"passvault/internal/services/keyshare"
+)
+
+// SaveKeyPart stores the base64 encoded key part of the account and returns its ID.
+func (c *Client) SaveKeyPart(ctx context.Context, keyPart string) (int64, error) {
+	var res keysave.Response
+	if err := c.do(ctx, http.MethodPost, "/keys", nil, keysave.Request{KeyPart: keyPart}, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// KeyPart returns the current key part of the account.
+func (c *Client) KeyPart(ctx context.Context) (string, error) {
+	var res keyget.Response
+	if err := c.do(ctx, http.MethodGet, "/keys", nil, nil, &res); err != nil {
+		return "", err
+	}
+	return res.KeyPart, nil
+}
+
+// DeleteKeyPart deletes the key part of an account. It is refused while the key part
+// encrypts entries, unless Shamir shares to recover it exist.
+func (c *Client) DeleteKeyPart(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/keys", nil, nil, nil)
+}
+
+// SplitKey splits the key part of the account into one Shamir share per holder,
+// threshold of which recover it, and returns them for distribution to the holders.
+func (c *Client) SplitKey(ctx context.Context, holders []string, threshold int) ([]keyshare.Share, error) {
+	var res keysplit.Response
+	if err := c.do(ctx, http.MethodPost, "/keys/split", nil, keysplit.Request{Holders: holders, Threshold: threshold}, &res); err != nil {
+		return nil, err
+	}
+	return res.Shares, nil
+}
+
+// RecoverKey restores the key part of the account from Shamir shares.
+func (c *Client) RecoverKey(ctx context.Context, shares []keyshare.Share) error {
+	return c.do(ctx, http.MethodPost, "/keys/recover", nil, keyrecover.Request{Shares: shares}, nil)
+}
+
+// Prelogin returns the KDF parameters and key check of an account in zero-knowledge
+// mode; see package vaultcrypto.
+func (c *Client) Prelogin(ctx context.Context) (*keyprelogin.Response, error) {
+	var res keyprelogin.Response
+	if err := c.do(ctx, http.MethodGet, "/keys/prelogin", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// EnableZeroKnowledge switches the account to zero-knowledge mode. Only accounts
+// without personal entries or revisions can, others get storage.ErrVaultNotEmpty.
+func (c *Client) EnableZeroKnowledge(ctx context.Context, req keyzeroknowledge.Request) error {
+	return c.do(ctx, http.MethodPost, "/keys/zero-knowledge", nil, req, nil)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"passvault/internal/domain/models"
+	collectioncreate "passvault/internal/http-server/handlers/collection/create"
+	memberset "passvault/internal/http-server/handlers/member/set"
+	orgcreate "passvault/internal/http-server/handlers/organization/create"
+)
+
+// CreateOrganization creates an organization owned by the account and returns its ID.
+func (c *Client) CreateOrganization(ctx context.Context, name string) (int64, error) {
+	var res orgcreate.Response
+	if err := c.do(ctx, http.MethodPost, "/organizations", nil, orgcreate.Request{Name: name}, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// ListOrganizations returns the organizations the account is a member of.
+func (c *Client) ListOrganizations(ctx context.Context) ([]models.Organization, error) {
+	var orgs []models.Organization
+	if err := c.do(ctx, http.MethodGet, "/organizations", nil, nil, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// DeleteOrganization deletes an organization without collections. Only owners may.
+func (c *Client) DeleteOrganization(ctx context.Context, orgID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/organizations/%d", orgID), nil, nil, nil)
+}
+
+// ListMembers returns the members of an organization.
+func (c *Client) ListMembers(ctx context.Context, orgID int64) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	if err := c.do(ctx, http.MethodGet, pathf("/organizations/%d/members", orgID), nil, nil, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SetMember adds an account to an organization or changes its role, one of the
+// models.OrgRole constants.
+func (c *Client) SetMember(ctx context.Context, orgID int64, accountID int64, role string) error {
+	return c.do(ctx, http.MethodPut, pathf("/organizations/%d/members/%d", orgID, accountID), nil, memberset.Request{Role: role}, nil)
+}
+
+// RemoveMember removes an account from an organization.
+func (c *Client) RemoveMember(ctx context.Context, orgID int64, accountID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/organizations/%d/members/%d", orgID, accountID), nil, nil, nil)
+}
+
+// ListCollections returns the collections of an organization.
+func (c *Client) ListCollections(ctx context.Context, orgID int64) ([]models.Collection, error) {
+	var collections []models.Collection
+	if err := c.do(ctx, http.MethodGet, pathf("/organizations/%d/collections", orgID), nil, nil, &collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// CreateCollection creates a collection in an organization and returns its ID.
+func (c *Client) CreateCollection(ctx context.Context, orgID int64, name string) (int64, error) {
+	var res collectioncreate.Response
+	if err := c.do(ctx, http.MethodPost, pathf("/organizations/%d/collections", orgID), nil, collectioncreate.Request{Name: name}, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// DeleteCollection deletes an empty collection of an organization.
+func (c *Client) DeleteCollection(ctx context.Context, orgID int64, collectionID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/organizations/%d/collections/%d", orgID, collectionID), nil, nil, nil)
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"passvault/internal/domain/models"
+	sharecreate "passvault/internal/http-server/handlers/share/create"
+)
+
+// ShareEntry shares an entry of the account with another account and returns the ID of the share.
+func (c *Client) ShareEntry(ctx context.Context, entryID int64, req sharecreate.Request) (int64, error) {
+	var res sharecreate.Response
+	if err := c.do(ctx, http.MethodPost, pathf("/entries/%d/shares", entryID), nil, req, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// ListShares returns the shares of an entry of the account.
+func (c *Client) ListShares(ctx context.Context, entryID int64) ([]models.EntryShare, error) {
+	var shares []models.EntryShare
+	if err := c.do(ctx, http.MethodGet, pathf("/entries/%d/shares", entryID), nil, nil, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// RevokeShare stops sharing an entry with an account.
+func (c *Client) RevokeShare(ctx context.Context, entryID int64, accountID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/entries/%d/shares/%d", entryID, accountID), nil, nil, nil)
+}
+
+// ReceivedShares returns the entries other accounts share with the account.
+func (c *Client) ReceivedShares(ctx context.Context) ([]models.EntryShare, error) {
+	var shares []models.EntryShare
+	if err := c.do(ctx, http.MethodGet, "/shares", nil, nil, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
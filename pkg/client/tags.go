@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"passvault/internal/domain/models"
+	tagcreate "passvault/internal/http-server/handlers/tag/create"
+	tagrename "passvault/internal/http-server/handlers/tag/rename"
+)
+
+// CreateTag creates a tag and returns its ID.
+func (c *Client) CreateTag(ctx context.Context, name string) (int64, error) {
+	var res tagcreate.Response
+	if err := c.do(ctx, http.MethodPost, "/tags", nil, tagcreate.Request{Name: name}, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// ListTags returns the tags of the account.
+func (c *Client) ListTags(ctx context.Context) ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := c.do(ctx, http.MethodGet, "/tags", nil, nil, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// RenameTag renames a tag.
+func (c *Client) RenameTag(ctx context.Context, tagID int64, name string) error {
+	return c.do(ctx, http.MethodPatch, pathf("/tags/%d", tagID), nil, tagrename.Request{Name: name}, nil)
+}
+
+// DeleteTag deletes a tag and removes it from its entries.
+func (c *Client) DeleteTag(ctx context.Context, tagID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/tags/%d", tagID), nil, nil, nil)
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"passvault/internal/domain/models"
+	breachcheck "passvault/internal/http-server/handlers/breach/check"
+	"passvault/internal/http-server/handlers/client/register"
+	entrytypelist "passvault/internal/http-server/handlers/entry-type/list"
+	"passvault/internal/http-server/handlers/generator/generate"
+	reporthealth "passvault/internal/http-server/handlers/report/health"
+	"passvault/internal/http-server/handlers/vault/export"
+	"passvault/internal/lib/entryschema"
+	"passvault/internal/services/exporter"
+	"passvault/internal/services/health"
+	"strconv"
+	"time"
+)
+
+// EntryTypes returns the schemas of the entry types the server accepts.
+func (c *Client) EntryTypes(ctx context.Context) ([]entryschema.Schema, error) {
+	var res entrytypelist.Response
+	if err := c.do(ctx, http.MethodGet, "/entry-types", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.EntryTypes, nil
+}
+
+// Generate generates a password or passphrase.
+func (c *Client) Generate(ctx context.Context, req generate.Request) (*generate.Response, error) {
+	var res generate.Response
+	if err := c.do(ctx, http.MethodPost, "/generate", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CheckBreach reports whether password is in the breached password index of the server.
+func (c *Client) CheckBreach(ctx context.Context, password string) (bool, error) {
+	var res breachcheck.Response
+	if err := c.do(ctx, http.MethodPost, "/breach-check", nil, breachcheck.Request{Password: password}, &res); err != nil {
+		return false, err
+	}
+	return res.Breached, nil
+}
+
+// HealthReport returns the password health report of the account. Passwords older
+// than maxAgeDays are reported as old; zero keeps the default of the server.
+func (c *Client) HealthReport(ctx context.Context, maxAgeDays int) (*health.Report, error) {
+	query := url.Values{}
+	if maxAgeDays > 0 {
+		query.Set("max_age_days", strconv.Itoa(maxAgeDays))
+	}
+
+	var res reporthealth.Response
+	if err := c.do(ctx, http.MethodGet, "/reports/health", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Report, nil
+}
+
+// AuditEvents returns the audit log of the account matching filter, newest first.
+func (c *Client) AuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	query := url.Values{}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.EntryID != nil {
+		query.Set("entry_id", strconv.FormatInt(*filter.EntryID, 10))
+	}
+	if filter.BeforeID > 0 {
+		query.Set("before_id", strconv.FormatInt(filter.BeforeID, 10))
+	}
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+	if filter.Since != nil {
+		query.Set("since", filter.Since.Format(time.RFC3339Nano))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.Format(time.RFC3339Nano))
+	}
+
+	var events []models.AuditEvent
+	if err := c.do(ctx, http.MethodGet, "/audit", query, nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Export writes an export of the personal vault to w, in the format and with the
+// passphrase of opts; see package exporter.
+func (c *Client) Export(ctx context.Context, opts exporter.Options, w io.Writer) error {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+	if opts.ConfirmPlain {
+		query.Set("confirm", "true")
+	}
+
+	req := &request{method: http.MethodGet, path: "/export", query: query}
+	if opts.Passphrase != "" {
+		req.header = http.Header{export.PassphraseHeader: {opts.Passphrase}}
+	}
+
+	return c.send(ctx, req, func(res *http.Response) error {
+		_, err := io.Copy(w, res.Body)
+		return err
+	})
+}
+
+// RegisterClient registers an OAuth client with the SSO service and returns its app ID.
+func (c *Client) RegisterClient(ctx context.Context, req register.Request) (int64, error) {
+	var res register.Response
+	if err := c.do(ctx, http.MethodPost, "/register", nil, req, &res); err != nil {
+		return 0, err
+	}
+	return res.AppID, nil
+}
@@ -0,0 +1,244 @@
+// Package vaultcrypto implements the client side of the passvault zero-knowledge
+// protocol. Clients derive a key from the master password of the account with the
+// KDF parameters the server keeps for it, and encrypt entry data before it is sent,
+// so the server only ever stores and returns ciphertext.
+//
+// Version 1 of the protocol derives a 32 byte master key with Argon2id and expands
+// it into the entry key with HKDF-SHA256. Entry data is sealed with AES-256-GCM under
+// a random nonce; ciphertexts are written as
+//
+//	zk1:<base64 nonce | sealed data>
+//
+// so the server can tell them apart from plaintext and from its own envelopes. The
+// key check stored with the KDF parameters is a ciphertext of a fixed value, which
+// lets clients detect a wrong master password before they write anything with it.
+package vaultcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ProtocolVersion is the version of the protocol written by this package.
+const ProtocolVersion = 1
+
+// KDFArgon2id is the only key derivation function of protocol version 1.
+const KDFArgon2id = "argon2id"
+
+const (
+	// DefaultIterations, DefaultMemory and DefaultParallelism are the Argon2id
+	// parameters of new accounts. Memory is in KiB.
+	DefaultIterations  = 3
+	DefaultMemory      = 64 * 1024
+	DefaultParallelism = 4
+
+	// Parameters outside these bounds are refused, by the server when an account
+	// enables zero-knowledge mode and by clients when they derive a key, so a
+	// server cannot downgrade the KDF of an account.
+	MinIterations  = 2
+	MaxIterations  = 16
+	MinMemory      = 16 * 1024
+	MaxMemory      = 1024 * 1024
+	MaxParallelism = 16
+	MinSaltSize    = 16
+	MaxSaltSize    = 64
+
+	keySize   = 32
+	nonceSize = 12
+	prefix    = "zk"
+)
+
+var (
+	ErrInvalidKDFParams   = errors.New("invalid KDF parameters")
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrMalformed          = errors.New("malformed ciphertext")
+	ErrDecrypt            = errors.New("failed to decrypt")
+	ErrWrongPassword      = errors.New("wrong master password")
+)
+
+// Additional data of entries and of the key check, so one cannot pass for the other.
+var (
+	entryAD    = []byte("passvault entry")
+	keyCheckAD = []byte("passvault key check")
+	keyCheck   = []byte("passvault zero-knowledge key check")
+)
+
+// KDFParams are the key derivation parameters of an account. Memory is in KiB.
+type KDFParams struct {
+	Algorithm   string `json:"algorithm"`
+	Iterations  uint32 `json:"iterations"`
+	Memory      uint32 `json:"memory"`
+	Parallelism uint8  `json:"parallelism"`
+	Salt        []byte `json:"salt"`
+}
+
+// NewKDFParams returns the default parameters with a random salt.
+func NewKDFParams() (KDFParams, error) {
+	salt := make([]byte, MinSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return KDFParams{}, err
+	}
+
+	return KDFParams{
+		Algorithm:   KDFArgon2id,
+		Iterations:  DefaultIterations,
+		Memory:      DefaultMemory,
+		Parallelism: DefaultParallelism,
+		Salt:        salt,
+	}, nil
+}
+
+// Validate checks the parameters against the bounds of the protocol.
+func (p KDFParams) Validate() error {
+	switch {
+	case p.Algorithm != KDFArgon2id:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidKDFParams, p.Algorithm)
+	case p.Iterations < MinIterations || p.Iterations > MaxIterations:
+		return fmt.Errorf("%w: iterations must be between %d and %d", ErrInvalidKDFParams, MinIterations, MaxIterations)
+	case p.Memory < MinMemory || p.Memory > MaxMemory:
+		return fmt.Errorf("%w: memory must be between %d and %d KiB", ErrInvalidKDFParams, MinMemory, MaxMemory)
+	case p.Parallelism < 1 || p.Parallelism > MaxParallelism:
+		return fmt.Errorf("%w: parallelism must be between 1 and %d", ErrInvalidKDFParams, MaxParallelism)
+	case len(p.Salt) < MinSaltSize || len(p.Salt) > MaxSaltSize:
+		return fmt.Errorf("%w: salt must be %d to %d bytes", ErrInvalidKDFParams, MinSaltSize, MaxSaltSize)
+	}
+	return nil
+}
+
+// Key is the entry key of an account.
+type Key struct {
+	aead cipher.AEAD
+}
+
+// DeriveKey derives the entry key of an account from its master password. It takes
+// as much time and memory as the parameters ask for.
+func DeriveKey(masterPassword string, params KDFParams) (*Key, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	masterKey := argon2.IDKey([]byte(masterPassword), params.Salt, params.Iterations, params.Memory, params.Parallelism, keySize)
+
+	entryKey := make([]byte, keySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte("passvault zero-knowledge entry key v1")), entryKey); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(entryKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{aead: aead}, nil
+}
+
+// SealEntry encrypts the entry_data of an entry.
+func (k *Key) SealEntry(entryData string) (string, error) {
+	return k.seal([]byte(entryData), entryAD)
+}
+
+// OpenEntry decrypts entry_data sealed by SealEntry.
+func (k *Key) OpenEntry(ciphertext string) (string, error) {
+	plaintext, err := k.open(ciphertext, entryAD)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// KeyCheck returns the key check the server stores with the KDF parameters.
+func (k *Key) KeyCheck() (string, error) {
+	return k.seal(keyCheck, keyCheckAD)
+}
+
+// VerifyKeyCheck fails with ErrWrongPassword when check was not made with this key.
+func (k *Key) VerifyKeyCheck(check string) error {
+	plaintext, err := k.open(check, keyCheckAD)
+	if errors.Is(err, ErrDecrypt) || err == nil && string(plaintext) != string(keyCheck) {
+		return ErrWrongPassword
+	}
+	return err
+}
+
+func (k *Key) seal(plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, nonceSize, nonceSize+len(plaintext)+k.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := k.aead.Seal(nonce, nonce, plaintext, additionalData)
+	return prefix + strconv.Itoa(ProtocolVersion) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Key) open(ciphertext string, additionalData []byte) ([]byte, error) {
+	raw, err := decode(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := k.aead.Open(nil, raw[:nonceSize], raw[nonceSize:], additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// IsCiphertext reports whether s is marked as a ciphertext of the protocol. It does
+// not check the version or the payload, see Check.
+func IsCiphertext(s string) bool {
+	return Version(s) > 0
+}
+
+// Version returns the protocol version of a ciphertext, or 0 for anything else.
+func Version(s string) int {
+	if !strings.HasPrefix(s, prefix) {
+		return 0
+	}
+	digits, _, ok := strings.Cut(s[len(prefix):], ":")
+	if !ok || digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return 0
+	}
+	version, err := strconv.Atoi(digits)
+	if err != nil || version <= 0 {
+		return 0
+	}
+	return version
+}
+
+// Check validates the form of a ciphertext without decrypting it: a supported
+// version and a payload long enough to hold a nonce and a tag.
+func Check(ciphertext string) error {
+	_, err := decode(ciphertext)
+	return err
+}
+
+func decode(ciphertext string) ([]byte, error) {
+	switch Version(ciphertext) {
+	case 0:
+		return nil, ErrMalformed
+	case ProtocolVersion:
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, Version(ciphertext))
+	}
+
+	_, payload, _ := strings.Cut(ciphertext, ":")
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(raw) < nonceSize+16 {
+		return nil, ErrMalformed
+	}
+	return raw, nil
+}
@@ -0,0 +1,134 @@
+package vaultcrypto_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"passvault/pkg/vaultcrypto"
+	"strings"
+	"testing"
+)
+
+// params are the cheapest parameters the protocol accepts, to keep the tests fast.
+func params(t *testing.T) vaultcrypto.KDFParams {
+	params, err := vaultcrypto.NewKDFParams()
+	require.NoError(t, err)
+	params.Iterations = vaultcrypto.MinIterations
+	params.Memory = vaultcrypto.MinMemory
+	params.Parallelism = 1
+	return params
+}
+
+func TestEntryRoundTrip(t *testing.T) {
+	p := params(t)
+	key, err := vaultcrypto.DeriveKey("correct horse battery staple", p)
+	require.NoError(t, err)
+
+	data := `{"title":"mail","password":"s3cret"}`
+	sealed, err := key.SealEntry(data)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sealed, "zk1:"))
+	require.NotContains(t, sealed, "s3cret")
+	require.True(t, vaultcrypto.IsCiphertext(sealed))
+	require.Equal(t, vaultcrypto.ProtocolVersion, vaultcrypto.Version(sealed))
+	require.NoError(t, vaultcrypto.Check(sealed))
+
+	again, err := key.SealEntry(data)
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, again, "nonces must be random")
+
+	// The same password and parameters derive the same key on another client.
+	other, err := vaultcrypto.DeriveKey("correct horse battery staple", p)
+	require.NoError(t, err)
+	opened, err := other.OpenEntry(sealed)
+	require.NoError(t, err)
+	require.Equal(t, data, opened)
+
+	wrong, err := vaultcrypto.DeriveKey("battery staple", p)
+	require.NoError(t, err)
+	_, err = wrong.OpenEntry(sealed)
+	require.ErrorIs(t, err, vaultcrypto.ErrDecrypt)
+
+	// Flip a character of the payload, keeping it valid base64.
+	tampered := []byte(sealed)
+	i := len("zk1:") + 20
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	_, err = key.OpenEntry(string(tampered))
+	require.ErrorIs(t, err, vaultcrypto.ErrDecrypt)
+}
+
+func TestKeyCheck(t *testing.T) {
+	p := params(t)
+	key, err := vaultcrypto.DeriveKey("correct horse battery staple", p)
+	require.NoError(t, err)
+
+	check, err := key.KeyCheck()
+	require.NoError(t, err)
+	require.NoError(t, key.VerifyKeyCheck(check))
+
+	wrong, err := vaultcrypto.DeriveKey("battery staple", p)
+	require.NoError(t, err)
+	require.ErrorIs(t, wrong.VerifyKeyCheck(check), vaultcrypto.ErrWrongPassword)
+
+	// An entry ciphertext does not pass for a key check, nor the other way round.
+	sealed, err := key.SealEntry(`{"title":"mail"}`)
+	require.NoError(t, err)
+	require.ErrorIs(t, key.VerifyKeyCheck(sealed), vaultcrypto.ErrWrongPassword)
+	_, err = key.OpenEntry(check)
+	require.ErrorIs(t, err, vaultcrypto.ErrDecrypt)
+}
+
+func TestValidate(t *testing.T) {
+	defaults, err := vaultcrypto.NewKDFParams()
+	require.NoError(t, err)
+	require.NoError(t, defaults.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(p *vaultcrypto.KDFParams)
+	}{
+		{"algorithm", func(p *vaultcrypto.KDFParams) { p.Algorithm = "pbkdf2" }},
+		{"few iterations", func(p *vaultcrypto.KDFParams) { p.Iterations = 1 }},
+		{"many iterations", func(p *vaultcrypto.KDFParams) { p.Iterations = vaultcrypto.MaxIterations + 1 }},
+		{"little memory", func(p *vaultcrypto.KDFParams) { p.Memory = vaultcrypto.MinMemory - 1 }},
+		{"much memory", func(p *vaultcrypto.KDFParams) { p.Memory = vaultcrypto.MaxMemory + 1 }},
+		{"no parallelism", func(p *vaultcrypto.KDFParams) { p.Parallelism = 0 }},
+		{"short salt", func(p *vaultcrypto.KDFParams) { p.Salt = p.Salt[:8] }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := defaults
+			tt.modify(&p)
+			require.ErrorIs(t, p.Validate(), vaultcrypto.ErrInvalidKDFParams)
+
+			_, err := vaultcrypto.DeriveKey("password", p)
+			require.ErrorIs(t, err, vaultcrypto.ErrInvalidKDFParams)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		ciphertext string
+		version    int
+		err        error
+	}{
+		{`{"title":"mail"}`, 0, vaultcrypto.ErrMalformed},
+		{"v1:AAAA", 0, vaultcrypto.ErrMalformed},
+		{"zk:AAAA", 0, vaultcrypto.ErrMalformed},
+		{"zk1:not base64!", 1, vaultcrypto.ErrMalformed},
+		{"zk1:AAAA", 1, vaultcrypto.ErrMalformed},
+		{"zk2:" + strings.Repeat("A", 40), 2, vaultcrypto.ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ciphertext, func(t *testing.T) {
+			require.Equal(t, tt.version, vaultcrypto.Version(tt.ciphertext))
+			require.Equal(t, tt.version > 0, vaultcrypto.IsCiphertext(tt.ciphertext))
+			require.ErrorIs(t, vaultcrypto.Check(tt.ciphertext), tt.err)
+		})
+	}
+}